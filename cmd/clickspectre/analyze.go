@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,16 +17,44 @@ import (
 	"github.com/ppiankov/clickspectre/internal/analyzer"
 	"github.com/ppiankov/clickspectre/internal/baseline"
 	"github.com/ppiankov/clickspectre/internal/collector"
-	"github.com/ppiankov/clickspectre/internal/k8s"
 	"github.com/ppiankov/clickspectre/internal/logging"
 	"github.com/ppiankov/clickspectre/internal/models"
-	"github.com/ppiankov/clickspectre/internal/policy"
 	"github.com/ppiankov/clickspectre/internal/reporter"
-	"github.com/ppiankov/clickspectre/internal/scorer"
+	"github.com/ppiankov/clickspectre/pkg/clickspectre"
 	"github.com/ppiankov/clickspectre/pkg/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// newCollector opens the ClickHouse collector for analyze; tests replace it
+// to verify offline modes never connect.
+var newCollector = collector.New
+
+// reportClock is the clock behind report timestamps; tests replace it to pin
+// the output. By default it honours SOURCE_DATE_EPOCH so reproducible runs
+// produce identical reports. The run time logged and written to
+// --summary-json is measured with the real clock.
+var reportClock = func() time.Time {
+	if epoch, ok, err := sourceDateEpoch(); ok && err == nil {
+		return epoch
+	}
+	return time.Now()
+}
+
+// sourceDateEpoch parses the SOURCE_DATE_EPOCH environment variable
+// (seconds since the Unix epoch), reporting whether it is set.
+func sourceDateEpoch() (time.Time, bool, error) {
+	value := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH"))
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("must be a Unix timestamp in seconds: %w", err)
+	}
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
+
 // NewAnalyzeCmd creates the analyze command
 func NewAnalyzeCmd() *cobra.Command {
 	cfg := config.DefaultConfig()
@@ -31,7 +63,15 @@ func NewAnalyzeCmd() *cobra.Command {
 	var lookbackStr string
 	var queryTimeoutStr string
 	var k8sCacheTTLStr string
+	var diversityBucketsStr string
+	var recencyHalfLifeStr string
+	var minTableAgeStr string
+	var protectRecentWriteStr string
+	var inventoryCacheTTLStr string
+	var noColor bool
+	var ipAliasValues []string
 	var configPath string
+	var stdoutMode bool
 
 	cmd := &cobra.Command{
 		Use:     "analyze",
@@ -49,6 +89,9 @@ generate cleanup recommendations, and create an interactive visual report.`,
 				slog.Debug("loaded config file", slog.String("path", loadedConfigPath))
 			}
 
+			// Environment overrides the config file but not explicit flags
+			applyAnalyzeEnvOverrides(cmd, cfg, config.LoadEnv(), &lookbackStr, &queryTimeoutStr)
+
 			// Parse custom durations
 			if lookbackStr != "" {
 				cfg.LookbackPeriod, err = config.ParseDuration(lookbackStr)
@@ -71,6 +114,168 @@ generate cleanup recommendations, and create an interactive visual report.`,
 				}
 			}
 
+			if diversityBucketsStr != "" {
+				cfg.DiversityBuckets, err = config.ParseDiversityBuckets(diversityBucketsStr)
+				if err != nil {
+					return fmt.Errorf("invalid --diversity-buckets: %w", err)
+				}
+			}
+
+			if recencyHalfLifeStr != "" {
+				cfg.RecencyHalfLife, err = parseRecencyHalfLife(recencyHalfLifeStr)
+				if err != nil {
+					return fmt.Errorf("invalid --recency-half-life: %w", err)
+				}
+			}
+
+			if minTableAgeStr != "" {
+				cfg.MinTableAge, err = parseGuardDuration(minTableAgeStr)
+				if err != nil {
+					return fmt.Errorf("invalid --min-table-age: %w", err)
+				}
+			}
+
+			if protectRecentWriteStr != "" {
+				cfg.ProtectRecentWrite, err = parseGuardDuration(protectRecentWriteStr)
+				if err != nil {
+					return fmt.Errorf("invalid --protect-recent-write: %w", err)
+				}
+			}
+
+			if inventoryCacheTTLStr != "" {
+				cfg.InventoryCacheTTL, err = config.ParseDuration(inventoryCacheTTLStr)
+				if err != nil {
+					return fmt.Errorf("invalid --inventory-cache-ttl duration: %w", err)
+				}
+			}
+
+			if cmd.Flags().Changed("ip-alias") {
+				cfg.IPAliases, err = config.ParseIPAliases(ipAliasValues)
+				if err != nil {
+					return fmt.Errorf("invalid --ip-alias: %w", err)
+				}
+			}
+
+			if _, err := regexp.Compile(cfg.ShadowSuffixPattern); err != nil {
+				return fmt.Errorf("invalid --shadow-suffix-pattern: %w", err)
+			}
+			if cfg.SizePressureWeight < 0 || cfg.SizePressureWeight > 1 {
+				return fmt.Errorf("invalid --size-pressure-weight: must be between 0 and 1, got %g", cfg.SizePressureWeight)
+			}
+			if cfg.Anomalies.FullScanRatio < 0 {
+				return fmt.Errorf("invalid --full-scan-ratio: must be 0 (off) or positive, got %g", cfg.Anomalies.FullScanRatio)
+			}
+			if cfg.Anomalies.NewAccessAfter <= 0 || cfg.Anomalies.NewAccessAfter >= 1 {
+				return fmt.Errorf("invalid --new-access-after: must be between 0 and 1, got %g", cfg.Anomalies.NewAccessAfter)
+			}
+
+			if cfg.InventoryPageSize <= 0 {
+				return fmt.Errorf("invalid --inventory-page-size: must be positive, got %d", cfg.InventoryPageSize)
+			}
+			if cfg.RetryBudget < 0 {
+				return fmt.Errorf("invalid --retry-budget: must be 0 (unlimited) or positive, got %d", cfg.RetryBudget)
+			}
+			if err := collector.ValidateRetryErrorCodes(cfg.RetryErrorCodes); err != nil {
+				return err
+			}
+
+			if cfg.ReplicaFactor < 1 {
+				return fmt.Errorf("invalid --replica-factor: must be at least 1, got %d", cfg.ReplicaFactor)
+			}
+
+			if cfg.BaselineReason != "" && !cfg.UpdateBaseline {
+				return fmt.Errorf("invalid flags: --baseline-reason requires --update-baseline")
+			}
+			cfg.BaselineFormat = strings.ToLower(strings.TrimSpace(cfg.BaselineFormat))
+			if !slices.Contains(baseline.Formats, cfg.BaselineFormat) {
+				return fmt.Errorf("invalid --baseline-format value: %q (supported: %s)", cfg.BaselineFormat, strings.Join(baseline.Formats, ", "))
+			}
+			if cfg.CompareBaseline && (cfg.BaselinePath == "" || cfg.UpdateBaseline) {
+				return fmt.Errorf("invalid flags: --compare-baseline requires --baseline and cannot be combined with --update-baseline")
+			}
+			if cfg.BaselineFormat == baseline.FormatList && cfg.UpdateBaseline {
+				return fmt.Errorf("invalid flags: --update-baseline writes JSON and cannot be combined with --baseline-format list")
+			}
+			if stdoutMode {
+				if cmd.Flags().Changed("output") && cfg.OutputDir != "-" {
+					return fmt.Errorf("invalid flags: --stdout cannot be combined with --output %q", cfg.OutputDir)
+				}
+				cfg.OutputDir = "-"
+			}
+			if cfg.OutputURL != "" {
+				if reporter.IsStdout(cfg) || cfg.OutputArchive != "" {
+					return fmt.Errorf("invalid flags: --output-url cannot be combined with --stdout or --output-archive")
+				}
+				if _, err := reporter.NewSink(cfg.OutputURL); err != nil {
+					return fmt.Errorf("invalid --output-url: %w", err)
+				}
+			}
+			if cfg.OutputArchive != "" {
+				if reporter.IsStdout(cfg) {
+					return fmt.Errorf("invalid flags: --output-archive needs a report directory, not stdout")
+				}
+				if !reporter.IsArchivePath(cfg.OutputArchive) {
+					return fmt.Errorf("invalid --output-archive value: %q (supported extensions: %s)", cfg.OutputArchive, strings.Join(reporter.ArchiveExtensions, ", "))
+				}
+			}
+
+			cfg.Format = strings.ToLower(cfg.Format)
+			cfg.Normalize()
+			if !slices.Contains(config.ReportFormats, cfg.Format) {
+				return fmt.Errorf("invalid --format value: %q (supported: %s)", cfg.Format, strings.Join(config.ReportFormats, ", "))
+			}
+			cfg.TextSortBy = strings.ToLower(strings.TrimSpace(cfg.TextSortBy))
+			if !slices.Contains(reporter.TextSortKeys, cfg.TextSortBy) {
+				return fmt.Errorf("invalid --sort-by value: %q (supported: %s)", cfg.TextSortBy, strings.Join(reporter.TextSortKeys, ", "))
+			}
+			cfg.Color = strings.ToLower(strings.TrimSpace(cfg.Color))
+			if !slices.Contains(reporter.ColorModes, cfg.Color) {
+				return fmt.Errorf("invalid --color value: %q (supported: %s)", cfg.Color, strings.Join(reporter.ColorModes, ", "))
+			}
+			if noColor {
+				if cmd.Flags().Changed("color") && cfg.Color != "never" {
+					return fmt.Errorf("invalid flags: --no-color cannot be combined with --color=%s", cfg.Color)
+				}
+				cfg.Color = "never"
+			}
+			if _, err := config.LoadTimezone(cfg.Timezone); err != nil {
+				return fmt.Errorf("invalid --timezone: %w", err)
+			}
+			cfg.GraphGranularity = strings.ToLower(strings.TrimSpace(cfg.GraphGranularity))
+			if !slices.Contains(analyzer.GraphGranularities, cfg.GraphGranularity) {
+				return fmt.Errorf("invalid --graph-granularity value: %q (supported: %s)", cfg.GraphGranularity, strings.Join(analyzer.GraphGranularities, ", "))
+			}
+			for i, section := range cfg.ReportSections {
+				cfg.ReportSections[i] = strings.ToLower(strings.TrimSpace(section))
+				if !slices.Contains(reporter.ReportSections, cfg.ReportSections[i]) {
+					return fmt.Errorf("invalid --report-sections value: %q (supported: %s)", section, strings.Join(reporter.ReportSections, ", "))
+				}
+			}
+			if cfg.KeepSampleQueries < 0 {
+				return fmt.Errorf("invalid --keep-sample-queries: must be 0 (none) or positive, got %d", cfg.KeepSampleQueries)
+			}
+			if cfg.TopConsumers < 0 {
+				return fmt.Errorf("invalid --top-consumers: must be 0 (none) or positive, got %d", cfg.TopConsumers)
+			}
+			if cfg.MaxMemoryUsage < 0 {
+				return fmt.Errorf("invalid --max-memory-usage: must be 0 (server default) or positive, got %d", cfg.MaxMemoryUsage)
+			}
+			if cfg.MaxQueryLength < 0 {
+				return fmt.Errorf("invalid --max-query-length: must be 0 (unlimited) or positive, got %d", cfg.MaxQueryLength)
+			}
+			if cfg.TextTop < 0 {
+				return fmt.Errorf("invalid --top: must be 0 (all) or positive, got %d", cfg.TextTop)
+			}
+
+			if cfg.FromFile != "" && cfg.PlanReport != "" {
+				return fmt.Errorf("invalid flags: --from-file cannot be combined with --plan")
+			}
+
+			// Offline analysis does not need a ClickHouse connection
+			if (cfg.FromFile != "" || cfg.PlanReport != "") && cfg.ClickHouseDSN == "" {
+				return nil
+			}
+
 			if cfg.ClickHouseDSN == "" {
 				return fmt.Errorf("required flag(s) \"clickhouse-dsn\" or \"clickhouse-url\" not set")
 			}
@@ -79,24 +284,21 @@ generate cleanup recommendations, and create an interactive visual report.`,
 			cfg.ClickHouseDSNs = strings.Split(cfg.ClickHouseDSN, ",")
 			for i, dsn := range cfg.ClickHouseDSNs {
 				cfg.ClickHouseDSNs[i] = strings.TrimSpace(dsn)
-				parsed, err := url.Parse(cfg.ClickHouseDSNs[i])
-				if err != nil || parsed.Host == "" {
-					return fmt.Errorf("invalid --clickhouse-dsn[%d]: expected clickhouse://[user[:pass]@]host[:port]/db", i)
-				}
-				scheme := strings.ToLower(parsed.Scheme)
-				if scheme != "clickhouse" && scheme != "chhttp" && scheme != "chhttps" {
-					return fmt.Errorf("invalid --clickhouse-dsn[%d] scheme %q: expected clickhouse, chhttp, or chhttps", i, parsed.Scheme)
+				// Each node may list failover endpoints separated by "|"
+				for _, endpoint := range collector.SplitFailoverDSN(cfg.ClickHouseDSNs[i]) {
+					parsed, err := url.Parse(endpoint)
+					if err != nil || parsed.Host == "" {
+						return fmt.Errorf("invalid --clickhouse-dsn[%d]: expected clickhouse://[user[:pass]@]host[:port]/db", i)
+					}
+					scheme := strings.ToLower(parsed.Scheme)
+					switch scheme {
+					case "clickhouse", "http", "https", "chhttp", "chhttps":
+					default:
+						return fmt.Errorf("invalid --clickhouse-dsn[%d] scheme %q: expected clickhouse, http, https, chhttp, or chhttps", i, parsed.Scheme)
+					}
 				}
 			}
 
-			cfg.Format = strings.ToLower(cfg.Format)
-			cfg.Normalize()
-			switch cfg.Format {
-			case "json", "text", "sarif", "spectrehub":
-			default:
-				return fmt.Errorf("invalid --format value: %q (supported: json, text, sarif, spectrehub)", cfg.Format)
-			}
-
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -109,48 +311,147 @@ generate cleanup recommendations, and create an interactive visual report.`,
 	cmd.Flags().StringVar(&cfg.ClickHouseDSN, "clickhouse-dsn", "", "ClickHouse DSN")
 	cmd.Flags().StringVar(&cfg.ClickHouseDSN, "clickhouse-url", "", "Deprecated alias for --clickhouse-dsn")
 	_ = cmd.Flags().MarkDeprecated("clickhouse-url", "use --clickhouse-dsn instead")
+	cmd.Flags().BoolVar(&cfg.ClickHouseTLS, "clickhouse-tls", false, "Connect to ClickHouse over TLS, overriding the DSN's secure setting")
+	cmd.Flags().StringVar(&cfg.ClickHouseCACert, "clickhouse-ca-cert", "", "PEM CA bundle for verifying the ClickHouse server (implies --clickhouse-tls)")
+	cmd.Flags().StringVar(&cfg.ClickHousePasswordFile, "clickhouse-password-file", "", "Read the ClickHouse password from this file instead of the DSN")
+	cmd.Flags().BoolVar(&cfg.SessionSettings, "session-settings", false, "Cap queries with max_execution_time (from --query-timeout) and --max-memory-usage when the ClickHouse user is not readonly")
+	cmd.Flags().Int64Var(&cfg.MaxMemoryUsage, "max-memory-usage", 0, "max_memory_usage in bytes sent with --session-settings (0 = server default)")
+	cmd.Flags().BoolVar(&cfg.ClickHouseInsecureSkipVerify, "clickhouse-insecure-skip-verify", false, "Skip ClickHouse server certificate verification (implies --clickhouse-tls)")
 
 	cmd.Flags().StringVar(&queryTimeoutStr, "query-timeout", "5m", "Query timeout (e.g., 5m, 10m, 1h)")
 	cmd.Flags().IntVar(&cfg.BatchSize, "batch-size", 100000, "Query log batch size")
 	cmd.Flags().IntVar(&cfg.MaxRows, "max-rows", 1000000, "Max query log rows to process")
+	cmd.Flags().IntVar(&cfg.InventoryPageSize, "inventory-page-size", config.DefaultInventoryPageSize, "system.tables rows fetched per table inventory query")
+	cmd.Flags().IntVar(&cfg.MaxQueryLength, "max-query-length", config.DefaultMaxQueryLength, "Truncate stored query text to this many bytes after table extraction (0 = unlimited)")
+	cmd.Flags().IntVar(&cfg.RetryBudget, "retry-budget", config.DefaultRetryBudget, "Total query retries allowed across all query_log pages per node (0 = unlimited)")
+	cmd.Flags().Int32SliceVar(&cfg.RetryErrorCodes, "retry-error-codes", config.DefaultRetryErrorCodes(), "ClickHouse exception codes retried with a longer backoff as server overload (comma-separated)")
 	cmd.Flags().StringVar(&lookbackStr, "lookback", "30d", "Lookback period (e.g., 7d, 30d, 90d, 720h)")
+	cmd.Flags().BoolVar(&cfg.IncludeExceptions, "include-exceptions", false, "Also collect failed queries to compute per-table error rates")
 
 	// Kubernetes flags
 	cmd.Flags().BoolVar(&cfg.ResolveK8s, "resolve-k8s", false, "Enable Kubernetes IP resolution")
 	cmd.Flags().StringVar(&cfg.KubeConfig, "kubeconfig", "", "Path to kubeconfig (default: ~/.kube/config)")
 	cmd.Flags().StringVar(&k8sCacheTTLStr, "k8s-cache-ttl", "5m", "Kubernetes cache TTL (e.g., 5m, 10m, 1h)")
 	cmd.Flags().IntVar(&cfg.K8sRateLimit, "k8s-rate-limit", 10, "Kubernetes API rate limit (requests/sec)")
+	cmd.Flags().StringVar(&cfg.K8sCacheFile, "k8s-cache-file", "", "Persist the Kubernetes resolution cache to this file between runs")
 
 	// Concurrency flags
 	cmd.Flags().IntVar(&cfg.Concurrency, "concurrency", 5, "Worker pool size")
+	cmd.Flags().BoolVar(&cfg.PrefetchPages, "prefetch-pages", false, "Request up to --concurrency query_log pages concurrently")
 
 	// Output flags
-	cmd.Flags().StringVar(&cfg.OutputDir, "output", "./report", "Output directory")
-	cmd.Flags().StringVar(&cfg.Format, "format", "json", "Output format (json|text|sarif|spectrehub)")
+	cmd.Flags().StringVar(&cfg.OutputDir, "output", "./report", "Output directory (\"-\" writes the report to stdout)")
+	cmd.Flags().BoolVar(&stdoutMode, "stdout", false, "Write the report to stdout instead of a directory (same as --output -)")
+	cmd.Flags().IntVar(&cfg.TextTop, "top", 0, "Show only the first N tables in the text report (0 = all)")
+	cmd.Flags().StringVar(&cfg.TextSortBy, "sort-by", "score", "Text report table order (score|reads|writes|size|last_access|severity)")
+	cmd.Flags().StringVar(&cfg.Color, "color", "auto", "ANSI styling of the text report on stdout (auto|always|never); auto honors NO_COLOR and FORCE_COLOR, then checks for a terminal")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Shorthand for --color=never")
+	cmd.Flags().StringVar(&cfg.Timezone, "timezone", config.DefaultTimezone, "IANA timezone for sparkline and heatmap buckets and report times (e.g. America/New_York)")
+	cmd.Flags().StringVar(&cfg.GraphGranularity, "graph-granularity", "pod", "Collapse services in the graph and text views (pod|service|namespace); report.json keeps per-pod services")
+	cmd.Flags().StringSliceVar(&cfg.ReportSections, "report-sections", nil, "Report sections to include in json, text, and markdown output (tables,anomalies,recommendations,services; default all)")
+	cmd.Flags().StringVar(&cfg.SARIFLocationRoot, "sarif-location-root", "", "Repository directory holding <db>/<table>.sql files for SARIF result locations (default: README.md)")
+	cmd.Flags().StringVar(&cfg.Format, "format", "json", "Output format (json|text|sarif|spectrehub|openmetrics|markdown)")
+	cmd.Flags().StringVar(&cfg.SummaryJSON, "summary-json", "", "Also write a JSON summary of counts to this file regardless of --format (- for stderr)")
+	cmd.Flags().StringVar(&cfg.OutputArchive, "output-archive", "", "Also bundle the report directory into this .zip or .tar.gz file for sharing")
+	cmd.Flags().StringVar(&cfg.OutputURL, "output-url", "", "Upload the report to s3://bucket/prefix/, gs://bucket/prefix/, or POST it to http(s)://... instead of --output")
 	cmd.Flags().StringVar(&cfg.BaselinePath, "baseline", "", "Path to baseline file for suppressing known findings")
 	cmd.Flags().BoolVar(&cfg.UpdateBaseline, "update-baseline", false, "Update baseline with current findings")
+	cmd.Flags().BoolVar(&cfg.CompareBaseline, "compare-baseline", false, "Mark findings with in_baseline instead of suppressing the ones --baseline covers")
+	cmd.Flags().StringVar(&cfg.BaselineDiff, "baseline-diff", "", "Write suppressed/new finding counts relative to --baseline to this JSON file")
+	cmd.Flags().StringVar(&cfg.BaselineReason, "baseline-reason", "", "Reason recorded on findings newly added by --update-baseline")
+	cmd.Flags().StringVar(&cfg.BaselineFormat, "baseline-format", baseline.FormatJSON, "Baseline file format: json or list (one table glob or anomaly:<type> per line)")
 
 	// Analysis flags
 	cmd.Flags().StringVar(&cfg.ScoringAlgorithm, "scoring-algorithm", "simple", "Scoring algorithm (simple)")
+	cmd.Flags().StringVar(&recencyHalfLifeStr, "recency-half-life", "", "Access age at which the scorer's recency factor halves (default \"30d\")")
+	cmd.Flags().Float64Var(&cfg.SizePressureWeight, "size-pressure-weight", 0, "Weight (0-1) of a scoring factor favoring small hot tables over large cold ones (0 = off)")
+	cmd.Flags().StringVar(&diversityBucketsStr, "diversity-buckets", "", "Access diversity buckets as min_services:weight pairs (default \"6:0.2,3:0.15,1:0.05\")")
 	cmd.Flags().BoolVar(&cfg.AnomalyDetection, "anomaly-detection", true, "Enable anomaly detection")
+	cmd.Flags().Float64Var(&cfg.Anomalies.UsageSpikeMultiplier, "usage-spike-multiplier", 5.0, "Flag hourly usage above this multiple of the trailing mean as a spike")
+	cmd.Flags().Uint64Var(&cfg.Anomalies.UsageSpikeMinQueries, "usage-spike-min-queries", 10, "Minimum queries in an hourly bucket for it to count as a spike, so sparse tables don't flag small bursts")
+	cmd.Flags().IntVar(&cfg.Anomalies.UsageDropBuckets, "usage-drop-hours", 6, "Trailing hours of near-zero usage that flag a drop on a previously busy table")
+	cmd.Flags().Float64Var(&cfg.Anomalies.UsageDropMinMean, "usage-drop-min-mean", 10.0, "Minimum prior queries/hour for a table to be considered busy for drop detection")
+	cmd.Flags().Float64Var(&cfg.Anomalies.ErrorProneRate, "error-prone-rate", 0.2, "Flag tables whose share of failed queries exceeds this rate (needs --include-exceptions)")
+	cmd.Flags().Float64Var(&cfg.Anomalies.FullScanRatio, "full-scan-ratio", 0, "Flag tables whose reads return one row per this many rows scanned as full_scan_suspect, e.g. 10000 (0 = off). read_rows is per query, so each table of a JOIN is charged the whole query's rows")
+	cmd.Flags().Float64Var(&cfg.Anomalies.NewAccessAfter, "new-access-after", 0.5, "Share of the window after which a service's first access to a --sensitive-table counts as new (between 0 and 1)")
 	cmd.Flags().BoolVar(&cfg.IncludeMVDeps, "include-mv-deps", true, "Include materialized view dependencies")
 	cmd.Flags().BoolVar(&cfg.DetectUnusedTables, "detect-unused-tables", false, "Detect tables with zero usage in query logs")
+	cmd.Flags().BoolVar(&cfg.PartitionHeat, "partition-heat", false, "Estimate hot and cold partitions of date-partitioned tables from date filters in queries (heuristic; needs --detect-unused-tables)")
+	cmd.Flags().BoolVar(&cfg.DetectShadowTables, "detect-shadow-tables", false, "Recommend zero-usage tables named like an active table (events_old next to events); needs --detect-unused-tables")
+	cmd.Flags().StringVar(&cfg.ShadowSuffixPattern, "shadow-suffix-pattern", config.DefaultShadowSuffixPattern, "Regexp stripped from table names to group shadow tables with their active sibling")
 	cmd.Flags().Float64Var(&cfg.MinTableSizeMB, "min-table-size", 1.0, "Minimum table size in MB for unused table recommendations")
+	cmd.Flags().StringVar(&minTableAgeStr, "min-table-age", "", "Keep tables created or altered more recently than this as too_new, needs --detect-unused-tables for metadata times (default \"7d\", 0 = off)")
+	cmd.Flags().StringVar(&protectRecentWriteStr, "protect-recent-write", "", "Keep tables written or mutated more recently than this as recent_writes (default \"7d\", 0 = off)")
+	cmd.Flags().IntVar(&cfg.ReplicaFactor, "replica-factor", 1, "Replicas freed when dropping a replicated table, used to estimate reclaimable storage")
 	cmd.Flags().Uint64Var(&cfg.MinQueryCount, "min-query-count", 0, "Minimum query count required to consider a table active")
 	cmd.Flags().BoolVar(&cfg.ByUser, "by-user", false, "Include per-user query activity analysis")
-	cmd.Flags().BoolVar(&cfg.Incremental, "incremental", false, "Only fetch entries newer than last run")
-	cmd.Flags().StringVar(&cfg.WatermarkFile, "watermark-file", "", "Path to watermark file (default: ~/.config/clickspectre/watermark.json)")
+	cmd.Flags().StringSliceVar(&ipAliasValues, "ip-alias", []string{}, "Attribute a shared client IP to a service label as ip=label, instead of K8s resolution (repeatable)")
+	cmd.Flags().BoolVar(&cfg.NormalizeIPv6, "normalize-ipv6", true, "Canonicalize client IPs so IPv4-mapped IPv6 (::ffff:10.0.0.1) and bare IPv4 count as one service")
+	cmd.Flags().IntVar(&cfg.KeepSampleQueries, "keep-sample-queries", 0, "Keep up to N distinct example queries per table in the report (0 = none)")
+	cmd.Flags().IntVar(&cfg.TopConsumers, "top-consumers", config.DefaultTopConsumers, "List the N services with the most reads plus writes on each table (0 = none)")
+	cmd.Flags().BoolVar(&cfg.RedactLiterals, "redact-literals", false, "Replace string and numeric literals with ? in kept example queries")
+	cmd.Flags().BoolVar(&cfg.Incremental, "incremental", false, "Only fetch entries newer than last run, merging table usage accumulated by earlier runs in the watermark file (alias --since-last-run)")
+	// --since-last-run is another name for --incremental, not a second flag,
+	// so Changed("incremental") sees either spelling
+	cmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "since-last-run" {
+			name = "incremental"
+		}
+		return pflag.NormalizedName(name)
+	})
+	cmd.Flags().StringVar(&cfg.WatermarkFile, "watermark-file", "", "Path to watermark file, which holds the incremental state (default: ~/.config/clickspectre/watermark.json)")
 	cmd.Flags().BoolVar(&cfg.ResetWatermark, "reset-watermark", false, "Delete watermark and force full rescan")
+	cmd.Flags().StringVar(&inventoryCacheTTLStr, "inventory-cache-ttl", "", "Reuse the table inventory cached by a run against the same host within this duration (e.g., 1h, 12h; default: off)")
+	cmd.Flags().StringVar(&cfg.InventoryCacheFile, "inventory-cache-file", "", "Path to inventory cache file (default: ~/.config/clickspectre/inventory.json)")
+	cmd.Flags().StringVar(&cfg.FromFile, "from-file", "", "Analyze query log entries from a 'clickspectre collect' file or a system.query_log dump (TSV or JSON) instead of ClickHouse")
+	cmd.Flags().StringVar(&cfg.PlanReport, "plan", "", "Re-run scoring, recommendations, and anomaly detection on an existing report.json without connecting to ClickHouse")
 	cmd.Flags().StringVar(&cfg.PolicyFile, "policy", "", "Policy file for table hygiene enforcement (.clickspectre-policy.yaml)")
 	cmd.Flags().StringSliceVar(&cfg.ExcludeTables, "exclude-table", []string{}, "Exclude table pattern (repeatable, supports glob)")
 	cmd.Flags().StringSliceVar(&cfg.ExcludeDatabases, "exclude-database", []string{}, "Exclude database pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.IncludeTables, "include-table", []string{}, "Only analyze tables matching pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.IncludeDatabases, "include-database", []string{}, "Only analyze tables in databases matching pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeUsers, "exclude-user", []string{}, "Drop queries from user pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeQueryKinds, "exclude-query-kind", []string{}, "Drop queries of kind pattern, e.g. Create, Drop (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeLogComments, "exclude-log-comment", []string{}, "Drop queries whose log_comment matches pattern, e.g. monitoring tags (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.EngineAllow, "engine", []string{}, "Only analyze tables whose engine matches pattern, e.g. *MergeTree (repeatable, supports glob; needs --detect-unused-tables)")
+	cmd.Flags().StringSliceVar(&cfg.EngineDeny, "exclude-engine", []string{}, "Drop tables whose engine matches pattern from analysis (repeatable, supports glob; needs --detect-unused-tables)")
+	cmd.Flags().StringSliceVar(&cfg.ProtectedTables, "protect-table", []string{}, "Never recommend tables matching pattern for cleanup (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.SensitiveTables, "sensitive-table", []string{}, "Flag services that start accessing tables matching pattern late in the window as new_sensitive_access (repeatable, supports glob)")
+	cmd.Flags().BoolVar(&cfg.ExplainExclusions, "explain-exclusions", false, "Print which exclusion pattern removed each table to stderr")
+	cmd.Flags().StringSliceVar(&cfg.ExplainTables, "explain-table", []string{}, "Candidate table to explain with --explain-exclusions (repeatable, default: all excluded tables)")
 
 	// Operational flags
 	cmd.Flags().BoolVar(&cfg.DryRun, "dry-run", false, "Dry run mode (don't write output)")
+	cmd.Flags().BoolVar(&cfg.Progress, "progress", false, "Show a live count of collected query_log entries on stderr (terminals only)")
 
 	return cmd
 }
 
+// parseRecencyHalfLife parses a positive duration such as "30d" or "720h".
+func parseRecencyHalfLife(value string) (time.Duration, error) {
+	halfLife, err := config.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	if halfLife <= 0 {
+		return 0, fmt.Errorf("must be positive, got %s", value)
+	}
+	return halfLife, nil
+}
+
+// parseGuardDuration parses a non-negative duration such as "7d" for a
+// safety guard like --min-table-age; 0 turns the guard off.
+func parseGuardDuration(value string) (time.Duration, error) {
+	age, err := config.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	if age < 0 {
+		return 0, fmt.Errorf("must not be negative, got %s", value)
+	}
+	return age, nil
+}
+
 func applyAnalyzeConfigFileDefaults(
 	cmd *cobra.Command,
 	cfg *config.Config,
@@ -201,25 +502,193 @@ func applyAnalyzeConfigFileDefaults(
 	if !flags.Changed("exclude-database") && len(fileCfg.ExcludeDatabases) > 0 {
 		cfg.ExcludeDatabases = append([]string(nil), fileCfg.ExcludeDatabases...)
 	}
+	if !flags.Changed("include-table") && len(fileCfg.IncludeTables) > 0 {
+		cfg.IncludeTables = append([]string(nil), fileCfg.IncludeTables...)
+	}
+	if !flags.Changed("include-database") && len(fileCfg.IncludeDatabases) > 0 {
+		cfg.IncludeDatabases = append([]string(nil), fileCfg.IncludeDatabases...)
+	}
+	if !flags.Changed("ip-alias") && len(fileCfg.IPAliases) > 0 {
+		cfg.IPAliases, err = config.CanonicalIPAliases(fileCfg.IPAliases)
+		if err != nil {
+			return "", fmt.Errorf("config file %q: ip_aliases: %w", path, err)
+		}
+	}
+	if !flags.Changed("exclude-user") && len(fileCfg.ExcludeUsers) > 0 {
+		cfg.ExcludeUsers = append([]string(nil), fileCfg.ExcludeUsers...)
+	}
+	if !flags.Changed("exclude-query-kind") && len(fileCfg.ExcludeQueryKinds) > 0 {
+		cfg.ExcludeQueryKinds = append([]string(nil), fileCfg.ExcludeQueryKinds...)
+	}
+	if !flags.Changed("exclude-log-comment") && len(fileCfg.ExcludeLogComments) > 0 {
+		cfg.ExcludeLogComments = append([]string(nil), fileCfg.ExcludeLogComments...)
+	}
+	if !flags.Changed("engine") && len(fileCfg.Engines) > 0 {
+		cfg.EngineAllow = append([]string(nil), fileCfg.Engines...)
+	}
+	if !flags.Changed("exclude-engine") && len(fileCfg.ExcludeEngines) > 0 {
+		cfg.EngineDeny = append([]string(nil), fileCfg.ExcludeEngines...)
+	}
+	if !flags.Changed("protect-table") && len(fileCfg.ProtectedTables) > 0 {
+		cfg.ProtectedTables = append([]string(nil), fileCfg.ProtectedTables...)
+	}
+	if !flags.Changed("sensitive-table") && len(fileCfg.SensitiveTables) > 0 {
+		cfg.SensitiveTables = append([]string(nil), fileCfg.SensitiveTables...)
+	}
 	if !flags.Changed("min-query-count") && fileCfg.MinQueryCount != nil {
 		cfg.MinQueryCount = *fileCfg.MinQueryCount
 	}
 	if !flags.Changed("min-table-size") && fileCfg.MinTableSizeMB != nil {
 		cfg.MinTableSizeMB = *fileCfg.MinTableSizeMB
 	}
+	if !flags.Changed("timezone") && fileCfg.Timezone != "" {
+		cfg.Timezone = fileCfg.Timezone
+	}
+	if !flags.Changed("min-table-age") && fileCfg.MinTableAge != "" {
+		age, err := parseGuardDuration(fileCfg.MinTableAge)
+		if err != nil {
+			return "", fmt.Errorf("invalid min_table_age in %s: %w", path, err)
+		}
+		cfg.MinTableAge = age
+	}
+	if !flags.Changed("protect-recent-write") && fileCfg.ProtectRecentWrite != "" {
+		window, err := parseGuardDuration(fileCfg.ProtectRecentWrite)
+		if err != nil {
+			return "", fmt.Errorf("invalid protect_recent_write in %s: %w", path, err)
+		}
+		cfg.ProtectRecentWrite = window
+	}
+	if !flags.Changed("replica-factor") && fileCfg.ReplicaFactor != nil {
+		cfg.ReplicaFactor = *fileCfg.ReplicaFactor
+	}
+	if !flags.Changed("diversity-buckets") && fileCfg.Scoring != nil && len(fileCfg.Scoring.Diversity) > 0 {
+		buckets := append([]config.DiversityBucket(nil), fileCfg.Scoring.Diversity...)
+		if err := config.ValidateDiversityBuckets(buckets); err != nil {
+			return "", fmt.Errorf("invalid scoring.diversity in %s: %w", path, err)
+		}
+		config.SortDiversityBuckets(buckets)
+		cfg.DiversityBuckets = buckets
+	}
+	if !flags.Changed("recency-half-life") && fileCfg.Scoring != nil && fileCfg.Scoring.RecencyHalfLife != "" {
+		halfLife, err := parseRecencyHalfLife(fileCfg.Scoring.RecencyHalfLife)
+		if err != nil {
+			return "", fmt.Errorf("invalid scoring.recency_half_life in %s: %w", path, err)
+		}
+		cfg.RecencyHalfLife = halfLife
+	}
+	if !flags.Changed("size-pressure-weight") && fileCfg.Scoring != nil && fileCfg.Scoring.SizePressureWeight != nil {
+		cfg.SizePressureWeight = *fileCfg.Scoring.SizePressureWeight
+	}
+	if !flags.Changed("shadow-suffix-pattern") && fileCfg.Scoring != nil && fileCfg.Scoring.ShadowSuffixPattern != "" {
+		cfg.ShadowSuffixPattern = fileCfg.Scoring.ShadowSuffixPattern
+	}
+	if fileCfg.Anomalies != nil {
+		// Explicit flags win over the file for the thresholds that have one
+		flagged := cfg.Anomalies
+		fileCfg.Anomalies.ApplyTo(&cfg.Anomalies)
+		if flags.Changed("usage-spike-multiplier") {
+			cfg.Anomalies.UsageSpikeMultiplier = flagged.UsageSpikeMultiplier
+		}
+		if flags.Changed("usage-spike-min-queries") {
+			cfg.Anomalies.UsageSpikeMinQueries = flagged.UsageSpikeMinQueries
+		}
+		if flags.Changed("usage-drop-hours") {
+			cfg.Anomalies.UsageDropBuckets = flagged.UsageDropBuckets
+		}
+		if flags.Changed("usage-drop-min-mean") {
+			cfg.Anomalies.UsageDropMinMean = flagged.UsageDropMinMean
+		}
+		if flags.Changed("error-prone-rate") {
+			cfg.Anomalies.ErrorProneRate = flagged.ErrorProneRate
+		}
+		if flags.Changed("full-scan-ratio") {
+			cfg.Anomalies.FullScanRatio = flagged.FullScanRatio
+		}
+		if flags.Changed("new-access-after") {
+			cfg.Anomalies.NewAccessAfter = flagged.NewAccessAfter
+		}
+	}
 
 	return path, nil
 }
 
+// applyAnalyzeEnvOverrides applies CLICKSPECTRE_* environment values for
+// every setting whose flag was not explicitly set.
+func applyAnalyzeEnvOverrides(
+	cmd *cobra.Command,
+	cfg *config.Config,
+	env config.EnvConfig,
+	lookbackStr *string,
+	queryTimeoutStr *string,
+) {
+	flags := cmd.Flags()
+
+	if env.ClickHouseDSN != "" && !flags.Changed("clickhouse-dsn") && !flags.Changed("clickhouse-url") {
+		cfg.ClickHouseDSN = env.ClickHouseDSN
+	}
+	if env.Format != "" && !flags.Changed("format") {
+		cfg.Format = env.Format
+	}
+	if env.Lookback != "" && !flags.Changed("lookback") {
+		*lookbackStr = env.Lookback
+	}
+	if len(env.ExcludeTables) > 0 && !flags.Changed("exclude-table") {
+		cfg.ExcludeTables = append([]string(nil), env.ExcludeTables...)
+	}
+	if env.QueryTimeout != "" && !flags.Changed("query-timeout") {
+		*queryTimeoutStr = env.QueryTimeout
+	}
+}
+
+// writeExclusionTrace reports the exclusion rule behind each filtered-out
+// table, or the verdict for each --explain-table candidate when given.
+func writeExclusionTrace(w io.Writer, cfg *config.Config) {
+	if len(cfg.ExplainTables) > 0 {
+		fmt.Fprintln(w, "Exclusion trace (candidates):")
+		for _, table := range cfg.ExplainTables {
+			match, excluded := cfg.MatchTableExclusion(table)
+			if !excluded {
+				fmt.Fprintf(w, "  %s: not excluded\n", table)
+				continue
+			}
+			fmt.Fprintf(w, "  %s: %s\n", table, describeExclusion(match))
+		}
+		return
+	}
+
+	excluded := cfg.ExclusionTrace.Tables()
+	if len(excluded) == 0 {
+		fmt.Fprintln(w, "Exclusion trace: no tables excluded")
+		return
+	}
+	fmt.Fprintf(w, "Exclusion trace (%d tables excluded):\n", len(excluded))
+	for _, entry := range excluded {
+		fmt.Fprintf(w, "  %s: %s\n", entry.Table, describeExclusion(entry.ExclusionMatch))
+	}
+}
+
+// describeExclusion renders why a table was excluded for the exclusion trace.
+func describeExclusion(match config.ExclusionMatch) string {
+	if match.Rule == "include" {
+		return "excluded by include allowlist (no include_tables/include_databases match)"
+	}
+	return fmt.Sprintf("excluded by %s pattern %q", match.Rule, match.Pattern)
+}
+
 // runAnalyze executes the analysis workflow
 func runAnalyze(cfg *config.Config, isFirstRun bool) error {
 	var logOpts []logging.Option
-	if quiet {
+	// Keep stdout a clean report stream unless debug output was requested
+	if quiet || (reporter.IsStdout(cfg) && !cfg.Verbose) {
 		logOpts = append(logOpts, logging.WithQuiet())
 	}
 	logging.Init(cfg.Verbose, logOpts...)
 	if isFirstRun {
-		slog.Debug("first-time analysis", slog.String("clickhouse_host", extractHost(cfg.ClickHouseDSN)))
+		slog.Debug("first-time analysis", slog.String("clickhouse_host", collector.ExtractHost(cfg.ClickHouseDSN)))
+	}
+
+	if _, _, err := sourceDateEpoch(); err != nil {
+		return fmt.Errorf("invalid SOURCE_DATE_EPOCH: %w", err)
 	}
 
 	startTime := time.Now()
@@ -235,6 +704,42 @@ func runAnalyze(cfg *config.Config, isFirstRun bool) error {
 	)
 	logConnectionSettings(cfg)
 
+	if cfg.FromFile != "" {
+		if cfg.Incremental {
+			slog.Warn("--incremental has no effect with --from-file")
+			cfg.Incremental = false
+		}
+		if cfg.DetectUnusedTables {
+			slog.Warn("--detect-unused-tables requires ClickHouse access, disabled for --from-file")
+			cfg.DetectUnusedTables = false
+		}
+	}
+	if cfg.PlanReport != "" {
+		if cfg.Incremental {
+			slog.Warn("--incremental has no effect with --plan")
+			cfg.Incremental = false
+		}
+		if cfg.ResolveK8s {
+			slog.Warn("--resolve-k8s has no effect with --plan, services keep their prior resolution")
+			cfg.ResolveK8s = false
+		}
+	}
+
+	// A --plan report already carries engines; otherwise they come from system.tables
+	if (len(cfg.EngineAllow) > 0 || len(cfg.EngineDeny) > 0) && !cfg.DetectUnusedTables && cfg.PlanReport == "" {
+		slog.Warn("--engine and --exclude-engine need --detect-unused-tables for engine metadata, no tables will be filtered")
+	}
+	if cfg.DetectShadowTables && !cfg.DetectUnusedTables && cfg.PlanReport == "" {
+		slog.Warn("--detect-shadow-tables needs --detect-unused-tables to find unused siblings, no shadow tables will be flagged")
+	}
+	if cfg.PartitionHeat && !cfg.DetectUnusedTables && cfg.PlanReport == "" {
+		slog.Warn("--partition-heat needs --detect-unused-tables for partition keys, no partition heat will be estimated")
+	}
+
+	if cfg.ExplainExclusions && len(cfg.ExplainTables) == 0 {
+		cfg.ExclusionTrace = config.NewExclusionTrace()
+	}
+
 	// 0. Handle incremental watermark
 	wmPath := cfg.WatermarkFile
 	if wmPath == "" {
@@ -244,96 +749,52 @@ func runAnalyze(cfg *config.Config, isFirstRun bool) error {
 		_ = os.Remove(wmPath)
 		slog.Info("watermark reset, performing full scan")
 	}
+	var watermark *collector.Watermark
 	if cfg.Incremental {
-		wm, wmErr := collector.LoadWatermark(wmPath)
-		if wmErr != nil {
-			slog.Warn("failed to load watermark, performing full scan", slog.String("error", wmErr.Error()))
-		} else if wm != nil {
-			cfg.IncrementalSince = &wm.LastRun
-			slog.Info("incremental mode", slog.Time("since", wm.LastRun))
-		} else {
-			slog.Info("no watermark found, first incremental run — performing full scan")
-		}
+		watermark = loadIncrementalState(cfg, wmPath)
 	}
 
-	// 1. Initialize collector
-	slog.Debug("connecting to ClickHouse", slog.String("dsn", maskDSN(cfg.ClickHouseDSN)))
-	col, err := collector.New(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to create collector: %w", err)
-	}
-	defer func() { _ = col.Close() }()
-
-	// 2. Initialize K8s resolver (if enabled)
-	var resolver *k8s.Resolver
-	if cfg.ResolveK8s {
-		slog.Debug("connecting to Kubernetes", slog.String("kubeconfig", cfg.KubeConfig))
-		resolver, err = k8s.NewResolver(cfg)
+	// 1. Open the collector here so --progress can draw collection; --plan
+	// re-scores a prior report without one
+	var opts []clickspectre.Option
+	if cfg.PlanReport == "" {
+		if cfg.FromFile == "" {
+			slog.Debug("connecting to ClickHouse", slog.String("dsn", maskDSN(cfg.ClickHouseDSN)))
+		}
+		progressOpts, finishProgress := progressOptions(cfg.Progress)
+		col, err := newCollector(cfg, progressOpts...)
 		if err != nil {
-			slog.Error("failed to initialize Kubernetes resolver",
-				slog.String("error", err.Error()),
-				slog.String("fallback", "continuing without Kubernetes resolution"),
-			)
-			cfg.ResolveK8s = false
+			return fmt.Errorf("failed to create collector: %w", err)
 		}
+		defer func() { _ = col.Close() }()
+		opts = append(opts, clickspectre.WithCollector(progressCollector{Collector: col, finish: finishProgress}))
 	}
-
-	// 3. Collect query logs
-	slog.Debug("collecting query logs",
-		slog.Duration("lookback", cfg.LookbackPeriod),
-		slog.Int("batch_size", cfg.BatchSize),
-	)
-	entries, err := col.Collect(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to collect query logs: %w", err)
-	}
-	slog.Debug("collected query log entries", slog.Int("count", len(entries)))
-
-	// 4. Analyze data
-	slog.Debug("analyzing data", slog.Int("entries", len(entries)))
-	an := analyzer.New(cfg, resolver, col)
-	if err := an.Analyze(ctx, entries); err != nil {
-		return fmt.Errorf("failed to analyze data: %w", err)
+	opts = append(opts, clickspectre.WithClock(reportClock), clickspectre.WithVersion(version))
+	if watermark != nil {
+		opts = append(opts, clickspectre.WithPriorUsage(watermark.Tables))
 	}
-	slog.Debug("analysis complete",
-		slog.Int("tables", len(an.Tables())),
-		slog.Int("services", len(an.Services())),
-		slog.Int("edges", len(an.Edges())),
-	)
 
-	// 5. Score tables and generate recommendations
-	slog.Debug("scoring tables", slog.Int("tables", len(an.Tables())))
-	recommendations := scorer.GenerateRecommendations(an.Tables(), an.Services(), cfg)
-	slog.Debug("recommendations generated",
-		slog.Int("safe_to_drop", len(recommendations.SafeToDrop)),
-		slog.Int("likely_safe", len(recommendations.LikelySafe)),
-		slog.Int("keep", len(recommendations.Keep)),
-	)
-
-	// 6. Build report
-	report := buildReport(cfg, entries, an, recommendations, startTime, col.CollectionMeta())
-
-	// 7. Apply baseline (if enabled)
-	if err := applyBaseline(cfg, report); err != nil {
+	// 2-7. Collect, analyze, score, and apply the baseline and policy
+	result, err := clickspectre.Run(ctx, cfg, opts...)
+	if err != nil {
 		return err
 	}
+	report := result.Report
+	if cfg.ExplainExclusions {
+		writeExclusionTrace(os.Stderr, cfg)
+	}
 
-	// 7.5. Apply policy (if enabled)
-	if cfg.PolicyFile != "" {
-		pol, err := policy.Load(cfg.PolicyFile)
+	if result.Baseline != nil {
+		baselineRes, err := newBaselineResult(result.Baseline, report)
 		if err != nil {
-			return fmt.Errorf("failed to load policy: %w", err)
+			return err
+		}
+		if !reporter.IsStdout(cfg) || cfg.Verbose {
+			writeBaselineSummary(os.Stderr, baselineRes)
 		}
-		violations := pol.Evaluate(report)
-		if len(violations) > 0 {
-			slog.Info("policy violations detected", slog.Int("count", len(violations)))
-			for _, v := range violations {
-				report.Anomalies = append(report.Anomalies, models.Anomaly{
-					Type:          "policy_violation",
-					Severity:      v.Severity,
-					Description:   v.Message,
-					AffectedTable: v.Table,
-				})
+		if cfg.BaselineDiff != "" && !cfg.DryRun {
+			if err := writeBaselineDiff(cfg.BaselineDiff, baselineRes); err != nil {
+				return err
 			}
 		}
 	}
@@ -346,13 +807,18 @@ func runAnalyze(cfg *config.Config, isFirstRun bool) error {
 			return fmt.Errorf("failed to generate report: %w", err)
 		}
 		slog.Debug("report written", slog.String("output_dir", cfg.OutputDir))
+		if cfg.OutputArchive != "" {
+			if err := reporter.Archive(cfg.OutputDir, cfg.OutputArchive); err != nil {
+				return fmt.Errorf("failed to archive report: %w", err)
+			}
+		}
 	} else {
 		slog.Debug("dry run enabled", slog.String("output_dir", cfg.OutputDir))
 	}
 
 	// 9. Save watermark on success
 	if cfg.Incremental {
-		wm := &collector.Watermark{LastRun: time.Now().UTC()}
+		wm := collector.NextWatermark(watermark, result.Entries, report.Tables, time.Now())
 		if err := collector.SaveWatermark(wmPath, wm); err != nil {
 			slog.Warn("failed to save watermark", slog.String("error", err.Error()))
 		}
@@ -361,80 +827,45 @@ func runAnalyze(cfg *config.Config, isFirstRun bool) error {
 	// 10. Success
 	duration := time.Since(startTime)
 	logAnalysisSummary(cfg, report, duration)
+	if cfg.SummaryJSON != "" && (!cfg.DryRun || cfg.SummaryJSON == "-") {
+		if err := writeSummaryJSON(cfg.SummaryJSON, report, duration); err != nil {
+			return err
+		}
+	}
 
 	if isFirstRun {
 		slog.Debug("first run complete", slog.String("tip", "review the report in your browser"))
 	}
 
-	findingCount := countFindings(report)
-	if findingCount > 0 {
-		return &FindingsError{Count: findingCount}
+	if fe := findingsError(report); fe != nil {
+		return fe
 	}
 
 	return nil
 }
 
-// buildReport constructs the final report
-func buildReport(
-	cfg *config.Config,
-	entries []*models.QueryLogEntry,
-	an *analyzer.Analyzer,
-	recommendations models.CleanupRecommendations,
-	startTime time.Time,
-	collectionMeta *models.CollectionMeta,
-) *models.Report {
-	generatedAt := time.Now().UTC()
-
-	// Convert maps to slices
-	var tables []models.Table
-	for _, table := range an.Tables() {
-		tables = append(tables, *table)
-	}
-
-	var services []models.Service
-	for _, service := range an.Services() {
-		services = append(services, *service)
-	}
-
-	var edges []models.Edge
-	for _, edge := range an.Edges() {
-		edges = append(edges, *edge)
-	}
-
-	var anomalies []models.Anomaly
-	for _, anomaly := range an.Anomalies() {
-		anomalies = append(anomalies, *anomaly)
-	}
-
-	// Extract host from DSN
-	host := extractHost(cfg.ClickHouseDSN)
-
-	report := &models.Report{
-		Tool:       "clickspectre",
-		Version:    version,
-		Timestamp:  generatedAt.Format(time.RFC3339),
-		Collection: collectionMeta,
-		Metadata: models.Metadata{
-			GeneratedAt:          generatedAt,
-			LookbackDays:         int(cfg.LookbackPeriod.Hours() / 24),
-			ClickHouseHost:       host,
-			TotalQueriesAnalyzed: uint64(len(entries)),
-			AnalysisDuration:     time.Since(startTime).Round(time.Second).String(),
-			Version:              version,
-			K8sResolutionEnabled: cfg.ResolveK8s,
-		},
-		Tables:                 tables,
-		Services:               services,
-		Edges:                  edges,
-		Anomalies:              anomalies,
-		CleanupRecommendations: recommendations,
+// loadIncrementalState reads the watermark an --incremental run resumes
+// from and points collection at it. It returns nil on a first run, or when
+// the watermark is unreadable, in which case the full lookback is scanned.
+func loadIncrementalState(cfg *config.Config, path string) *collector.Watermark {
+	wm, err := collector.LoadWatermark(path)
+	if err != nil {
+		slog.Warn("failed to load watermark, performing full scan", slog.String("error", err.Error()))
+		return nil
 	}
-
-	if cfg.ByUser {
-		report.Users = analyzer.BuildUserActivity(entries)
+	if wm == nil {
+		slog.Info("no watermark found, first incremental run — performing full scan",
+			slog.Duration("lookback", cfg.LookbackPeriod),
+		)
+		return nil
 	}
-
-	return report
+	since := wm.LastRun
+	cfg.IncrementalSince = &since
+	slog.Info("incremental mode",
+		slog.Time("since", wm.LastRun),
+		slog.Int("accumulated_tables", len(wm.Tables)),
+	)
+	return wm
 }
 
 // maskDSN masks the password in a DSN while preserving scheme, user, host, port, and path.
@@ -442,8 +873,7 @@ func maskDSN(dsn string) string {
 	if dsn == "" {
 		return "[empty]"
 	}
-
-	parsed, err := url.Parse(dsn)
+	parsed, err := url.Parse(collector.RedactDSNPasswordRef(dsn))
 	if err != nil {
 		return "[unparseable DSN]"
 	}
@@ -459,39 +889,55 @@ func maskDSN(dsn string) string {
 	return parsed.String()
 }
 
-// extractHost extracts host from DSN
-func extractHost(dsn string) string {
-	if dsn == "" {
-		return "unknown"
-	}
-
-	parsed, err := url.Parse(dsn)
-	if err != nil {
-		return "unknown"
-	}
-
-	if host := parsed.Hostname(); host != "" {
-		return host
-	}
-
-	return "unknown"
-}
-
 func logConnectionSettings(cfg *config.Config) {
 	slog.Debug("connection settings",
-		slog.String("clickhouse_host", extractHost(cfg.ClickHouseDSN)),
+		slog.String("clickhouse_host", collector.ExtractHost(cfg.ClickHouseDSN)),
+		slog.String("protocol", dsnProtocol(cfg.ClickHouseDSN)),
 		slog.Duration("lookback", cfg.LookbackPeriod),
 		slog.Duration("query_timeout", cfg.QueryTimeout),
 	)
+
+	dsns := cfg.ClickHouseDSNs
+	if len(dsns) == 0 && cfg.ClickHouseDSN != "" {
+		dsns = []string{cfg.ClickHouseDSN}
+	}
+	for _, dsn := range dsns {
+		for _, endpoint := range collector.SplitFailoverDSN(dsn) {
+			slog.Debug("clickhouse endpoint",
+				slog.String("dsn", maskDSN(endpoint)),
+				slog.String("protocol", dsnProtocol(endpoint)),
+				slog.String("host", collector.ExtractHost(endpoint)),
+			)
+		}
+	}
+}
+
+// dsnProtocol returns the wire protocol of the first endpoint in dsn, or
+// "unknown" when it cannot be parsed.
+func dsnProtocol(dsn string) string {
+	endpoints := collector.SplitFailoverDSN(strings.Split(dsn, ",")[0])
+	if len(endpoints) == 0 {
+		return "unknown"
+	}
+	protocol, err := collector.DetectProtocol(endpoints[0])
+	if err != nil {
+		return "unknown"
+	}
+	return protocol
 }
 
 type analysisSummary struct {
-	clickHouseHost string
-	databaseCount  int
-	tableCount     int
-	serviceCount   int
-	queryCount     uint64
-	findingCount   int
+	clickHouseHost      string
+	databaseCount       int
+	tableCount          int
+	unusedCount         int
+	safeToDropCount     int
+	likelySafeCount     int
+	serviceCount        int
+	queryCount          uint64
+	findingCount        int
+	anomaliesBySeverity map[string]int
+	reclaimableBytes    uint64
 }
 
 func buildAnalysisSummary(report *models.Report) analysisSummary {
@@ -500,16 +946,78 @@ func buildAnalysisSummary(report *models.Report) analysisSummary {
 		host = "unknown"
 	}
 
+	unused := 0
+	for _, table := range report.Tables {
+		if table.Category == "unused" {
+			unused++
+		}
+	}
+
+	bySeverity := make(map[string]int)
+	for _, anomaly := range report.Anomalies {
+		bySeverity[anomaly.Severity]++
+	}
+
+	recs := report.CleanupRecommendations
 	return analysisSummary{
-		clickHouseHost: host,
-		databaseCount:  countDatabases(report.Tables),
-		tableCount:     len(report.Tables),
-		serviceCount:   len(report.Services),
-		queryCount:     report.Metadata.TotalQueriesAnalyzed,
-		findingCount:   countFindings(report),
+		clickHouseHost:      host,
+		databaseCount:       countDatabases(report.Tables),
+		tableCount:          len(report.Tables),
+		unusedCount:         unused,
+		safeToDropCount:     len(recs.SafeToDrop),
+		likelySafeCount:     len(recs.LikelySafe),
+		serviceCount:        len(report.Services),
+		queryCount:          report.Metadata.TotalQueriesAnalyzed,
+		findingCount:        countFindings(report),
+		anomaliesBySeverity: bySeverity,
+		reclaimableBytes:    recs.ReclaimableBytes,
 	}
 }
 
+// summaryJSON is the --summary-json document: headline counts for
+// automation, independent of --format.
+type summaryJSON struct {
+	Tables              int            `json:"tables"`
+	Unused              int            `json:"unused"`
+	SafeToDrop          int            `json:"safe_to_drop"`
+	LikelySafe          int            `json:"likely_safe"`
+	AnomaliesBySeverity map[string]int `json:"anomalies_by_severity"`
+	ReclaimableBytes    uint64         `json:"reclaimable_bytes"`
+	Truncated           bool           `json:"truncated"`
+	Duration            string         `json:"duration"`
+}
+
+// writeSummaryJSON writes the --summary-json document to path, or to stderr
+// when path is "-".
+func writeSummaryJSON(path string, report *models.Report, duration time.Duration) error {
+	summary := buildAnalysisSummary(report)
+	data, err := json.MarshalIndent(summaryJSON{
+		Tables:              summary.tableCount,
+		Unused:              summary.unusedCount,
+		SafeToDrop:          summary.safeToDropCount,
+		LikelySafe:          summary.likelySafeCount,
+		AnomaliesBySeverity: summary.anomaliesBySeverity,
+		ReclaimableBytes:    summary.reclaimableBytes,
+		Truncated:           report.Metadata.Truncated,
+		Duration:            duration.Round(time.Millisecond).String(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		if _, err := os.Stderr.Write(data); err != nil {
+			return fmt.Errorf("failed to write summary to stderr: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
 func logAnalysisSummary(cfg *config.Config, report *models.Report, duration time.Duration) {
 	summary := buildAnalysisSummary(report)
 	message := "analysis completed with findings"
@@ -559,50 +1067,111 @@ func countFindings(report *models.Report) int {
 	return recommendationFindings + len(report.Anomalies)
 }
 
-func applyBaseline(cfg *config.Config, report *models.Report) error {
-	// Determine baseline file path
-	baselinePath := cfg.BaselinePath
-	if baselinePath == "" {
-		baselinePath = baseline.DefaultPath
+// findingsError returns the FindingsError for report's findings, or nil when
+// there are none. Unreplicated zero-usage tables count as high, replicated
+// ones and safe-to-drop tables as medium, and likely-safe tables as low.
+// Anomalies keep their severity, with "info" counted as low and unknown
+// severities as medium.
+func findingsError(report *models.Report) *FindingsError {
+	recs := report.CleanupRecommendations
+	fe := &FindingsError{
+		High:   len(recs.ZeroUsageNonReplicated),
+		Medium: len(recs.ZeroUsageReplicated) + len(recs.SafeToDrop),
+		Low:    len(recs.LikelySafe),
 	}
-
-	// Load existing baseline findings
-	existingBaselineFindings, err := baseline.Load(baselinePath)
-	if err != nil {
-		return fmt.Errorf("failed to load baseline from %s: %w", baselinePath, err)
+	for _, anomaly := range report.Anomalies {
+		switch strings.ToLower(strings.TrimSpace(anomaly.Severity)) {
+		case "high":
+			fe.High++
+		case "low", "info":
+			fe.Low++
+		default:
+			fe.Medium++
+		}
 	}
-
-	// Generate current findings from the report
-	currentFindings, err := baseline.GenerateFindings(report)
-	if err != nil {
-		return fmt.Errorf("failed to generate findings for baseline: %w", err)
+	fe.Count = fe.High + fe.Medium + fe.Low
+	if fe.Count == 0 {
+		return nil
 	}
+	return fe
+}
 
-	// If --baseline flag is used (but not --update-baseline), apply suppression
-	if cfg.BaselinePath != "" && !cfg.UpdateBaseline {
-		suppressedCount, err := baseline.ApplySuppression(report, existingBaselineFindings)
+// BaselineResult describes how the report's findings relate to the --baseline file.
+type BaselineResult struct {
+	BaselineFile string         `json:"baseline_file"`
+	Suppressed   int            `json:"suppressed"`
+	Compared     bool           `json:"compared,omitempty"` // --compare-baseline: findings were annotated, not suppressed
+	Known        int            `json:"known,omitempty"`    // Findings the baseline covers, with --compare-baseline
+	NewCount     int            `json:"new_count"`
+	New          []FindingEntry `json:"new"`
+	ExpiredRules []string       `json:"expired_rules,omitempty"`
+}
+
+// newBaselineResult lists the findings left in report after the baseline
+// suppressed the known ones, or with --compare-baseline the ones it does not
+// cover; those are new since the baseline.
+func newBaselineResult(outcome *clickspectre.BaselineOutcome, report *models.Report) (*BaselineResult, error) {
+	result := &BaselineResult{
+		BaselineFile: outcome.File,
+		Suppressed:   outcome.Suppressed,
+		Compared:     outcome.Compared,
+		Known:        outcome.Known,
+		New:          []FindingEntry{},
+		ExpiredRules: outcome.ExpiredRules,
+	}
+	remaining := outcome.New
+	if !outcome.Compared {
+		var err error
+		remaining, err = baseline.GenerateFindings(report)
 		if err != nil {
-			return fmt.Errorf("failed to apply baseline suppression: %w", err)
+			return nil, fmt.Errorf("failed to generate findings for baseline: %w", err)
 		}
-		if suppressedCount > 0 {
-			slog.Debug("suppressed known findings",
-				slog.Int("suppressed", suppressedCount),
-				slog.String("baseline_file", baselinePath),
-			)
+	}
+	newKeeps := 0
+	for _, f := range remaining {
+		if f.Type == "keep_table" {
+			newKeeps++
+			continue
 		}
+		result.New = append(result.New, newFindingEntry(f))
+	}
+	if outcome.Compared {
+		// Keep decisions are not findings to review, so count them in
+		// neither Known nor New
+		result.Known -= len(report.CleanupRecommendations.Keep) - newKeeps
 	}
+	sortFindingEntries(result.New)
+	result.NewCount = len(result.New)
+	return result, nil
+}
 
-	// If --update-baseline flag is used, merge current findings into the baseline and save
-	if cfg.UpdateBaseline {
-		mergedFindings := baseline.MergeFindings(existingBaselineFindings, currentFindings)
-		if err := baseline.Save(baselinePath, mergedFindings); err != nil {
-			return fmt.Errorf("failed to save updated baseline to %s: %w", baselinePath, err)
+// writeBaselineSummary prints a one-line summary of the baseline comparison.
+func writeBaselineSummary(w io.Writer, result *BaselineResult) {
+	if result.Compared {
+		fmt.Fprintf(w, "baseline drift: %d known, %d new since baseline (nothing suppressed)\n", result.Known, result.NewCount)
+	} else {
+		fmt.Fprintf(w, "baseline: %d suppressed, %d new since baseline\n", result.Suppressed, result.NewCount)
+	}
+	for _, f := range result.New {
+		label := f.Table
+		if label == "" {
+			label = f.Service
+		}
+		if f.Description != "" {
+			fmt.Fprintf(w, "  + %s %s: %s\n", f.Type, label, f.Description)
+		} else {
+			fmt.Fprintf(w, "  + %s %s\n", f.Type, label)
 		}
-		slog.Debug("baseline updated",
-			slog.String("baseline_file", baselinePath),
-			slog.Int("total_findings", len(mergedFindings)),
-		)
 	}
+}
 
+func writeBaselineDiff(path string, result *BaselineResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline diff: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
 	return nil
 }
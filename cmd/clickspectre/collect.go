@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/ppiankov/clickspectre/internal/collector"
+	"github.com/ppiankov/clickspectre/internal/logging"
+	"github.com/ppiankov/clickspectre/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// NewCollectCmd creates the collect command.
+func NewCollectCmd() *cobra.Command {
+	cfg := config.DefaultConfig()
+
+	var (
+		output          string
+		lookbackStr     string
+		queryTimeoutStr string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "collect",
+		Short: "Collect query logs to a file for offline analysis",
+		Long: `Run only the collection step and write normalized query_log entries
+(with extracted table references) to disk. Feed the file back with
+'clickspectre analyze --from-file' to iterate on analysis without
+re-querying ClickHouse.
+
+Files ending in .json are written as a JSON array, anything else as JSONL.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var logOpts []logging.Option
+			if quiet {
+				logOpts = append(logOpts, logging.WithQuiet())
+			}
+			logging.Init(verbose, logOpts...)
+
+			if cfg.ClickHouseDSN == "" {
+				return fmt.Errorf("required flag --clickhouse-dsn not set")
+			}
+
+			var err error
+			cfg.LookbackPeriod, err = config.ParseDuration(lookbackStr)
+			if err != nil {
+				return fmt.Errorf("invalid --lookback duration: %w", err)
+			}
+			cfg.QueryTimeout, err = config.ParseDuration(queryTimeoutStr)
+			if err != nil {
+				return fmt.Errorf("invalid --query-timeout duration: %w", err)
+			}
+
+			if cfg.MaxMemoryUsage < 0 {
+				return fmt.Errorf("invalid --max-memory-usage: must be 0 (server default) or positive, got %d", cfg.MaxMemoryUsage)
+			}
+			if cfg.MaxQueryLength < 0 {
+				return fmt.Errorf("invalid --max-query-length: must be 0 (unlimited) or positive, got %d", cfg.MaxQueryLength)
+			}
+			if err := collector.ValidateRetryErrorCodes(cfg.RetryErrorCodes); err != nil {
+				return err
+			}
+
+			cfg.ClickHouseDSNs = strings.Split(cfg.ClickHouseDSN, ",")
+			for i := range cfg.ClickHouseDSNs {
+				cfg.ClickHouseDSNs[i] = strings.TrimSpace(cfg.ClickHouseDSNs[i])
+			}
+			cfg.Verbose = verbose
+			cfg.Normalize()
+
+			return runCollect(cmd, cfg, output)
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.ClickHouseDSN, "clickhouse-dsn", "", "ClickHouse DSN (comma-separated for multi-node)")
+	cmd.Flags().BoolVar(&cfg.ClickHouseTLS, "clickhouse-tls", false, "Connect to ClickHouse over TLS, overriding the DSN's secure setting")
+	cmd.Flags().StringVar(&cfg.ClickHouseCACert, "clickhouse-ca-cert", "", "PEM CA bundle for verifying the ClickHouse server (implies --clickhouse-tls)")
+	cmd.Flags().StringVar(&cfg.ClickHousePasswordFile, "clickhouse-password-file", "", "Read the ClickHouse password from this file instead of the DSN")
+	cmd.Flags().BoolVar(&cfg.SessionSettings, "session-settings", false, "Cap queries with max_execution_time (from --query-timeout) and --max-memory-usage when the ClickHouse user is not readonly")
+	cmd.Flags().Int64Var(&cfg.MaxMemoryUsage, "max-memory-usage", 0, "max_memory_usage in bytes sent with --session-settings (0 = server default)")
+	cmd.Flags().BoolVar(&cfg.ClickHouseInsecureSkipVerify, "clickhouse-insecure-skip-verify", false, "Skip ClickHouse server certificate verification (implies --clickhouse-tls)")
+	cmd.Flags().StringVarP(&output, "output", "o", "query_log.jsonl", "Output file (.json for a JSON array, otherwise JSONL; use - for stdout)")
+	cmd.Flags().StringVar(&lookbackStr, "lookback", "30d", "Lookback period (e.g., 7d, 30d, 90d, 720h)")
+	cmd.Flags().BoolVar(&cfg.IncludeExceptions, "include-exceptions", false, "Also collect failed queries (ExceptionBeforeStart/ExceptionWhileProcessing rows)")
+	cmd.Flags().StringVar(&queryTimeoutStr, "query-timeout", "5m", "Query timeout (e.g., 5m, 10m, 1h)")
+	cmd.Flags().IntVar(&cfg.BatchSize, "batch-size", 100000, "Query log batch size")
+	cmd.Flags().IntVar(&cfg.MaxRows, "max-rows", 1000000, "Max query log rows to process")
+	cmd.Flags().IntVar(&cfg.MaxQueryLength, "max-query-length", config.DefaultMaxQueryLength, "Truncate stored query text to this many bytes after table extraction (0 = unlimited)")
+	cmd.Flags().IntVar(&cfg.RetryBudget, "retry-budget", config.DefaultRetryBudget, "Total query retries allowed across all query_log pages per node (0 = unlimited)")
+	cmd.Flags().Int32SliceVar(&cfg.RetryErrorCodes, "retry-error-codes", config.DefaultRetryErrorCodes(), "ClickHouse exception codes retried with a longer backoff as server overload (comma-separated)")
+	cmd.Flags().IntVar(&cfg.Concurrency, "concurrency", 5, "Worker pool size")
+	cmd.Flags().BoolVar(&cfg.PrefetchPages, "prefetch-pages", false, "Request up to --concurrency query_log pages concurrently")
+	cmd.Flags().BoolVar(&cfg.Progress, "progress", false, "Show a live count of collected query_log entries on stderr (terminals only)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeTables, "exclude-table", []string{}, "Exclude table pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeDatabases, "exclude-database", []string{}, "Exclude database pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.IncludeTables, "include-table", []string{}, "Only analyze tables matching pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.IncludeDatabases, "include-database", []string{}, "Only analyze tables in databases matching pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeUsers, "exclude-user", []string{}, "Drop queries from user pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeQueryKinds, "exclude-query-kind", []string{}, "Drop queries of kind pattern, e.g. Create, Drop (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeLogComments, "exclude-log-comment", []string{}, "Drop queries whose log_comment matches pattern, e.g. monitoring tags (repeatable, supports glob)")
+
+	return cmd
+}
+
+// runCollect fetches query logs and writes them to output.
+func runCollect(cmd *cobra.Command, cfg *config.Config, output string) error {
+	progressOpts, finishProgress := progressOptions(cfg.Progress)
+	col, err := collector.New(cfg, progressOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create collector: %w", err)
+	}
+	defer func() { _ = col.Close() }()
+
+	entries, err := col.Collect(context.Background())
+	finishProgress()
+	if err != nil {
+		return fmt.Errorf("failed to collect query logs: %w", err)
+	}
+
+	if output == "-" {
+		return collector.WriteEntriesJSONL(os.Stdout, entries)
+	}
+
+	if err := collector.SaveEntries(output, entries); err != nil {
+		return fmt.Errorf("failed to save entries: %w", err)
+	}
+	slog.Debug("query log entries saved", slog.String("path", output), slog.Int("count", len(entries)))
+	cmd.Printf("Collected %d query log entries to %s\n", len(entries), output)
+	return nil
+}
@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/ppiankov/clickspectre/internal/reporter"
 	"github.com/spf13/cobra"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -19,10 +24,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
 )
 
 // Embedded Kubernetes manifests templates
@@ -172,12 +180,26 @@ func runDeploy(kubeconfigPath, namespace, reportDir string, localPort int, openB
 		)
 	}
 
-	// Start port-forward
-	stopCh := make(chan struct{}, 1)
+	// Start port-forward; Ctrl+C closes stopCh, which tears the forwarder down
+	stopCh := make(chan struct{})
 	readyCh := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func() { stopOnce.Do(func() { close(stopCh) }) }
 
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
 	go func() {
-		if err := portForward(config, namespace, localPort, stopCh, readyCh); err != nil {
+		select {
+		case <-sigCh:
+			stop()
+		case <-stopCh:
+		}
+	}()
+
+	go func() {
+		defer stop()
+		if err := portForward(ctx, config, clientset, namespace, localPort, stopCh, readyCh); err != nil {
 			slog.Error("port-forward error", slog.String("error", err.Error()))
 		}
 	}()
@@ -191,7 +213,10 @@ func runDeploy(kubeconfigPath, namespace, reportDir string, localPort int, openB
 			slog.String("stop", "Ctrl+C"),
 		)
 		slog.Debug("port-forward ready", slog.String("url", url))
+	case <-stopCh:
+		return fmt.Errorf("port-forward stopped before becoming ready")
 	case <-time.After(10 * time.Second):
+		stop()
 		return fmt.Errorf("timeout waiting for port-forward to be ready")
 	}
 
@@ -280,6 +305,17 @@ func createConfigMapFromDirectory(ctx context.Context, clientset *kubernetes.Cli
 		}
 	}
 
+	// Fill in UI assets the report directory lacks from the embedded copy
+	assets, err := reporter.ReadAssets()
+	if err != nil {
+		return fmt.Errorf("failed to read web assets: %w", err)
+	}
+	for name, content := range assets {
+		if _, ok := data[name]; !ok {
+			data[name] = string(content)
+		}
+	}
+
 	// Delete existing ConfigMap
 	err = clientset.CoreV1().ConfigMaps(namespace).Delete(ctx, configMapName, metav1.DeleteOptions{})
 	if err != nil && !errors.IsNotFound(err) {
@@ -514,40 +550,66 @@ func waitForDeployment(ctx context.Context, clientset *kubernetes.Clientset, nam
 	return fmt.Errorf("timeout waiting for deployment to be ready")
 }
 
-// portForward sets up port forwarding to the service
-func portForward(config *rest.Config, namespace string, localPort int, stopCh, readyCh chan struct{}) error {
-	// This is a simplified version - in production you'd use the full port-forward implementation
-	// For now, we'll use kubectl port-forward as a subprocess
-	cmd := exec.Command("kubectl", "port-forward",
-		"-n", namespace,
-		fmt.Sprintf("svc/%s", serviceName),
-		fmt.Sprintf("%d:80", localPort),
-	)
+// portForward forwards localPort to port 80 of a ready pod backing the report
+// service. It closes readyCh once listening and returns when stopCh is closed.
+func portForward(ctx context.Context, config *rest.Config, clientset kubernetes.Interface, namespace string, localPort int, stopCh, readyCh chan struct{}) error {
+	pod, err := findReadyPod(ctx, clientset, namespace, map[string]string{"app": deploymentName})
+	if err != nil {
+		return err
+	}
 
-	if verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = io.Discard
-		cmd.Stderr = io.Discard
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return fmt.Errorf("failed to create port-forward transport: %w", err)
 	}
 
-	if err := cmd.Start(); err != nil {
-		return err
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	out, errOut := io.Discard, io.Discard
+	if verbose && !quiet {
+		out, errOut = os.Stdout, os.Stderr
 	}
 
-	// Signal ready after a short delay
-	time.Sleep(2 * time.Second)
-	close(readyCh)
+	slog.Debug("forwarding to pod",
+		slog.String("pod", pod.Name),
+		slog.Int("local_port", localPort),
+	)
 
-	// Wait for stop signal or command to finish
-	go func() {
-		_ = cmd.Wait() // Ignore error - we're just waiting for termination
-		close(stopCh)
-	}()
+	forwarder, err := portforward.New(dialer, []string{fmt.Sprintf("%d:80", localPort)}, stopCh, readyCh, out, errOut)
+	if err != nil {
+		return fmt.Errorf("failed to create port-forward: %w", err)
+	}
 
-	<-stopCh
-	return cmd.Process.Kill()
+	return forwarder.ForwardPorts()
+}
+
+// findReadyPod returns a running, ready pod matching the label selector
+func findReadyPod(ctx context.Context, clientset kubernetes.Interface, namespace string, selector map[string]string) (*corev1.Pod, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+				return pod, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no ready pod found for selector %s in namespace %s", labels.SelectorFromSet(selector), namespace)
 }
 
 // openURL opens a URL in the default browser
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/ppiankov/clickspectre/internal/baseline"
 	"github.com/ppiankov/clickspectre/internal/models"
 	"github.com/spf13/cobra"
 )
@@ -17,9 +19,40 @@ type DiffResult struct {
 	Added     []DiffEntry  `json:"added,omitempty"`
 	Removed   []DiffEntry  `json:"removed,omitempty"`
 	Changed   []DiffChange `json:"changed,omitempty"`
+	Findings  FindingsDiff `json:"findings"`
 	Summary   DiffSummary  `json:"summary"`
 }
 
+// FindingsDiff describes recommendation and anomaly changes, keyed by
+// baseline fingerprint.
+type FindingsDiff struct {
+	Added   []FindingEntry  `json:"added,omitempty"`
+	Removed []FindingEntry  `json:"removed,omitempty"`
+	Changed []FindingChange `json:"changed,omitempty"`
+}
+
+// FindingEntry is a finding present in only one of the reports.
+type FindingEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Type        string `json:"type"`
+	Table       string `json:"table,omitempty"`
+	Service     string `json:"service,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// FindingChange is an anomaly whose severity changed between reports.
+type FindingChange struct {
+	OldFingerprint string `json:"old_fingerprint"`
+	NewFingerprint string `json:"new_fingerprint"`
+	Table          string `json:"table,omitempty"`
+	Service        string `json:"service,omitempty"`
+	Description    string `json:"description"`
+	OldDescription string `json:"old_description,omitempty"` // Set only when the description changed
+	OldSeverity    string `json:"old_severity"`
+	NewSeverity    string `json:"new_severity"`
+}
+
 // DiffEntry is a table that appeared or disappeared.
 type DiffEntry struct {
 	Table    string `json:"table"`
@@ -35,75 +68,87 @@ type DiffChange struct {
 
 // DiffSummary counts changes.
 type DiffSummary struct {
-	Added   int `json:"added"`
-	Removed int `json:"removed"`
-	Changed int `json:"changed"`
+	Added           int `json:"added"`
+	Removed         int `json:"removed"`
+	Changed         int `json:"changed"`
+	FindingsAdded   int `json:"findings_added"`
+	FindingsRemoved int `json:"findings_removed"`
+	FindingsChanged int `json:"findings_changed"`
 }
 
 // NewDiffCmd creates the diff command.
 func NewDiffCmd() *cobra.Command {
-	var format string
+	var (
+		format     string
+		outputPath string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "diff <old-report> <new-report>",
 		Short: "Compare two analysis reports and show changes",
-		Long:  "Show tables added, removed, or changed between two report.json files. Useful for reviewing drift after watch runs.",
-		Args:  cobra.ExactArgs(2),
+		Long: `Show tables added, removed, or changed between two report.json files,
+along with recommendations and anomalies that appeared, disappeared, or changed
+severity. Findings are matched by the same fingerprints used for baselines.
+Useful for reviewing drift after watch runs.`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			oldReport, err := loadReport(args[0])
+			oldReport, err := models.LoadReport(args[0])
 			if err != nil {
 				return fmt.Errorf("old report: %w", err)
 			}
-			newReport, err := loadReport(args[1])
+			newReport, err := models.LoadReport(args[1])
 			if err != nil {
 				return fmt.Errorf("new report: %w", err)
 			}
 
-			result := computeDiff(oldReport, newReport, args[0], args[1])
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid --format value: %q (supported: text, json)", format)
+			}
+
+			result, err := computeDiff(oldReport, newReport, args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			if outputPath != "" {
+				if err := writeDiffJSON(outputPath, result); err != nil {
+					return err
+				}
+			}
 
 			if format == "json" {
-				enc := json.NewEncoder(os.Stdout)
+				enc := json.NewEncoder(cmd.OutOrStdout())
 				enc.SetIndent("", "  ")
 				return enc.Encode(result)
 			}
 
 			printDiff(cmd, result)
 
-			if result.Summary.Added > 0 || result.Summary.Changed > 0 {
-				return &FindingsError{Count: result.Summary.Added + result.Summary.Changed}
+			if count := result.Summary.Added + result.Summary.Changed + result.Summary.FindingsAdded + result.Summary.FindingsChanged; count > 0 {
+				return &FindingsError{Count: count}
 			}
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&format, "format", "text", "Output format (text|json)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Also write the diff as JSON to this file")
 
 	return cmd
 }
 
-func loadReport(path string) (*models.Report, error) {
-	// If path is a directory, look for report.json inside it
-	info, err := os.Stat(path)
-	if err != nil {
-		return nil, err
-	}
-	if info.IsDir() {
-		path = filepath.Join(path, "report.json")
-	}
-
-	data, err := os.ReadFile(path)
+func writeDiffJSON(path string, result *DiffResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to marshal diff: %w", err)
 	}
-
-	var report models.Report
-	if err := json.Unmarshal(data, &report); err != nil {
-		return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
-	return &report, nil
+	return nil
 }
 
-func computeDiff(oldR, newR *models.Report, oldPath, newPath string) *DiffResult {
+func computeDiff(oldR, newR *models.Report, oldPath, newPath string) (*DiffResult, error) {
 	oldTables := tableMap(oldR)
 	newTables := tableMap(newR)
 
@@ -137,13 +182,142 @@ func computeDiff(oldR, newR *models.Report, oldPath, newPath string) *DiffResult
 		}
 	}
 
+	sort.Slice(result.Added, func(i, j int) bool { return result.Added[i].Table < result.Added[j].Table })
+	sort.Slice(result.Removed, func(i, j int) bool { return result.Removed[i].Table < result.Removed[j].Table })
+	sort.Slice(result.Changed, func(i, j int) bool { return result.Changed[i].Table < result.Changed[j].Table })
+
+	findings, err := diffFindings(oldR, newR)
+	if err != nil {
+		return nil, err
+	}
+	result.Findings = findings
+
 	result.Summary = DiffSummary{
-		Added:   len(result.Added),
-		Removed: len(result.Removed),
-		Changed: len(result.Changed),
+		Added:           len(result.Added),
+		Removed:         len(result.Removed),
+		Changed:         len(result.Changed),
+		FindingsAdded:   len(findings.Added),
+		FindingsRemoved: len(findings.Removed),
+		FindingsChanged: len(findings.Changed),
 	}
 
-	return result
+	return result, nil
+}
+
+// diffFindings compares the baseline findings of two reports. Severity and
+// description are part of an anomaly's fingerprint, so anomalies are paired
+// back up by models.AnomalyID (type, table, and service) and reported as
+// changed rather than as a removal plus an addition.
+func diffFindings(oldR, newR *models.Report) (FindingsDiff, error) {
+	oldFindings, err := baseline.GenerateFindings(oldR)
+	if err != nil {
+		return FindingsDiff{}, fmt.Errorf("failed to fingerprint old report: %w", err)
+	}
+	newFindings, err := baseline.GenerateFindings(newR)
+	if err != nil {
+		return FindingsDiff{}, fmt.Errorf("failed to fingerprint new report: %w", err)
+	}
+
+	oldSet := make(map[string]bool, len(oldFindings))
+	for _, f := range oldFindings {
+		oldSet[f.Fingerprint] = true
+	}
+	newSet := make(map[string]bool, len(newFindings))
+	for _, f := range newFindings {
+		newSet[f.Fingerprint] = true
+	}
+
+	var added, removed []baseline.Finding
+	for _, f := range newFindings {
+		if !oldSet[f.Fingerprint] {
+			added = append(added, f)
+		}
+	}
+	for _, f := range oldFindings {
+		if !newSet[f.Fingerprint] {
+			removed = append(removed, f)
+		}
+	}
+
+	var diff FindingsDiff
+	removedAnomalies := make(map[string]baseline.Finding)
+	for _, f := range removed {
+		if f.Type == "anomaly" {
+			removedAnomalies[f.AnomalyID] = f
+		}
+	}
+	paired := make(map[string]bool)
+	for _, f := range added {
+		if f.Type == "anomaly" {
+			if old, found := removedAnomalies[f.AnomalyID]; found && !paired[old.Fingerprint] {
+				paired[old.Fingerprint] = true
+				change := FindingChange{
+					OldFingerprint: old.Fingerprint,
+					NewFingerprint: f.Fingerprint,
+					Table:          f.Stable.AffectedTable,
+					Service:        f.Stable.AffectedService,
+					Description:    f.Stable.Description,
+					OldSeverity:    old.Stable.Severity,
+					NewSeverity:    f.Stable.Severity,
+				}
+				if old.Stable.Description != f.Stable.Description {
+					change.OldDescription = old.Stable.Description
+				}
+				diff.Changed = append(diff.Changed, change)
+				continue
+			}
+		}
+		diff.Added = append(diff.Added, newFindingEntry(f))
+	}
+	for _, f := range removed {
+		if !paired[f.Fingerprint] {
+			diff.Removed = append(diff.Removed, newFindingEntry(f))
+		}
+	}
+
+	sortFindingEntries(diff.Added)
+	sortFindingEntries(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		if diff.Changed[i].Table != diff.Changed[j].Table {
+			return diff.Changed[i].Table < diff.Changed[j].Table
+		}
+		return diff.Changed[i].Description < diff.Changed[j].Description
+	})
+
+	return diff, nil
+}
+
+func newFindingEntry(f baseline.Finding) FindingEntry {
+	table := f.Stable.AffectedTable
+	if f.Stable.TableName != "" {
+		table = f.Stable.TableName
+		if f.Stable.DatabaseName != "" {
+			table = f.Stable.DatabaseName + "." + f.Stable.TableName
+		}
+	}
+	return FindingEntry{
+		Fingerprint: f.Fingerprint,
+		Type:        f.Type,
+		Table:       table,
+		Service:     f.Stable.AffectedService,
+		Severity:    f.Stable.Severity,
+		Description: f.Stable.Description,
+	}
+}
+
+func sortFindingEntries(entries []FindingEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Type != entries[j].Type {
+			return entries[i].Type < entries[j].Type
+		}
+		if entries[i].Table != entries[j].Table {
+			return entries[i].Table < entries[j].Table
+		}
+		if entries[i].Service != entries[j].Service {
+			return entries[i].Service < entries[j].Service
+		}
+		return entries[i].Description < entries[j].Description
+	})
 }
 
 func tableMap(r *models.Report) map[string]string {
@@ -159,7 +333,9 @@ func tableMap(r *models.Report) map[string]string {
 }
 
 func printDiff(cmd *cobra.Command, result *DiffResult) {
-	if result.Summary.Added == 0 && result.Summary.Removed == 0 && result.Summary.Changed == 0 {
+	summary := result.Summary
+	if summary.Added == 0 && summary.Removed == 0 && summary.Changed == 0 &&
+		summary.FindingsAdded == 0 && summary.FindingsRemoved == 0 && summary.FindingsChanged == 0 {
 		cmd.Println("No changes between reports.")
 		return
 	}
@@ -183,6 +359,60 @@ func printDiff(cmd *cobra.Command, result *DiffResult) {
 		}
 	}
 
-	cmd.Printf("\nSummary: %d added, %d removed, %d changed\n",
-		result.Summary.Added, result.Summary.Removed, result.Summary.Changed)
+	if len(result.Findings.Added) > 0 {
+		cmd.Printf("New findings (%d):\n", len(result.Findings.Added))
+		for _, f := range result.Findings.Added {
+			cmd.Printf("  + %s\n", describeFinding(f))
+		}
+	}
+	if len(result.Findings.Removed) > 0 {
+		cmd.Printf("Resolved findings (%d):\n", len(result.Findings.Removed))
+		for _, f := range result.Findings.Removed {
+			cmd.Printf("  - %s\n", describeFinding(f))
+		}
+	}
+	if len(result.Findings.Changed) > 0 {
+		cmd.Printf("Changed findings (%d):\n", len(result.Findings.Changed))
+		for _, c := range result.Findings.Changed {
+			cmd.Printf("  ~ %s: %s (%s)\n", findingSubject(c.Table, c.Service), c.Description, describeFindingChange(c))
+		}
+	}
+
+	cmd.Printf("\nSummary: %d added, %d removed, %d changed tables; %d new, %d resolved, %d changed findings\n",
+		summary.Added, summary.Removed, summary.Changed,
+		summary.FindingsAdded, summary.FindingsRemoved, summary.FindingsChanged)
+}
+
+func describeFinding(f FindingEntry) string {
+	line := fmt.Sprintf("%s [%s]", findingSubject(f.Table, f.Service), f.Type)
+	if f.Severity != "" {
+		line += " " + f.Severity
+	}
+	if f.Description != "" {
+		line += ": " + f.Description
+	}
+	return line
+}
+
+// describeFindingChange lists what changed about a paired anomaly
+func describeFindingChange(c FindingChange) string {
+	var changes []string
+	if c.OldSeverity != c.NewSeverity {
+		changes = append(changes, fmt.Sprintf("severity %s -> %s", c.OldSeverity, c.NewSeverity))
+	}
+	if c.OldDescription != "" {
+		changes = append(changes, fmt.Sprintf("was %q", c.OldDescription))
+	}
+	return strings.Join(changes, "; ")
+}
+
+func findingSubject(table, service string) string {
+	switch {
+	case table != "" && service != "":
+		return service + " -> " + table
+	case service != "":
+		return service
+	default:
+		return table
+	}
 }
@@ -4,7 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/ppiankov/clickspectre/internal/collector"
+	"github.com/ppiankov/clickspectre/internal/models"
 )
 
 func TestClassifyError_Nil(t *testing.T) {
@@ -57,6 +61,8 @@ func TestClassifyError_Network(t *testing.T) {
 		{"connection refused", errors.New("connection refused")},
 		{"i/o timeout", errors.New("i/o timeout")},
 		{"network unreachable", errors.New("network is unreachable")},
+		{"collector connect", fmt.Errorf("failed to create collector: %w", collector.ErrConnect)},
+		{"collector timeout", fmt.Errorf("collection failed: %w", collector.ErrTimeout)},
 	}
 
 	for _, tc := range cases {
@@ -68,6 +74,26 @@ func TestClassifyError_Network(t *testing.T) {
 	}
 }
 
+func TestCollectorHint(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("failed to create collector: %w", collector.ErrAuth), "user and password"},
+		{fmt.Errorf("collection failed: %w", collector.ErrSchema), "DESCRIBE system.query_log"},
+		{fmt.Errorf("failed to create collector: %w", collector.ErrConnect), "clickspectre doctor"},
+		{fmt.Errorf("collection failed: %w", collector.ErrTimeout), "--query-timeout"},
+	}
+	for _, tc := range cases {
+		if got := collectorHint(tc.err); !strings.Contains(got, tc.want) {
+			t.Errorf("collectorHint(%q) = %q, want it to mention %q", tc.err, got, tc.want)
+		}
+	}
+	if got := collectorHint(errors.New("something went wrong")); got != "" {
+		t.Errorf("expected no hint for an unclassified error, got %q", got)
+	}
+}
+
 func TestClassifyError_InvalidArg(t *testing.T) {
 	cases := []struct {
 		name string
@@ -102,3 +128,39 @@ func TestFindingsError_Error(t *testing.T) {
 		t.Errorf("FindingsError.Error() = %q, want %q", got, want)
 	}
 }
+
+func TestFindingsErrorSeverityBreakdown(t *testing.T) {
+	report := &models.Report{
+		CleanupRecommendations: models.CleanupRecommendations{
+			ZeroUsageNonReplicated: []models.TableRecommendation{{Name: "a"}},
+			ZeroUsageReplicated:    []models.TableRecommendation{{Name: "b"}, {Name: "c"}},
+			SafeToDrop:             []string{"db.d"},
+			LikelySafe:             []string{"db.e", "db.f"},
+		},
+		Anomalies: []models.Anomaly{
+			{Severity: "high"},
+			{Severity: "medium"},
+			{Severity: ""},
+			{Severity: "low"},
+		},
+	}
+
+	fe := findingsError(report)
+	if fe == nil {
+		t.Fatal("expected a FindingsError")
+	}
+	if fe.Count != 10 || fe.High != 2 || fe.Medium != 5 || fe.Low != 3 {
+		t.Fatalf("unexpected breakdown: %+v", *fe)
+	}
+	if fe.Count != countFindings(report) {
+		t.Fatalf("Count = %d, want countFindings %d", fe.Count, countFindings(report))
+	}
+	want := "10 findings (2 high, 5 medium, 3 low)"
+	if got := fe.Error(); got != want {
+		t.Errorf("FindingsError.Error() = %q, want %q", got, want)
+	}
+
+	if fe := findingsError(&models.Report{}); fe != nil {
+		t.Fatalf("expected no FindingsError for an empty report, got %+v", *fe)
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/ppiankov/clickspectre/internal/collector"
 	"github.com/ppiankov/clickspectre/internal/logging"
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/internal/scorer"
 	"github.com/ppiankov/clickspectre/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -43,19 +46,61 @@ type ExplainKind struct {
 	Count int64  `json:"count"`
 }
 
+// ReportExplanation is the evidence behind one table's categorization in a saved report.
+type ReportExplanation struct {
+	Table          string               `json:"table"`
+	Report         string               `json:"report"`
+	Category       string               `json:"category"`
+	Recommendation string               `json:"recommendation"`        // cleanup bucket, or "none"
+	KeepReason     string               `json:"keep_reason,omitempty"` // safety rule that forced "keep"
+	Score          float64              `json:"score"`
+	ScoreFactors   []scorer.ScoreFactor `json:"score_factors"`
+	Reads          uint64               `json:"reads"`
+	Writes         uint64               `json:"writes"`
+	FirstSeen      time.Time            `json:"first_seen"`
+	LastAccess     time.Time            `json:"last_access"`
+	Services       []ExplainService     `json:"services"`
+	Sparkline      ExplainSparkline     `json:"sparkline"`
+	IsMV           bool                 `json:"is_materialized_view"`
+	MVDependencies []string             `json:"mv_dependencies,omitempty"`
+	Anomalies      []models.Anomaly     `json:"anomalies,omitempty"`
+}
+
+// ExplainService is a service that touched the table.
+type ExplainService struct {
+	Service string `json:"service"`
+	Reads   uint64 `json:"reads"`
+	Writes  uint64 `json:"writes"`
+}
+
+// ExplainSparkline summarizes a table's query time series.
+type ExplainSparkline struct {
+	Points    int       `json:"points"`
+	Total     uint64    `json:"total"`
+	Peak      uint64    `json:"peak"`
+	PeakAt    time.Time `json:"peak_at,omitempty"`
+	FirstSeen time.Time `json:"first_seen,omitempty"`
+	LastSeen  time.Time `json:"last_seen,omitempty"`
+}
+
 // NewExplainCmd creates the explain command.
 func NewExplainCmd() *cobra.Command {
 	var (
-		dsn      string
-		lookback string
-		format   string
+		dsn        string
+		lookback   string
+		format     string
+		reportPath string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "explain <table>",
 		Short: "Structured table intelligence — what is this table?",
-		Long:  "Produce a structured summary of a table: metadata, usage, top users, top IPs, query patterns, and recommendation. Primary use case: agent context gathering.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Produce a structured summary of a table: metadata, usage, top users, top IPs, query patterns, and recommendation. Primary use case: agent context gathering.
+
+With --report, explain the table from a saved report.json instead of querying
+ClickHouse: score breakdown, services that touched it, read/write totals,
+sparkline summary, MV dependencies, and recommendation bucket.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var logOpts []logging.Option
 			if quiet {
@@ -63,8 +108,26 @@ func NewExplainCmd() *cobra.Command {
 			}
 			logging.Init(verbose, logOpts...)
 
+			if reportPath != "" {
+				report, err := models.LoadReport(reportPath)
+				if err != nil {
+					return fmt.Errorf("failed to load report: %w", err)
+				}
+				explanation, err := explainFromReport(report, reportPath, args[0])
+				if err != nil {
+					return err
+				}
+				if format == "json" {
+					enc := json.NewEncoder(cmd.OutOrStdout())
+					enc.SetIndent("", "  ")
+					return enc.Encode(explanation)
+				}
+				printReportExplanation(cmd, explanation)
+				return nil
+			}
+
 			if dsn == "" {
-				return fmt.Errorf("required flag --clickhouse-dsn not set")
+				return fmt.Errorf("required flag --clickhouse-dsn or --report not set")
 			}
 
 			dur, err := config.ParseDuration(lookback)
@@ -104,6 +167,7 @@ func NewExplainCmd() *cobra.Command {
 	cmd.Flags().StringVar(&dsn, "clickhouse-dsn", "", "ClickHouse DSN")
 	cmd.Flags().StringVar(&lookback, "lookback", "30d", "Analysis window")
 	cmd.Flags().StringVar(&format, "format", "text", "Output format (text|json)")
+	cmd.Flags().StringVar(&reportPath, "report", "", "Explain from a report directory or report.json instead of ClickHouse")
 
 	return cmd
 }
@@ -266,3 +330,186 @@ func printExplain(cmd *cobra.Command, o *ExplainOutput) {
 		}
 	}
 }
+
+// explainFromReport gathers the evidence for table from a saved report.
+// Score factors are recomputed as of the report's generation time with the
+// default diversity buckets.
+func explainFromReport(report *models.Report, reportPath, table string) (*ReportExplanation, error) {
+	var found *models.Table
+	for i := range report.Tables {
+		t := &report.Tables[i]
+		name := t.FullName
+		if name == "" {
+			name = t.Database + "." + t.Name
+		}
+		if name == table {
+			found = t
+			break
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("table %q does not exist in report %s", table, reportPath)
+	}
+
+	services := make(map[string]*models.Service, len(report.Services))
+	for i := range report.Services {
+		services[report.Services[i].IP] = &report.Services[i]
+	}
+
+	asOf := report.Metadata.GeneratedAt
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+
+	explanation := &ReportExplanation{
+		Table:          table,
+		Report:         reportPath,
+		Category:       found.Category,
+		Recommendation: recommendationBucket(report.CleanupRecommendations, table),
+		KeepReason:     report.CleanupRecommendations.KeepReasons[table],
+		Score:          found.Score,
+		ScoreFactors:   (&scorer.SimpleScorer{}).Explain(found, services, asOf),
+		Reads:          found.Reads,
+		Writes:         found.Writes,
+		FirstSeen:      found.FirstSeen,
+		LastAccess:     found.LastAccess,
+		Services:       []ExplainService{},
+		Sparkline:      summarizeSparkline(found.Sparkline),
+		IsMV:           found.IsMV,
+		MVDependencies: found.MVDependency,
+	}
+	if found.ZeroUsage && explanation.Category == "" {
+		explanation.Category = "zero_usage"
+	}
+
+	for _, edge := range report.Edges {
+		if edge.TableName != table {
+			continue
+		}
+		name := edge.ServiceName
+		if name == "" {
+			name = edge.ServiceIP
+		}
+		explanation.Services = append(explanation.Services, ExplainService{
+			Service: name,
+			Reads:   edge.Reads,
+			Writes:  edge.Writes,
+		})
+	}
+	sort.Slice(explanation.Services, func(i, j int) bool {
+		ti := explanation.Services[i].Reads + explanation.Services[i].Writes
+		tj := explanation.Services[j].Reads + explanation.Services[j].Writes
+		if ti != tj {
+			return ti > tj
+		}
+		return explanation.Services[i].Service < explanation.Services[j].Service
+	})
+
+	for _, anomaly := range report.Anomalies {
+		if anomaly.AffectedTable == table {
+			explanation.Anomalies = append(explanation.Anomalies, anomaly)
+		}
+	}
+
+	return explanation, nil
+}
+
+// recommendationBucket returns the cleanup bucket a table landed in.
+func recommendationBucket(recs models.CleanupRecommendations, table string) string {
+	for _, rec := range recs.ZeroUsageNonReplicated {
+		if rec.Name == table {
+			return "zero_usage_non_replicated"
+		}
+	}
+	for _, rec := range recs.ZeroUsageReplicated {
+		if rec.Name == table {
+			return "zero_usage_replicated"
+		}
+	}
+	buckets := []struct {
+		name   string
+		tables []string
+	}{
+		{name: "safe_to_drop", tables: recs.SafeToDrop},
+		{name: "likely_safe", tables: recs.LikelySafe},
+		{name: "keep", tables: recs.Keep},
+	}
+	for _, bucket := range buckets {
+		for _, name := range bucket.tables {
+			if name == table {
+				return bucket.name
+			}
+		}
+	}
+	return "none"
+}
+
+func summarizeSparkline(points []models.TimeSeriesPoint) ExplainSparkline {
+	summary := ExplainSparkline{Points: len(points)}
+	for i, point := range points {
+		summary.Total += point.Value
+		if i == 0 || point.Value > summary.Peak {
+			summary.Peak = point.Value
+			summary.PeakAt = point.Timestamp
+		}
+		if summary.FirstSeen.IsZero() || point.Timestamp.Before(summary.FirstSeen) {
+			summary.FirstSeen = point.Timestamp
+		}
+		if point.Timestamp.After(summary.LastSeen) {
+			summary.LastSeen = point.Timestamp
+		}
+	}
+	return summary
+}
+
+func printReportExplanation(cmd *cobra.Command, e *ReportExplanation) {
+	cmd.Printf("Table: %s\n", e.Table)
+	recommendation := e.Recommendation
+	if e.KeepReason != "" {
+		recommendation += " (" + e.KeepReason + ")"
+	}
+	cmd.Printf("Category: %s  Recommendation: %s  Score: %.2f\n\n", e.Category, recommendation, e.Score)
+
+	cmd.Println("Score breakdown:")
+	for _, factor := range e.ScoreFactors {
+		cmd.Printf("  %-18s %+.2f  %s\n", factor.Name, factor.Contribution, factor.Detail)
+	}
+	cmd.Println()
+
+	cmd.Printf("Usage: %d reads, %d writes\n", e.Reads, e.Writes)
+	if !e.LastAccess.IsZero() {
+		cmd.Printf("Last access: %s\n", e.LastAccess.Format(time.RFC3339))
+	}
+	if e.Sparkline.Points > 0 {
+		cmd.Printf("Activity: %d queries over %d buckets, peak %d at %s\n",
+			e.Sparkline.Total, e.Sparkline.Points, e.Sparkline.Peak, e.Sparkline.PeakAt.Format(time.RFC3339))
+	}
+	cmd.Println()
+
+	if len(e.Services) > 0 {
+		cmd.Println("Services:")
+		for _, svc := range e.Services {
+			cmd.Printf("  %-20s %d reads, %d writes\n", svc.Service, svc.Reads, svc.Writes)
+		}
+		cmd.Println()
+	}
+
+	if e.IsMV || len(e.MVDependencies) > 0 {
+		cmd.Printf("Materialized view: %t\n", e.IsMV)
+		for _, dep := range e.MVDependencies {
+			cmd.Printf("  depends: %s\n", dep)
+		}
+		cmd.Println()
+	}
+
+	if len(e.Anomalies) > 0 {
+		cmd.Println("Anomalies:")
+		for _, anomaly := range e.Anomalies {
+			if metrics := anomaly.MetricsText(); metrics != "" {
+				cmd.Printf("  [%s] %s [%s]\n", anomaly.Severity, anomaly.Description, metrics)
+			} else {
+				cmd.Printf("  [%s] %s\n", anomaly.Severity, anomaly.Description)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/logging"
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/internal/reporter/format"
+	"github.com/ppiankov/clickspectre/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// inventoryFormats lists the values accepted by inventory --format.
+var inventoryFormats = []string{"text", "json", "csv"}
+
+// InventoryTable is one table in the inventory output.
+type InventoryTable struct {
+	Database         string   `json:"database"`
+	Name             string   `json:"name"`
+	Engine           string   `json:"engine"`
+	IsReplicated     bool     `json:"is_replicated"`
+	IsMV             bool     `json:"is_materialized_view"`
+	TotalBytes       uint64   `json:"total_bytes"`
+	TotalRows        uint64   `json:"total_rows"`
+	MetadataModified string   `json:"metadata_modified,omitempty"` // RFC 3339, empty when unknown
+	PartitionKey     string   `json:"partition_key,omitempty"`
+	MVDependencies   []string `json:"mv_dependencies,omitempty"`
+}
+
+// InventoryOutput is the structured output.
+type InventoryOutput struct {
+	Tables []InventoryTable `json:"tables"`
+}
+
+// NewInventoryCmd creates the inventory command.
+func NewInventoryCmd() *cobra.Command {
+	cfg := config.DefaultConfig()
+	var (
+		outputFormat    string
+		queryTimeoutStr string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "List every table with its metadata, without analyzing usage",
+		Long: `List every table from system.tables with its engine, size, rows, creation
+time, and replication, for feeding other tooling. No query_log is read, so it
+is fast and works for readonly users. Exclusion and engine filters apply as
+they do for analyze.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var logOpts []logging.Option
+			if quiet {
+				logOpts = append(logOpts, logging.WithQuiet())
+			}
+			logging.Init(verbose, logOpts...)
+
+			if cfg.ClickHouseDSN == "" {
+				return fmt.Errorf("required flag --clickhouse-dsn not set")
+			}
+			outputFormat = strings.ToLower(strings.TrimSpace(outputFormat))
+			if !slices.Contains(inventoryFormats, outputFormat) {
+				return fmt.Errorf("invalid --format value: %q (supported: %s)", outputFormat, strings.Join(inventoryFormats, ", "))
+			}
+			timeout, err := config.ParseDuration(queryTimeoutStr)
+			if err != nil {
+				return fmt.Errorf("invalid --query-timeout duration: %w", err)
+			}
+			cfg.QueryTimeout = timeout
+			cfg.ClickHouseDSNs = strings.Split(cfg.ClickHouseDSN, ",")
+			for i := range cfg.ClickHouseDSNs {
+				cfg.ClickHouseDSNs[i] = strings.TrimSpace(cfg.ClickHouseDSNs[i])
+			}
+			cfg.Normalize()
+
+			col, err := newCollector(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to connect: %w", err)
+			}
+			defer func() { _ = col.Close() }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.QueryTimeout)
+			defer cancel()
+
+			tables, err := col.FetchTableMetadata(ctx)
+			if err != nil {
+				return err
+			}
+
+			output := buildInventory(tables, cfg)
+			return writeInventory(cmd.OutOrStdout(), output, outputFormat)
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.ClickHouseDSN, "clickhouse-dsn", "", "ClickHouse DSN")
+	cmd.Flags().StringVar(&outputFormat, "format", "text", "Output format (text|json|csv)")
+	cmd.Flags().StringVar(&queryTimeoutStr, "query-timeout", "5m", "Query timeout (e.g., 5m, 10m, 1h)")
+	cmd.Flags().IntVar(&cfg.InventoryPageSize, "inventory-page-size", config.DefaultInventoryPageSize, "system.tables rows fetched per query")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeTables, "exclude-table", []string{}, "Exclude table pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeDatabases, "exclude-database", []string{}, "Exclude database pattern (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.EngineAllow, "engine", []string{}, "Only list tables whose engine matches pattern, e.g. *MergeTree (repeatable, supports glob)")
+	cmd.Flags().StringSliceVar(&cfg.EngineDeny, "exclude-engine", []string{}, "Drop tables whose engine matches pattern (repeatable, supports glob)")
+
+	return cmd
+}
+
+// buildInventory lists tables not excluded by cfg, ordered by full name.
+// The collector already drops excluded tables; engines are filtered here.
+func buildInventory(tables map[string]*models.Table, cfg *config.Config) InventoryOutput {
+	output := InventoryOutput{Tables: make([]InventoryTable, 0, len(tables))}
+	for fullName, table := range tables {
+		if cfg.IsTableExcluded(fullName) || cfg.IsEngineExcluded(table.Engine) {
+			continue
+		}
+		entry := InventoryTable{
+			Database:       table.Database,
+			Name:           table.Name,
+			Engine:         table.Engine,
+			IsReplicated:   table.IsReplicated,
+			IsMV:           table.IsMV,
+			TotalBytes:     table.TotalBytes,
+			TotalRows:      table.TotalRows,
+			PartitionKey:   table.PartitionKey,
+			MVDependencies: table.MVDependency,
+		}
+		if !table.MetadataModified.IsZero() {
+			entry.MetadataModified = table.MetadataModified.UTC().Format(time.RFC3339)
+		}
+		output.Tables = append(output.Tables, entry)
+	}
+	sort.Slice(output.Tables, func(i, j int) bool {
+		a, b := output.Tables[i], output.Tables[j]
+		if a.Database != b.Database {
+			return a.Database < b.Database
+		}
+		return a.Name < b.Name
+	})
+	return output
+}
+
+func writeInventory(w io.Writer, output InventoryOutput, outputFormat string) error {
+	switch outputFormat {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output)
+	case "csv":
+		return writeInventoryCSV(w, output)
+	}
+
+	if len(output.Tables) == 0 {
+		_, err := fmt.Fprintln(w, "No tables found.")
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-45s %-28s %10s %10s %-10s %s\n", "TABLE", "ENGINE", "SIZE", "ROWS", "REPLICATED", "MODIFIED")
+	b.WriteString(strings.Repeat("-", 116) + "\n")
+	var totalBytes uint64
+	for _, table := range output.Tables {
+		created := "unknown"
+		if table.MetadataModified != "" {
+			created = table.MetadataModified[:len("2006-01-02")]
+		}
+		fmt.Fprintf(&b, "%-45s %-28s %10s %10s %-10t %s\n",
+			table.Database+"."+table.Name,
+			table.Engine,
+			format.HumanBytes(table.TotalBytes),
+			format.HumanCount(table.TotalRows),
+			table.IsReplicated,
+			created,
+		)
+		totalBytes += table.TotalBytes
+	}
+	fmt.Fprintf(&b, "\n%d table(s), %s\n", len(output.Tables), format.HumanBytes(totalBytes))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeInventoryCSV writes one row per table under a header row;
+// dependencies are joined with ";".
+func writeInventoryCSV(w io.Writer, output InventoryOutput) error {
+	cw := csv.NewWriter(w)
+	header := []string{"database", "name", "engine", "is_replicated", "is_materialized_view", "total_bytes", "total_rows", "metadata_modified", "partition_key", "mv_dependencies"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, table := range output.Tables {
+		record := []string{
+			table.Database,
+			table.Name,
+			table.Engine,
+			strconv.FormatBool(table.IsReplicated),
+			strconv.FormatBool(table.IsMV),
+			strconv.FormatUint(table.TotalBytes, 10),
+			strconv.FormatUint(table.TotalRows, 10),
+			table.MetadataModified,
+			table.PartitionKey,
+			strings.Join(table.MVDependencies, ";"),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/ppiankov/clickspectre/internal/app"
+	"github.com/ppiankov/clickspectre/internal/collector"
 	"github.com/ppiankov/clickspectre/internal/logging"
 	"github.com/spf13/cobra"
 )
@@ -31,18 +32,50 @@ const (
 )
 
 // FindingsError indicates the analysis completed but findings were detected.
+// High, Medium, and Low break Count down by severity when the command knows
+// it; Count stays the total.
 type FindingsError struct {
-	Count int
+	Count  int
+	High   int
+	Medium int
+	Low    int
 }
 
 func (e *FindingsError) Error() string {
-	return fmt.Sprintf("%d findings detected", e.Count)
+	if e.High+e.Medium+e.Low == 0 {
+		return fmt.Sprintf("%d findings detected", e.Count)
+	}
+	return fmt.Sprintf("%d findings (%d high, %d medium, %d low)", e.Count, e.High, e.Medium, e.Low)
 }
 
 func main() {
 	logging.Init(false)
 	isFirstRun = app.IsFirstRun()
 
+	if err := newRootCmd().Execute(); err != nil {
+		exitCode := classifyError(err)
+		var fe *FindingsError
+		if errors.As(err, &fe) {
+			slog.Info("findings detected",
+				slog.Int("count", fe.Count),
+				slog.Int("high", fe.High),
+				slog.Int("medium", fe.Medium),
+				slog.Int("low", fe.Low),
+			)
+		} else {
+			attrs := []any{slog.String("error", err.Error())}
+			if hint := collectorHint(err); hint != "" {
+				attrs = append(attrs, slog.String("hint", hint))
+			}
+			slog.Error("command failed", attrs...)
+		}
+		os.Exit(exitCode)
+	}
+}
+
+// newRootCmd builds the clickspectre command tree. --quiet applies to every
+// subcommand: logs drop to errors only and takes precedence over --verbose.
+func newRootCmd() *cobra.Command {
 	root := &cobra.Command{
 		Use:   "clickspectre",
 		Short: "ClickHouse usage analyzer",
@@ -67,11 +100,13 @@ service-to-table relationships, usage statistics, and cleanup recommendations.`,
 
 	root.AddCommand(NewAnalyzeCmd())
 	root.AddCommand(NewCIInitCmd())
+	root.AddCommand(NewCollectCmd())
 	root.AddCommand(NewDiffCmd())
 	root.AddCommand(NewDoctorCmd())
 	root.AddCommand(NewExplainCmd())
 	root.AddCommand(NewGrantsCmd())
 	root.AddCommand(NewInitCmd())
+	root.AddCommand(NewInventoryCmd())
 	root.AddCommand(NewLSCmd())
 	root.AddCommand(NewMCPCmd())
 	root.AddCommand(NewQueryCmd())
@@ -79,21 +114,13 @@ service-to-table relationships, usage statistics, and cleanup recommendations.`,
 	root.AddCommand(NewSlowCmd())
 	root.AddCommand(NewSnapshotCmd())
 	root.AddCommand(NewTopCmd())
+	root.AddCommand(NewValidateConfigCmd())
 	root.AddCommand(NewWhoCmd())
 	root.AddCommand(NewDeployCmd())
 	root.AddCommand(NewWatchCmd())
 	root.AddCommand(NewVersionCmd())
 
-	if err := root.Execute(); err != nil {
-		exitCode := classifyError(err)
-		var fe *FindingsError
-		if errors.As(err, &fe) {
-			slog.Info("findings detected", slog.Int("count", fe.Count))
-		} else {
-			slog.Error("command failed", slog.String("error", err.Error()))
-		}
-		os.Exit(exitCode)
-	}
+	return root
 }
 
 func classifyError(err error) int {
@@ -110,6 +137,10 @@ func classifyError(err error) int {
 		return ExitNotFound
 	}
 
+	if errors.Is(err, collector.ErrConnect) || errors.Is(err, collector.ErrTimeout) {
+		return ExitNetwork
+	}
+
 	msg := strings.ToLower(err.Error())
 
 	if strings.Contains(msg, "not a directory") ||
@@ -134,3 +165,19 @@ func classifyError(err error) int {
 
 	return ExitInternal
 }
+
+// collectorHint suggests a fix for the collector failures ErrAuth,
+// ErrSchema, ErrConnect, and ErrTimeout, or returns "".
+func collectorHint(err error) string {
+	switch {
+	case errors.Is(err, collector.ErrAuth):
+		return "check the ClickHouse user and password in --clickhouse-dsn or --clickhouse-password-file"
+	case errors.Is(err, collector.ErrSchema):
+		return "compare DESCRIBE system.query_log with the columns listed in docs/cli-reference.md"
+	case errors.Is(err, collector.ErrConnect):
+		return "check the host and port in --clickhouse-dsn; 'clickspectre doctor' tests the connection"
+	case errors.Is(err, collector.ErrTimeout):
+		return "raise --query-timeout, or shorten --lookback or --batch-size"
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ppiankov/clickspectre/internal/collector"
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+// progressPrinter renders a single, continuously rewritten line with the
+// number of query_log entries collected so far across all nodes.
+type progressPrinter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	totals  map[string]int
+	printed bool
+}
+
+func newProgressPrinter(w io.Writer) *progressPrinter {
+	return &progressPrinter{
+		w:      w,
+		totals: make(map[string]int),
+	}
+}
+
+// Update is a collector.ProgressFunc.
+func (p *progressPrinter) Update(progress collector.Progress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.totals[progress.Node] = progress.Total
+
+	total := 0
+	for _, n := range p.totals {
+		total += n
+	}
+	if len(p.totals) == 1 {
+		fmt.Fprintf(p.w, "\rcollecting query_log: %d entries (offset %d)\033[K", total, progress.Offset)
+	} else {
+		fmt.Fprintf(p.w, "\rcollecting query_log: %d entries from %d nodes\033[K", total, len(p.totals))
+	}
+	p.printed = true
+}
+
+// Finish ends the progress line so later output starts on a fresh line.
+func (p *progressPrinter) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.printed {
+		fmt.Fprintln(p.w)
+		p.printed = false
+	}
+}
+
+// progressOptions returns collector options for --progress. Progress is
+// only drawn when stderr is a terminal, so redirected logs stay clean.
+func progressOptions(enabled bool) ([]collector.Option, func()) {
+	if !enabled || !isTerminal(os.Stderr) {
+		return nil, func() {}
+	}
+	printer := newProgressPrinter(os.Stderr)
+	return []collector.Option{collector.WithProgress(printer.Update)}, printer.Finish
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressCollector ends the progress line as soon as collection returns,
+// before analysis starts logging.
+type progressCollector struct {
+	collector.Collector
+	finish func()
+}
+
+// Collect collects through the wrapped collector, then finishes progress.
+func (c progressCollector) Collect(ctx context.Context) ([]*models.QueryLogEntry, error) {
+	entries, err := c.Collector.Collect(ctx)
+	c.finish()
+	return entries, err
+}
@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
+	"time"
 
+	"github.com/ppiankov/clickspectre/internal/models"
 	"github.com/spf13/cobra"
 )
 
+// reportPollInterval is how often --watch checks report.json for changes.
+const reportPollInterval = time.Second
+
 // NewServeCmd creates the serve command
 func NewServeCmd() *cobra.Command {
 	var dir string
 	var port int
+	var watch bool
 
 	cmd := &cobra.Command{
 		Use:   "serve [directory]",
@@ -27,18 +35,19 @@ The report will be available at http://localhost:PORT`,
 				dir = args[0]
 			}
 
-			return runServe(dir, port)
+			return runServe(dir, port, watch)
 		},
 	}
 
 	cmd.Flags().StringVar(&dir, "dir", "./report", "Directory to serve")
 	cmd.Flags().IntVar(&port, "port", 8080, "Port to serve on")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Reload open report pages when report.json changes")
 
 	return cmd
 }
 
 // runServe starts the HTTP server
-func runServe(dir string, port int) error {
+func runServe(dir string, port int, watch bool) error {
 	// Validate directory exists
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		return fmt.Errorf("directory not found: %s", dir)
@@ -49,20 +58,134 @@ func runServe(dir string, port int) error {
 	if _, err := os.Stat(reportPath); os.IsNotExist(err) {
 		return fmt.Errorf("report.json not found in %s\nRun 'clickspectre analyze' first to generate a report", dir)
 	}
+	// The UI may still render most of a mismatched report, so only warn
+	if _, err := models.LoadReport(reportPath); err != nil {
+		slog.Warn("report.json may not render correctly", slog.String("error", err.Error()))
+	}
 
 	// Start server
-	http.Handle("/", http.FileServer(http.Dir(dir)))
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir(dir)))
+	if watch {
+		watcher := newReportWatcher(reportPath)
+		ticker := time.NewTicker(reportPollInterval)
+		defer ticker.Stop()
+		go watcher.Run(context.Background(), ticker.C)
+		mux.Handle("/events", watcher)
+	}
 	addr := fmt.Sprintf(":%d", port)
 
 	url := "http://localhost:" + strconv.Itoa(port)
 	slog.Info("report server started",
 		slog.String("url", url),
 		slog.String("dir", dir),
+		slog.Bool("watch", watch),
 		slog.String("stop", "Ctrl+C"),
 	)
 
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	if err := http.ListenAndServe(addr, mux); err != nil {
 		return fmt.Errorf("server stopped: %w", err)
 	}
 	return nil
 }
+
+// reportWatcher polls report.json's modification time and pushes a reload
+// event to every connected browser over Server-Sent Events when it changes.
+type reportWatcher struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	clients map[chan struct{}]struct{}
+}
+
+func newReportWatcher(path string) *reportWatcher {
+	w := &reportWatcher{
+		path:    path,
+		clients: make(map[chan struct{}]struct{}),
+	}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+// Run checks for changes on every tick until ctx is done.
+func (w *reportWatcher) Run(ctx context.Context, ticks <-chan time.Time) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			w.Check()
+		}
+	}
+}
+
+// Check notifies clients if report.json changed since the last check and
+// reports whether it did. A missing file (mid-rewrite) is not a change.
+func (w *reportWatcher) Check() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if info.ModTime().Equal(w.modTime) {
+		return false
+	}
+	w.modTime = info.ModTime()
+
+	slog.Info("report changed, notifying viewers",
+		slog.String("path", w.path),
+		slog.Int("clients", len(w.clients)),
+	)
+	for client := range w.clients {
+		// Clients only need to know that something changed, so never block
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+func (w *reportWatcher) subscribe() (chan struct{}, func()) {
+	client := make(chan struct{}, 1)
+	w.mu.Lock()
+	w.clients[client] = struct{}{}
+	w.mu.Unlock()
+	return client, func() {
+		w.mu.Lock()
+		delete(w.clients, client)
+		w.mu.Unlock()
+	}
+}
+
+// ServeHTTP streams a "reload" event each time report.json changes.
+func (w *reportWatcher) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client, unsubscribe := w.subscribe()
+	defer unsubscribe()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	fmt.Fprint(rw, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client:
+			fmt.Fprint(rw, "event: reload\ndata: report.json\n\n")
+			flusher.Flush()
+		}
+	}
+}
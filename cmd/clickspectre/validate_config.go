@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ppiankov/clickspectre/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// NewValidateConfigCmd creates the validate-config command.
+func NewValidateConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate-config [path]",
+		Short: "Check a config file for unknown keys and invalid values",
+		Long: `Loads a .clickspectre.yaml/.clickspectre.json file and reports which keys
+were recognized, warns about keys that would be silently ignored, and checks
+duration and format values.
+
+Without a path, the same discovery as analyze is used (current directory,
+then home directory). Unknown keys are warnings; invalid values exit non-zero.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			} else {
+				_, found, err := config.AutoLoadFile()
+				if err != nil {
+					return err
+				}
+				if found == "" {
+					return fmt.Errorf("no config file found: %w", os.ErrNotExist)
+				}
+				path = found
+			}
+
+			result, err := config.ValidateFile(path)
+			if err != nil {
+				return err
+			}
+			printConfigValidation(cmd, result)
+
+			if len(result.Errors) > 0 {
+				return fmt.Errorf("config file %q has %d invalid value(s)", result.Path, len(result.Errors))
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func printConfigValidation(cmd *cobra.Command, result *config.FileValidation) {
+	cmd.Printf("Config file: %s\n", result.Path)
+	if len(result.Recognized) > 0 {
+		cmd.Printf("  ✓ recognized: %s\n", strings.Join(result.Recognized, ", "))
+	}
+	for _, key := range result.Unknown {
+		cmd.Printf("  ! unknown key: %s (ignored)\n", key)
+	}
+	for _, msg := range result.Errors {
+		cmd.Printf("  ✗ %s\n", msg)
+	}
+	cmd.Printf("\n%d recognized, %d unknown, %d invalid\n",
+		len(result.Recognized), len(result.Unknown), len(result.Errors))
+}
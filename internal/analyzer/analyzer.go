@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/ppiankov/clickspectre/internal/k8s"
@@ -16,66 +19,204 @@ type CollectorInterface interface {
 	FetchTableMetadata(ctx context.Context) (map[string]*models.Table, error)
 }
 
-// Analyzer processes query log entries and builds analysis models
+// Analyzer processes query log entries and builds analysis models.
+//
+// Analyze and AnalyzeReport hold the analyzer's lock for the whole run, so
+// concurrent runs on one Analyzer are serialized and the *Snapshot accessors
+// are safe to call at any time. Tables, Services, Edges, and Anomalies return
+// the live models for the scorer to update in place; use them only once
+// analysis has finished.
 type Analyzer struct {
-	config    *config.Config
-	resolver  k8s.K8sResolverInterface // Use interface here
-	collector CollectorInterface
-	tables    map[string]*models.Table
-	services  map[string]*models.Service
-	edges     []*models.Edge
-	anomalies []*models.Anomaly
+	config     *config.Config
+	resolver   k8s.K8sResolverInterface // Use interface here
+	collector  CollectorInterface
+	mu         sync.RWMutex // Guards the models below
+	tables     map[string]*models.Table
+	services   map[string]*models.Service
+	edges      []*models.Edge
+	anomalies  []*models.Anomaly
+	anomalyIDs map[string]struct{}          // IDs in anomalies, for de-duplication
+	inventory  map[string]*models.Table     // system.tables snapshot, set by inventory enrichment
+	priorUsage map[string]models.TableUsage // Usage from earlier incremental runs, see SetPriorUsage
+	k8sStats   K8sResolutionStats           // Outcome of the last K8s lookup, see K8sResolution
+	scans      map[string]*scanStats        // This run's successful reads per table, for full_scan_suspect
 }
 
 // New creates a new analyzer instance
 func New(cfg *config.Config, resolver k8s.K8sResolverInterface, collector CollectorInterface) *Analyzer {
 	return &Analyzer{
-		config:    cfg,
-		resolver:  resolver,
-		collector: collector,
-		tables:    make(map[string]*models.Table),
-		services:  make(map[string]*models.Service),
-		edges:     make([]*models.Edge, 0),
-		anomalies: make([]*models.Anomaly, 0),
+		config:     cfg,
+		resolver:   resolver,
+		collector:  collector,
+		tables:     make(map[string]*models.Table),
+		services:   make(map[string]*models.Service),
+		edges:      make([]*models.Edge, 0),
+		anomalies:  make([]*models.Anomaly, 0),
+		anomalyIDs: make(map[string]struct{}),
+		scans:      make(map[string]*scanStats),
+	}
+}
+
+// SetPriorUsage supplies table usage accumulated by earlier incremental runs.
+// Analyze folds it into the table model before inventory enrichment, so a
+// table idle since the last run keeps its history instead of looking unused.
+func (a *Analyzer) SetPriorUsage(usage map[string]models.TableUsage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.priorUsage = usage
+}
+
+// analysisStage is one step of the Analyze pipeline
+type analysisStage struct {
+	name    string
+	enabled func() bool // nil means always run
+	run     func(ctx context.Context, entries []*models.QueryLogEntry) error
+}
+
+// pipeline returns the analysis stages in execution order. Later stages
+// read what earlier ones produce: edges need services, and anomaly
+// detection reads table sparklines, so sparklines must come first.
+func (a *Analyzer) pipeline() []analysisStage {
+	return []analysisStage{
+		{
+			name: "build table model",
+			run:  a.buildTableModel,
+		},
+		{
+			name:    "merge prior usage",
+			enabled: func() bool { return len(a.priorUsage) > 0 },
+			run:     a.mergePriorUsage,
+		},
+		{
+			name:    "enrich with table inventory",
+			enabled: func() bool { return a.config.DetectUnusedTables },
+			run: func(ctx context.Context, _ []*models.QueryLogEntry) error {
+				return a.enrichWithCompleteInventory(ctx)
+			},
+		},
+		{
+			name: "estimate partition heat",
+			enabled: func() bool {
+				return a.config.DetectUnusedTables && a.config.PartitionHeat
+			},
+			run: a.estimatePartitionHeat,
+		},
+		{
+			name: "detect orphaned materialized views",
+			enabled: func() bool {
+				return a.config.DetectUnusedTables && a.config.AnomalyDetection
+			},
+			run: func(ctx context.Context, _ []*models.QueryLogEntry) error {
+				return a.detectOrphanedMVs(ctx)
+			},
+		},
+		{
+			name: "build service model",
+			run:  a.buildServiceModel,
+		},
+		{
+			name: "build edges",
+			run:  a.buildEdges,
+		},
+		{
+			name: "generate sparklines",
+			run:  a.generateSparklines,
+		},
+		a.anomalyStage(),
+	}
+}
+
+// anomalyStage detects anomalies from the table models built so far
+func (a *Analyzer) anomalyStage() analysisStage {
+	return analysisStage{
+		name:    "detect anomalies",
+		enabled: func() bool { return a.config.AnomalyDetection },
+		run: func(ctx context.Context, _ []*models.QueryLogEntry) error {
+			return a.detectAnomalies(ctx)
+		},
 	}
 }
 
 // Analyze processes query log entries and builds all data models
 func (a *Analyzer) Analyze(ctx context.Context, entries []*models.QueryLogEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	slog.Debug("starting analysis", slog.Int("query_entries", len(entries)))
 
-	// 1. Build table usage model
-	if err := a.buildTableModel(entries); err != nil {
-		return fmt.Errorf("failed to build table model: %w", err)
+	entries = a.filterExcludedEntries(entries)
+	a.canonicalizeClientIPs(entries)
+	if err := a.runStages(ctx, entries, a.pipeline()); err != nil {
+		return err
 	}
 
-	// 1.5. Enrich with complete table inventory (if enabled)
-	if a.config.DetectUnusedTables {
-		if err := a.enrichWithCompleteInventory(ctx); err != nil {
-			return fmt.Errorf("failed to enrich with table inventory: %w", err)
-		}
+	slog.Debug("analysis complete",
+		slog.Int("tables", len(a.tables)),
+		slog.Int("services", len(a.services)),
+		slog.Int("edges", len(a.edges)),
+		slog.Int("anomalies", len(a.anomalies)),
+	)
+
+	return nil
+}
+
+// filterExcludedEntries drops entries from excluded users, query kinds, or
+// log comments so they contribute to no table, service, or edge. The
+// collector already drops them while fetching; this covers entries loaded
+// with --from-file.
+func (a *Analyzer) filterExcludedEntries(entries []*models.QueryLogEntry) []*models.QueryLogEntry {
+	if !a.config.HasEntryExclusions() {
+		return entries
 	}
 
-	// 2. Build service model (with K8s resolution if enabled)
-	if err := a.buildServiceModel(ctx, entries); err != nil {
-		return fmt.Errorf("failed to build service model: %w", err)
+	kept := make([]*models.QueryLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if a.config.IsEntryExcluded(entry.User, entry.QueryKind, entry.LogComment) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if dropped := len(entries) - len(kept); dropped > 0 {
+		slog.Debug("dropped excluded query log entries", slog.Int("entries", dropped))
 	}
+	return kept
+}
 
-	// 3. Create service→table edges
-	if err := a.buildEdges(entries); err != nil {
-		return fmt.Errorf("failed to build edges: %w", err)
+// canonicalizeClientIPs rewrites each entry's client IP to its canonical
+// form so ::ffff:10.0.0.1 and 10.0.0.1 key the same service and edges.
+// Entries are updated in place.
+func (a *Analyzer) canonicalizeClientIPs(entries []*models.QueryLogEntry) {
+	if !a.config.NormalizeIPv6 {
+		return
+	}
+	for _, entry := range entries {
+		entry.ClientIP = config.CanonicalIP(entry.ClientIP)
 	}
+}
 
-	// 4. Detect anomalies (if enabled)
-	if a.config.AnomalyDetection {
-		if err := a.detectAnomalies(); err != nil {
-			return fmt.Errorf("failed to detect anomalies: %w", err)
-		}
+// AnalyzeReport seeds the models from a previously written report instead of
+// query log entries and re-runs anomaly detection with the current config.
+// Scores and anomalies from the prior report are discarded so they can be
+// recomputed, and tables matching the current exclusions are dropped.
+func (a *Analyzer) AnalyzeReport(ctx context.Context, report *models.Report) error {
+	if report == nil {
+		return fmt.Errorf("report is nil")
 	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	slog.Debug("starting analysis from report", slog.Int("tables", len(report.Tables)))
 
-	// 5. Generate time series for sparklines
-	if err := a.generateSparklines(entries); err != nil {
-		return fmt.Errorf("failed to generate sparklines: %w", err)
+	stages := []analysisStage{
+		{
+			name: "load prior report",
+			run: func(_ context.Context, _ []*models.QueryLogEntry) error {
+				a.loadReport(report)
+				return nil
+			},
+		},
+		a.anomalyStage(),
+	}
+	if err := a.runStages(ctx, nil, stages); err != nil {
+		return err
 	}
 
 	slog.Debug("analysis complete",
@@ -88,11 +229,139 @@ func (a *Analyzer) Analyze(ctx context.Context, entries []*models.QueryLogEntry)
 	return nil
 }
 
-// Tables returns the analyzed tables
+// loadReport copies tables, services, and edges from report into the models
+func (a *Analyzer) loadReport(report *models.Report) {
+	for i := range report.Tables {
+		table := report.Tables[i]
+		name := table.FullName
+		if name == "" || a.config.IsTableExcluded(name) || a.config.IsEngineExcluded(table.Engine) {
+			continue
+		}
+		table.Score = 0
+		table.Category = ""
+		a.tables[name] = &table
+	}
+
+	for i := range report.Services {
+		service := report.Services[i]
+		tablesUsed := make([]string, 0, len(service.TablesUsed))
+		for _, name := range service.TablesUsed {
+			if !a.config.IsTableExcluded(name) {
+				tablesUsed = append(tablesUsed, name)
+			}
+		}
+		service.TablesUsed = tablesUsed
+		a.services[service.IP] = &service
+	}
+
+	for i := range report.Edges {
+		edge := report.Edges[i]
+		if a.config.IsTableExcluded(edge.TableName) {
+			continue
+		}
+		a.edges = append(a.edges, &edge)
+	}
+	a.countDistinctServices()
+}
+
+// ctxCheckInterval is how many loop iterations the analysis stages run
+// between context checks, so cancellation is noticed promptly on large
+// inputs without paying for a check on every entry.
+const ctxCheckInterval = 1024
+
+// checkContext returns the context error on every ctxCheckInterval-th
+// iteration, including the first, and nil otherwise.
+func checkContext(ctx context.Context, i int) error {
+	if i%ctxCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// runStages executes stages in order, stopping at the first failure or
+// when ctx is cancelled
+func (a *Analyzer) runStages(ctx context.Context, entries []*models.QueryLogEntry, stages []analysisStage) error {
+	for _, stage := range stages {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("failed to %s: %w", stage.name, err)
+		}
+		if stage.enabled != nil && !stage.enabled() {
+			slog.Debug("skipping analysis stage", slog.String("stage", stage.name))
+			continue
+		}
+		if err := stage.run(ctx, entries); err != nil {
+			return fmt.Errorf("failed to %s: %w", stage.name, err)
+		}
+	}
+	return nil
+}
+
+// Tables returns the analyzed tables. The map is live; see TablesSnapshot
+// for a copy that is safe to read while analysis runs.
 func (a *Analyzer) Tables() map[string]*models.Table {
 	return a.tables
 }
 
+// TablesSnapshot returns a copy of the analyzed tables taken under the
+// analyzer's lock. Changes to the copy do not affect the analyzer.
+func (a *Analyzer) TablesSnapshot() map[string]*models.Table {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	snapshot := make(map[string]*models.Table, len(a.tables))
+	for name, table := range a.tables {
+		copied := *table
+		copied.Sparkline = slices.Clone(table.Sparkline)
+		if table.Heatmap != nil {
+			heatmap := *table.Heatmap
+			copied.Heatmap = &heatmap
+		}
+		copied.MVDependency = slices.Clone(table.MVDependency)
+		copied.MVSources = slices.Clone(table.MVSources)
+		copied.SampleQueries = slices.Clone(table.SampleQueries)
+		snapshot[name] = &copied
+	}
+	return snapshot
+}
+
+// ServicesSnapshot returns a copy of the analyzed services taken under the
+// analyzer's lock.
+func (a *Analyzer) ServicesSnapshot() map[string]*models.Service {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	snapshot := make(map[string]*models.Service, len(a.services))
+	for ip, service := range a.services {
+		copied := *service
+		copied.TablesUsed = slices.Clone(service.TablesUsed)
+		snapshot[ip] = &copied
+	}
+	return snapshot
+}
+
+// EdgesSnapshot returns a copy of the service→table edges taken under the
+// analyzer's lock.
+func (a *Analyzer) EdgesSnapshot() []*models.Edge {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	snapshot := make([]*models.Edge, 0, len(a.edges))
+	for _, edge := range a.edges {
+		copied := *edge
+		snapshot = append(snapshot, &copied)
+	}
+	return snapshot
+}
+
+// K8sResolution returns how many client IPs the last Analyze resolved
+// through Kubernetes and how many failed. It is zero when resolution is
+// disabled.
+func (a *Analyzer) K8sResolution() K8sResolutionStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.k8sStats
+}
+
 // Services returns the analyzed services
 func (a *Analyzer) Services() map[string]*models.Service {
 	return a.services
@@ -103,11 +372,31 @@ func (a *Analyzer) Edges() []*models.Edge {
 	return a.edges
 }
 
-// Anomalies returns detected anomalies
+// Anomalies returns detected anomalies. The slice is live; see
+// AnomaliesSnapshot for a copy that is safe to read while analysis runs.
 func (a *Analyzer) Anomalies() []*models.Anomaly {
 	return a.anomalies
 }
 
+// AnomaliesSnapshot returns a copy of the detected anomalies taken under the
+// analyzer's lock.
+func (a *Analyzer) AnomaliesSnapshot() []*models.Anomaly {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	snapshot := make([]*models.Anomaly, 0, len(a.anomalies))
+	for _, anomaly := range a.anomalies {
+		copied := *anomaly
+		copied.Metrics = maps.Clone(anomaly.Metrics)
+		if anomaly.InBaseline != nil {
+			inBaseline := *anomaly.InBaseline
+			copied.InBaseline = &inBaseline
+		}
+		snapshot = append(snapshot, &copied)
+	}
+	return snapshot
+}
+
 // BuildUserActivity aggregates query log entries by user and returns per-user activity summaries.
 func BuildUserActivity(entries []*models.QueryLogEntry) []models.UserActivity {
 	type userAgg struct {
@@ -174,23 +463,37 @@ func (a *Analyzer) enrichWithCompleteInventory(ctx context.Context) error {
 	}
 
 	slog.Debug("table inventory fetched", slog.Int("tables", len(allTables)))
+	a.inventory = allTables
 
 	// 2. Merge with existing usage data
 	zeroUsageCount := 0
+	engineFiltered := 0
 	for fullName, metaTable := range allTables {
 		if a.config.IsTableExcluded(fullName) {
 			continue
 		}
+		// Engines are only known from the inventory, so the engine filter
+		// also removes tables the query log already modelled
+		if a.config.IsEngineExcluded(metaTable.Engine) {
+			delete(a.tables, fullName)
+			engineFiltered++
+			continue
+		}
 
 		if existing, found := a.tables[fullName]; found {
 			// Table HAS usage - enrich with metadata
+			existing.Database = metaTable.Database
+			existing.Name = metaTable.Name
 			existing.Engine = metaTable.Engine
 			existing.IsReplicated = metaTable.IsReplicated
 			existing.TotalBytes = metaTable.TotalBytes
 			existing.TotalRows = metaTable.TotalRows
-			existing.CreateTime = metaTable.CreateTime
+			existing.MetadataModified = metaTable.MetadataModified
 			existing.IsMV = metaTable.IsMV
 			existing.MVDependency = metaTable.MVDependency
+			existing.MVSources = metaTable.MVSources
+			existing.PartitionKey = metaTable.PartitionKey
+			existing.Partitions = metaTable.Partitions
 			existing.ZeroUsage = false
 		} else {
 			// Table has ZERO usage - add as new entry
@@ -208,6 +511,7 @@ func (a *Analyzer) enrichWithCompleteInventory(ctx context.Context) error {
 	slog.Debug("table inventory enrichment complete",
 		slog.Int("total_tables", len(a.tables)),
 		slog.Int("zero_usage_tables", zeroUsageCount),
+		slog.Int("engine_filtered_tables", engineFiltered),
 	)
 
 	return nil
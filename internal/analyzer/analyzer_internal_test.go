@@ -2,7 +2,11 @@ package analyzer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,6 +18,8 @@ import (
 // Mock K8s Resolver
 type mockK8sResolver struct {
 	resolveIPFunc func(ctx context.Context, ip string) (*k8s.ServiceInfo, error)
+	batchCalls    int
+	batchErr      error
 }
 
 func (m *mockK8sResolver) ResolveIP(ctx context.Context, ip string) (*k8s.ServiceInfo, error) {
@@ -23,6 +29,22 @@ func (m *mockK8sResolver) ResolveIP(ctx context.Context, ip string) (*k8s.Servic
 	return nil, nil
 }
 
+func (m *mockK8sResolver) ResolveIPs(ctx context.Context, ips []string) (map[string]*k8s.ServiceInfo, error) {
+	m.batchCalls++
+	if m.batchErr != nil {
+		return nil, m.batchErr
+	}
+	result := make(map[string]*k8s.ServiceInfo, len(ips))
+	for _, ip := range ips {
+		info, err := m.ResolveIP(ctx, ip)
+		if err != nil {
+			continue
+		}
+		result[ip] = info
+	}
+	return result, nil
+}
+
 func (m *mockK8sResolver) Close() error {
 	return nil
 }
@@ -87,7 +109,7 @@ func TestBuildTableModel(t *testing.T) {
 		},
 	}
 
-	if err := a.buildTableModel(entries); err != nil { // Directly call the internal method
+	if err := a.buildTableModel(context.Background(), entries); err != nil { // Directly call the internal method
 		t.Fatalf("buildTableModel failed: %v", err)
 	}
 
@@ -151,7 +173,7 @@ func TestBuildTableModel(t *testing.T) {
 
 	// Test with empty entries
 	a = New(cfg, nil, nil)
-	if err := a.buildTableModel([]*models.QueryLogEntry{}); err != nil {
+	if err := a.buildTableModel(context.Background(), []*models.QueryLogEntry{}); err != nil {
 		t.Fatalf("buildTableModel with empty entries failed: %v", err)
 	}
 	if len(a.Tables()) != 0 {
@@ -168,7 +190,7 @@ func TestBuildTableModel(t *testing.T) {
 			Tables:    []string{"", "db.valid_table"},
 		},
 	}
-	if err := a.buildTableModel(emptyTableNameEntries); err != nil {
+	if err := a.buildTableModel(context.Background(), emptyTableNameEntries); err != nil {
 		t.Fatalf("buildTableModel with empty table name failed: %v", err)
 	}
 	if _, ok := a.Tables()["db.valid_table"]; !ok {
@@ -180,7 +202,7 @@ func TestBuildTableModel(t *testing.T) {
 }
 
 func TestGenerateSparklines(t *testing.T) {
-	now := time.Now().Truncate(time.Hour) // Use truncated time for consistent hourly buckets
+	now := time.Now().UTC().Truncate(time.Hour) // Buckets are reported in the configured zone, UTC by default
 	cfg := config.DefaultConfig()
 
 	// Test case 1: Single entry for a table
@@ -191,7 +213,7 @@ func TestGenerateSparklines(t *testing.T) {
 	// Manually populate tables map for sparkline generation
 	a1.Tables()["db.table1"] = &models.Table{FullName: "db.table1"}
 
-	if err := a1.generateSparklines(entries1); err != nil {
+	if err := a1.generateSparklines(context.Background(), entries1); err != nil {
 		t.Fatalf("generateSparklines failed: %v", err)
 	}
 	expectedSparkline1 := []models.TimeSeriesPoint{
@@ -210,7 +232,7 @@ func TestGenerateSparklines(t *testing.T) {
 	}
 	a2.Tables()["db.table2"] = &models.Table{FullName: "db.table2"}
 
-	if err := a2.generateSparklines(entries2); err != nil {
+	if err := a2.generateSparklines(context.Background(), entries2); err != nil {
 		t.Fatalf("generateSparklines failed: %v", err)
 	}
 	expectedSparkline2 := []models.TimeSeriesPoint{
@@ -229,7 +251,7 @@ func TestGenerateSparklines(t *testing.T) {
 	}
 	a3.Tables()["db.table3"] = &models.Table{FullName: "db.table3"}
 
-	if err := a3.generateSparklines(entries3); err != nil {
+	if err := a3.generateSparklines(context.Background(), entries3); err != nil {
 		t.Fatalf("generateSparklines failed: %v", err)
 	}
 	expectedSparkline3 := []models.TimeSeriesPoint{
@@ -250,7 +272,7 @@ func TestGenerateSparklines(t *testing.T) {
 	a4.Tables()["db.tableA"] = &models.Table{FullName: "db.tableA"}
 	a4.Tables()["db.tableB"] = &models.Table{FullName: "db.tableB"}
 
-	if err := a4.generateSparklines(entries4); err != nil {
+	if err := a4.generateSparklines(context.Background(), entries4); err != nil {
 		t.Fatalf("generateSparklines failed: %v", err)
 	}
 	expectedSparklineA := []models.TimeSeriesPoint{
@@ -269,7 +291,7 @@ func TestGenerateSparklines(t *testing.T) {
 
 	// Test case 5: Empty entries list
 	a5 := New(cfg, nil, nil)
-	if err := a5.generateSparklines([]*models.QueryLogEntry{}); err != nil {
+	if err := a5.generateSparklines(context.Background(), []*models.QueryLogEntry{}); err != nil {
 		t.Fatalf("generateSparklines with empty entries failed: %v", err)
 	}
 	// No tables, so no sparklines will be generated
@@ -383,6 +405,28 @@ func TestBuildServiceModel(t *testing.T) {
 		t.Errorf("expected no K8s info on error, got %+v", svc4_1)
 	}
 
+	// Test case 4.5: K8s resolution batches every distinct IP into one call
+	batchResolver := &mockK8sResolver{
+		resolveIPFunc: func(ctx context.Context, ip string) (*k8s.ServiceInfo, error) {
+			return &k8s.ServiceInfo{Service: "svc-" + ip, Namespace: "default"}, nil
+		},
+	}
+	aBatch := New(cfg, batchResolver, nil)
+	batchEntries := []*models.QueryLogEntry{
+		{EventTime: now, ClientIP: "7.7.7.7", Tables: []string{"db.t8"}},
+		{EventTime: now, ClientIP: "8.8.8.8", Tables: []string{"db.t8"}},
+		{EventTime: now, ClientIP: "7.7.7.7", Tables: []string{"db.t9"}},
+	}
+	if err := aBatch.buildServiceModel(context.Background(), batchEntries); err != nil {
+		t.Fatalf("buildServiceModel batch failed: %v", err)
+	}
+	if batchResolver.batchCalls != 1 {
+		t.Errorf("expected one batch resolution call, got %d", batchResolver.batchCalls)
+	}
+	if got := aBatch.Services()["8.8.8.8"].K8sService; got != "svc-8.8.8.8" {
+		t.Errorf("expected batch-resolved service for 8.8.8.8, got %q", got)
+	}
+
 	// Test case 5: Empty entries list
 	a5 := New(cfg, nil, nil)
 	if err := a5.buildServiceModel(context.Background(), []*models.QueryLogEntry{}); err != nil {
@@ -409,6 +453,178 @@ func TestBuildServiceModel(t *testing.T) {
 	}
 }
 
+func TestBuildServiceModelCountsK8sResolutionErrors(t *testing.T) {
+	now := time.Now()
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = true
+
+	failing := map[string]bool{"10.0.0.2": true, "10.0.0.4": true}
+	entries := []*models.QueryLogEntry{
+		{EventTime: now, ClientIP: "10.0.0.1", Tables: []string{"db.t"}},
+		{EventTime: now, ClientIP: "10.0.0.2", Tables: []string{"db.t"}},
+		{EventTime: now, ClientIP: "10.0.0.3", Tables: []string{"db.t"}},
+		{EventTime: now, ClientIP: "10.0.0.4", Tables: []string{"db.t"}},
+		{EventTime: now, ClientIP: "10.0.0.1", Tables: []string{"db.t"}},
+	}
+
+	cases := []struct {
+		name     string
+		batchErr error
+	}{
+		{name: "batch"},
+		{name: "per_ip_after_batch_failure", batchErr: errors.New("api unavailable")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := &mockK8sResolver{
+				batchErr: tc.batchErr,
+				resolveIPFunc: func(ctx context.Context, ip string) (*k8s.ServiceInfo, error) {
+					if failing[ip] {
+						return nil, errors.New("api unavailable")
+					}
+					return &k8s.ServiceInfo{Service: "svc", Namespace: "default"}, nil
+				},
+			}
+			a := New(cfg, resolver, nil)
+			if err := a.buildServiceModel(context.Background(), entries); err != nil {
+				t.Fatalf("buildServiceModel failed: %v", err)
+			}
+
+			stats := a.K8sResolution()
+			if stats.Resolved != 2 || stats.Errors != 2 {
+				t.Fatalf("expected 2 resolved and 2 errors, got %+v", stats)
+			}
+			if !stats.Degraded() {
+				t.Fatal("expected resolution to be degraded when half the lookups fail")
+			}
+			if got := a.Services()["10.0.0.2"].K8sService; got != "" {
+				t.Fatalf("expected failed IP to keep no service label, got %q", got)
+			}
+		})
+	}
+
+	// IPs the cluster does not know are unresolved, not failed
+	outside := &mockK8sResolver{
+		resolveIPFunc: func(ctx context.Context, ip string) (*k8s.ServiceInfo, error) {
+			if ip == "10.0.0.1" {
+				return &k8s.ServiceInfo{Service: "svc", Namespace: "default"}, nil
+			}
+			return &k8s.ServiceInfo{Service: ip}, nil
+		},
+	}
+	a := New(cfg, outside, nil)
+	if err := a.buildServiceModel(context.Background(), entries); err != nil {
+		t.Fatalf("buildServiceModel failed: %v", err)
+	}
+	if stats := a.K8sResolution(); stats.Resolved != 1 || stats.Unresolved != 3 || stats.Errors != 0 || stats.Degraded() {
+		t.Fatalf("expected 1 resolved and 3 unresolved without degradation, got %+v", stats)
+	}
+
+	healthy := K8sResolutionStats{Resolved: 3, Errors: 1}
+	if healthy.Degraded() {
+		t.Fatalf("expected %+v not to be degraded", healthy)
+	}
+	if (K8sResolutionStats{}).Degraded() {
+		t.Fatal("expected no lookups not to be degraded")
+	}
+}
+
+func TestBuildServiceModelAppliesIPAliases(t *testing.T) {
+	now := time.Now()
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = true
+	aliases, err := config.ParseIPAliases([]string{"10.0.0.1=ingress-batch"})
+	if err != nil {
+		t.Fatalf("ParseIPAliases failed: %v", err)
+	}
+	cfg.IPAliases = aliases
+
+	var asked []string
+	resolver := &mockK8sResolver{resolveIPFunc: func(ctx context.Context, ip string) (*k8s.ServiceInfo, error) {
+		asked = append(asked, ip)
+		return &k8s.ServiceInfo{Service: "resolved-" + ip, Namespace: "default"}, nil
+	}}
+
+	a := New(cfg, resolver, nil)
+	entries := []*models.QueryLogEntry{
+		{EventTime: now, ClientIP: "::ffff:10.0.0.1", QueryKind: "Select", Tables: []string{"db.t1"}},
+		{EventTime: now, ClientIP: "10.0.0.2", QueryKind: "Select", Tables: []string{"db.t1"}},
+	}
+	if err := a.buildServiceModel(context.Background(), entries); err != nil {
+		t.Fatalf("buildServiceModel failed: %v", err)
+	}
+
+	aliased := a.Services()["::ffff:10.0.0.1"]
+	if aliased == nil || aliased.K8sService != "ingress-batch" || aliased.K8sNamespace != "" {
+		t.Fatalf("expected aliased IP labelled ingress-batch, got %+v", aliased)
+	}
+	resolved := a.Services()["10.0.0.2"]
+	if resolved == nil || resolved.K8sService != "resolved-10.0.0.2" || resolved.K8sNamespace != "default" {
+		t.Fatalf("expected non-aliased IP to fall through to K8s resolution, got %+v", resolved)
+	}
+	if !reflect.DeepEqual(asked, []string{"10.0.0.2"}) {
+		t.Fatalf("expected only the non-aliased IP to be resolved, got %v", asked)
+	}
+
+	if err := a.buildEdges(context.Background(), entries); err != nil {
+		t.Fatalf("buildEdges failed: %v", err)
+	}
+	for _, edge := range a.Edges() {
+		if edge.ServiceIP == "::ffff:10.0.0.1" && edge.ServiceName != "ingress-batch" {
+			t.Fatalf("expected aliased edge to carry the alias label, got %+v", edge)
+		}
+	}
+}
+
+func TestAnalyzeCanonicalizesClientIPs(t *testing.T) {
+	now := time.Now()
+	entries := func() []*models.QueryLogEntry {
+		return []*models.QueryLogEntry{
+			{EventTime: now, ClientIP: "::ffff:10.0.0.1", QueryKind: "Select", ReadRows: 5, Tables: []string{"db.t1"}},
+			{EventTime: now, ClientIP: "10.0.0.1", QueryKind: "Select", ReadRows: 7, Tables: []string{"db.t1"}},
+			{EventTime: now, ClientIP: "2001:0DB8:0000:0000:0000:0000:0000:0001", QueryKind: "Select", Tables: []string{"db.t1"}},
+			{EventTime: now, ClientIP: "2001:db8::1", QueryKind: "Select", Tables: []string{"db.t1"}},
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.AnomalyDetection = false
+	a := New(cfg, nil, nil)
+	if err := a.Analyze(context.Background(), entries()); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(a.Services()) != 2 {
+		t.Fatalf("expected mapped and bare forms to collapse to 2 services, got %v", a.Services())
+	}
+	service := a.Services()["10.0.0.1"]
+	if service == nil || service.QueryCount != 2 {
+		t.Fatalf("expected one 10.0.0.1 service with 2 queries, got %+v", service)
+	}
+	if _, ok := a.Services()["2001:db8::1"]; !ok {
+		t.Fatalf("expected IPv6 keyed in compressed form, got %v", a.Services())
+	}
+	if len(a.Edges()) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(a.Edges()))
+	}
+	for _, edge := range a.Edges() {
+		if edge.ServiceIP == "10.0.0.1" && edge.Reads != 12 {
+			t.Fatalf("expected merged edge to sum read rows, got %+v", edge)
+		}
+	}
+
+	cfg = config.DefaultConfig()
+	cfg.AnomalyDetection = false
+	cfg.NormalizeIPv6 = false
+	raw := New(cfg, nil, nil)
+	if err := raw.Analyze(context.Background(), entries()); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(raw.Services()) != 4 || len(raw.Edges()) != 4 {
+		t.Fatalf("expected raw IPs to stay distinct with normalization off, got %d services, %d edges",
+			len(raw.Services()), len(raw.Edges()))
+	}
+}
+
 func TestBuildEdges(t *testing.T) {
 	now := time.Now().Truncate(time.Second)
 	cfg := config.DefaultConfig()
@@ -464,7 +680,7 @@ func TestBuildEdges(t *testing.T) {
 		},
 	}
 
-	if err := a.buildEdges(entries); err != nil {
+	if err := a.buildEdges(context.Background(), entries); err != nil {
 		t.Fatalf("buildEdges failed: %v", err)
 	}
 
@@ -531,7 +747,7 @@ func TestBuildEdges(t *testing.T) {
 
 	// Test with empty entries
 	aEmpty := New(cfg, nil, nil)
-	if err := aEmpty.buildEdges([]*models.QueryLogEntry{}); err != nil {
+	if err := aEmpty.buildEdges(context.Background(), []*models.QueryLogEntry{}); err != nil {
 		t.Fatalf("buildEdges with empty entries failed: %v", err)
 	}
 	if len(aEmpty.Edges()) != 0 {
@@ -545,7 +761,7 @@ func TestBuildEdges(t *testing.T) {
 		{EventTime: now, ClientIP: "3.3.3.3", Tables: []string{"db.t_ip"}},
 	}
 	aEmptyIP.Services()["3.3.3.3"] = &models.Service{IP: "3.3.3.3"}
-	if err := aEmptyIP.buildEdges(emptyClientIPEntries); err != nil {
+	if err := aEmptyIP.buildEdges(context.Background(), emptyClientIPEntries); err != nil {
 		t.Fatalf("buildEdges with empty client IP failed: %v", err)
 	}
 	if findEdge(aEmptyIP.Edges(), "", "db.t_ip") != nil {
@@ -559,6 +775,42 @@ func TestBuildEdges(t *testing.T) {
 	}
 }
 
+func TestBuildEdgesCountsQueryKinds(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	a := New(config.DefaultConfig(), nil, nil)
+
+	entries := []*models.QueryLogEntry{
+		{EventTime: now, ClientIP: "1.1.1.1", QueryKind: "Select", ReadRows: 10, Tables: []string{"db.t"}},
+		{EventTime: now, ClientIP: "1.1.1.1", QueryKind: "SELECT", ReadRows: 5, Tables: []string{"db.t"}},
+		{EventTime: now, ClientIP: "1.1.1.1", QueryKind: "Alter", WrittenRows: 3, Tables: []string{"db.t"}},
+		{EventTime: now, ClientIP: "1.1.1.1", QueryKind: "", Tables: []string{"db.t"}},
+		{EventTime: now, ClientIP: "2.2.2.2", QueryKind: "Insert", WrittenRows: 7, Tables: []string{"db.t"}},
+	}
+	if err := a.buildEdges(context.Background(), entries); err != nil {
+		t.Fatalf("buildEdges failed: %v", err)
+	}
+
+	edge := findEdge(a.Edges(), "1.1.1.1", "db.t")
+	if edge == nil {
+		t.Fatal("expected edge 1.1.1.1 -> db.t")
+	}
+	want := map[string]uint64{"SELECT": 2, "ALTER": 1, "UNKNOWN": 1}
+	if !reflect.DeepEqual(edge.KindCounts, want) {
+		t.Errorf("expected kind counts %v, got %v", want, edge.KindCounts)
+	}
+	if edge.Reads != 15 || edge.Writes != 3 {
+		t.Errorf("expected reads=15 writes=3, got reads=%d writes=%d", edge.Reads, edge.Writes)
+	}
+
+	other := findEdge(a.Edges(), "2.2.2.2", "db.t")
+	if other == nil {
+		t.Fatal("expected edge 2.2.2.2 -> db.t")
+	}
+	if !reflect.DeepEqual(other.KindCounts, map[string]uint64{"INSERT": 1}) {
+		t.Errorf("expected kind counts to stay per edge, got %v", other.KindCounts)
+	}
+}
+
 func findEdge(edges []*models.Edge, serviceIP, tableName string) *models.Edge {
 	for _, edge := range edges {
 		if edge.ServiceIP == serviceIP && edge.TableName == tableName {
@@ -590,7 +842,7 @@ func TestDetectAnomalies(t *testing.T) {
 			"db.single_access_table": {Reads: 1, Writes: 0, LastAccess: now.Add(-time.Hour)},
 		}
 		a := newTestAnalyzer(tables, nil)
-		if err := a.detectAnomalies(); err != nil {
+		if err := a.detectAnomalies(context.Background()); err != nil {
 			t.Fatalf("detectAnomalies failed: %v", err)
 		}
 		anomalies := a.Anomalies()
@@ -608,7 +860,7 @@ func TestDetectAnomalies(t *testing.T) {
 			"db.stale_table": {Reads: 10, Writes: 1, LastAccess: now.Add(-31 * 24 * time.Hour)}, // > 30 days
 		}
 		a := newTestAnalyzer(tables, nil)
-		if err := a.detectAnomalies(); err != nil {
+		if err := a.detectAnomalies(context.Background()); err != nil {
 			t.Fatalf("detectAnomalies failed: %v", err)
 		}
 		anomalies := a.Anomalies()
@@ -620,13 +872,47 @@ func TestDetectAnomalies(t *testing.T) {
 		}
 	})
 
+	t.Run("stale_table_configurable_days", func(t *testing.T) {
+		tables := func() map[string]*models.Table {
+			return map[string]*models.Table{
+				"db.quiet_table": {Reads: 10, Writes: 1, LastAccess: now.Add(-20 * 24 * time.Hour)},
+			}
+		}
+
+		a := newTestAnalyzer(tables(), nil)
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+		if hasAnomaly(a.Anomalies(), "stale_table", "db.quiet_table") {
+			t.Fatalf("expected no stale_table anomaly with default 30-day threshold")
+		}
+
+		staleCfg := config.DefaultConfig()
+		staleCfg.Anomalies.StaleDays = 14
+		a = New(staleCfg, nil, nil)
+		for k, v := range tables() {
+			a.Tables()[k] = v
+		}
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+		if !hasAnomaly(a.Anomalies(), "stale_table", "db.quiet_table") {
+			t.Fatalf("expected stale_table anomaly with 14-day threshold, got %v", anomalyTypes(a.Anomalies()))
+		}
+		for _, anomaly := range a.Anomalies() {
+			if anomaly.Type == "stale_table" && anomaly.Description != "Table not accessed in over 14 days" {
+				t.Fatalf("unexpected stale_table description: %q", anomaly.Description)
+			}
+		}
+	})
+
 	// Test Case: write_only anomaly
 	t.Run("write_only", func(t *testing.T) {
 		tables := map[string]*models.Table{
 			"db.write_only_table": {Reads: 0, Writes: 5, LastAccess: now.Add(-time.Hour)},
 		}
 		a := newTestAnalyzer(tables, nil)
-		if err := a.detectAnomalies(); err != nil {
+		if err := a.detectAnomalies(context.Background()); err != nil {
 			t.Fatalf("detectAnomalies failed: %v", err)
 		}
 		anomalies := a.Anomalies()
@@ -644,7 +930,7 @@ func TestDetectAnomalies(t *testing.T) {
 			"db.read_only_table": {Reads: 101, Writes: 0, LastAccess: now.Add(-time.Hour)}, // Reads > 100
 		}
 		a := newTestAnalyzer(tables, nil)
-		if err := a.detectAnomalies(); err != nil {
+		if err := a.detectAnomalies(context.Background()); err != nil {
 			t.Fatalf("detectAnomalies failed: %v", err)
 		}
 		anomalies := a.Anomalies()
@@ -662,7 +948,7 @@ func TestDetectAnomalies(t *testing.T) {
 			"db.low_activity_table": {Reads: 5, Writes: 2, LastAccess: now.Add(-8 * 24 * time.Hour)}, // totalAccess < 10, daysSinceAccess > 7
 		}
 		a := newTestAnalyzer(tables, nil)
-		if err := a.detectAnomalies(); err != nil {
+		if err := a.detectAnomalies(context.Background()); err != nil {
 			t.Fatalf("detectAnomalies failed: %v", err)
 		}
 		anomalies := a.Anomalies()
@@ -684,7 +970,7 @@ func TestDetectAnomalies(t *testing.T) {
 			"10.0.0.1": {TablesUsed: tablesUsed}, // > 20 tables
 		}
 		a := newTestAnalyzer(nil, services)
-		if err := a.detectAnomalies(); err != nil {
+		if err := a.detectAnomalies(context.Background()); err != nil {
 			t.Fatalf("detectAnomalies failed: %v", err)
 		}
 		anomalies := a.Anomalies()
@@ -696,6 +982,158 @@ func TestDetectAnomalies(t *testing.T) {
 		}
 	})
 
+	t.Run("broad_access_configurable_count", func(t *testing.T) {
+		broadCfg := config.DefaultConfig()
+		broadCfg.Anomalies.BroadAccessTableCount = 2
+		a := New(broadCfg, nil, nil)
+		a.Services()["10.0.0.1"] = &models.Service{TablesUsed: []string{"db.a", "db.b", "db.c"}}
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+		anomalies := a.Anomalies()
+		if len(anomalies) != 1 || anomalies[0].Type != "broad_access" {
+			t.Fatalf("expected broad_access anomaly with threshold 2, got %v", anomalyTypes(anomalies))
+		}
+	})
+
+	// Test Case: usage_spike anomaly
+	t.Run("usage_spike", func(t *testing.T) {
+		tables := map[string]*models.Table{
+			"db.spiky_table": {
+				Reads:      200,
+				Writes:     10,
+				LastAccess: now.Add(-time.Hour),
+				Sparkline:  hourlySparkline(now, 10, 10, 12, 9, 11, 80, 10),
+			},
+		}
+		a := newTestAnalyzer(tables, nil)
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+		spike := findAnomaly(a.Anomalies(), "usage_spike", "db.spiky_table")
+		if spike == nil {
+			t.Fatalf("expected usage_spike anomaly, got %v", anomalyTypes(a.Anomalies()))
+		}
+		// The ratio and count change run to run, so they stay out of the
+		// fingerprinted description
+		if spike.Description != "Hourly usage spiked above the trailing mean" {
+			t.Fatalf("unexpected usage_spike description: %q", spike.Description)
+		}
+		if spike.Metrics["ratio"] != 7.7 || spike.Metrics["queries"] != 80 {
+			t.Fatalf("unexpected usage_spike metrics: %v", spike.Metrics)
+		}
+	})
+
+	t.Run("usage_spike_below_multiplier", func(t *testing.T) {
+		tables := map[string]*models.Table{
+			"db.steady_table": {
+				Reads:      200,
+				Writes:     10,
+				LastAccess: now.Add(-time.Hour),
+				Sparkline:  hourlySparkline(now, 10, 10, 12, 9, 11, 40, 10),
+			},
+		}
+		a := newTestAnalyzer(tables, nil)
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+		if hasAnomaly(a.Anomalies(), "usage_spike", "db.steady_table") {
+			t.Fatalf("expected no usage_spike anomaly below 5x multiplier")
+		}
+	})
+
+	// An all-zero history floors the mean at 1, so six queries are a 6x
+	// jump; the minimum bucket size keeps that from flagging
+	t.Run("usage_spike_zero_history", func(t *testing.T) {
+		for _, tc := range []struct {
+			name       string
+			minQueries uint64
+			want       bool
+		}{
+			{name: "default_min_queries", minQueries: config.DefaultAnomalyThresholds().UsageSpikeMinQueries, want: false},
+			{name: "min_queries_off", minQueries: 0, want: true},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				spikeCfg := config.DefaultConfig()
+				spikeCfg.Anomalies.UsageSpikeMinQueries = tc.minQueries
+				a := New(spikeCfg, nil, nil)
+				got := a.detectUsageSpike("db.sparse_table", []uint64{0, 0, 0, 6}, now)
+				if (got != nil) != tc.want {
+					t.Fatalf("usage_spike = %+v, want flagged=%v", got, tc.want)
+				}
+			})
+		}
+	})
+
+	t.Run("usage_spike_configurable_multiplier", func(t *testing.T) {
+		spikeCfg := config.DefaultConfig()
+		spikeCfg.Anomalies.UsageSpikeMultiplier = 3
+		a := New(spikeCfg, nil, nil)
+		a.Tables()["db.steady_table"] = &models.Table{
+			Reads:      200,
+			Writes:     10,
+			LastAccess: now.Add(-time.Hour),
+			Sparkline:  hourlySparkline(now, 10, 10, 12, 9, 11, 40, 10),
+		}
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+		if !hasAnomaly(a.Anomalies(), "usage_spike", "db.steady_table") {
+			t.Fatalf("expected usage_spike anomaly with 3x multiplier")
+		}
+	})
+
+	// Test Case: usage_drop anomaly
+	t.Run("usage_drop", func(t *testing.T) {
+		tables := map[string]*models.Table{
+			"db.dropped_table": {
+				Reads:      500,
+				Writes:     10,
+				LastAccess: now.Add(-7 * time.Hour),
+				Sparkline:  hourlySparkline(now, 50, 60, 55, 45, 50, 0, 0, 0, 0, 0, 0),
+			},
+			// Another table keeps the observed window open until the final hour.
+			"db.busy_table": {
+				Reads:      500,
+				Writes:     10,
+				LastAccess: now.Add(-time.Hour),
+				Sparkline:  hourlySparkline(now, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10),
+			},
+		}
+		a := newTestAnalyzer(tables, nil)
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+		drop := findAnomaly(a.Anomalies(), "usage_drop", "db.dropped_table")
+		if drop == nil {
+			t.Fatalf("expected usage_drop anomaly, got %v", anomalyTypes(a.Anomalies()))
+		}
+		if drop.Description != "Hourly usage dropped to near-zero for the last 6 hours" || drop.Metrics["prior_mean"] != 52 {
+			t.Fatalf("unexpected usage_drop anomaly: %q %v", drop.Description, drop.Metrics)
+		}
+		if hasAnomaly(a.Anomalies(), "usage_drop", "db.busy_table") {
+			t.Fatalf("expected no usage_drop anomaly for steady table")
+		}
+	})
+
+	t.Run("usage_drop_quiet_table", func(t *testing.T) {
+		tables := map[string]*models.Table{
+			"db.quiet_table": {
+				Reads:      20,
+				Writes:     10,
+				LastAccess: now.Add(-time.Hour),
+				Sparkline:  hourlySparkline(now, 3, 2, 4, 3, 2, 0, 0, 0, 0, 0, 1),
+			},
+		}
+		a := newTestAnalyzer(tables, nil)
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+		if hasAnomaly(a.Anomalies(), "usage_drop", "db.quiet_table") {
+			t.Fatalf("expected no usage_drop anomaly for table below busy threshold")
+		}
+	})
+
 	// Test Case: No anomalies
 	t.Run("no_anomalies", func(t *testing.T) {
 		tables := map[string]*models.Table{
@@ -705,7 +1143,7 @@ func TestDetectAnomalies(t *testing.T) {
 			"10.0.0.1": {TablesUsed: []string{"db.active_table"}},
 		}
 		a := newTestAnalyzer(tables, services)
-		if err := a.detectAnomalies(); err != nil {
+		if err := a.detectAnomalies(context.Background()); err != nil {
 			t.Fatalf("detectAnomalies failed: %v", err)
 		}
 		anomalies := a.Anomalies()
@@ -714,3 +1152,818 @@ func TestDetectAnomalies(t *testing.T) {
 		}
 	})
 }
+
+func TestDetectAnomaliesWriteOnlySkipsMVSources(t *testing.T) {
+	end := time.Now().UTC().Truncate(time.Hour)
+	start := end.Add(-48 * time.Hour)
+	wholeWindow := []models.TimeSeriesPoint{{Timestamp: start, Value: 10}, {Timestamp: end, Value: 10}}
+
+	a := New(config.DefaultConfig(), nil, nil)
+	a.tables = map[string]*models.Table{
+		// Written all window and read by nothing: a true sink
+		"db.orphan_sink": {FullName: "db.orphan_sink", Writes: 20, LastAccess: end, Sparkline: wholeWindow},
+		// Written all window, but system.tables lists the MV reading it
+		"db.ingest_buffer": {FullName: "db.ingest_buffer", Writes: 20, LastAccess: end, Sparkline: wholeWindow, MVDependency: []string{"db.events_mv"}},
+		// The same, with dependents known only from the inventory
+		"db.raw_clicks": {FullName: "db.raw_clicks", Writes: 20, LastAccess: end, Sparkline: wholeWindow},
+		// Listed in an MV's dependencies, so it reads the MV rather than
+		// feeding it
+		"db.clicks_rollup": {FullName: "db.clicks_rollup", Writes: 20, LastAccess: end, Sparkline: wholeWindow},
+		// Only written in the last hours of the window
+		"db.new_sink": {FullName: "db.new_sink", Writes: 20, LastAccess: end, Sparkline: []models.TimeSeriesPoint{{Timestamp: end.Add(-2 * time.Hour), Value: 10}, {Timestamp: end, Value: 10}}},
+	}
+	a.inventory = map[string]*models.Table{
+		"db.raw_clicks": {FullName: "db.raw_clicks", MVDependency: []string{"db.clicks_mv"}},
+		"db.clicks_mv":  {FullName: "db.clicks_mv", IsMV: true, MVDependency: []string{"db.clicks_rollup"}, MVSources: []string{"db.raw_clicks"}},
+	}
+	if err := a.detectAnomalies(context.Background()); err != nil {
+		t.Fatalf("detectAnomalies failed: %v", err)
+	}
+
+	severities := make(map[string]string)
+	for _, anomaly := range a.Anomalies() {
+		if anomaly.Type == "write_only" {
+			severities[anomaly.AffectedTable] = anomaly.Severity
+		}
+	}
+	want := map[string]string{"db.orphan_sink": "low", "db.clicks_rollup": "low", "db.new_sink": "info"}
+	if !reflect.DeepEqual(severities, want) {
+		t.Fatalf("expected write_only severities %v, got %v", want, severities)
+	}
+}
+
+func TestAnomalyIDsAreStableAndDeduplicated(t *testing.T) {
+	now := time.Now()
+	newTestAnalyzer := func(table *models.Table) *Analyzer {
+		a := New(config.DefaultConfig(), nil, nil)
+		a.Tables()["db.old_table"] = table
+		return a
+	}
+
+	a := newTestAnalyzer(&models.Table{Reads: 3, Writes: 2, LastAccess: now.Add(-40 * 24 * time.Hour)})
+	for i := 0; i < 2; i++ {
+		if err := a.detectAnomalies(context.Background()); err != nil {
+			t.Fatalf("detectAnomalies failed: %v", err)
+		}
+	}
+
+	ids := make(map[string]string)
+	for _, anomaly := range a.Anomalies() {
+		if anomaly.ID == "" {
+			t.Fatalf("expected anomaly %s to have an ID", anomaly.Type)
+		}
+		if other, dup := ids[anomaly.ID]; dup {
+			t.Fatalf("duplicate anomaly ID %s for %s and %s", anomaly.ID, other, anomaly.Type)
+		}
+		ids[anomaly.ID] = anomaly.Type
+	}
+	if !hasAnomaly(a.Anomalies(), "stale_table", "db.old_table") || !hasAnomaly(a.Anomalies(), "low_activity", "db.old_table") {
+		t.Fatalf("expected distinct stale_table and low_activity anomalies, got %v", anomalyTypes(a.Anomalies()))
+	}
+
+	later := newTestAnalyzer(&models.Table{Reads: 3, Writes: 2, LastAccess: now.Add(-41 * 24 * time.Hour)})
+	if err := later.detectAnomalies(context.Background()); err != nil {
+		t.Fatalf("detectAnomalies failed: %v", err)
+	}
+	for _, anomaly := range later.Anomalies() {
+		if ids[anomaly.ID] != anomaly.Type {
+			t.Fatalf("expected %s to keep its ID across runs, got %s", anomaly.Type, anomaly.ID)
+		}
+	}
+}
+
+func TestMutationsCountedAndProtectFromStaleAnomalies(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name    string
+		entries []*models.QueryLogEntry
+	}{
+		{
+			name: "alter_only",
+			entries: []*models.QueryLogEntry{
+				{QueryKind: "Alter", EventTime: now.Add(-3 * time.Hour), Tables: []string{"db.mutated"}},
+				{QueryKind: "Alter", EventTime: now.Add(-2 * time.Hour), Tables: []string{"db.mutated"}},
+				{QueryKind: "Alter", EventTime: now.Add(-time.Hour), Tables: []string{"db.mutated"}},
+			},
+		},
+		{
+			name: "delete_only",
+			entries: []*models.QueryLogEntry{
+				{QueryKind: "Select", EventTime: now.Add(-3 * time.Hour), ReadRows: 500, Tables: []string{"db.mutated"}},
+				{QueryKind: "Delete", EventTime: now.Add(-2 * time.Hour), Tables: []string{"db.mutated"}},
+				{QueryKind: "Delete", EventTime: now.Add(-time.Hour), Tables: []string{"db.mutated"}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := New(config.DefaultConfig(), nil, nil)
+			if err := a.buildTableModel(context.Background(), tc.entries); err != nil {
+				t.Fatalf("buildTableModel failed: %v", err)
+			}
+
+			table := a.Tables()["db.mutated"]
+			if table == nil {
+				t.Fatal("expected db.mutated in table model")
+			}
+			if table.Writes != 0 {
+				t.Fatalf("expected zero written rows, got %d", table.Writes)
+			}
+			if table.Mutations == 0 {
+				t.Fatal("expected nonzero mutations")
+			}
+
+			if err := a.detectAnomalies(context.Background()); err != nil {
+				t.Fatalf("detectAnomalies failed: %v", err)
+			}
+			for _, anomalyType := range []string{"stale_table", "read_only", "single_access", "low_activity"} {
+				if hasAnomaly(a.Anomalies(), anomalyType, "db.mutated") {
+					t.Fatalf("did not expect %s anomaly for mutated table, got %v", anomalyType, anomalyTypes(a.Anomalies()))
+				}
+			}
+		})
+	}
+}
+
+func TestBuildTableModelTracksLastWrite(t *testing.T) {
+	now := time.Now()
+	written := now.Add(-20 * 24 * time.Hour)
+	mutated := now.Add(-10 * 24 * time.Hour)
+	entries := []*models.QueryLogEntry{
+		{QueryKind: "Insert", EventTime: written, WrittenRows: 100, Tables: []string{"db.events", "db.audit"}},
+		{QueryKind: "Alter", EventTime: mutated, Tables: []string{"db.audit"}},
+		{QueryKind: "Insert", EventTime: now.Add(-time.Hour), Exception: "Code: 241. MEMORY_LIMIT_EXCEEDED", Tables: []string{"db.events"}},
+		{QueryKind: "Select", EventTime: now, ReadRows: 500, Tables: []string{"db.events", "db.readonly"}},
+		// A successful insert that wrote no rows
+		{QueryKind: "Insert", EventTime: mutated, Tables: []string{"db.empty_insert"}},
+	}
+
+	a := New(config.DefaultConfig(), nil, nil)
+	if err := a.buildTableModel(context.Background(), entries); err != nil {
+		t.Fatalf("buildTableModel failed: %v", err)
+	}
+
+	tables := a.Tables()
+	// Reads and failed writes move LastAccess but not LastWrite
+	if events := tables["db.events"]; !events.LastWrite.Equal(written) || !events.LastAccess.Equal(now) {
+		t.Fatalf("expected db.events last write %v and last access %v, got %v and %v", written, now, events.LastWrite, events.LastAccess)
+	}
+	if audit := tables["db.audit"]; !audit.LastWrite.Equal(mutated) {
+		t.Fatalf("expected db.audit last write at its mutation %v, got %v", mutated, audit.LastWrite)
+	}
+	if readonly := tables["db.readonly"]; !readonly.LastWrite.IsZero() {
+		t.Fatalf("expected no last write for a read-only table, got %v", readonly.LastWrite)
+	}
+	if empty := tables["db.empty_insert"]; empty.Writes != 0 || empty.Mutations != 0 || !empty.LastWrite.Equal(mutated) {
+		t.Fatalf("expected an empty insert to set last write %v without counting writes, got %+v", mutated, empty)
+	}
+}
+
+func TestAnalyzeReportSeedsModelsAndRedetectsAnomalies(t *testing.T) {
+	now := time.Now()
+	cfg := config.DefaultConfig()
+	cfg.ExcludeTables = []string{"db.skip"}
+
+	prior := &models.Report{
+		Tables: []models.Table{
+			{FullName: "db.old", Reads: 10, Writes: 1, LastAccess: now.Add(-60 * 24 * time.Hour), Score: 0.9, Category: "active"},
+			{FullName: "db.skip", Reads: 10, LastAccess: now},
+		},
+		Services: []models.Service{{IP: "10.0.0.1", TablesUsed: []string{"db.old", "db.skip"}}},
+		Edges: []models.Edge{
+			{ServiceIP: "10.0.0.1", TableName: "db.old", Reads: 10},
+			{ServiceIP: "10.0.0.1", TableName: "db.skip", Reads: 10},
+		},
+		Anomalies: []models.Anomaly{{Type: "write_only", AffectedTable: "db.old"}},
+	}
+
+	a := New(cfg, nil, nil)
+	if err := a.AnalyzeReport(context.Background(), prior); err != nil {
+		t.Fatalf("AnalyzeReport failed: %v", err)
+	}
+
+	table := a.Tables()["db.old"]
+	if table == nil || len(a.Tables()) != 1 {
+		t.Fatalf("expected only db.old in table model, got %v", a.Tables())
+	}
+	if table.Score != 0 || table.Category != "" {
+		t.Fatalf("expected prior score to be reset, got score=%v category=%q", table.Score, table.Category)
+	}
+	if got := a.Services()["10.0.0.1"].TablesUsed; !reflect.DeepEqual(got, []string{"db.old"}) {
+		t.Fatalf("expected excluded table removed from service, got %v", got)
+	}
+	if len(a.Edges()) != 1 || a.Edges()[0].TableName != "db.old" {
+		t.Fatalf("expected only db.old edge, got %+v", a.Edges())
+	}
+	if !hasAnomaly(a.Anomalies(), "stale_table", "db.old") || hasAnomaly(a.Anomalies(), "write_only", "db.old") {
+		t.Fatalf("expected anomalies recomputed from current thresholds, got %v", anomalyTypes(a.Anomalies()))
+	}
+
+	if err := New(cfg, nil, nil).AnalyzeReport(context.Background(), nil); err == nil {
+		t.Fatal("expected error for nil report")
+	}
+}
+
+func TestBuildTableModelErrorRateAndErrorProneAnomaly(t *testing.T) {
+	now := time.Now()
+	var entries []*models.QueryLogEntry
+	addEntries := func(table, entryType, exception string, n int) {
+		for i := 0; i < n; i++ {
+			entries = append(entries, &models.QueryLogEntry{
+				Type:      entryType,
+				QueryKind: "Select",
+				ReadRows:  10,
+				Exception: exception,
+				EventTime: now.Add(-time.Hour),
+				Tables:    []string{table},
+			})
+		}
+	}
+	// db.broken: 6 of 10 queries fail
+	addEntries("db.broken", "QueryFinish", "", 4)
+	addEntries("db.broken", "ExceptionWhileProcessing", "Code: 47. Missing columns", 6)
+	// db.flaky: 1 of 20 queries fails, below the default 20% rate
+	addEntries("db.flaky", "QueryFinish", "", 19)
+	addEntries("db.flaky", "ExceptionBeforeStart", "Code: 62. Syntax error", 1)
+	// db.healthy: no failures
+	addEntries("db.healthy", "QueryFinish", "", 10)
+
+	a := New(config.DefaultConfig(), nil, nil)
+	if err := a.buildTableModel(context.Background(), entries); err != nil {
+		t.Fatalf("buildTableModel failed: %v", err)
+	}
+
+	cases := []struct {
+		table      string
+		wantFailed uint64
+		wantRate   float64
+		wantReads  uint64
+	}{
+		{table: "db.broken", wantFailed: 6, wantRate: 0.6, wantReads: 40},
+		{table: "db.flaky", wantFailed: 1, wantRate: 0.05, wantReads: 190},
+		{table: "db.healthy", wantFailed: 0, wantRate: 0, wantReads: 100},
+	}
+	for _, tc := range cases {
+		table := a.Tables()[tc.table]
+		if table == nil {
+			t.Fatalf("expected %s in table model", tc.table)
+		}
+		if table.FailedQueries != tc.wantFailed {
+			t.Fatalf("%s: expected %d failed queries, got %d", tc.table, tc.wantFailed, table.FailedQueries)
+		}
+		if diff := table.ErrorRate - tc.wantRate; diff > 1e-9 || diff < -1e-9 {
+			t.Fatalf("%s: expected error rate %v, got %v", tc.table, tc.wantRate, table.ErrorRate)
+		}
+		if table.Reads != tc.wantReads {
+			t.Fatalf("%s: expected failed queries excluded from reads (%d), got %d", tc.table, tc.wantReads, table.Reads)
+		}
+	}
+
+	if err := a.detectAnomalies(context.Background()); err != nil {
+		t.Fatalf("detectAnomalies failed: %v", err)
+	}
+	broken := findAnomaly(a.Anomalies(), "error_prone", "db.broken")
+	if broken == nil {
+		t.Fatalf("expected error_prone anomaly for db.broken, got %v", anomalyTypes(a.Anomalies()))
+	}
+	if broken.Description != "Many queries failed, table may be broken rather than unused" {
+		t.Fatalf("unexpected error_prone description: %q", broken.Description)
+	}
+	if broken.Metrics["error_rate"] != 0.6 || broken.Metrics["failures"] != 6 {
+		t.Fatalf("unexpected error_prone metrics: %v", broken.Metrics)
+	}
+	for _, table := range []string{"db.flaky", "db.healthy"} {
+		if hasAnomaly(a.Anomalies(), "error_prone", table) {
+			t.Fatalf("did not expect error_prone anomaly for %s", table)
+		}
+	}
+}
+
+func TestDetectAnomaliesFlagsFullScanSuspects(t *testing.T) {
+	now := time.Now()
+	var entries []*models.QueryLogEntry
+	addReads := func(table string, n int, readRows, resultRows uint64) {
+		for i := 0; i < n; i++ {
+			entries = append(entries, &models.QueryLogEntry{
+				Type:       "QueryFinish",
+				QueryKind:  "Select",
+				ReadRows:   readRows,
+				ResultRows: resultRows,
+				EventTime:  now.Add(-time.Hour),
+				Tables:     []string{table},
+			})
+		}
+	}
+	// db.scanned: every read scans 5M rows to return 10
+	addReads("db.scanned", 12, 5_000_000, 10)
+	// db.indexed: point lookups read little more than they return
+	addReads("db.indexed", 12, 8192, 100)
+	// db.rare: scans as badly, but too few queries to call it a pattern
+	addReads("db.rare", 3, 5_000_000, 10)
+	// db.empty: full scans that never match anything
+	addReads("db.empty", 10, 50_000, 0)
+	// A failed read scans nothing useful and is not counted
+	entries = append(entries, &models.QueryLogEntry{
+		Type: "ExceptionWhileProcessing", QueryKind: "Select", ReadRows: 1 << 40, Exception: "Code: 241. Memory limit",
+		EventTime: now.Add(-time.Hour), Tables: []string{"db.indexed"},
+	})
+
+	cases := []struct {
+		name  string
+		ratio float64
+		want  []string
+	}{
+		{name: "default_off", ratio: config.DefaultAnomalyThresholds().FullScanRatio, want: nil},
+		{name: "ratio_10000", ratio: 10000, want: []string{"db.scanned", "db.empty"}},
+		{name: "lower_ratio", ratio: 50, want: []string{"db.scanned", "db.indexed", "db.empty"}},
+		{name: "disabled", ratio: 0, want: nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Anomalies.FullScanRatio = tc.ratio
+			a := New(cfg, nil, nil)
+			if err := a.buildTableModel(context.Background(), entries); err != nil {
+				t.Fatalf("buildTableModel failed: %v", err)
+			}
+			if err := a.detectAnomalies(context.Background()); err != nil {
+				t.Fatalf("detectAnomalies failed: %v", err)
+			}
+			for _, table := range []string{"db.scanned", "db.indexed", "db.rare", "db.empty"} {
+				want := slices.Contains(tc.want, table)
+				if got := hasAnomaly(a.Anomalies(), "full_scan_suspect", table); got != want {
+					t.Fatalf("%s: full_scan_suspect = %v, want %v (anomalies %v)", table, got, want, anomalyTypes(a.Anomalies()))
+				}
+			}
+			if scanned := findAnomaly(a.Anomalies(), "full_scan_suspect", "db.scanned"); scanned != nil {
+				if scanned.Description != "Reads scan far more rows than they return (check primary key and partition pruning)" {
+					t.Fatalf("unexpected full_scan_suspect description: %q", scanned.Description)
+				}
+				if scanned.Metrics["rows_per_result"] != 500_000 || scanned.Metrics["queries"] != 12 {
+					t.Fatalf("unexpected full_scan_suspect metrics: %v", scanned.Metrics)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectAnomaliesFlagsNewSensitiveAccess(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	read := func(ip, table string, ago time.Duration) *models.QueryLogEntry {
+		return &models.QueryLogEntry{QueryKind: "Select", ClientIP: ip, ReadRows: 10, EventTime: now.Add(-ago), Tables: []string{table}}
+	}
+	entries := []*models.QueryLogEntry{
+		// 10.0.0.1 reads billing.invoices throughout the window
+		read("10.0.0.1", "billing.invoices", 10*day),
+		read("10.0.0.1", "billing.invoices", 5*day),
+		read("10.0.0.1", "billing.invoices", day),
+		// 10.0.0.2 is an established service that starts reading
+		// billing.invoices two days before the end, and a new non-sensitive table
+		read("10.0.0.2", "db.events", 10*day),
+		read("10.0.0.2", "billing.invoices", 2*day),
+		read("10.0.0.2", "billing.invoices", day),
+		read("10.0.0.2", "db.events_v2", 2*day),
+		// A new pod of the api service, which has read the table all along
+		read("10.0.0.3", "billing.invoices", 9*day),
+		read("10.0.0.4", "billing.invoices", day),
+	}
+
+	since := now.Add(-11 * day)
+	cases := []struct {
+		name        string
+		sensitive   []string
+		incremental bool
+		want        bool
+	}{
+		{name: "sensitive", sensitive: []string{"billing.*"}, want: true},
+		{name: "off", sensitive: nil, want: false},
+		// An incremental run sees only entries since the watermark, so
+		// established consumers cannot be told from new ones
+		{name: "incremental", sensitive: []string{"billing.*"}, incremental: true, want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.ResolveK8s = false
+			cfg.SensitiveTables = tc.sensitive
+			if tc.incremental {
+				cfg.IncrementalSince = &since
+			}
+			aliases, err := config.ParseIPAliases([]string{"10.0.0.3=api", "10.0.0.4=api"})
+			if err != nil {
+				t.Fatalf("ParseIPAliases failed: %v", err)
+			}
+			cfg.IPAliases = aliases
+			a := New(cfg, nil, nil)
+			if err := a.Analyze(context.Background(), entries); err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+
+			var flagged []*models.Anomaly
+			for _, anomaly := range a.Anomalies() {
+				if anomaly.Type == "new_sensitive_access" {
+					flagged = append(flagged, anomaly)
+				}
+			}
+			if !tc.want {
+				if len(flagged) != 0 {
+					t.Fatalf("expected no new_sensitive_access, got %+v", flagged)
+				}
+				return
+			}
+			if len(flagged) != 1 {
+				t.Fatalf("expected one new_sensitive_access anomaly, got %+v", flagged)
+			}
+			got := flagged[0]
+			if got.AffectedTable != "billing.invoices" || got.AffectedService != "10.0.0.2" || got.Severity != "high" {
+				t.Fatalf("expected high-severity new access by 10.0.0.2 to billing.invoices, got %+v", got)
+			}
+			// When the run happens moves the first access within the window,
+			// so the share is a metric rather than part of the description
+			if got.Description != `Service first accessed sensitive table (matches "billing.*") with no access in the first 50% of the window (check for new grants)` {
+				t.Fatalf("unexpected new_sensitive_access description: %q", got.Description)
+			}
+			if got.Metrics["first_access_pct"] != 89 {
+				t.Fatalf("unexpected new_sensitive_access metrics: %v", got.Metrics)
+			}
+		})
+	}
+}
+
+func TestAnalyzePipelineSparklinesBeforeAnomalies(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	entries := []*models.QueryLogEntry{
+		{QueryID: "q1", EventTime: now.Add(-2 * time.Hour), QueryKind: "SELECT", ClientIP: "10.0.0.1", Tables: []string{"db.events"}},
+		{QueryID: "q2", EventTime: now.Add(-time.Hour), QueryKind: "SELECT", ClientIP: "10.0.0.1", Tables: []string{"db.events"}},
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.AnomalyDetection = true
+	a := New(cfg, nil, nil)
+
+	stages := a.pipeline()
+	detected := false
+	for i := range stages {
+		if stages[i].name != "detect anomalies" {
+			continue
+		}
+		detect := stages[i].run
+		stages[i].run = func(ctx context.Context, entries []*models.QueryLogEntry) error {
+			for name, table := range a.Tables() {
+				if len(table.Sparkline) == 0 {
+					t.Errorf("expected sparkline for %s before anomaly detection", name)
+				}
+			}
+			detected = true
+			return detect(ctx, entries)
+		}
+	}
+
+	if err := a.runStages(context.Background(), entries, stages); err != nil {
+		t.Fatalf("runStages failed: %v", err)
+	}
+	if !detected {
+		t.Fatalf("expected detect anomalies stage to run")
+	}
+	if len(a.Tables()) == 0 {
+		t.Fatalf("expected tables to be built")
+	}
+}
+
+func TestAnalyzePipelineSkipsDisabledStages(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AnomalyDetection = false
+	cfg.DetectUnusedTables = false
+	a := New(cfg, nil, nil)
+
+	var ran []string
+	stages := a.pipeline()
+	for i := range stages {
+		name := stages[i].name
+		stages[i].run = func(context.Context, []*models.QueryLogEntry) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	if err := a.runStages(context.Background(), nil, stages); err != nil {
+		t.Fatalf("runStages failed: %v", err)
+	}
+	want := []string{"build table model", "build service model", "build edges", "generate sparklines"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Fatalf("expected stages %v, got %v", want, ran)
+	}
+}
+
+// hourlySparkline builds consecutive hourly points ending one hour before now.
+func hourlySparkline(now time.Time, values ...uint64) []models.TimeSeriesPoint {
+	start := now.Truncate(time.Hour).Add(-time.Duration(len(values)) * time.Hour)
+	points := make([]models.TimeSeriesPoint, 0, len(values))
+	for i, value := range values {
+		if value == 0 {
+			continue
+		}
+		points = append(points, models.TimeSeriesPoint{
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			Value:     value,
+		})
+	}
+	return points
+}
+
+func TestAnomaliesSnapshotIsACopy(t *testing.T) {
+	a := New(config.DefaultConfig(), nil, nil)
+	a.addAnomaly(&models.Anomaly{
+		Type:          "usage_spike",
+		Severity:      "low",
+		AffectedTable: "db.events",
+		Metrics:       map[string]float64{"ratio": 6},
+	})
+
+	snapshot := a.AnomaliesSnapshot()
+	if len(snapshot) != 1 || snapshot[0].ID != a.Anomalies()[0].ID {
+		t.Fatalf("expected a snapshot of the one anomaly, got %+v", snapshot)
+	}
+	snapshot[0].Severity = "high"
+	snapshot[0].Metrics["ratio"] = 0
+
+	live := a.Anomalies()[0]
+	if live.Severity != "low" || live.Metrics["ratio"] != 6 {
+		t.Fatalf("expected snapshot changes not to reach the analyzer, got %+v", live)
+	}
+}
+
+func hasAnomaly(anomalies []*models.Anomaly, anomalyType, table string) bool {
+	return findAnomaly(anomalies, anomalyType, table) != nil
+}
+
+func findAnomaly(anomalies []*models.Anomaly, anomalyType, table string) *models.Anomaly {
+	for _, anomaly := range anomalies {
+		if anomaly.Type == anomalyType && anomaly.AffectedTable == table {
+			return anomaly
+		}
+	}
+	return nil
+}
+
+func anomalyTypes(anomalies []*models.Anomaly) []string {
+	types := make([]string, 0, len(anomalies))
+	for _, anomaly := range anomalies {
+		types = append(types, anomaly.Type)
+	}
+	return types
+}
+
+func TestAnalysisStagesReturnContextError(t *testing.T) {
+	entries := []*models.QueryLogEntry{
+		{QueryID: "q1", Tables: []string{"db.t1"}, ClientIP: "10.0.0.1", QueryKind: "Select", EventTime: time.Now()},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stages := map[string]func(a *Analyzer) error{
+		"buildTableModel":    func(a *Analyzer) error { return a.buildTableModel(ctx, entries) },
+		"buildServiceModel":  func(a *Analyzer) error { return a.buildServiceModel(ctx, entries) },
+		"buildEdges":         func(a *Analyzer) error { return a.buildEdges(ctx, entries) },
+		"generateSparklines": func(a *Analyzer) error { return a.generateSparklines(ctx, entries) },
+		"detectAnomalies":    func(a *Analyzer) error { return a.detectAnomalies(ctx) },
+	}
+
+	for name, run := range stages {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			a := New(cfg, nil, nil)
+			// Seed a table so the loops over a.tables have work to do.
+			if err := a.buildTableModel(context.Background(), entries); err != nil {
+				t.Fatalf("buildTableModel failed: %v", err)
+			}
+			if err := run(a); !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateSparklines(b *testing.B) {
+	const (
+		tableCount = 2000
+		hours      = 24 * 7
+	)
+	start := time.Now().Truncate(time.Hour).Add(-hours * time.Hour)
+	cfg := config.DefaultConfig()
+
+	entries := make([]*models.QueryLogEntry, 0, tableCount*hours)
+	for h := 0; h < hours; h++ {
+		for i := 0; i < tableCount; i++ {
+			entries = append(entries, &models.QueryLogEntry{
+				EventTime: start.Add(time.Duration(h) * time.Hour),
+				Tables:    []string{fmt.Sprintf("db.table%d", i)},
+			})
+		}
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		a := New(cfg, nil, nil)
+		for i := 0; i < tableCount; i++ {
+			name := fmt.Sprintf("db.table%d", i)
+			a.Tables()[name] = &models.Table{FullName: name}
+		}
+		if err := a.generateSparklines(context.Background(), entries); err != nil {
+			b.Fatalf("generateSparklines failed: %v", err)
+		}
+	}
+}
+
+func TestCountDistinctServicesGroupsPodsByServiceName(t *testing.T) {
+	a := New(config.DefaultConfig(), nil, nil)
+	a.tables["db.events"] = &models.Table{FullName: "db.events"}
+	a.tables["db.idle"] = &models.Table{FullName: "db.idle", DistinctServices: 3}
+	a.edges = []*models.Edge{
+		{ServiceIP: "10.0.0.1", ServiceName: "ingest", TableName: "db.events"},
+		{ServiceIP: "10.0.0.2", ServiceName: "ingest", TableName: "db.events"},
+		{ServiceIP: "10.0.0.3", TableName: "db.events"},
+	}
+
+	a.countDistinctServices()
+
+	if got := a.tables["db.events"].DistinctServices; got != 2 {
+		t.Fatalf("expected 2 distinct services (ingest + 10.0.0.3), got %d", got)
+	}
+	if got := a.tables["db.idle"].DistinctServices; got != 0 {
+		t.Fatalf("expected stale count to be reset to 0, got %d", got)
+	}
+}
+
+func TestGenerateSparklinesBuildsUTCHeatmap(t *testing.T) {
+	newYork := time.FixedZone("UTC-5", -5*60*60)
+	tokyo := time.FixedZone("UTC+9", 9*60*60)
+	entries := []*models.QueryLogEntry{
+		// Last second of Sunday and first of Monday, 2026-02-15/16 UTC
+		{EventTime: time.Date(2026, 2, 15, 23, 59, 59, 0, time.UTC), Tables: []string{"db.batch"}},
+		{EventTime: time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC), Tables: []string{"db.batch"}},
+		// Sunday evening in New York is Monday 01:00 UTC
+		{EventTime: time.Date(2026, 2, 15, 20, 30, 0, 0, newYork), Tables: []string{"db.batch"}},
+		// Monday morning in Tokyo is Sunday 23:00 UTC
+		{EventTime: time.Date(2026, 2, 16, 8, 15, 0, 0, tokyo), Tables: []string{"db.batch", "db.other"}},
+		// Saturday 2026-02-21 12:00 UTC
+		{EventTime: time.Date(2026, 2, 21, 12, 0, 0, 0, time.UTC), Tables: []string{"db.batch"}},
+	}
+
+	a := New(config.DefaultConfig(), nil, nil)
+	a.Tables()["db.batch"] = &models.Table{FullName: "db.batch"}
+	a.Tables()["db.other"] = &models.Table{FullName: "db.other"}
+	a.Tables()["db.idle"] = &models.Table{FullName: "db.idle"}
+	if err := a.generateSparklines(context.Background(), entries); err != nil {
+		t.Fatalf("generateSparklines failed: %v", err)
+	}
+
+	var want models.AccessHeatmap
+	want[time.Sunday][23] = 2
+	want[time.Monday][0] = 1
+	want[time.Monday][1] = 1
+	want[time.Saturday][12] = 1
+	if got := a.Tables()["db.batch"].Heatmap; got == nil || *got != want {
+		t.Fatalf("unexpected heatmap for db.batch: %v", got)
+	}
+
+	var other models.AccessHeatmap
+	other[time.Sunday][23] = 1
+	if got := a.Tables()["db.other"].Heatmap; got == nil || *got != other {
+		t.Fatalf("unexpected heatmap for db.other: %v", got)
+	}
+	if got := a.Tables()["db.idle"].Heatmap; got != nil {
+		t.Fatalf("expected no heatmap without queries, got %v", got)
+	}
+}
+
+func TestGenerateSparklinesUsesConfiguredTimezone(t *testing.T) {
+	entries := []*models.QueryLogEntry{
+		// Monday 2026-02-16 03:30 UTC is Sunday 22:30 in New York
+		{EventTime: time.Date(2026, 2, 16, 3, 30, 0, 0, time.UTC), Tables: []string{"db.events"}},
+		// DST ends in New York on 2026-11-01: 05:30 and 06:30 UTC are both
+		// 01:30 local, in the first and the repeated hour
+		{EventTime: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), Tables: []string{"db.events"}},
+		{EventTime: time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), Tables: []string{"db.events"}},
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	run := func(timezone string) *models.Table {
+		cfg := config.DefaultConfig()
+		cfg.Timezone = timezone
+		a := New(cfg, nil, nil)
+		a.Tables()["db.events"] = &models.Table{FullName: "db.events"}
+		if err := a.generateSparklines(context.Background(), entries); err != nil {
+			t.Fatalf("generateSparklines(%s) failed: %v", timezone, err)
+		}
+		return a.Tables()["db.events"]
+	}
+
+	var utcHeatmap models.AccessHeatmap
+	utcHeatmap[time.Monday][3] = 1
+	utcHeatmap[time.Sunday][5] = 1
+	utcHeatmap[time.Sunday][6] = 1
+	if got := run("UTC").Heatmap; got == nil || *got != utcHeatmap {
+		t.Fatalf("unexpected UTC heatmap: %v", got)
+	}
+
+	local := run("America/New_York")
+	var localHeatmap models.AccessHeatmap
+	localHeatmap[time.Sunday][22] = 1
+	localHeatmap[time.Sunday][1] = 2
+	if local.Heatmap == nil || *local.Heatmap != localHeatmap {
+		t.Fatalf("unexpected New York heatmap: %v", local.Heatmap)
+	}
+
+	// The repeated hour stays two sparkline points an hour apart
+	want := []time.Time{
+		time.Date(2026, 2, 15, 22, 0, 0, 0, newYork),
+		time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC),
+		time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC),
+	}
+	if len(local.Sparkline) != len(want) {
+		t.Fatalf("expected %d sparkline points, got %v", len(want), local.Sparkline)
+	}
+	for i, point := range local.Sparkline {
+		if !point.Timestamp.Equal(want[i]) || point.Value != 1 {
+			t.Errorf("point %d: expected %v x1, got %v x%d", i, want[i], point.Timestamp, point.Value)
+		}
+		if point.Timestamp.Location().String() != "America/New_York" {
+			t.Errorf("point %d: expected a New York timestamp, got %v", i, point.Timestamp)
+		}
+	}
+}
+
+func TestBuildServiceModelResolvesConcurrentlyWhenBatchFails(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = true
+	cfg.Concurrency = 3
+
+	// A burst of 20 tokens at 10/s: the last 4 of 24 lookups must wait
+	limiter := k8s.NewRateLimiter(10)
+	var inFlight, maxInFlight, calls atomic.Int32
+	resolver := &mockK8sResolver{
+		batchErr: errors.New("list pods: timeout"),
+		resolveIPFunc: func(ctx context.Context, ip string) (*k8s.ServiceInfo, error) {
+			calls.Add(1)
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				peak := maxInFlight.Load()
+				if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+					break
+				}
+			}
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+			time.Sleep(5 * time.Millisecond)
+			if ip == "10.0.0.13" {
+				return nil, errors.New("lookup failed")
+			}
+			return &k8s.ServiceInfo{Service: "svc-" + ip, Namespace: "default"}, nil
+		},
+	}
+
+	entries := make([]*models.QueryLogEntry, 0, 48)
+	for i := range 24 {
+		ip := fmt.Sprintf("10.0.0.%d", i)
+		// Each IP appears twice but must be looked up once
+		entries = append(entries,
+			&models.QueryLogEntry{ClientIP: ip, Tables: []string{"db.events"}},
+			&models.QueryLogEntry{ClientIP: ip, Tables: []string{"db.events"}},
+		)
+	}
+
+	a := New(cfg, resolver, nil)
+	start := time.Now()
+	if err := a.buildServiceModel(context.Background(), entries); err != nil {
+		t.Fatalf("buildServiceModel failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if resolver.batchCalls != 1 || calls.Load() != 24 {
+		t.Fatalf("expected 1 batch call and 24 lookups, got %d and %d", resolver.batchCalls, calls.Load())
+	}
+	if peak := maxInFlight.Load(); peak > 3 || peak < 2 {
+		t.Fatalf("expected 2-3 lookups in flight with --concurrency 3, peak was %d", peak)
+	}
+	if elapsed < 300*time.Millisecond {
+		t.Fatalf("expected the rate limiter to pace lookups past its burst, took %v", elapsed)
+	}
+
+	services := a.Services()
+	if len(services) != 24 || services["10.0.0.5"].K8sService != "svc-10.0.0.5" || services["10.0.0.5"].QueryCount != 2 {
+		t.Fatalf("unexpected resolved services: %+v", services["10.0.0.5"])
+	}
+	if svc := services["10.0.0.13"]; svc.K8sService != "" {
+		t.Fatalf("expected the failed lookup to keep the raw IP, got %+v", svc)
+	}
+}
@@ -3,8 +3,12 @@ package analyzer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,6 +25,263 @@ func (f *fakeCollector) FetchTableMetadata(ctx context.Context) (map[string]*mod
 	return f.tables, f.err
 }
 
+func TestAnalyzeStopsOnCancelledContext(t *testing.T) {
+	entries := loadFixtureEntries(t, "query_logs.json")
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = false
+	cfg.AnomalyDetection = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	analyzer := New(cfg, nil, &fakeCollector{})
+	err := analyzer.Analyze(ctx, entries)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(analyzer.Tables()) != 0 {
+		t.Fatalf("expected no tables after cancellation, got %d", len(analyzer.Tables()))
+	}
+}
+
+func TestAnalyzeDropsExcludedUsersAndQueryKinds(t *testing.T) {
+	now := time.Now()
+	entries := []*models.QueryLogEntry{
+		{QueryID: "q1", User: "backup_user", QueryKind: "Select", ClientIP: "10.0.0.1", EventTime: now, Tables: []string{"db.backed_up"}},
+		{QueryID: "q2", User: "app", QueryKind: "Create", ClientIP: "10.0.0.2", EventTime: now, Tables: []string{"db.ddl_only"}},
+		{QueryID: "q3", User: "app", QueryKind: "Select", ClientIP: "10.0.0.3", EventTime: now, Tables: []string{"db.events"}},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = false
+	cfg.ExcludeUsers = []string{"backup_*"}
+	cfg.ExcludeQueryKinds = []string{"create"}
+	cfg.Normalize()
+
+	analyzer := New(cfg, nil, &fakeCollector{})
+	if err := analyzer.Analyze(context.Background(), entries); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	tables := analyzer.Tables()
+	if len(tables) != 1 || tables["db.events"] == nil {
+		t.Fatalf("expected only db.events, got %v", tables)
+	}
+	if services := analyzer.Services(); len(services) != 1 || services["10.0.0.3"] == nil {
+		t.Fatalf("expected only the 10.0.0.3 service, got %v", services)
+	}
+	for _, edge := range analyzer.Edges() {
+		if edge.ServiceIP != "10.0.0.3" {
+			t.Fatalf("unexpected edge from excluded entry: %+v", edge)
+		}
+	}
+}
+
+func TestAnalyzeTreatsMonitoringOnlyTablesAsUnused(t *testing.T) {
+	now := time.Now()
+	entries := []*models.QueryLogEntry{
+		{QueryID: "q1", User: "app", QueryKind: "Select", ClientIP: "10.0.0.1", EventTime: now, Tables: []string{"db.events"}},
+		{QueryID: "q2", User: "app", QueryKind: "Select", ClientIP: "10.0.0.9", EventTime: now, Tables: []string{"db.probed"}, LogComment: "monitoring:probe"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = true
+	cfg.ExcludeLogComments = []string{"monitoring*"}
+	cfg.Normalize()
+
+	analyzer := New(cfg, nil, &fakeCollector{tables: map[string]*models.Table{
+		"db.events": {Database: "db", Name: "events", FullName: "db.events", Engine: "MergeTree"},
+		"db.probed": {Database: "db", Name: "probed", FullName: "db.probed", Engine: "MergeTree"},
+	}})
+	if err := analyzer.Analyze(context.Background(), entries); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	tables := analyzer.Tables()
+	if events := tables["db.events"]; events == nil || events.ZeroUsage {
+		t.Fatalf("expected db.events to be in use, got %+v", events)
+	}
+	probed := tables["db.probed"]
+	if probed == nil || !probed.ZeroUsage || probed.Reads != 0 {
+		t.Fatalf("expected monitoring-only db.probed to be unused, got %+v", probed)
+	}
+	if services := analyzer.Services(); services["10.0.0.9"] != nil {
+		t.Fatalf("expected no service for the monitoring client, got %v", services)
+	}
+}
+
+func TestAnalyzeFlagsOrphanedMaterializedViews(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = true
+	cfg.AnomalyDetection = true
+	cfg.ExcludeDatabases = []string{"staging"}
+	cfg.Normalize()
+
+	// As system.tables reports them: a live source lists its views in
+	// dependencies, a dropped source lists nothing, and each view's sources
+	// come from its CREATE query
+	collector := &fakeCollector{tables: map[string]*models.Table{
+		"db.events": {
+			Database: "db", Name: "events", FullName: "db.events", Engine: "MergeTree",
+			MVDependency: []string{"db.events_mv"},
+		},
+		"db.events_mv": {
+			Database: "db", Name: "events_mv", FullName: "db.events_mv", Engine: "MaterializedView",
+			IsMV: true, MVDependency: []string{"db.events_rollup_mv"}, MVSources: []string{"db.events"},
+		},
+		"db.events_rollup_mv": {
+			Database: "db", Name: "events_rollup_mv", FullName: "db.events_rollup_mv", Engine: "MaterializedView",
+			IsMV: true, MVSources: []string{"db.events_mv"},
+		},
+		"db.orders_mv": {
+			Database: "db", Name: "orders_mv", FullName: "db.orders_mv", Engine: "MaterializedView",
+			IsMV: true, MVSources: []string{"db.orders"},
+		},
+		"db.staging_mv": {
+			Database: "db", Name: "staging_mv", FullName: "db.staging_mv", Engine: "MaterializedView",
+			IsMV: true, MVSources: []string{"staging.raw"},
+		},
+	}}
+
+	analyzer := New(cfg, nil, collector)
+	if err := analyzer.Analyze(context.Background(), nil); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	var orphaned []string
+	for _, anomaly := range analyzer.Anomalies() {
+		if anomaly.Type != "orphaned_mv" {
+			continue
+		}
+		if anomaly.Severity != "medium" {
+			t.Fatalf("expected medium severity, got %s", anomaly.Severity)
+		}
+		orphaned = append(orphaned, anomaly.AffectedTable)
+	}
+	if len(orphaned) != 1 || orphaned[0] != "db.orders_mv" {
+		t.Fatalf("expected only db.orders_mv to be orphaned, got %v", orphaned)
+	}
+}
+
+func TestAnalyzeCountsDistinctServicesAndFlagsSingleConsumer(t *testing.T) {
+	now := time.Now()
+	entries := []*models.QueryLogEntry{
+		{QueryID: "q1", QueryKind: "Select", ClientIP: "10.0.0.1", EventTime: now, Tables: []string{"db.shared"}},
+		{QueryID: "q2", QueryKind: "Select", ClientIP: "10.0.0.2", EventTime: now, Tables: []string{"db.shared"}},
+		{QueryID: "q3", QueryKind: "Insert", ClientIP: "10.0.0.2", EventTime: now, Tables: []string{"db.shared"}},
+		{QueryID: "q4", QueryKind: "Select", ClientIP: "10.0.0.1", EventTime: now, Tables: []string{"db.owned"}},
+		{QueryID: "q5", QueryKind: "Insert", ClientIP: "10.0.0.1", EventTime: now, Tables: []string{"db.owned"}},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = false
+	cfg.AnomalyDetection = true
+
+	analyzer := New(cfg, nil, &fakeCollector{})
+	if err := analyzer.Analyze(context.Background(), entries); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	tables := analyzer.Tables()
+	if got := tables["db.shared"].DistinctServices; got != 2 {
+		t.Fatalf("expected db.shared to have 2 distinct services, got %d", got)
+	}
+	if got := tables["db.owned"].DistinctServices; got != 1 {
+		t.Fatalf("expected db.owned to have 1 distinct service, got %d", got)
+	}
+
+	var flagged []string
+	for _, anomaly := range analyzer.Anomalies() {
+		if anomaly.Type == "single_consumer" {
+			flagged = append(flagged, anomaly.AffectedTable)
+		}
+	}
+	if len(flagged) != 1 || flagged[0] != "db.owned" {
+		t.Fatalf("expected only db.owned flagged as single_consumer, got %v", flagged)
+	}
+}
+
+func TestAnalyzeKeepsDistinctSampleQueries(t *testing.T) {
+	now := time.Now()
+	entry := func(id, query string) *models.QueryLogEntry {
+		return &models.QueryLogEntry{QueryID: id, QueryKind: "Select", ClientIP: "10.0.0.1", EventTime: now, Query: query, Tables: []string{"db.events"}}
+	}
+	entries := []*models.QueryLogEntry{
+		entry("q1", "SELECT * FROM db.events WHERE id = 1"),
+		entry("q2", "select *  FROM db.events\nWHERE id = 42"),
+		entry("q3", "SELECT count() FROM db.events WHERE user = 'alice'"),
+		entry("q4", "SELECT count() FROM db.events WHERE user = 'bob'"),
+		entry("q5", "SELECT max(ts) FROM db.events"),
+		entry("q6", "SELECT min(ts) FROM db.events"),
+	}
+
+	tests := []struct {
+		name   string
+		limit  int
+		redact bool
+		want   []string
+	}{
+		{
+			name:  "disabled",
+			limit: 0,
+		},
+		{
+			name:  "dedup collapses normalized queries",
+			limit: 10,
+			want: []string{
+				"SELECT * FROM db.events WHERE id = 1",
+				"SELECT count() FROM db.events WHERE user = 'alice'",
+				"SELECT max(ts) FROM db.events",
+				"SELECT min(ts) FROM db.events",
+			},
+		},
+		{
+			name:  "at most N retained",
+			limit: 2,
+			want: []string{
+				"SELECT * FROM db.events WHERE id = 1",
+				"SELECT count() FROM db.events WHERE user = 'alice'",
+			},
+		},
+		{
+			name:   "redacted literals",
+			limit:  2,
+			redact: true,
+			want: []string{
+				"SELECT * FROM db.events WHERE id = ?",
+				"SELECT count() FROM db.events WHERE user = ?",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.ResolveK8s = false
+			cfg.DetectUnusedTables = false
+			cfg.KeepSampleQueries = tt.limit
+			cfg.RedactLiterals = tt.redact
+
+			analyzer := New(cfg, nil, &fakeCollector{})
+			if err := analyzer.Analyze(context.Background(), entries); err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+
+			got := analyzer.Tables()["db.events"].SampleQueries
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d sample queries, got %q", len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("sample %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
 func TestAnalyzeBuildsModels(t *testing.T) {
 	entries := loadFixtureEntries(t, "query_logs.json")
 	cfg := config.DefaultConfig()
@@ -117,25 +378,25 @@ func TestAnalyzeEnrichesInventory(t *testing.T) {
 	collector := &fakeCollector{
 		tables: map[string]*models.Table{
 			"db1.table1": {
-				Name:         "table1",
-				Database:     "db1",
-				FullName:     "db1.table1",
-				Engine:       "ReplicatedMergeTree",
-				IsReplicated: true,
-				TotalBytes:   123,
-				TotalRows:    45,
-				CreateTime:   metaTime,
-				IsMV:         false,
-				MVDependency: []string{"db1.dep"},
+				Name:             "table1",
+				Database:         "db1",
+				FullName:         "db1.table1",
+				Engine:           "ReplicatedMergeTree",
+				IsReplicated:     true,
+				TotalBytes:       123,
+				TotalRows:        45,
+				MetadataModified: metaTime,
+				IsMV:             false,
+				MVDependency:     []string{"db1.dep"},
 			},
 			"db3.table3": {
-				Name:       "table3",
-				Database:   "db3",
-				FullName:   "db3.table3",
-				Engine:     "MergeTree",
-				TotalBytes: 10,
-				TotalRows:  1,
-				CreateTime: metaTime,
+				Name:             "table3",
+				Database:         "db3",
+				FullName:         "db3.table3",
+				Engine:           "MergeTree",
+				TotalBytes:       10,
+				TotalRows:        1,
+				MetadataModified: metaTime,
 			},
 		},
 	}
@@ -169,6 +430,179 @@ func TestAnalyzeEnrichesInventory(t *testing.T) {
 	}
 }
 
+func TestAnalyzeAttachesMixedCaseUsageToInventory(t *testing.T) {
+	now := time.Now()
+	// Table names as the collector extracts them from
+	// SELECT * FROM "Analytics"."MyTable" and FROM db.`v1.Events`
+	entries := []*models.QueryLogEntry{
+		{QueryID: "q1", EventTime: now, QueryKind: "Select", ClientIP: "10.0.0.1", ReadRows: 10, Tables: []string{"Analytics.MyTable"}},
+		{QueryID: "q2", EventTime: now, QueryKind: "Select", ClientIP: "10.0.0.1", ReadRows: 5, Tables: []string{"db.v1.Events"}},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = true
+	cfg.AnomalyDetection = false
+
+	collector := &fakeCollector{
+		tables: map[string]*models.Table{
+			"Analytics.MyTable": {Name: "MyTable", Database: "Analytics", FullName: "Analytics.MyTable", Engine: "MergeTree"},
+			"analytics.mytable": {Name: "mytable", Database: "analytics", FullName: "analytics.mytable", Engine: "MergeTree"},
+			"db.v1.Events":      {Name: "v1.Events", Database: "db", FullName: "db.v1.Events", Engine: "MergeTree"},
+		},
+	}
+
+	analyzer := New(cfg, nil, collector)
+	if err := analyzer.Analyze(context.Background(), entries); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	tables := analyzer.Tables()
+	if len(tables) != 3 {
+		t.Fatalf("expected usage to join the inventory, got %d tables", len(tables))
+	}
+	mixed := tables["Analytics.MyTable"]
+	if mixed == nil || mixed.ZeroUsage || mixed.Reads != 10 || mixed.Engine != "MergeTree" {
+		t.Fatalf("expected Analytics.MyTable usage on its inventory entry, got %+v", mixed)
+	}
+	if lower := tables["analytics.mytable"]; lower == nil || !lower.ZeroUsage {
+		t.Fatalf("expected analytics.mytable to stay unused, got %+v", lower)
+	}
+	dotted := tables["db.v1.Events"]
+	if dotted == nil || dotted.ZeroUsage || dotted.Database != "db" || dotted.Name != "v1.Events" {
+		t.Fatalf("expected db.v1.Events usage with database db and name v1.Events, got %+v", dotted)
+	}
+}
+
+func TestAnalyzeFiltersInventoryByEngine(t *testing.T) {
+	entries := []*models.QueryLogEntry{
+		{QueryID: "q1", EventTime: time.Now(), QueryKind: "Select", ClientIP: "10.0.0.1", Tables: []string{"db.events_queue"}},
+	}
+	inventory := func() map[string]*models.Table {
+		return map[string]*models.Table{
+			"db.events":       {Name: "events", Database: "db", FullName: "db.events", Engine: "ReplicatedMergeTree"},
+			"db.sessions":     {Name: "sessions", Database: "db", FullName: "db.sessions", Engine: "MergeTree"},
+			"db.events_queue": {Name: "events_queue", Database: "db", FullName: "db.events_queue", Engine: "Kafka"},
+			"db.events_dist":  {Name: "events_dist", Database: "db", FullName: "db.events_dist", Engine: "Distributed"},
+		}
+	}
+
+	cases := []struct {
+		name  string
+		allow []string
+		deny  []string
+		want  []string
+	}{
+		{name: "no_filter", want: []string{"db.events", "db.events_dist", "db.events_queue", "db.sessions"}},
+		{name: "allowlist", allow: []string{"*MergeTree"}, want: []string{"db.events", "db.sessions"}},
+		{name: "denylist", deny: []string{"kafka"}, want: []string{"db.events", "db.events_dist", "db.sessions"}},
+		{name: "allow_and_deny", allow: []string{"*MergeTree"}, deny: []string{"Replicated*"}, want: []string{"db.sessions"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.DetectUnusedTables = true
+			cfg.AnomalyDetection = false
+			cfg.EngineAllow = tc.allow
+			cfg.EngineDeny = tc.deny
+			cfg.Normalize()
+
+			analyzer := New(cfg, nil, &fakeCollector{tables: inventory()})
+			if err := analyzer.Analyze(context.Background(), entries); err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+
+			got := make([]string, 0, len(analyzer.Tables()))
+			for name := range analyzer.Tables() {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected tables %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzeEstimatesPartitionHeat(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	read := func(id, query string, tables ...string) *models.QueryLogEntry {
+		return &models.QueryLogEntry{QueryID: id, EventTime: now, QueryKind: "Select", ClientIP: "10.0.0.1", Query: query, Tables: tables}
+	}
+	entries := []*models.QueryLogEntry{
+		read("q1", "SELECT * FROM db.events WHERE event_date >= '2026-03-01' AND event_date < '2026-04-01'", "db.events"),
+		read("q2", "SELECT count() FROM db.events AS e WHERE e.event_date BETWEEN toDate('2026-02-10') AND toDate('2026-02-12')", "db.events"),
+		read("q3", "SELECT * FROM db.events WHERE `event_date` = '2026-03-15'", "db.events"),
+		read("q4", "SELECT count() FROM db.events", "db.events"),
+		read("q5", "SELECT * FROM db.daily WHERE day >= '2026-03-02 00:00:00'", "db.daily"),
+		read("q6", "SELECT * FROM db.tupled WHERE event_date = '2026-03-01'", "db.tupled"),
+		// A failed read and a write say nothing about which partitions are read
+		{QueryID: "q7", EventTime: now, QueryKind: "Select", Exception: "timeout", Query: "SELECT * FROM db.events WHERE event_date = '2025-12-01'", Tables: []string{"db.events"}},
+		{QueryID: "q8", EventTime: now, QueryKind: "Insert", Query: "INSERT INTO db.events SELECT * FROM db.staging WHERE event_date = '2026-01-05'", Tables: []string{"db.events"}},
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = true
+	cfg.AnomalyDetection = false
+	cfg.PartitionHeat = true
+
+	collector := &fakeCollector{
+		tables: map[string]*models.Table{
+			"db.events": {
+				Name: "events", Database: "db", FullName: "db.events", Engine: "MergeTree",
+				PartitionKey: "toYYYYMM(event_date)",
+				Partitions:   []string{"202512", "202601", "202602", "202603"},
+			},
+			"db.daily": {
+				Name: "daily", Database: "db", FullName: "db.daily", Engine: "MergeTree",
+				PartitionKey: "day",
+				Partitions:   []string{"20260301", "20260302", "20260303"},
+			},
+			"db.tupled": {
+				Name: "tupled", Database: "db", FullName: "db.tupled", Engine: "MergeTree",
+				PartitionKey: "(toYYYYMM(event_date), region)",
+				Partitions:   []string{"5f1c2e0a9b"},
+			},
+			"db.idle": {
+				Name: "idle", Database: "db", FullName: "db.idle", Engine: "MergeTree",
+				PartitionKey: "toYYYYMM(event_date)",
+				Partitions:   []string{"202603"},
+			},
+		},
+	}
+
+	analyzer := New(cfg, nil, collector)
+	if err := analyzer.Analyze(context.Background(), entries); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	tables := analyzer.Tables()
+
+	want := map[string]*models.PartitionHeat{
+		"db.events": {
+			Estimated:       true,
+			HotPartitions:   []string{"202602", "202603"},
+			ColdPartitions:  []string{"202512", "202601"},
+			FilteredReads:   3,
+			UnfilteredReads: 1,
+		},
+		"db.daily": {
+			Estimated:      true,
+			HotPartitions:  []string{"20260302", "20260303"},
+			ColdPartitions: []string{"20260301"},
+			FilteredReads:  1,
+		},
+		"db.tupled": nil, // Unsupported partition key
+		"db.idle":   nil, // No reads to estimate from
+	}
+	for name, wantHeat := range want {
+		got := tables[name].PartitionHeat
+		if !reflect.DeepEqual(got, wantHeat) {
+			t.Fatalf("%s: expected partition heat %+v, got %+v", name, wantHeat, got)
+		}
+	}
+}
+
 func TestAnalyzeRespectsExclusions(t *testing.T) {
 	entries := []*models.QueryLogEntry{
 		{
@@ -274,3 +708,174 @@ func containsString(values []string, target string) bool {
 	}
 	return false
 }
+
+func TestAnalyzeMergesPriorUsage(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-48 * time.Hour)
+	entries := []*models.QueryLogEntry{
+		{QueryID: "q1", QueryKind: "Select", ClientIP: "10.0.0.1", ReadRows: 1, EventTime: now, Tables: []string{"db.events"}},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = true
+
+	analyzer := New(cfg, nil, &fakeCollector{tables: map[string]*models.Table{
+		"db.events": {Name: "events", Database: "db", FullName: "db.events", Engine: "MergeTree"},
+		"db.idle":   {Name: "idle", Database: "db", FullName: "db.idle", Engine: "MergeTree"},
+	}})
+	analyzer.SetPriorUsage(map[string]models.TableUsage{
+		"db.events": {Reads: 4, FirstSeen: earlier, LastAccess: earlier},
+		"db.idle":   {Reads: 7, Writes: 1, FirstSeen: earlier, LastAccess: earlier, LastWrite: earlier},
+	})
+	if err := analyzer.Analyze(context.Background(), entries); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	tables := analyzer.Tables()
+	events := tables["db.events"]
+	if events == nil || events.Reads != 5 {
+		t.Fatalf("expected db.events reads to include prior usage, got %+v", events)
+	}
+	if !events.FirstSeen.Equal(earlier) || !events.LastAccess.Equal(now) {
+		t.Fatalf("expected db.events to span prior and current runs, got first=%v last=%v", events.FirstSeen, events.LastAccess)
+	}
+	idle := tables["db.idle"]
+	if idle == nil || idle.ZeroUsage || idle.Reads != 7 || idle.Writes != 1 || !idle.LastWrite.Equal(earlier) {
+		t.Fatalf("expected db.idle to keep its prior usage, got %+v", idle)
+	}
+}
+
+func TestAnalyzeIsSafeForConcurrentUse(t *testing.T) {
+	const workers = 8
+	now := time.Now()
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = false
+	cfg.DetectUnusedTables = false
+	analyzer := New(cfg, nil, &fakeCollector{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		// Analyze canonicalizes entries in place, so each run gets its own
+		entries := []*models.QueryLogEntry{
+			{QueryID: "r", QueryKind: "Select", ClientIP: "10.0.0.1", ReadRows: 2, EventTime: now, Tables: []string{"db.events"}},
+			{QueryID: "w", QueryKind: "Insert", ClientIP: "10.0.0.2", WrittenRows: 1, EventTime: now, Tables: []string{"db.events"}},
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := analyzer.Analyze(context.Background(), entries); err != nil {
+				t.Errorf("Analyze failed: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, table := range analyzer.TablesSnapshot() {
+				table.Reads = 0 // Snapshots are copies; this must not reach the analyzer
+			}
+			_ = analyzer.ServicesSnapshot()
+			_ = analyzer.EdgesSnapshot()
+			for _, anomaly := range analyzer.AnomaliesSnapshot() {
+				anomaly.Severity = "" // Also a copy
+			}
+		}()
+	}
+	wg.Wait()
+
+	events := analyzer.TablesSnapshot()["db.events"]
+	if events == nil || events.Reads != 2*workers || events.Writes != workers {
+		t.Fatalf("expected usage from all %d runs, got %+v", workers, events)
+	}
+	if services := analyzer.ServicesSnapshot(); len(services) != 2 {
+		t.Fatalf("expected 2 services, got %d", len(services))
+	}
+}
+
+func TestRollupGraphByNamespace(t *testing.T) {
+	early := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	services := []models.Service{
+		{IP: "10.0.0.1", K8sNamespace: "billing", K8sService: "api", K8sPod: "api-1", QueryCount: 3, TablesUsed: []string{"db.invoices"}, LastSeen: early},
+		{IP: "10.0.0.2", K8sNamespace: "billing", K8sService: "worker", K8sPod: "worker-1", QueryCount: 5, TablesUsed: []string{"db.invoices", "db.payments"}, LastSeen: late},
+		{IP: "10.0.0.9", QueryCount: 1, TablesUsed: []string{"db.payments"}},
+	}
+	edges := []models.Edge{
+		{ServiceIP: "10.0.0.1", ServiceName: "api", TableName: "db.invoices", Reads: 10, Writes: 1, LastActivity: early},
+		{ServiceIP: "10.0.0.2", ServiceName: "worker", TableName: "db.invoices", Reads: 20, Writes: 2, LastActivity: late},
+		{ServiceIP: "10.0.0.2", ServiceName: "worker", TableName: "db.payments", Reads: 7},
+		{ServiceIP: "10.0.0.9", ServiceName: "10.0.0.9", TableName: "db.payments", Reads: 4},
+	}
+
+	if graph := RollupGraph(services, edges, GraphPod); graph != nil {
+		t.Fatalf("expected no rollup at pod granularity, got %+v", graph)
+	}
+
+	graph := RollupGraph(services, edges, GraphNamespace)
+	if graph == nil || graph.Granularity != GraphNamespace {
+		t.Fatalf("expected a namespace graph, got %+v", graph)
+	}
+	if len(graph.Services) != 2 {
+		t.Fatalf("expected 2 service nodes, got %+v", graph.Services)
+	}
+	billing := graph.Services[0]
+	if billing.IP != "billing" || billing.K8sNamespace != "billing" || billing.K8sService != "" || billing.K8sPod != "" {
+		t.Fatalf("unexpected namespace node: %+v", billing)
+	}
+	if billing.QueryCount != 8 || !billing.LastSeen.Equal(late) {
+		t.Fatalf("expected 8 queries last seen %v, got %+v", late, billing)
+	}
+	if !reflect.DeepEqual(billing.TablesUsed, []string{"db.invoices", "db.payments"}) {
+		t.Fatalf("unexpected tables used: %v", billing.TablesUsed)
+	}
+	if graph.Services[1].IP != "10.0.0.9" {
+		t.Fatalf("expected the unresolved IP to keep its own node, got %+v", graph.Services[1])
+	}
+
+	want := []models.Edge{
+		{ServiceIP: "10.0.0.9", ServiceName: "10.0.0.9", TableName: "db.payments", Reads: 4},
+		{ServiceIP: "billing", ServiceName: "billing", TableName: "db.invoices", Reads: 30, Writes: 3, LastActivity: late},
+		{ServiceIP: "billing", ServiceName: "billing", TableName: "db.payments", Reads: 7},
+	}
+	if !reflect.DeepEqual(graph.Edges, want) {
+		t.Fatalf("unexpected edges:\n got %+v\nwant %+v", graph.Edges, want)
+	}
+
+	byService := RollupGraph(services, edges, GraphService)
+	if len(byService.Services) != 3 || byService.Services[0].IP != "billing/worker" {
+		t.Fatalf("expected one node per namespace/service, got %+v", byService.Services)
+	}
+}
+
+func TestTopConsumers(t *testing.T) {
+	edges := []models.Edge{
+		{ServiceIP: "10.0.0.1", ServiceName: "api", TableName: "db.hot", Reads: 50, Writes: 5},
+		{ServiceIP: "10.0.0.2", ServiceName: "api", TableName: "db.hot", Reads: 40},
+		{ServiceIP: "10.0.0.3", ServiceName: "etl", TableName: "db.hot", Writes: 120},
+		{ServiceIP: "10.0.0.4", ServiceName: "billing", TableName: "db.hot", Reads: 30},
+		{ServiceIP: "10.0.0.5", ServiceName: "search", TableName: "db.hot", Reads: 30},
+		{ServiceIP: "10.0.0.6", ServiceName: "reports", TableName: "db.hot", Reads: 10},
+		{ServiceIP: "10.0.0.7", TableName: "db.hot", Reads: 1},
+		{ServiceIP: "10.0.0.3", ServiceName: "etl", TableName: "db.cold", Writes: 2},
+	}
+
+	top := TopConsumers(edges, 5)
+	want := []models.EdgeSummary{
+		{Service: "etl", Writes: 120},
+		{Service: "api", Reads: 90, Writes: 5},
+		{Service: "billing", Reads: 30},
+		{Service: "search", Reads: 30},
+		{Service: "reports", Reads: 10},
+	}
+	if !reflect.DeepEqual(top["db.hot"], want) {
+		t.Fatalf("expected top consumers %+v, got %+v", want, top["db.hot"])
+	}
+	if got := top["db.cold"]; len(got) != 1 || got[0].Service != "etl" {
+		t.Fatalf("expected one consumer of db.cold, got %+v", got)
+	}
+
+	if got := TopConsumers(edges, 2)["db.hot"]; len(got) != 2 || got[1].Service != "api" {
+		t.Fatalf("expected the top 2 consumers, got %+v", got)
+	}
+	if got := TopConsumers(edges, 0); got != nil {
+		t.Fatalf("expected no top consumers when disabled, got %+v", got)
+	}
+}
@@ -1,21 +1,35 @@
 package analyzer
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ppiankov/clickspectre/internal/models"
 )
 
 // detectAnomalies detects unusual access patterns
-func (a *Analyzer) detectAnomalies() error {
+func (a *Analyzer) detectAnomalies(ctx context.Context) error {
 	now := time.Now()
+	thresholds := a.config.Anomalies
+	sparklineStart, sparklineEnd := earliestSparklineHour(a.tables), latestSparklineHour(a.tables)
+	mvSources := a.mvSourceTables()
 
+	checked := 0
 	for tableName, table := range a.tables {
+		if err := checkContext(ctx, checked); err != nil {
+			return err
+		}
+		checked++
+
 		// Anomaly 1: Tables accessed only once
-		totalAccess := table.Reads + table.Writes
+		totalAccess := table.Reads + table.Writes + table.Mutations
 		if totalAccess == 1 {
-			a.anomalies = append(a.anomalies, &models.Anomaly{
+			a.addAnomaly(&models.Anomaly{
 				Type:          "single_access",
 				Description:   "Table accessed only once in lookback period",
 				Severity:      "low",
@@ -27,30 +41,37 @@ func (a *Analyzer) detectAnomalies() error {
 
 		// Anomaly 2: Tables not accessed recently
 		daysSinceAccess := now.Sub(table.LastAccess).Hours() / 24
-		if daysSinceAccess > 30 {
-			a.anomalies = append(a.anomalies, &models.Anomaly{
+		if daysSinceAccess > float64(thresholds.StaleDays) {
+			a.addAnomaly(&models.Anomaly{
 				Type:          "stale_table",
-				Description:   "Table not accessed in over 30 days",
+				Description:   fmt.Sprintf("Table not accessed in over %d days", thresholds.StaleDays),
 				Severity:      "medium",
 				AffectedTable: tableName,
 				DetectedAt:    now,
 			})
 		}
 
-		// Anomaly 3: Write-only tables (no reads)
-		if table.Writes > 0 && table.Reads == 0 {
-			a.anomalies = append(a.anomalies, &models.Anomaly{
+		// Anomaly 3: Write-only tables (no reads). Tables feeding a
+		// materialized view are read by it, not by queries, so they are not
+		// sinks; tables written only part of the window may just be new
+		if table.Writes > 0 && table.Reads == 0 && !mvSources[tableName] {
+			anomaly := &models.Anomaly{
 				Type:          "write_only",
-				Description:   "Table has writes but no reads (possible data sink)",
+				Description:   "Table has writes but no reads for the whole window (likely data sink)",
 				Severity:      "low",
 				AffectedTable: tableName,
 				DetectedAt:    now,
-			})
+			}
+			if !spansWindow(table.Sparkline, sparklineStart, sparklineEnd) {
+				anomaly.Description = "Table has writes but no reads, but only part of the window was observed (possible data sink)"
+				anomaly.Severity = "info"
+			}
+			a.addAnomaly(anomaly)
 		}
 
 		// Anomaly 4: Read-only tables (no writes, might be outdated)
-		if table.Reads > 100 && table.Writes == 0 {
-			a.anomalies = append(a.anomalies, &models.Anomaly{
+		if table.Reads > thresholds.ReadOnlyMinReads && table.Writes == 0 && table.Mutations == 0 {
+			a.addAnomaly(&models.Anomaly{
 				Type:          "read_only",
 				Description:   "Table has many reads but no writes (check if data is stale)",
 				Severity:      "low",
@@ -60,24 +81,65 @@ func (a *Analyzer) detectAnomalies() error {
 		}
 
 		// Anomaly 5: Tables with very few accesses (potential candidates for cleanup)
-		if totalAccess < 10 && daysSinceAccess > 7 {
-			a.anomalies = append(a.anomalies, &models.Anomaly{
+		if totalAccess < thresholds.LowActivityMaxAccess && daysSinceAccess > float64(thresholds.LowActivityMinDays) {
+			a.addAnomaly(&models.Anomaly{
 				Type:          "low_activity",
-				Description:   "Table has very low activity (< 10 accesses)",
+				Description:   fmt.Sprintf("Table has very low activity (< %d accesses)", thresholds.LowActivityMaxAccess),
 				Severity:      "medium",
 				AffectedTable: tableName,
 				DetectedAt:    now,
 			})
 		}
+
+		// Anomalies 6-7: Sudden spikes and drops in hourly usage
+		buckets := denseSparkline(table.Sparkline, sparklineEnd)
+		if anomaly := a.detectUsageSpike(tableName, buckets, now); anomaly != nil {
+			a.addAnomaly(anomaly)
+		}
+		if anomaly := a.detectUsageDrop(tableName, buckets, now); anomaly != nil {
+			a.addAnomaly(anomaly)
+		}
+
+		// Anomaly 8: Tables whose queries keep failing (broken rather than unused)
+		if table.FailedQueries >= thresholds.ErrorProneMinFailures && table.ErrorRate > thresholds.ErrorProneRate {
+			a.addAnomaly(&models.Anomaly{
+				Type:          "error_prone",
+				Description:   "Many queries failed, table may be broken rather than unused",
+				Severity:      "medium",
+				AffectedTable: tableName,
+				DetectedAt:    now,
+				Metrics: map[string]float64{
+					"error_rate": math.Round(table.ErrorRate*100) / 100,
+					"failures":   float64(table.FailedQueries),
+				},
+			})
+		}
+
+		// Anomaly 9: Tables read or written by a single service, which
+		// become orphaned if that service is retired
+		if table.DistinctServices == 1 {
+			a.addAnomaly(&models.Anomaly{
+				Type:          "single_consumer",
+				Description:   "Table is used by a single service (at risk if that service is retired)",
+				Severity:      "low",
+				AffectedTable: tableName,
+				DetectedAt:    now,
+			})
+		}
+
+		// Anomaly 10: Tables whose reads scan far more rows than they return
+		if anomaly := a.detectFullScan(tableName, now); anomaly != nil {
+			a.addAnomaly(anomaly)
+		}
 	}
 
 	// Service-level anomalies
 	for serviceIP, service := range a.services {
 		// Anomaly: Service accessing many tables (potential over-reach)
-		if len(service.TablesUsed) > 20 {
-			a.anomalies = append(a.anomalies, &models.Anomaly{
+		if len(service.TablesUsed) > thresholds.BroadAccessTableCount {
+			a.addAnomaly(&models.Anomaly{
 				Type:            "broad_access",
-				Description:     "Service accesses many tables (> 20), check for over-privileged access",
+				Description:     fmt.Sprintf("Service accesses many tables (> %d), check for over-privileged access", thresholds.BroadAccessTableCount),
 				Severity:        "low",
 				AffectedService: serviceIP,
 				DetectedAt:      now,
@@ -85,7 +147,374 @@ func (a *Analyzer) detectAnomalies() error {
 		}
 	}
 
+	// Anomaly: Services that started reaching a sensitive table late in
+	// the window, e.g. after a privilege grant
+	a.detectNewSensitiveAccess(now)
+
 	slog.Debug("detected anomalies", slog.Int("count", len(a.anomalies)))
 
 	return nil
 }
+
+// addAnomaly assigns the anomaly its stable ID and records it, unless an
+// anomaly with the same ID was already recorded this run.
+func (a *Analyzer) addAnomaly(anomaly *models.Anomaly) {
+	anomaly.ID = models.AnomalyID(anomaly.Type, anomaly.AffectedTable, anomaly.AffectedService)
+	if _, seen := a.anomalyIDs[anomaly.ID]; seen {
+		return
+	}
+	a.anomalyIDs[anomaly.ID] = struct{}{}
+	a.anomalies = append(a.anomalies, anomaly)
+}
+
+// detectNewSensitiveAccess flags each service whose first query against a
+// sensitive table came after the NewAccessAfter share of the window, so it
+// never touched the table in the earlier part. The window spans the edges'
+// activity; pods behind one K8s service share a first access, so a new pod
+// of a known consumer is not new access. Edges loaded from reports that
+// predate first-activity tracking are skipped. Incremental runs only see
+// entries since the watermark, where every established consumer would look
+// new, so the check is skipped for them.
+func (a *Analyzer) detectNewSensitiveAccess(now time.Time) {
+	after := a.config.Anomalies.NewAccessAfter
+	if len(a.config.SensitiveTables) == 0 || after <= 0 || after >= 1 {
+		return
+	}
+	if a.config.IncrementalSince != nil {
+		slog.Warn("skipping new_sensitive_access detection on an incremental run, the window holds only entries since the last run",
+			slog.Time("since", *a.config.IncrementalSince))
+		return
+	}
+
+	type access struct {
+		service string
+		table   string
+	}
+	firstAccess := make(map[access]time.Time)
+	var windowStart, windowEnd time.Time
+	for _, edge := range a.edges {
+		if edge.FirstActivity.IsZero() {
+			continue
+		}
+		if windowStart.IsZero() || edge.FirstActivity.Before(windowStart) {
+			windowStart = edge.FirstActivity
+		}
+		if edge.LastActivity.After(windowEnd) {
+			windowEnd = edge.LastActivity
+		}
+		if _, sensitive := a.config.MatchSensitiveTable(edge.TableName); !sensitive {
+			continue
+		}
+		service := edge.ServiceName
+		if service == "" {
+			service = edge.ServiceIP
+		}
+		key := access{service: service, table: edge.TableName}
+		if first, seen := firstAccess[key]; !seen || edge.FirstActivity.Before(first) {
+			firstAccess[key] = edge.FirstActivity
+		}
+	}
+	if !windowEnd.After(windowStart) {
+		return
+	}
+
+	window := windowEnd.Sub(windowStart)
+	cutoff := windowStart.Add(time.Duration(float64(window) * after))
+	for key, first := range firstAccess {
+		if !first.After(cutoff) {
+			continue
+		}
+		pattern, _ := a.config.MatchSensitiveTable(key.table)
+		a.addAnomaly(&models.Anomaly{
+			Type: "new_sensitive_access",
+			Description: fmt.Sprintf("Service first accessed sensitive table (matches %q) with no access in the first %.0f%% of the window (check for new grants)",
+				pattern, after*100),
+			Severity:        "high",
+			AffectedTable:   key.table,
+			AffectedService: key.service,
+			DetectedAt:      now,
+			Metrics: map[string]float64{
+				"first_access_pct": math.Round(float64(first.Sub(windowStart)) / float64(window) * 100),
+			},
+		})
+	}
+}
+
+// inventorySkippedDatabases are never fetched into the system.tables
+// inventory, so MV sources there cannot be checked for existence.
+var inventorySkippedDatabases = map[string]bool{
+	"system":             true,
+	"information_schema": true,
+}
+
+// detectOrphanedMVs flags materialized views whose source table is missing
+// from the system.tables inventory. Such views silently stop updating once
+// their source is dropped. Sources come from each view's CREATE query, since
+// system.tables dependencies only link a live source to its views. Sources
+// that the inventory never contains (excluded or system tables) are not
+// reported.
+func (a *Analyzer) detectOrphanedMVs(ctx context.Context) error {
+	now := time.Now()
+	checked := 0
+	for fullName, table := range a.inventory {
+		if err := checkContext(ctx, checked); err != nil {
+			return err
+		}
+		checked++
+
+		if !table.IsMV {
+			continue
+		}
+
+		var missing []string
+		for _, source := range table.MVSources {
+			if _, exists := a.inventory[source]; exists {
+				continue
+			}
+			database, _, _ := strings.Cut(source, ".")
+			if inventorySkippedDatabases[strings.ToLower(database)] {
+				continue
+			}
+			if _, excluded := a.config.MatchTableExclusion(source); excluded {
+				continue
+			}
+			missing = append(missing, source)
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		sort.Strings(missing)
+		a.addAnomaly(&models.Anomaly{
+			Type:          "orphaned_mv",
+			Description:   fmt.Sprintf("Materialized view source table no longer exists (%s), view has stopped updating", strings.Join(missing, ", ")),
+			Severity:      "medium",
+			AffectedTable: fullName,
+			DetectedAt:    now,
+		})
+	}
+
+	return nil
+}
+
+// minSpikeHistory is the number of preceding hourly buckets needed before a
+// bucket can be compared against its trailing mean.
+const minSpikeHistory = 3
+
+// usageDropNearZeroRatio is the fraction of the prior hourly mean at or below
+// which a trailing bucket counts as near-zero.
+const usageDropNearZeroRatio = 0.05
+
+// detectUsageSpike flags the hourly bucket with the largest jump above its
+// trailing mean when it exceeds the configured multiplier. The mean is floored
+// at one query per hour so an empty history still gives a ratio; buckets
+// below UsageSpikeMinQueries are ignored, so a sparse table does not flag a
+// single-digit burst.
+func (a *Analyzer) detectUsageSpike(tableName string, buckets []uint64, now time.Time) *models.Anomaly {
+	multiplier := a.config.Anomalies.UsageSpikeMultiplier
+	minQueries := a.config.Anomalies.UsageSpikeMinQueries
+	if multiplier <= 0 || len(buckets) <= minSpikeHistory {
+		return nil
+	}
+
+	var (
+		sum       uint64
+		peakIndex = -1
+		peakRatio float64
+	)
+	for i, value := range buckets {
+		if i >= minSpikeHistory && value >= minQueries {
+			mean := float64(sum) / float64(i)
+			if mean < 1 {
+				mean = 1
+			}
+			if ratio := float64(value) / mean; ratio > multiplier && ratio > peakRatio {
+				peakIndex = i
+				peakRatio = ratio
+			}
+		}
+		sum += value
+	}
+	if peakIndex < 0 {
+		return nil
+	}
+
+	return &models.Anomaly{
+		Type:          "usage_spike",
+		Description:   "Hourly usage spiked above the trailing mean",
+		Severity:      "low",
+		AffectedTable: tableName,
+		DetectedAt:    now,
+		Metrics: map[string]float64{
+			"ratio":   roundTenths(peakRatio),
+			"queries": float64(buckets[peakIndex]),
+		},
+	}
+}
+
+// detectUsageDrop flags a previously busy table whose final hourly buckets
+// fell to near-zero.
+func (a *Analyzer) detectUsageDrop(tableName string, buckets []uint64, now time.Time) *models.Anomaly {
+	window := a.config.Anomalies.UsageDropBuckets
+	if window <= 0 || len(buckets) <= window {
+		return nil
+	}
+
+	prior := buckets[:len(buckets)-window]
+	var sum uint64
+	for _, value := range prior {
+		sum += value
+	}
+	mean := float64(sum) / float64(len(prior))
+	if mean < a.config.Anomalies.UsageDropMinMean || mean <= 0 {
+		return nil
+	}
+
+	threshold := mean * usageDropNearZeroRatio
+	for _, value := range buckets[len(buckets)-window:] {
+		if float64(value) > threshold {
+			return nil
+		}
+	}
+
+	return &models.Anomaly{
+		Type:          "usage_drop",
+		Description:   fmt.Sprintf("Hourly usage dropped to near-zero for the last %d hours", window),
+		Severity:      "medium",
+		AffectedTable: tableName,
+		DetectedAt:    now,
+		Metrics: map[string]float64{
+			"prior_mean": roundTenths(mean),
+		},
+	}
+}
+
+// scanStats accumulates a table's successful reads in the current run.
+// Usage carried over from earlier incremental runs is not included, since it
+// has no result row counts.
+type scanStats struct {
+	queries    uint64
+	readRows   uint64
+	resultRows uint64
+}
+
+// recordScan adds a successful read of tableName to its scan stats.
+func (a *Analyzer) recordScan(tableName string, entry *models.QueryLogEntry) {
+	scan := a.scans[tableName]
+	if scan == nil {
+		scan = &scanStats{}
+		a.scans[tableName] = scan
+	}
+	scan.queries++
+	scan.readRows += entry.ReadRows
+	scan.resultRows += entry.ResultRows
+}
+
+// detectFullScan flags a table whose reads scanned more rows per returned
+// row than the configured ratio, across at least the configured number of
+// queries. That points at missing primary key or partition pruning rather
+// than an unused table. Result rows are floored at one so reads that return
+// nothing still compare. query_log reports read_rows per query, not per
+// table, so every table of a multi-table query such as a JOIN is charged the
+// whole query's rows; the check is therefore off unless a ratio is set.
+func (a *Analyzer) detectFullScan(tableName string, now time.Time) *models.Anomaly {
+	thresholds := a.config.Anomalies
+	scan := a.scans[tableName]
+	if thresholds.FullScanRatio <= 0 || scan == nil || scan.queries < max(thresholds.FullScanMinQueries, 1) {
+		return nil
+	}
+	ratio := float64(scan.readRows) / float64(max(scan.resultRows, 1))
+	if ratio <= thresholds.FullScanRatio {
+		return nil
+	}
+
+	return &models.Anomaly{
+		Type:          "full_scan_suspect",
+		Description:   "Reads scan far more rows than they return (check primary key and partition pruning)",
+		Severity:      "medium",
+		AffectedTable: tableName,
+		DetectedAt:    now,
+		Metrics: map[string]float64{
+			"rows_per_result": math.Round(ratio),
+			"queries":         float64(scan.queries),
+		},
+	}
+}
+
+// roundTenths rounds an anomaly metric to one decimal place.
+func roundTenths(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// mvSourceTables returns the tables materialized views read from: those
+// whose system.tables dependencies list at least one dependent view. A view
+// that feeds further views is a source too.
+func (a *Analyzer) mvSourceTables() map[string]bool {
+	sources := make(map[string]bool)
+	for _, tables := range []map[string]*models.Table{a.inventory, a.tables} {
+		for tableName, table := range tables {
+			if len(table.MVDependency) > 0 {
+				sources[tableName] = true
+			}
+		}
+	}
+	return sources
+}
+
+// spansWindow reports whether time-ordered sparkline points fall in both
+// halves of the observed window from start through end, i.e. the activity
+// lasted the whole window rather than starting or stopping midway.
+func spansWindow(points []models.TimeSeriesPoint, start, end time.Time) bool {
+	if len(points) == 0 || end.Sub(start) < time.Hour {
+		return false
+	}
+	mid := start.Add(end.Sub(start) / 2)
+	return points[0].Timestamp.Before(mid) && !points[len(points)-1].Timestamp.Before(mid)
+}
+
+// earliestSparklineHour returns the first sparkline bucket across all
+// tables, which marks the start of the observed window.
+func earliestSparklineHour(tables map[string]*models.Table) time.Time {
+	var earliest time.Time
+	for _, table := range tables {
+		if len(table.Sparkline) > 0 && (earliest.IsZero() || table.Sparkline[0].Timestamp.Before(earliest)) {
+			earliest = table.Sparkline[0].Timestamp
+		}
+	}
+	return earliest
+}
+
+// latestSparklineHour returns the most recent sparkline bucket across all
+// tables, which marks the end of the observed window.
+func latestSparklineHour(tables map[string]*models.Table) time.Time {
+	var latest time.Time
+	for _, table := range tables {
+		if n := len(table.Sparkline); n > 0 && table.Sparkline[n-1].Timestamp.After(latest) {
+			latest = table.Sparkline[n-1].Timestamp
+		}
+	}
+	return latest
+}
+
+// denseSparkline expands time-ordered sparse hourly points into a contiguous series
+// from the first point through end, filling missing hours with zero.
+func denseSparkline(points []models.TimeSeriesPoint, end time.Time) []uint64 {
+	if len(points) == 0 {
+		return nil
+	}
+
+	start := points[0].Timestamp.Truncate(time.Hour)
+	if last := points[len(points)-1].Timestamp; last.After(end) {
+		end = last
+	}
+	size := int(end.Truncate(time.Hour).Sub(start)/time.Hour) + 1
+
+	buckets := make([]uint64, size)
+	for _, point := range points {
+		index := int(point.Timestamp.Truncate(time.Hour).Sub(start) / time.Hour)
+		if index >= 0 && index < size {
+			buckets[index] += point.Value
+		}
+	}
+	return buckets
+}
@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/ppiankov/clickspectre/internal/models"
@@ -13,10 +14,13 @@ type EdgeKey struct {
 }
 
 // buildEdges creates service→table relationship edges
-func (a *Analyzer) buildEdges(entries []*models.QueryLogEntry) error {
+func (a *Analyzer) buildEdges(ctx context.Context, entries []*models.QueryLogEntry) error {
 	edgeMap := make(map[EdgeKey]*models.Edge)
 
-	for _, entry := range entries {
+	for i, entry := range entries {
+		if err := checkContext(ctx, i); err != nil {
+			return err
+		}
 		clientIP := entry.ClientIP
 		if clientIP == "" {
 			continue
@@ -45,10 +49,12 @@ func (a *Analyzer) buildEdges(entries []*models.QueryLogEntry) error {
 			edge, exists := edgeMap[key]
 			if !exists {
 				edge = &models.Edge{
-					ServiceIP:    clientIP,
-					ServiceName:  serviceName,
-					TableName:    tableName,
-					LastActivity: entry.EventTime,
+					ServiceIP:     clientIP,
+					ServiceName:   serviceName,
+					TableName:     tableName,
+					FirstActivity: entry.EventTime,
+					LastActivity:  entry.EventTime,
+					KindCounts:    make(map[string]uint64),
 				}
 				edgeMap[key] = edge
 			}
@@ -59,8 +65,12 @@ func (a *Analyzer) buildEdges(entries []*models.QueryLogEntry) error {
 			} else if isWriteQuery(entry.QueryKind) {
 				edge.Writes += entry.WrittenRows
 			}
+			edge.KindCounts[queryKindLabel(entry.QueryKind)]++
 
-			// Update last activity
+			// Update first and last activity
+			if entry.EventTime.Before(edge.FirstActivity) {
+				edge.FirstActivity = entry.EventTime
+			}
 			if entry.EventTime.After(edge.LastActivity) {
 				edge.LastActivity = entry.EventTime
 			}
@@ -75,5 +85,28 @@ func (a *Analyzer) buildEdges(entries []*models.QueryLogEntry) error {
 
 	slog.Debug("built service to table edges", slog.Int("count", len(a.edges)))
 
+	a.countDistinctServices()
+
 	return nil
 }
+
+// countDistinctServices sets each table's DistinctServices from the edge
+// set, so it reflects exclusions. Pods behind one K8s service share an edge
+// ServiceName and count once.
+func (a *Analyzer) countDistinctServices() {
+	consumers := make(map[string]map[string]struct{})
+	for _, edge := range a.edges {
+		name := edge.ServiceName
+		if name == "" {
+			name = edge.ServiceIP
+		}
+		if consumers[edge.TableName] == nil {
+			consumers[edge.TableName] = make(map[string]struct{})
+		}
+		consumers[edge.TableName][name] = struct{}{}
+	}
+
+	for tableName, table := range a.tables {
+		table.DistinctServices = len(consumers[tableName])
+	}
+}
@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+// Graph granularities accepted by --graph-granularity.
+const (
+	GraphPod       = "pod"
+	GraphService   = "service"
+	GraphNamespace = "namespace"
+)
+
+// GraphGranularities lists the values accepted by --graph-granularity.
+var GraphGranularities = []string{GraphPod, GraphService, GraphNamespace}
+
+// RollupGraph collapses services into one node per "namespace/service" or
+// per namespace and merges their edges, summing query counts, reads, and
+// writes. Services without K8s metadata for the granularity keep their own
+// node. It returns nil at pod granularity, where the graph is the report's
+// own services and edges.
+func RollupGraph(services []models.Service, edges []models.Edge, granularity string) *models.ServiceGraph {
+	if granularity != GraphService && granularity != GraphNamespace {
+		return nil
+	}
+
+	nodeByIP := make(map[string]string, len(services))
+	nodes := make(map[string]*models.Service)
+	tablesUsed := make(map[string]map[string]struct{})
+	for _, service := range services {
+		key := graphNodeKey(service, granularity)
+		nodeByIP[service.IP] = key
+
+		node := nodes[key]
+		if node == nil {
+			node = &models.Service{IP: key, K8sNamespace: service.K8sNamespace}
+			if granularity == GraphService || key == service.IP {
+				node.K8sService = service.K8sService
+			}
+			if key == service.IP {
+				node.K8sPod = service.K8sPod
+			}
+			nodes[key] = node
+			tablesUsed[key] = make(map[string]struct{})
+		}
+		node.QueryCount += service.QueryCount
+		if service.LastSeen.After(node.LastSeen) {
+			node.LastSeen = service.LastSeen
+		}
+		for _, table := range service.TablesUsed {
+			tablesUsed[key][table] = struct{}{}
+		}
+	}
+
+	type edgeKey struct{ node, table string }
+	merged := make(map[edgeKey]*models.Edge)
+	for _, edge := range edges {
+		node, ok := nodeByIP[edge.ServiceIP]
+		if !ok {
+			node = edge.ServiceIP
+		}
+		key := edgeKey{node: node, table: edge.TableName}
+		rolled := merged[key]
+		if rolled == nil {
+			rolled = &models.Edge{ServiceIP: node, ServiceName: node, TableName: edge.TableName}
+			if node == edge.ServiceIP {
+				rolled.ServiceName = edge.ServiceName
+			}
+			merged[key] = rolled
+		}
+		rolled.Reads += edge.Reads
+		rolled.Writes += edge.Writes
+		for kind, count := range edge.KindCounts {
+			if rolled.KindCounts == nil {
+				rolled.KindCounts = make(map[string]uint64)
+			}
+			rolled.KindCounts[kind] += count
+		}
+		if !edge.FirstActivity.IsZero() && (rolled.FirstActivity.IsZero() || edge.FirstActivity.Before(rolled.FirstActivity)) {
+			rolled.FirstActivity = edge.FirstActivity
+		}
+		if edge.LastActivity.After(rolled.LastActivity) {
+			rolled.LastActivity = edge.LastActivity
+		}
+	}
+
+	graph := &models.ServiceGraph{
+		Granularity: granularity,
+		Services:    make([]models.Service, 0, len(nodes)),
+		Edges:       make([]models.Edge, 0, len(merged)),
+	}
+	for key, node := range nodes {
+		node.TablesUsed = make([]string, 0, len(tablesUsed[key]))
+		for table := range tablesUsed[key] {
+			node.TablesUsed = append(node.TablesUsed, table)
+		}
+		slices.Sort(node.TablesUsed)
+		graph.Services = append(graph.Services, *node)
+	}
+	for _, edge := range merged {
+		graph.Edges = append(graph.Edges, *edge)
+	}
+
+	// Busiest nodes first, since the HTML graph draws only the top services
+	slices.SortFunc(graph.Services, func(a, b models.Service) int {
+		if c := cmp.Compare(b.QueryCount, a.QueryCount); c != 0 {
+			return c
+		}
+		return strings.Compare(a.IP, b.IP)
+	})
+	slices.SortFunc(graph.Edges, func(a, b models.Edge) int {
+		if c := strings.Compare(a.ServiceIP, b.ServiceIP); c != 0 {
+			return c
+		}
+		return strings.Compare(a.TableName, b.TableName)
+	})
+	return graph
+}
+
+// graphNodeKey returns the node service collapses into at granularity:
+// "namespace/service" or its namespace, falling back to the client IP when
+// the K8s metadata is missing.
+func graphNodeKey(service models.Service, granularity string) string {
+	namespace := strings.TrimSpace(service.K8sNamespace)
+	name := strings.TrimSpace(service.K8sService)
+	switch {
+	case granularity == GraphNamespace && namespace != "":
+		return namespace
+	case granularity == GraphService && namespace != "" && name != "":
+		return namespace + "/" + name
+	case granularity == GraphService && name != "":
+		return name
+	default:
+		return service.IP
+	}
+}
@@ -0,0 +1,237 @@
+package analyzer
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+// partitionKeyPattern matches the date partition keys partition heat
+// understands: a bare date column, or one bucketed by a date function.
+var partitionKeyPattern = regexp.MustCompile("(?i)^\\s*(?:(toYYYYMM|toYYYYMMDD|toStartOfMonth|toMonday|toDate)\\s*\\(\\s*`?(\\w+)`?\\s*\\)|`?(\\w+)`?)\\s*$")
+
+// dateLiteral matches a date literal, optionally cast with toDate,
+// toDateTime, or toDateTime64, e.g. '2026-01-15' or toDate('2026-01-15').
+const dateLiteral = `(?:toDate(?:Time(?:64)?)?\s*\(\s*)?'(\d{4}-\d{2}-\d{2})[^']*'`
+
+// partitionScheme describes how a table's partition key buckets a date
+// column.
+type partitionScheme struct {
+	column string
+	step   func(time.Time) time.Time // Start of the following partition
+}
+
+// parsePartitionKey returns the scheme for key, or false when key is not a
+// single date column bucketed by month, week, or day.
+func parsePartitionKey(key string) (partitionScheme, bool) {
+	match := partitionKeyPattern.FindStringSubmatch(key)
+	if match == nil {
+		return partitionScheme{}, false
+	}
+	day := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	switch strings.ToLower(match[1]) {
+	case "toyyyymm", "tostartofmonth":
+		return partitionScheme{column: match[2], step: func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }}, true
+	case "tomonday":
+		return partitionScheme{column: match[2], step: func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }}, true
+	case "":
+		return partitionScheme{column: match[3], step: day}, true
+	default:
+		return partitionScheme{column: match[2], step: day}, true
+	}
+}
+
+// span returns the time range [start, end) a partition ID covers, from
+// YYYYMM or YYYYMMDD IDs. Other IDs, e.g. "all" or a tuple hash, are not
+// dates.
+func (s partitionScheme) span(partitionID string) (time.Time, time.Time, bool) {
+	switch len(partitionID) {
+	case 6:
+		start, err := time.Parse("200601", partitionID)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		return start, start.AddDate(0, 1, 0), true
+	case 8:
+		start, err := time.Parse("20060102", partitionID)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		return start, s.step(start), true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// dateRange is the [from, to) window a read filtered on; a zero bound is
+// open.
+type dateRange struct {
+	from time.Time
+	to   time.Time
+}
+
+func (r dateRange) overlaps(start, end time.Time) bool {
+	return (r.to.IsZero() || start.Before(r.to)) && (r.from.IsZero() || end.After(r.from))
+}
+
+// dateFilter extracts the window a query filters column on from literal
+// comparisons and BETWEEN. Bounds are coarsened to whole days.
+type dateFilter struct {
+	comparison *regexp.Regexp
+	between    *regexp.Regexp
+}
+
+func newDateFilter(column string) dateFilter {
+	// An optional table or alias qualifier, and backquotes, around the column
+	ref := "(?:^|[^\\w.`])(?:`?\\w+`?\\.)?`?" + regexp.QuoteMeta(column) + "`?"
+	return dateFilter{
+		comparison: regexp.MustCompile(`(?i)` + ref + `\s*(>=|<=|>|<|=)\s*` + dateLiteral),
+		between:    regexp.MustCompile(`(?i)` + ref + `\s+BETWEEN\s+` + dateLiteral + `\)?\s+AND\s+` + dateLiteral),
+	}
+}
+
+// window returns the narrowest window query's filters on the column allow,
+// or false when it has none.
+func (f dateFilter) window(query string) (dateRange, bool) {
+	var r dateRange
+	found := false
+	narrowFrom := func(t time.Time) {
+		if r.from.IsZero() || t.After(r.from) {
+			r.from = t
+		}
+	}
+	narrowTo := func(t time.Time) {
+		if r.to.IsZero() || t.Before(r.to) {
+			r.to = t
+		}
+	}
+
+	for _, match := range f.between.FindAllStringSubmatch(query, -1) {
+		from, errFrom := time.Parse(time.DateOnly, match[1])
+		to, errTo := time.Parse(time.DateOnly, match[2])
+		if errFrom != nil || errTo != nil {
+			continue
+		}
+		narrowFrom(from)
+		narrowTo(to.AddDate(0, 0, 1))
+		found = true
+	}
+	for _, match := range f.comparison.FindAllStringSubmatch(query, -1) {
+		date, err := time.Parse(time.DateOnly, match[2])
+		if err != nil {
+			continue
+		}
+		switch match[1] {
+		case ">=", ">":
+			narrowFrom(date)
+		case "<":
+			narrowTo(date)
+		case "<=":
+			narrowTo(date.AddDate(0, 0, 1))
+		case "=":
+			narrowFrom(date)
+			narrowTo(date.AddDate(0, 0, 1))
+		}
+		found = true
+	}
+	return r, found
+}
+
+// estimatePartitionHeat splits the active partitions of each
+// date-partitioned table into hot ones, which a successful read's literal
+// date filter on the partition key column reached, and cold ones. Tables
+// without reads, without a supported partition key, or without date
+// partition IDs get no estimate.
+func (a *Analyzer) estimatePartitionHeat(ctx context.Context, entries []*models.QueryLogEntry) error {
+	type tableHeat struct {
+		scheme partitionScheme
+		filter dateFilter
+		hot    map[string]bool
+		heat   models.PartitionHeat
+	}
+	heats := make(map[string]*tableHeat)
+	filters := make(map[string]dateFilter) // By column, shared across tables
+
+	heatFor := func(tableName string) *tableHeat {
+		if heat, ok := heats[tableName]; ok {
+			return heat
+		}
+		var heat *tableHeat
+		if table := a.tables[tableName]; table != nil && len(table.Partitions) > 0 {
+			if scheme, ok := parsePartitionKey(table.PartitionKey); ok {
+				filter, ok := filters[scheme.column]
+				if !ok {
+					filter = newDateFilter(scheme.column)
+					filters[scheme.column] = filter
+				}
+				heat = &tableHeat{scheme: scheme, filter: filter, hot: make(map[string]bool)}
+			}
+		}
+		heats[tableName] = heat
+		return heat
+	}
+
+	for i, entry := range entries {
+		if err := checkContext(ctx, i); err != nil {
+			return err
+		}
+		if isFailedQuery(entry) || !isReadQuery(entry.QueryKind) {
+			continue
+		}
+		for _, tableName := range entry.Tables {
+			heat := heatFor(tableName)
+			if heat == nil {
+				continue
+			}
+			window, ok := heat.filter.window(entry.Query)
+			if !ok {
+				heat.heat.UnfilteredReads++
+				continue
+			}
+			heat.heat.FilteredReads++
+			for _, partitionID := range a.tables[tableName].Partitions {
+				if heat.hot[partitionID] {
+					continue
+				}
+				if start, end, ok := heat.scheme.span(partitionID); ok && window.overlaps(start, end) {
+					heat.hot[partitionID] = true
+				}
+			}
+		}
+	}
+
+	estimated := 0
+	for tableName, heat := range heats {
+		if heat == nil {
+			continue
+		}
+		table := a.tables[tableName]
+		result := heat.heat
+		result.Estimated = true
+		result.HotPartitions = []string{}
+		result.ColdPartitions = []string{}
+		dated := false
+		for _, partitionID := range table.Partitions {
+			if _, _, ok := heat.scheme.span(partitionID); !ok {
+				continue
+			}
+			dated = true
+			if heat.hot[partitionID] {
+				result.HotPartitions = append(result.HotPartitions, partitionID)
+			} else {
+				result.ColdPartitions = append(result.ColdPartitions, partitionID)
+			}
+		}
+		if !dated {
+			continue
+		}
+		table.PartitionHeat = &result
+		estimated++
+	}
+
+	slog.Debug("estimated partition heat", slog.Int("tables", estimated))
+	return nil
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	stringLiteralPattern  = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+	numericLiteralPattern = regexp.MustCompile(`\b\d+(?:\.\d+)?(?:[eE][+-]?\d+)?\b`)
+	whitespacePattern     = regexp.MustCompile(`\s+`)
+)
+
+// redactQueryLiterals replaces string and numeric literals with ? and
+// collapses whitespace, so queries differing only in parameters look alike.
+func redactQueryLiterals(query string) string {
+	redacted := stringLiteralPattern.ReplaceAllString(query, "?")
+	redacted = numericLiteralPattern.ReplaceAllString(redacted, "?")
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(redacted, " "))
+}
+
+// normalizeQueryText returns the form sample queries are deduplicated by.
+func normalizeQueryText(query string) string {
+	return strings.ToLower(redactQueryLiterals(query))
+}
+
+// sampleQueryCollector keeps up to limit distinct queries per table,
+// preferring the first occurrence of each normalized form.
+type sampleQueryCollector struct {
+	limit  int
+	redact bool
+	seen   map[string]map[string]bool
+}
+
+func newSampleQueryCollector(limit int, redact bool) *sampleQueryCollector {
+	if limit <= 0 {
+		return nil
+	}
+	return &sampleQueryCollector{
+		limit:  limit,
+		redact: redact,
+		seen:   make(map[string]map[string]bool),
+	}
+}
+
+// sampleQuery is the normalized key and retained text for one entry,
+// computed once and shared by every table the entry touches.
+type sampleQuery struct {
+	key  string
+	text string
+}
+
+func (c *sampleQueryCollector) prepare(query string) sampleQuery {
+	text := strings.TrimSpace(query)
+	if c.redact {
+		text = redactQueryLiterals(text)
+	}
+	return sampleQuery{key: normalizeQueryText(query), text: text}
+}
+
+// add records query against tableName and returns the updated samples.
+func (c *sampleQueryCollector) add(tableName string, samples []string, query sampleQuery) []string {
+	if len(samples) >= c.limit || query.key == "" {
+		return samples
+	}
+	seen := c.seen[tableName]
+	if seen == nil {
+		seen = make(map[string]bool)
+		c.seen[tableName] = seen
+	}
+	if seen[query.key] {
+		return samples
+	}
+	seen[query.key] = true
+	return append(samples, query.text)
+}
@@ -3,13 +3,20 @@ package analyzer
 import (
 	"context"
 	"log/slog"
+	"sync"
 
+	"github.com/ppiankov/clickspectre/internal/k8s"
 	"github.com/ppiankov/clickspectre/internal/models"
 )
 
 // buildServiceModel builds the service usage model from query log entries
 func (a *Analyzer) buildServiceModel(ctx context.Context, entries []*models.QueryLogEntry) error {
-	for _, entry := range entries {
+	resolved := a.resolveServiceIPs(ctx, entries)
+
+	for i, entry := range entries {
+		if err := checkContext(ctx, i); err != nil {
+			return err
+		}
 		clientIP := entry.ClientIP
 		if clientIP == "" {
 			continue
@@ -24,14 +31,13 @@ func (a *Analyzer) buildServiceModel(ctx context.Context, entries []*models.Quer
 				LastSeen:   entry.EventTime,
 			}
 
-			// Resolve K8s service if enabled
-			if a.config.ResolveK8s && a.resolver != nil {
-				info, err := a.resolver.ResolveIP(ctx, clientIP)
-				if err == nil && info != nil {
-					service.K8sService = info.Service
-					service.K8sNamespace = info.Namespace
-					service.K8sPod = info.Pod
-				}
+			// A static alias takes precedence over K8s resolution
+			if label, ok := a.config.IPAlias(clientIP); ok {
+				service.K8sService = label
+			} else if info := resolved[clientIP]; info != nil {
+				service.K8sService = info.Service
+				service.K8sNamespace = info.Namespace
+				service.K8sPod = info.Pod
 			}
 
 			a.services[clientIP] = service
@@ -72,3 +78,128 @@ func (a *Analyzer) buildServiceModel(ctx context.Context, entries []*models.Quer
 
 	return nil
 }
+
+// k8sDegradedRatio is the share of failed lookups at which K8s resolution
+// is reported as degraded.
+const k8sDegradedRatio = 0.5
+
+// K8sResolutionStats counts the client IPs looked up in Kubernetes.
+type K8sResolutionStats struct {
+	Resolved   int // Resolved to a workload
+	Unresolved int // No pod or service has the IP, e.g. clients outside the cluster
+	Errors     int // The lookup failed, so the label fell back to the raw IP
+}
+
+// Degraded reports whether enough lookups failed that service labels, which
+// fall back to raw IPs, should not be trusted. IPs the cluster does not know
+// are a normal outcome and do not count.
+func (s K8sResolutionStats) Degraded() bool {
+	total := s.Resolved + s.Unresolved + s.Errors
+	return total > 0 && float64(s.Errors) >= k8sDegradedRatio*float64(total)
+}
+
+// resolveServiceIPs batch-resolves every distinct client IP through the K8s
+// resolver, falling back to concurrent per-IP lookups when the batch fails.
+// It returns nil when resolution is disabled.
+func (a *Analyzer) resolveServiceIPs(ctx context.Context, entries []*models.QueryLogEntry) map[string]*k8s.ServiceInfo {
+	a.k8sStats = K8sResolutionStats{}
+	if !a.config.ResolveK8s || a.resolver == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	ips := make([]string, 0)
+	for _, entry := range entries {
+		if entry.ClientIP == "" || seen[entry.ClientIP] {
+			continue
+		}
+		seen[entry.ClientIP] = true
+		// Aliased IPs are attributed statically, so skip the lookup
+		if _, ok := a.config.IPAlias(entry.ClientIP); ok {
+			continue
+		}
+		ips = append(ips, entry.ClientIP)
+	}
+	if len(ips) == 0 {
+		return nil
+	}
+
+	resolved, err := a.resolver.ResolveIPs(ctx, ips)
+	if err != nil {
+		slog.Debug("k8s batch resolution failed, resolving IPs individually",
+			slog.Int("ips", len(ips)),
+			slog.String("error", err.Error()),
+		)
+		resolved = a.resolveIPsConcurrently(ctx, ips)
+	}
+	a.countK8sResolution(ips, resolved)
+	return resolved
+}
+
+// countK8sResolution records how many of ips resolved to a workload, were
+// unknown to the cluster, or failed to resolve, and warns when too many
+// lookups failed.
+func (a *Analyzer) countK8sResolution(ips []string, resolved map[string]*k8s.ServiceInfo) {
+	for _, ip := range ips {
+		info := resolved[ip]
+		switch {
+		// The resolver reports API failures as the raw IP flagged LookupFailed
+		case info == nil || info.Service == "" || info.LookupFailed:
+			a.k8sStats.Errors++
+		case info.Service == ip && info.Namespace == "":
+			a.k8sStats.Unresolved++
+		default:
+			a.k8sStats.Resolved++
+		}
+	}
+
+	if a.k8sStats.Degraded() {
+		slog.Warn("kubernetes resolution degraded, service labels may be raw IPs",
+			slog.Int("resolved", a.k8sStats.Resolved),
+			slog.Int("unresolved", a.k8sStats.Unresolved),
+			slog.Int("failed", a.k8sStats.Errors),
+		)
+	}
+}
+
+// resolveIPsConcurrently resolves ips one at a time through ResolveIP, with
+// up to --concurrency lookups in flight. API pressure is still bounded by
+// the resolver's shared rate limiter. IPs that fail to resolve are left out.
+func (a *Analyzer) resolveIPsConcurrently(ctx context.Context, ips []string) map[string]*k8s.ServiceInfo {
+	workers := min(max(a.config.Concurrency, 1), len(ips))
+	jobs := make(chan string)
+	resolved := make(map[string]*k8s.ServiceInfo, len(ips))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Go(func() {
+			for ip := range jobs {
+				info, err := a.resolver.ResolveIP(ctx, ip)
+				if err != nil {
+					slog.Debug("k8s resolution failed",
+						slog.String("ip", ip),
+						slog.String("error", err.Error()),
+					)
+					continue
+				}
+				mu.Lock()
+				resolved[ip] = info
+				mu.Unlock()
+			}
+		})
+	}
+
+feed:
+	for _, ip := range ips {
+		select {
+		case jobs <- ip:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return resolved
+}
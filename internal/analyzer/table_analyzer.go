@@ -1,16 +1,73 @@
 package analyzer
 
 import (
+	"context"
 	"log/slog"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/ppiankov/clickspectre/internal/models"
 )
 
+// mergePriorUsage adds usage accumulated by earlier incremental runs to the
+// tables built from this run's entries, creating tables that saw no queries
+// since the last run.
+func (a *Analyzer) mergePriorUsage(ctx context.Context, _ []*models.QueryLogEntry) error {
+	merged := 0
+	for tableName, usage := range a.priorUsage {
+		if err := checkContext(ctx, merged); err != nil {
+			return err
+		}
+		if a.config.IsTableExcluded(tableName) {
+			continue
+		}
+		merged++
+
+		table, exists := a.tables[tableName]
+		if !exists {
+			database, name, ok := strings.Cut(tableName, ".")
+			if !ok {
+				database, name = "", tableName
+			}
+			table = &models.Table{
+				Name:      name,
+				Database:  database,
+				FullName:  tableName,
+				Sparkline: make([]models.TimeSeriesPoint, 0),
+			}
+			a.tables[tableName] = table
+		}
+
+		table.Reads += usage.Reads
+		table.Writes += usage.Writes
+		table.Mutations += usage.Mutations
+		if !usage.FirstSeen.IsZero() && (table.FirstSeen.IsZero() || usage.FirstSeen.Before(table.FirstSeen)) {
+			table.FirstSeen = usage.FirstSeen
+		}
+		if usage.LastAccess.After(table.LastAccess) {
+			table.LastAccess = usage.LastAccess
+		}
+		if usage.LastWrite.After(table.LastWrite) {
+			table.LastWrite = usage.LastWrite
+		}
+	}
+
+	slog.Debug("merged prior table usage", slog.Int("tables", merged))
+	return nil
+}
+
 // buildTableModel builds the table usage model from query log entries
-func (a *Analyzer) buildTableModel(entries []*models.QueryLogEntry) error {
-	for _, entry := range entries {
+func (a *Analyzer) buildTableModel(ctx context.Context, entries []*models.QueryLogEntry) error {
+	queries := make(map[string]uint64)
+	samples := newSampleQueryCollector(a.config.KeepSampleQueries, a.config.RedactLiterals)
+
+	for i, entry := range entries {
+		if err := checkContext(ctx, i); err != nil {
+			return err
+		}
+		var sample sampleQuery
+		sampled := false
 		for _, tableName := range entry.Tables {
 			// Skip empty table names
 			if tableName == "" {
@@ -23,15 +80,11 @@ func (a *Analyzer) buildTableModel(entries []*models.QueryLogEntry) error {
 			// Get or create table
 			table, exists := a.tables[tableName]
 			if !exists {
-				// Parse database.table format
-				parts := strings.Split(tableName, ".")
-				var database, name string
-				if len(parts) == 2 {
-					database = parts[0]
-					name = parts[1]
-				} else {
-					database = ""
-					name = tableName
+				// Parse database.table format; a quoted table name may
+				// itself contain dots
+				database, name, ok := strings.Cut(tableName, ".")
+				if !ok {
+					database, name = "", tableName
 				}
 
 				table = &models.Table{
@@ -44,12 +97,25 @@ func (a *Analyzer) buildTableModel(entries []*models.QueryLogEntry) error {
 				a.tables[tableName] = table
 			}
 
-			// Update statistics based on query kind and actual row counts
-			if isReadQuery(entry.QueryKind) {
+			queries[tableName]++
+
+			// Failed queries count toward the error rate, not reads or writes
+			if isFailedQuery(entry) {
+				table.FailedQueries++
+			} else if isReadQuery(entry.QueryKind) {
 				table.Reads += entry.ReadRows
+				a.recordScan(tableName, entry)
 			} else if isWriteQuery(entry.QueryKind) {
 				table.Writes += entry.WrittenRows
 			}
+			// Lightweight deletes and ALTER mutations report zero written_rows,
+			// so count them separately to keep mutated tables from looking write-free
+			if isMutationQuery(entry.QueryKind) && !isFailedQuery(entry) {
+				table.Mutations++
+			}
+			if !isFailedQuery(entry) && (isWriteQuery(entry.QueryKind) || isMutationQuery(entry.QueryKind)) && entry.EventTime.After(table.LastWrite) {
+				table.LastWrite = entry.EventTime
+			}
 
 			// Update last access time
 			if entry.EventTime.After(table.LastAccess) {
@@ -60,6 +126,20 @@ func (a *Analyzer) buildTableModel(entries []*models.QueryLogEntry) error {
 			if entry.EventTime.Before(table.FirstSeen) {
 				table.FirstSeen = entry.EventTime
 			}
+
+			if samples != nil {
+				if !sampled {
+					sample = samples.prepare(entry.Query)
+					sampled = true
+				}
+				table.SampleQueries = samples.add(tableName, table.SampleQueries, sample)
+			}
+		}
+	}
+
+	for tableName, table := range a.tables {
+		if table.FailedQueries > 0 && queries[tableName] > 0 {
+			table.ErrorRate = float64(table.FailedQueries) / float64(queries[tableName])
 		}
 	}
 
@@ -68,18 +148,26 @@ func (a *Analyzer) buildTableModel(entries []*models.QueryLogEntry) error {
 	return nil
 }
 
-// generateSparklines generates time series data for sparkline visualization
-func (a *Analyzer) generateSparklines(entries []*models.QueryLogEntry) error {
+// generateSparklines generates time series data for sparkline visualization,
+// along with each table's weekday × hour access heatmap. Both bucket by the
+// hour in the configured timezone.
+func (a *Analyzer) generateSparklines(ctx context.Context, entries []*models.QueryLogEntry) error {
+	loc := a.config.TimeLocation()
+
 	// Group entries by table and hourly buckets
 	type bucketKey struct {
 		table string
-		hour  int64 // Unix timestamp truncated to hour
+		hour  int64 // Unix timestamp of the start of the local hour
 	}
 
 	buckets := make(map[bucketKey]uint64)
+	heatmaps := make(map[string]*models.AccessHeatmap)
 
-	for _, entry := range entries {
-		hourTimestamp := entry.EventTime.Truncate(time.Hour).Unix()
+	for i, entry := range entries {
+		if err := checkContext(ctx, i); err != nil {
+			return err
+		}
+		hourTimestamp := localHourStart(entry.EventTime, loc).Unix()
 
 		for _, tableName := range entry.Tables {
 			if tableName == "" {
@@ -91,34 +179,42 @@ func (a *Analyzer) generateSparklines(entries []*models.QueryLogEntry) error {
 				hour:  hourTimestamp,
 			}
 			buckets[key]++
+
+			heatmap := heatmaps[tableName]
+			if heatmap == nil {
+				heatmap = &models.AccessHeatmap{}
+				heatmaps[tableName] = heatmap
+			}
+			heatmap.Add(entry.EventTime, loc)
 		}
 	}
 
-	// Convert buckets to sparkline points
+	// Group bucket counts per table in a single pass
+	points := make(map[string][]models.TimeSeriesPoint)
+	for key, count := range buckets {
+		points[key.table] = append(points[key.table], models.TimeSeriesPoint{
+			Timestamp: time.Unix(key.hour, 0).In(loc),
+			Value:     count,
+		})
+	}
+
+	checked := 0
 	for tableName, table := range a.tables {
-		points := make([]models.TimeSeriesPoint, 0)
-
-		// Find all time buckets for this table
-		for key, count := range buckets {
-			if key.table == tableName {
-				points = append(points, models.TimeSeriesPoint{
-					Timestamp: time.Unix(key.hour, 0),
-					Value:     count,
-				})
-			}
+		if err := checkContext(ctx, checked); err != nil {
+			return err
 		}
+		checked++
 
-		// Sort points by timestamp
-		// (Simple bubble sort for small datasets)
-		for i := 0; i < len(points); i++ {
-			for j := i + 1; j < len(points); j++ {
-				if points[i].Timestamp.After(points[j].Timestamp) {
-					points[i], points[j] = points[j], points[i]
-				}
-			}
+		tablePoints := points[tableName]
+		if tablePoints == nil {
+			tablePoints = make([]models.TimeSeriesPoint, 0)
 		}
+		sort.Slice(tablePoints, func(i, j int) bool {
+			return tablePoints[i].Timestamp.Before(tablePoints[j].Timestamp)
+		})
 
-		table.Sparkline = points
+		table.Sparkline = tablePoints
+		table.Heatmap = heatmaps[tableName]
 	}
 
 	slog.Debug("generated sparklines", slog.Int("tables", len(a.tables)))
@@ -126,6 +222,17 @@ func (a *Analyzer) generateSparklines(entries []*models.QueryLogEntry) error {
 	return nil
 }
 
+// localHourStart returns the start of the hour containing t on loc's wall
+// clock. It steps back from t rather than rebuilding the time from its
+// fields, so the repeated hour when DST ends yields two distinct buckets and
+// half-hour offsets are honoured.
+func localHourStart(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return local.Add(-time.Duration(local.Minute())*time.Minute -
+		time.Duration(local.Second())*time.Second -
+		time.Duration(local.Nanosecond()))
+}
+
 // isReadQuery checks if a query kind is a read operation
 func isReadQuery(kind string) bool {
 	kind = strings.ToUpper(kind)
@@ -139,3 +246,23 @@ func isWriteQuery(kind string) bool {
 		kind == "ALTER" || kind == "UPDATE" || kind == "DELETE" ||
 		strings.HasPrefix(kind, "INSERT") || strings.HasPrefix(kind, "CREATE")
 }
+
+// queryKindLabel normalizes a query kind for Edge.KindCounts
+func queryKindLabel(kind string) string {
+	kind = strings.ToUpper(strings.TrimSpace(kind))
+	if kind == "" {
+		return "UNKNOWN"
+	}
+	return kind
+}
+
+// isFailedQuery checks if a query ended in an exception
+func isFailedQuery(entry *models.QueryLogEntry) bool {
+	return entry.Exception != "" || strings.HasPrefix(entry.Type, "Exception")
+}
+
+// isMutationQuery checks if a query kind modifies existing rows in place
+func isMutationQuery(kind string) bool {
+	kind = strings.ToUpper(kind)
+	return kind == "ALTER" || kind == "UPDATE" || kind == "DELETE"
+}
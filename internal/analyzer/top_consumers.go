@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+// TopConsumers returns, per table name, the n services with the most reads
+// plus writes on it, busiest first. Pods behind one K8s service share an
+// edge ServiceName and merge into one entry. It returns nil when n <= 0.
+func TopConsumers(edges []models.Edge, n int) map[string][]models.EdgeSummary {
+	if n <= 0 {
+		return nil
+	}
+
+	type consumerKey struct{ table, service string }
+	merged := make(map[consumerKey]*models.EdgeSummary)
+	byTable := make(map[string][]*models.EdgeSummary)
+	for _, edge := range edges {
+		service := edge.ServiceName
+		if service == "" {
+			service = edge.ServiceIP
+		}
+		key := consumerKey{table: edge.TableName, service: service}
+		summary := merged[key]
+		if summary == nil {
+			summary = &models.EdgeSummary{Service: service}
+			merged[key] = summary
+			byTable[edge.TableName] = append(byTable[edge.TableName], summary)
+		}
+		summary.Reads += edge.Reads
+		summary.Writes += edge.Writes
+	}
+
+	top := make(map[string][]models.EdgeSummary, len(byTable))
+	for table, summaries := range byTable {
+		slices.SortFunc(summaries, func(a, b *models.EdgeSummary) int {
+			if c := cmp.Compare(b.Reads+b.Writes, a.Reads+a.Writes); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.Service, b.Service)
+		})
+		summaries = summaries[:min(n, len(summaries))]
+		consumers := make([]models.EdgeSummary, 0, len(summaries))
+		for _, summary := range summaries {
+			consumers = append(consumers, *summary)
+		}
+		top[table] = consumers
+	}
+	return top
+}
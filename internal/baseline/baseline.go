@@ -1,12 +1,18 @@
 package baseline
 
 import (
+	"bufio"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"path"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/ppiankov/clickspectre/internal/models" // Corrected import path
 )
@@ -42,10 +48,156 @@ func (sf StableFinding) Fingerprint() (string, error) {
 	return hex.EncodeToString(hash[:]), nil
 }
 
+// UntilLayout is the date format of a suppression rule's until field.
+const UntilLayout = "2006-01-02"
+
+// regexPrefix marks a rule pattern as a regular expression instead of a glob.
+const regexPrefix = "re:"
+
+// anomalyPrefix marks a list baseline line as an anomaly-kind rule, e.g.
+// anomaly:broad_access.
+const anomalyPrefix = "anomaly:"
+
+// Baseline file formats accepted by LoadFormat.
+const (
+	FormatJSON = "json" // Fingerprints and rules written by --update-baseline
+	FormatList = "list" // Hand-maintained allowlist, one glob per line
+)
+
+// Formats lists the supported baseline file formats.
+var Formats = []string{FormatJSON, FormatList}
+
 // finding stores the fingerprint of a stableFinding for easy comparison.
+// Entries with a Pattern instead of a Fingerprint are suppression rules: they
+// match every finding whose db.table matches the glob (or "re:" regex), and
+// stop applying after the Until date. A rule with a Type only matches findings
+// of that type. A rule with an AnomalyType matches anomalies of that kind
+// (glob), on any table unless it also has a Pattern.
 type Finding struct {
-	Fingerprint string `json:"fingerprint"`
-	Type        string `json:"type"` // Store type for debugging/readability, though Fingerprint is primary key
+	Fingerprint string        `json:"fingerprint,omitempty"`
+	Type        string        `json:"type"` // Store type for debugging/readability, though Fingerprint is primary key
+	Pattern     string        `json:"pattern,omitempty"`
+	AnomalyType string        `json:"anomaly_type,omitempty"` // e.g. broad_access
+	Until       string        `json:"until,omitempty"`        // YYYY-MM-DD, inclusive
+	Reason      string        `json:"reason,omitempty"`       // Why the finding or rule is suppressed
+	AddedBy     string        `json:"added_by,omitempty"`     // Who added the entry
+	AddedAt     string        `json:"added_at,omitempty"`     // RFC 3339 time the entry was first added
+	Stable      StableFinding `json:"-"`                      // Fields the fingerprint was computed from; not persisted
+	AnomalyID   string        `json:"-"`                      // models.AnomalyID of an anomaly finding; not persisted
+}
+
+// IsRule reports whether the entry is a pattern rule rather than an exact fingerprint.
+func (f Finding) IsRule() bool {
+	return f.Pattern != "" || f.AnomalyType != ""
+}
+
+// Expired reports whether a rule's until date has passed. The rule stays
+// active through the whole until day (UTC). Rules without until never expire.
+func (f Finding) Expired(now time.Time) (bool, error) {
+	if f.Until == "" {
+		return false, nil
+	}
+	until, err := time.Parse(UntilLayout, f.Until)
+	if err != nil {
+		return false, fmt.Errorf("invalid until %q for pattern %q: %w", f.Until, f.Pattern, err)
+	}
+	return !now.UTC().Before(until.AddDate(0, 0, 1)), nil
+}
+
+// suppressionRule is a compiled, active pattern rule.
+type suppressionRule struct {
+	findingType string
+	anomalyType string
+	glob        string
+	re          *regexp.Regexp
+}
+
+// matches reports whether the rule suppresses a finding. anomalyType is the
+// anomaly's kind and is empty for table recommendations.
+func (r suppressionRule) matches(findingType, anomalyType, tableName string) bool {
+	if r.findingType != "" && r.findingType != findingType {
+		return false
+	}
+	if r.anomalyType != "" {
+		if ok, _ := path.Match(r.anomalyType, anomalyType); anomalyType == "" || !ok {
+			return false
+		}
+		if r.glob == "" && r.re == nil {
+			return true
+		}
+	}
+	if tableName == "" {
+		return false
+	}
+	if r.re != nil {
+		return r.re.MatchString(tableName)
+	}
+	// Pattern was validated when the rule was compiled
+	ok, _ := path.Match(r.glob, tableName)
+	return ok
+}
+
+// compileRules returns the active pattern rules from the baseline entries.
+// Expired rules are skipped; invalid patterns or dates are errors.
+func compileRules(baselineFindings []Finding, now time.Time) ([]suppressionRule, error) {
+	var rules []suppressionRule
+	for _, bf := range baselineFindings {
+		if !bf.IsRule() {
+			continue
+		}
+		expired, err := bf.Expired(now)
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			continue
+		}
+		rule, err := compileRule(bf)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// compileRule validates a single pattern rule.
+func compileRule(bf Finding) (suppressionRule, error) {
+	rule := suppressionRule{findingType: bf.Type, anomalyType: bf.AnomalyType}
+	if bf.AnomalyType != "" {
+		if _, err := path.Match(bf.AnomalyType, ""); err != nil {
+			return rule, fmt.Errorf("invalid anomaly type %q: %w", bf.AnomalyType, err)
+		}
+	}
+	switch expr, ok := strings.CutPrefix(bf.Pattern, regexPrefix); {
+	case bf.Pattern == "":
+	case ok:
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return rule, fmt.Errorf("invalid regex pattern %q: %w", bf.Pattern, err)
+		}
+		rule.re = re
+	default:
+		if _, err := path.Match(bf.Pattern, ""); err != nil {
+			return rule, fmt.Errorf("invalid glob pattern %q: %w", bf.Pattern, err)
+		}
+		rule.glob = bf.Pattern
+	}
+	return rule, nil
+}
+
+// ExpiredRules returns the pattern rules whose until date has passed as of now.
+func ExpiredRules(baselineFindings []Finding, now time.Time) []Finding {
+	var expired []Finding
+	for _, bf := range baselineFindings {
+		if !bf.IsRule() {
+			continue
+		}
+		if isExpired, err := bf.Expired(now); err == nil && isExpired {
+			expired = append(expired, bf)
+		}
+	}
+	return expired
 }
 
 // GenerateFindings converts a models.Report into a slice of stable finding fingerprints.
@@ -53,29 +205,23 @@ func GenerateFindings(report *models.Report) ([]Finding, error) {
 	var findings []Finding
 	var stableFindings []StableFinding
 
-	// Process Anomalies
+	// Process Anomalies. Their findings come first, so anomalyIDs[i] belongs
+	// to stableFindings[i]
+	anomalyIDs := make([]string, 0, len(report.Anomalies))
 	for _, a := range report.Anomalies {
-		sf := StableFinding{
-			Type:            "anomaly",
-			Description:     a.Description,
-			Severity:        a.Severity,
-			AffectedTable:   a.AffectedTable,
-			AffectedService: a.AffectedService,
+		stableFindings = append(stableFindings, anomalyFinding(a))
+		id := a.ID
+		if id == "" {
+			// Reports written before anomalies had IDs
+			id = models.AnomalyID(a.Type, a.AffectedTable, a.AffectedService)
 		}
-		stableFindings = append(stableFindings, sf)
+		anomalyIDs = append(anomalyIDs, id)
 	}
 
 	// Process CleanupRecommendations
 	processTableRecommendations := func(category string, recs []models.TableRecommendation) {
 		for _, tr := range recs {
-			sf := StableFinding{
-				Type:         category, // e.g., "zero_usage_non_replicated_table"
-				TableName:    tr.Name,
-				DatabaseName: tr.Database,
-				Engine:       tr.Engine,
-				IsReplicated: tr.IsReplicated,
-			}
-			stableFindings = append(stableFindings, sf)
+			stableFindings = append(stableFindings, recommendationFinding(category, tr))
 		}
 	}
 
@@ -87,14 +233,7 @@ func GenerateFindings(report *models.Report) ([]Finding, error) {
 	// This ensures we capture the "decision" for each table.
 	processStringRecommendations := func(category string, names []string) {
 		for _, name := range names {
-			// Split full_name (db.table) into database and table
-			db, table := SplitTableName(name)
-			sf := StableFinding{
-				Type:         category,
-				TableName:    table,
-				DatabaseName: db,
-			}
-			stableFindings = append(stableFindings, sf)
+			stableFindings = append(stableFindings, tableFinding(category, name))
 		}
 	}
 
@@ -103,12 +242,16 @@ func GenerateFindings(report *models.Report) ([]Finding, error) {
 	processStringRecommendations("keep_table", report.CleanupRecommendations.Keep)
 
 	// Generate fingerprints for all stable findings
-	for _, sf := range stableFindings {
+	for i, sf := range stableFindings {
 		fp, err := sf.Fingerprint()
 		if err != nil {
 			return nil, err
 		}
-		findings = append(findings, Finding{Fingerprint: fp, Type: sf.Type})
+		finding := Finding{Fingerprint: fp, Type: sf.Type, Stable: sf}
+		if i < len(anomalyIDs) {
+			finding.AnomalyID = anomalyIDs[i]
+		}
+		findings = append(findings, finding)
 	}
 
 	// Sort findings by fingerprint for consistent output, though not strictly necessary for a set comparison.
@@ -119,18 +262,106 @@ func GenerateFindings(report *models.Report) ([]Finding, error) {
 	return findings, nil
 }
 
+// anomalyFinding returns the stable fields of an anomaly.
+func anomalyFinding(a models.Anomaly) StableFinding {
+	return StableFinding{
+		Type:            "anomaly",
+		Description:     a.Description,
+		Severity:        a.Severity,
+		AffectedTable:   a.AffectedTable,
+		AffectedService: a.AffectedService,
+	}
+}
+
+// recommendationFinding returns the stable fields of a detailed table
+// recommendation of the finding type category, e.g.
+// "zero_usage_non_replicated_table".
+func recommendationFinding(category string, tr models.TableRecommendation) StableFinding {
+	return StableFinding{
+		Type:         category,
+		TableName:    tr.Name,
+		DatabaseName: tr.Database,
+		Engine:       tr.Engine,
+		IsReplicated: tr.IsReplicated,
+	}
+}
+
+// tableFinding returns the stable fields of a recommendation listed only
+// by its db.table name.
+func tableFinding(category, name string) StableFinding {
+	// Split full_name (db.table) into database and table
+	db, table := SplitTableName(name)
+	return StableFinding{
+		Type:         category,
+		TableName:    table,
+		DatabaseName: db,
+	}
+}
+
 // Load reads baseline findings from a JSON file.
 func Load(filePath string) ([]Finding, error) {
-	data, err := os.ReadFile(filePath)
+	return LoadFormat(filePath, FormatJSON)
+}
+
+// LoadFormat reads baseline findings from a file in the given format (see
+// Formats). A missing file is an empty baseline.
+func LoadFormat(filePath, format string) ([]Finding, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []Finding{}, nil // Return empty slice if file does not exist
 		}
 		return nil, err
 	}
+	defer func() { _ = file.Close() }()
 
-	var findings []Finding
-	if err := json.Unmarshal(data, &findings); err != nil {
+	switch format {
+	case FormatJSON, "":
+		var findings []Finding
+		if err := json.NewDecoder(file).Decode(&findings); err != nil && err != io.EOF {
+			return nil, err
+		}
+		if findings == nil {
+			findings = []Finding{}
+		}
+		return findings, nil
+	case FormatList:
+		return ParseList(file)
+	default:
+		return nil, fmt.Errorf("unsupported baseline format %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// ParseList parses a list baseline: one rule per line, where a line is a
+// db.table glob (or "re:" regex) such as analytics.staging_*, or an anomaly
+// kind such as anomaly:broad_access. Blank lines and lines starting with #
+// are ignored; text after " #" is kept as the rule's reason.
+func ParseList(r io.Reader) ([]Finding, error) {
+	findings := []Finding{}
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var reason string
+		if before, after, ok := strings.Cut(line, " #"); ok {
+			line, reason = strings.TrimSpace(before), strings.TrimSpace(after)
+		}
+
+		f := Finding{Pattern: line, Reason: reason}
+		if kind, ok := strings.CutPrefix(line, anomalyPrefix); ok {
+			if kind == "" {
+				return nil, fmt.Errorf("line %d: missing anomaly type after %q", lineNo, anomalyPrefix)
+			}
+			f = Finding{Type: "anomaly", AnomalyType: kind, Reason: reason}
+		}
+		if _, err := compileRule(f); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		findings = append(findings, f)
+	}
+	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
 	return findings, nil
@@ -173,30 +404,69 @@ func SplitTableName(fullName string) (string, string) {
 	return "", fullName // If no dot, assume it's just a table name without a database specified
 }
 
+// knownSet matches findings against a baseline's fingerprints and active
+// pattern rules.
+type knownSet struct {
+	fingerprints map[string]struct{}
+	rules        []suppressionRule
+}
+
+func newKnownSet(baselineFindings []Finding, now time.Time) (*knownSet, error) {
+	rules, err := compileRules(baselineFindings, now)
+	if err != nil {
+		return nil, err
+	}
+	known := &knownSet{fingerprints: make(map[string]struct{}), rules: rules}
+	for _, bf := range baselineFindings {
+		if bf.Fingerprint != "" {
+			known.fingerprints[bf.Fingerprint] = struct{}{}
+		}
+	}
+	return known, nil
+}
+
+// contains reports whether the baseline covers the finding sf. anomalyType
+// is the anomaly's kind and is empty for table recommendations; tableName is
+// the db.table pattern rules match.
+func (k *knownSet) contains(sf StableFinding, anomalyType, tableName string) (bool, error) {
+	fp, err := sf.Fingerprint()
+	if err != nil {
+		return false, err
+	}
+	if _, found := k.fingerprints[fp]; found {
+		return true, nil
+	}
+	for _, rule := range k.rules {
+		if rule.matches(sf.Type, anomalyType, tableName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // ApplySuppression modifies the report in-place, removing findings whose fingerprints
-// are present in the baselineFindings. It returns the count of suppressed findings.
+// are present in the baselineFindings or whose table matches an active pattern rule.
+// It returns the count of suppressed findings.
 func ApplySuppression(report *models.Report, baselineFindings []Finding) (int, error) {
+	return ApplySuppressionAt(report, baselineFindings, time.Now())
+}
+
+// ApplySuppressionAt is ApplySuppression with rule expiry evaluated at now.
+func ApplySuppressionAt(report *models.Report, baselineFindings []Finding, now time.Time) (int, error) {
 	suppressedCount := 0
-	baselineSet := make(map[string]struct{})
-	for _, bf := range baselineFindings {
-		baselineSet[bf.Fingerprint] = struct{}{}
+	known, err := newKnownSet(baselineFindings, now)
+	if err != nil {
+		return 0, err
 	}
 
 	// Filter Anomalies
 	var newAnomalies []models.Anomaly
 	for _, a := range report.Anomalies {
-		sf := StableFinding{
-			Type:            "anomaly",
-			Description:     a.Description,
-			Severity:        a.Severity,
-			AffectedTable:   a.AffectedTable,
-			AffectedService: a.AffectedService,
-		}
-		fp, err := sf.Fingerprint()
+		suppressed, err := known.contains(anomalyFinding(a), a.Type, a.AffectedTable)
 		if err != nil {
 			return suppressedCount, err
 		}
-		if _, found := baselineSet[fp]; !found {
+		if !suppressed {
 			newAnomalies = append(newAnomalies, a)
 		} else {
 			suppressedCount++
@@ -208,18 +478,11 @@ func ApplySuppression(report *models.Report, baselineFindings []Finding) (int, e
 	filterTableRecommendations := func(category string, recs []models.TableRecommendation) ([]models.TableRecommendation, error) {
 		var newRecs []models.TableRecommendation
 		for _, tr := range recs {
-			sf := StableFinding{
-				Type:         category,
-				TableName:    tr.Name,
-				DatabaseName: tr.Database,
-				Engine:       tr.Engine,
-				IsReplicated: tr.IsReplicated,
-			}
-			fp, err := sf.Fingerprint()
+			suppressed, err := known.contains(recommendationFinding(category, tr), "", joinTableName(tr.Database, tr.Name))
 			if err != nil {
 				return nil, err
 			}
-			if _, found := baselineSet[fp]; !found {
+			if !suppressed {
 				newRecs = append(newRecs, tr)
 			} else {
 				suppressedCount++
@@ -231,17 +494,11 @@ func ApplySuppression(report *models.Report, baselineFindings []Finding) (int, e
 	filterStringRecommendations := func(category string, names []string) ([]string, error) {
 		var newNames []string
 		for _, name := range names {
-			db, table := SplitTableName(name)
-			sf := StableFinding{
-				Type:         category,
-				TableName:    table,
-				DatabaseName: db,
-			}
-			fp, err := sf.Fingerprint()
+			suppressed, err := known.contains(tableFinding(category, name), "", name)
 			if err != nil {
 				return nil, err
 			}
-			if _, found := baselineSet[fp]; !found {
+			if !suppressed {
 				newNames = append(newNames, name)
 			} else {
 				suppressedCount++
@@ -250,7 +507,6 @@ func ApplySuppression(report *models.Report, baselineFindings []Finding) (int, e
 		return newNames, nil
 	}
 
-	var err error
 	report.CleanupRecommendations.ZeroUsageNonReplicated, err = filterTableRecommendations("zero_usage_non_replicated_table", report.CleanupRecommendations.ZeroUsageNonReplicated)
 	if err != nil {
 		return suppressedCount, err
@@ -271,17 +527,164 @@ func ApplySuppression(report *models.Report, baselineFindings []Finding) (int, e
 	if err != nil {
 		return suppressedCount, err
 	}
+	report.CleanupRecommendations.Ranked = rankedRemaining(report.CleanupRecommendations)
 
 	return suppressedCount, nil
 }
 
+// Annotate marks every finding in the report with whether the baseline
+// covers it, without removing any. See AnnotateAt.
+func Annotate(report *models.Report, baselineFindings []Finding) (int, []Finding, error) {
+	return AnnotateAt(report, baselineFindings, time.Now())
+}
+
+// AnnotateAt compares the report's findings with the baseline the way
+// ApplySuppressionAt does, with rule expiry evaluated at now, but leaves the
+// report intact: anomalies and table recommendations get InBaseline set
+// instead. It returns how many findings the baseline covers and, sorted by
+// fingerprint as GenerateFindings lists them, the ones it does not.
+func AnnotateAt(report *models.Report, baselineFindings []Finding, now time.Time) (int, []Finding, error) {
+	known, err := newKnownSet(baselineFindings, now)
+	if err != nil {
+		return 0, nil, err
+	}
+	knownCount := 0
+	var newFindings []Finding
+	check := func(sf StableFinding, anomalyType, tableName string) (bool, error) {
+		in, err := known.contains(sf, anomalyType, tableName)
+		if err != nil {
+			return false, err
+		}
+		if in {
+			knownCount++
+			return true, nil
+		}
+		fp, err := sf.Fingerprint()
+		if err != nil {
+			return false, err
+		}
+		newFindings = append(newFindings, Finding{Fingerprint: fp, Type: sf.Type, Stable: sf})
+		return false, nil
+	}
+
+	for i := range report.Anomalies {
+		a := &report.Anomalies[i]
+		in, err := check(anomalyFinding(*a), a.Type, a.AffectedTable)
+		if err != nil {
+			return 0, nil, err
+		}
+		a.InBaseline = &in
+	}
+	recs := &report.CleanupRecommendations
+	for category, list := range map[string][]models.TableRecommendation{
+		"zero_usage_non_replicated_table": recs.ZeroUsageNonReplicated,
+		"zero_usage_replicated_table":     recs.ZeroUsageReplicated,
+	} {
+		for i := range list {
+			in, err := check(recommendationFinding(category, list[i]), "", joinTableName(list[i].Database, list[i].Name))
+			if err != nil {
+				return 0, nil, err
+			}
+			list[i].InBaseline = &in
+		}
+	}
+	for category, names := range map[string][]string{
+		"safe_to_drop_table": recs.SafeToDrop,
+		"likely_safe_table":  recs.LikelySafe,
+		"keep_table":         recs.Keep,
+	} {
+		for _, name := range names {
+			if _, err := check(tableFinding(category, name), "", name); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+
+	// Ranked duplicates the lists above, so it is annotated but not counted
+	for i := range recs.Ranked {
+		rec := &recs.Ranked[i]
+		category := rec.Category + "_table"
+		sf, tableName := tableFinding(category, rec.Name), rec.Name
+		if strings.HasPrefix(rec.Category, "zero_usage") {
+			sf, tableName = recommendationFinding(category, *rec), joinTableName(rec.Database, rec.Name)
+		}
+		in, err := known.contains(sf, "", tableName)
+		if err != nil {
+			return 0, nil, err
+		}
+		rec.InBaseline = &in
+	}
+
+	sort.Slice(newFindings, func(i, j int) bool {
+		return newFindings[i].Fingerprint < newFindings[j].Fingerprint
+	})
+	return knownCount, newFindings, nil
+}
+
+// rankedRemaining returns the ranked recommendations still listed in their
+// category after suppression. Ranked duplicates the other lists, so it is not
+// counted as suppressed itself.
+func rankedRemaining(recs models.CleanupRecommendations) []models.TableRecommendation {
+	if recs.Ranked == nil {
+		return nil
+	}
+	remaining := make(map[string]bool)
+	for _, rec := range recs.ZeroUsageNonReplicated {
+		remaining["zero_usage_non_replicated/"+rec.Name] = true
+	}
+	for _, rec := range recs.ZeroUsageReplicated {
+		remaining["zero_usage_replicated/"+rec.Name] = true
+	}
+	for _, name := range recs.SafeToDrop {
+		remaining["safe_to_drop/"+name] = true
+	}
+	for _, name := range recs.LikelySafe {
+		remaining["likely_safe/"+name] = true
+	}
+
+	ranked := []models.TableRecommendation{}
+	for _, rec := range recs.Ranked {
+		if remaining[rec.Category+"/"+rec.Name] {
+			ranked = append(ranked, rec)
+		}
+	}
+	return ranked
+}
+
+// joinTableName is the inverse of SplitTableName.
+func joinTableName(database, table string) string {
+	if database == "" {
+		return table
+	}
+	return database + "." + table
+}
+
 // MergeFindings merges two slices of findings, removing duplicates and returning a new, sorted slice of unique findings.
+// Pattern rules are kept as-is and sorted after fingerprint entries. Existing entries keep
+// their metadata; findings not already in the baseline are stamped with the current time.
 func MergeFindings(existingFindings, newFindings []Finding) []Finding {
+	return MergeFindingsAt(existingFindings, newFindings, time.Now())
+}
+
+// MergeFindingsAt is MergeFindings with newly added findings stamped at now.
+func MergeFindingsAt(existingFindings, newFindings []Finding, now time.Time) []Finding {
+	addedAt := now.UTC().Format(time.RFC3339)
 	mergedSet := make(map[string]Finding)
+	var rules []Finding
 	for _, f := range existingFindings {
+		if f.IsRule() {
+			rules = append(rules, f)
+			continue
+		}
 		mergedSet[f.Fingerprint] = f
 	}
 	for _, f := range newFindings {
+		if _, found := mergedSet[f.Fingerprint]; found {
+			continue
+		}
+		if f.AddedAt == "" {
+			f.AddedAt = addedAt
+		}
 		mergedSet[f.Fingerprint] = f
 	}
 
@@ -293,6 +696,13 @@ func MergeFindings(existingFindings, newFindings []Finding) []Finding {
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Fingerprint < result[j].Fingerprint
 	})
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Pattern != rules[j].Pattern {
+			return rules[i].Pattern < rules[j].Pattern
+		}
+		return rules[i].AnomalyType < rules[j].AnomalyType
+	})
+	result = append(result, rules...)
 
 	return result
 }
@@ -5,7 +5,11 @@ import (
 	"github.com/ppiankov/clickspectre/internal/models"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestStableFinding_Fingerprint(t *testing.T) {
@@ -198,6 +202,10 @@ func TestApplySuppression(t *testing.T) {
 			},
 			SafeToDrop: []string{"db.tbl4"},
 			Keep:       []string{"db.tbl5"},
+			Ranked: []models.TableRecommendation{
+				{Name: "tbl3", Database: "db", Category: "zero_usage_non_replicated", Confidence: 0.9},
+				{Name: "db.tbl4", Database: "db", Category: "safe_to_drop", Confidence: 0.6},
+			},
 		},
 	}
 
@@ -237,6 +245,333 @@ func TestApplySuppression(t *testing.T) {
 	if len(report.CleanupRecommendations.SafeToDrop) != 1 || report.CleanupRecommendations.SafeToDrop[0] != "db.tbl4" {
 		t.Errorf("Expected db.tbl4 to remain in SafeToDrop, got %+v", report.CleanupRecommendations.SafeToDrop)
 	}
+	if ranked := report.CleanupRecommendations.Ranked; len(ranked) != 1 || ranked[0].Name != "db.tbl4" {
+		t.Errorf("Expected only db.tbl4 to remain ranked, got %+v", ranked)
+	}
+}
+
+func TestApplySuppressionPatternRules(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		rules          []baseline.Finding
+		wantSuppressed int
+		wantAnomalies  []string
+		wantSafeToDrop []string
+	}{
+		{
+			name:           "glob_matches_db_table",
+			rules:          []baseline.Finding{{Pattern: "db.staging_*", Reason: "staging churn"}},
+			wantSuppressed: 2,
+			wantAnomalies:  []string{"db.prod"},
+			wantSafeToDrop: []string{"db.old"},
+		},
+		{
+			name:           "regex_pattern",
+			rules:          []baseline.Finding{{Pattern: "re:^db\\.(prod|old)$"}},
+			wantSuppressed: 2,
+			wantAnomalies:  []string{"db.staging_a"},
+			wantSafeToDrop: []string{"db.staging_b"},
+		},
+		{
+			name:           "type_restricts_rule",
+			rules:          []baseline.Finding{{Pattern: "db.*", Type: "anomaly"}},
+			wantSuppressed: 2,
+			wantAnomalies:  nil,
+			wantSafeToDrop: []string{"db.staging_b", "db.old"},
+		},
+		{
+			name:           "rule_active_through_until_day",
+			rules:          []baseline.Finding{{Pattern: "db.staging_*", Until: "2026-03-15"}},
+			wantSuppressed: 2,
+			wantAnomalies:  []string{"db.prod"},
+			wantSafeToDrop: []string{"db.old"},
+		},
+		{
+			name:           "expired_rule_ignored",
+			rules:          []baseline.Finding{{Pattern: "db.staging_*", Until: "2026-03-14"}},
+			wantSuppressed: 0,
+			wantAnomalies:  []string{"db.staging_a", "db.prod"},
+			wantSafeToDrop: []string{"db.staging_b", "db.old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &models.Report{
+				Anomalies: []models.Anomaly{
+					{Description: "spike", AffectedTable: "db.staging_a"},
+					{Description: "spike", AffectedTable: "db.prod"},
+				},
+				CleanupRecommendations: models.CleanupRecommendations{
+					SafeToDrop: []string{"db.staging_b", "db.old"},
+				},
+			}
+
+			got, err := baseline.ApplySuppressionAt(report, tt.rules, now)
+			if err != nil {
+				t.Fatalf("ApplySuppressionAt() error = %v", err)
+			}
+			if got != tt.wantSuppressed {
+				t.Fatalf("suppressed = %d, want %d", got, tt.wantSuppressed)
+			}
+			var anomalies []string
+			for _, a := range report.Anomalies {
+				anomalies = append(anomalies, a.AffectedTable)
+			}
+			if !reflect.DeepEqual(anomalies, tt.wantAnomalies) {
+				t.Fatalf("anomalies = %v, want %v", anomalies, tt.wantAnomalies)
+			}
+			if !reflect.DeepEqual(report.CleanupRecommendations.SafeToDrop, tt.wantSafeToDrop) {
+				t.Fatalf("safe_to_drop = %v, want %v", report.CleanupRecommendations.SafeToDrop, tt.wantSafeToDrop)
+			}
+		})
+	}
+}
+
+func TestApplySuppressionMixedExactAndPattern(t *testing.T) {
+	report := &models.Report{
+		Anomalies: []models.Anomaly{
+			{Description: "spike", AffectedTable: "db.events"},
+			{Description: "spike", AffectedTable: "db.staging_x"},
+			{Description: "spike", AffectedTable: "db.orders"},
+		},
+		CleanupRecommendations: models.CleanupRecommendations{
+			ZeroUsageNonReplicated: []models.TableRecommendation{
+				{Name: "staging_y", Database: "db", Engine: "MergeTree"},
+			},
+		},
+	}
+
+	sf := baseline.StableFinding{Type: "anomaly", Description: "spike", AffectedTable: "db.events"}
+	fp, _ := sf.Fingerprint()
+	findings := []baseline.Finding{
+		{Fingerprint: fp, Type: "anomaly"},
+		{Pattern: "db.staging_*", Until: "2099-01-01"},
+	}
+
+	got, err := baseline.ApplySuppressionAt(report, findings, time.Now())
+	if err != nil {
+		t.Fatalf("ApplySuppressionAt() error = %v", err)
+	}
+	if got != 3 {
+		t.Fatalf("suppressed = %d, want 3", got)
+	}
+	if len(report.Anomalies) != 1 || report.Anomalies[0].AffectedTable != "db.orders" {
+		t.Fatalf("expected only db.orders anomaly to remain, got %+v", report.Anomalies)
+	}
+	if len(report.CleanupRecommendations.ZeroUsageNonReplicated) != 0 {
+		t.Fatalf("expected staging_y recommendation to be suppressed, got %+v", report.CleanupRecommendations.ZeroUsageNonReplicated)
+	}
+}
+
+func TestApplySuppressionRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule baseline.Finding
+	}{
+		{name: "bad_glob", rule: baseline.Finding{Pattern: "db.[staging"}},
+		{name: "bad_regex", rule: baseline.Finding{Pattern: "re:db.(staging"}},
+		{name: "bad_until", rule: baseline.Finding{Pattern: "db.*", Until: "next week"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &models.Report{Anomalies: []models.Anomaly{{AffectedTable: "db.a"}}}
+			if _, err := baseline.ApplySuppressionAt(report, []baseline.Finding{tt.rule}, time.Now()); err == nil {
+				t.Fatal("expected error for invalid rule")
+			}
+		})
+	}
+}
+
+func TestParseList(t *testing.T) {
+	input := `# Known noise
+analytics.staging_*
+
+re:^tmp\.import_\d+$
+anomaly:broad_access  # the BI service reads everything
+`
+	got, err := baseline.ParseList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseList() error = %v", err)
+	}
+	want := []baseline.Finding{
+		{Pattern: "analytics.staging_*"},
+		{Pattern: `re:^tmp\.import_\d+$`},
+		{Type: "anomaly", AnomalyType: "broad_access", Reason: "the BI service reads everything"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseList() = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{"db.[staging", "re:db.(x", "anomaly:"} {
+		_, err := baseline.ParseList(strings.NewReader("db.ok\n" + bad + "\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("ParseList(%q) error = %v, want a line 2 error", bad, err)
+		}
+	}
+}
+
+func TestLoadFormatListSuppressesFindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.txt")
+	list := "analytics.staging_*\nanomaly:broad_access\n"
+	if err := os.WriteFile(path, []byte(list), 0644); err != nil {
+		t.Fatalf("failed to write allowlist: %v", err)
+	}
+	findings, err := baseline.LoadFormat(path, baseline.FormatList)
+	if err != nil {
+		t.Fatalf("LoadFormat() error = %v", err)
+	}
+
+	report := &models.Report{
+		Anomalies: []models.Anomaly{
+			{Type: "broad_access", Description: "reads many tables", AffectedService: "bi"},
+			{Type: "stale_table", Description: "stale", AffectedTable: "analytics.staging_a"},
+			{Type: "stale_table", Description: "stale", AffectedTable: "analytics.events"},
+		},
+		CleanupRecommendations: models.CleanupRecommendations{
+			SafeToDrop: []string{"analytics.staging_b", "analytics.old"},
+		},
+	}
+	got, err := baseline.ApplySuppressionAt(report, findings, time.Now())
+	if err != nil {
+		t.Fatalf("ApplySuppressionAt() error = %v", err)
+	}
+	if got != 3 {
+		t.Fatalf("suppressed = %d, want 3", got)
+	}
+	if len(report.Anomalies) != 1 || report.Anomalies[0].AffectedTable != "analytics.events" {
+		t.Fatalf("expected only the analytics.events anomaly to remain, got %+v", report.Anomalies)
+	}
+	if !reflect.DeepEqual(report.CleanupRecommendations.SafeToDrop, []string{"analytics.old"}) {
+		t.Fatalf("safe_to_drop = %v, want [analytics.old]", report.CleanupRecommendations.SafeToDrop)
+	}
+
+	if _, err := baseline.LoadFormat(path, "yaml"); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
+
+func TestAnnotateKeepsFindingsAndMarksMembership(t *testing.T) {
+	report := &models.Report{
+		Anomalies: []models.Anomaly{
+			{Type: "stale_table", Description: "stale", AffectedTable: "db.events"},
+			{Type: "broad_access", Description: "reads many tables", AffectedService: "bi"},
+			{Type: "stale_table", Description: "stale", AffectedTable: "db.orders"},
+		},
+		CleanupRecommendations: models.CleanupRecommendations{
+			ZeroUsageNonReplicated: []models.TableRecommendation{{Name: "staging_x", Database: "db", Engine: "MergeTree"}},
+			SafeToDrop:             []string{"db.old"},
+			Ranked: []models.TableRecommendation{
+				{Name: "staging_x", Database: "db", Engine: "MergeTree", Category: "zero_usage_non_replicated"},
+				{Name: "db.old", Category: "safe_to_drop"},
+			},
+		},
+	}
+	fp, err := baseline.StableFinding{Type: "anomaly", Description: "stale", AffectedTable: "db.events"}.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	findings := []baseline.Finding{
+		{Fingerprint: fp, Type: "anomaly"},
+		{Type: "anomaly", AnomalyType: "broad_access"},
+		{Pattern: "db.staging_*"},
+	}
+
+	known, newFindings, err := baseline.AnnotateAt(report, findings, time.Now())
+	if err != nil {
+		t.Fatalf("AnnotateAt() error = %v", err)
+	}
+	if known != 3 || len(newFindings) != 2 {
+		t.Fatalf("known = %d, new = %+v, want 3 known and 2 new", known, newFindings)
+	}
+	if len(report.Anomalies) != 3 || len(report.CleanupRecommendations.ZeroUsageNonReplicated) != 1 ||
+		len(report.CleanupRecommendations.SafeToDrop) != 1 || len(report.CleanupRecommendations.Ranked) != 2 {
+		t.Fatalf("expected no findings removed, got %+v", report)
+	}
+
+	inBaseline := func(v *bool) string {
+		if v == nil {
+			return "unset"
+		}
+		return map[bool]string{true: "known", false: "new"}[*v]
+	}
+	got := []string{
+		inBaseline(report.Anomalies[0].InBaseline),
+		inBaseline(report.Anomalies[1].InBaseline),
+		inBaseline(report.Anomalies[2].InBaseline),
+		inBaseline(report.CleanupRecommendations.ZeroUsageNonReplicated[0].InBaseline),
+		inBaseline(report.CleanupRecommendations.Ranked[0].InBaseline),
+		inBaseline(report.CleanupRecommendations.Ranked[1].InBaseline),
+	}
+	want := []string{"known", "known", "new", "known", "known", "new"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("in_baseline = %v, want %v", got, want)
+	}
+
+	var newLabels []string
+	for _, f := range newFindings {
+		label := f.Stable.AffectedTable
+		if label == "" {
+			label = f.Stable.DatabaseName + "." + f.Stable.TableName
+		}
+		newLabels = append(newLabels, f.Type+" "+label)
+	}
+	sort.Strings(newLabels)
+	if !reflect.DeepEqual(newLabels, []string{"anomaly db.orders", "safe_to_drop_table db.old"}) {
+		t.Fatalf("new findings = %v", newLabels)
+	}
+}
+
+func TestExpiredRules(t *testing.T) {
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	findings := []baseline.Finding{
+		{Fingerprint: "fp1", Type: "anomaly"},
+		{Pattern: "db.old_*", Until: "2026-03-14", Reason: "migration"},
+		{Pattern: "db.today_*", Until: "2026-03-15"},
+		{Pattern: "db.forever_*"},
+	}
+
+	got := baseline.ExpiredRules(findings, now)
+	if len(got) != 1 || got[0].Pattern != "db.old_*" {
+		t.Fatalf("ExpiredRules() = %+v, want only db.old_*", got)
+	}
+}
+
+func TestLoadSaveAndMergeKeepPatternRules(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "baseline.json")
+	data := `[
+  {"fingerprint": "fp1", "type": "anomaly"},
+  {"type": "", "pattern": "db.staging_*", "until": "2026-12-31", "reason": "staging churn"}
+]`
+	if err := os.WriteFile(filePath, []byte(data), 0644); err != nil {
+		t.Fatalf("write baseline: %v", err)
+	}
+
+	loaded, err := baseline.Load(filePath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	merged := baseline.MergeFindings(loaded, []baseline.Finding{{Fingerprint: "fp2", Type: "anomaly"}})
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 merged entries, got %+v", merged)
+	}
+	rule := merged[2]
+	if !rule.IsRule() || rule.Pattern != "db.staging_*" || rule.Until != "2026-12-31" || rule.Reason != "staging churn" {
+		t.Fatalf("expected pattern rule to survive merge, got %+v", rule)
+	}
+
+	if err := baseline.Save(filePath, merged); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := baseline.Load(filePath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(reloaded, merged) {
+		t.Fatalf("round trip mismatch: %+v != %+v", reloaded, merged)
+	}
 }
 
 func TestMergeFindings(t *testing.T) {
@@ -272,6 +607,57 @@ func TestMergeFindings(t *testing.T) {
 	}
 }
 
+func TestMergeFindingsPreservesMetadataAndStampsNewFindings(t *testing.T) {
+	now := time.Date(2026, 4, 1, 9, 30, 0, 0, time.UTC)
+	existing := []baseline.Finding{
+		{Fingerprint: "fp1", Type: "anomaly", Reason: "known noisy job", AddedBy: "alice", AddedAt: "2026-01-02T03:04:05Z"},
+		{Fingerprint: "fp2", Type: "anomaly"}, // Written before metadata existed
+	}
+	current := []baseline.Finding{
+		{Fingerprint: "fp1", Type: "anomaly", Reason: "new reason", AddedBy: "bob"},
+		{Fingerprint: "fp2", Type: "anomaly", Reason: "new reason", AddedBy: "bob"},
+		{Fingerprint: "fp3", Type: "anomaly", Reason: "new reason", AddedBy: "bob"},
+	}
+
+	merged := baseline.MergeFindingsAt(existing, current, now)
+	want := []baseline.Finding{
+		{Fingerprint: "fp1", Type: "anomaly", Reason: "known noisy job", AddedBy: "alice", AddedAt: "2026-01-02T03:04:05Z"},
+		{Fingerprint: "fp2", Type: "anomaly"},
+		{Fingerprint: "fp3", Type: "anomaly", Reason: "new reason", AddedBy: "bob", AddedAt: "2026-04-01T09:30:00Z"},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("MergeFindingsAt() = %+v, want %+v", merged, want)
+	}
+}
+
+func TestLoadBaselineWithoutMetadata(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(filePath, []byte(`[{"fingerprint": "fp1", "type": "anomaly"}]`), 0644); err != nil {
+		t.Fatalf("write baseline: %v", err)
+	}
+
+	loaded, err := baseline.Load(filePath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Reason != "" || loaded[0].AddedBy != "" || loaded[0].AddedAt != "" {
+		t.Fatalf("unexpected loaded findings: %+v", loaded)
+	}
+
+	if err := baseline.Save(filePath, loaded); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("read baseline: %v", err)
+	}
+	for _, key := range []string{"reason", "added_by", "added_at"} {
+		if strings.Contains(string(data), key) {
+			t.Fatalf("expected %q to be omitted from saved baseline, got %s", key, data)
+		}
+	}
+}
+
 func TestSplitTableName(t *testing.T) {
 	tests := []struct {
 		input       string
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"sync"
 
 	"github.com/ppiankov/clickspectre/internal/models"
@@ -31,14 +32,46 @@ type collector struct {
 	meta    *models.CollectionMeta
 }
 
+// Progress reports collection progress after each query_log page.
+type Progress struct {
+	Node   string // Address of the node the page came from
+	Page   int    // 1-based page number on that node
+	Batch  int    // Entries in this page
+	Total  int    // Entries collected from the node so far
+	Offset int    // Offset the page was fetched at
+}
+
+// ProgressFunc is called after every page fetched from query_log. With
+// multiple nodes it may be called concurrently.
+type ProgressFunc func(Progress)
+
+// Option configures a collector created by New.
+type Option func(*collector)
+
+// WithProgress reports per-page collection progress to fn.
+func WithProgress(fn ProgressFunc) Option {
+	return func(c *collector) {
+		for _, client := range c.clients {
+			client.progress = fn
+		}
+	}
+}
+
 // New creates a new collector instance. Supports multiple DSNs for multi-node clusters.
-func New(cfg *config.Config) (Collector, error) {
+// With cfg.FromFile set, entries are read from that file instead and opts
+// are ignored.
+func New(cfg *config.Config, opts ...Option) (Collector, error) {
+	if cfg.FromFile != "" {
+		return newFileCollector(cfg), nil
+	}
+
 	dsns := cfg.ClickHouseDSNs
 	if len(dsns) == 0 {
 		dsns = []string{cfg.ClickHouseDSN}
 	}
 
 	var clients []*ClickHouseClient
+	var activeDSNs []string
 	for _, dsn := range dsns {
 		nodeCfg := *cfg
 		nodeCfg.ClickHouseDSN = dsn
@@ -48,19 +81,25 @@ func New(cfg *config.Config) (Collector, error) {
 			for _, c := range clients {
 				_ = c.Close()
 			}
-			return nil, fmt.Errorf("failed to create ClickHouse client for %s: %w", extractHost(dsn), err)
+			return nil, fmt.Errorf("failed to create ClickHouse client for %s: %w", ExtractHost(dsn), err)
 		}
 		clients = append(clients, client)
+		// Report the failover endpoint actually in use
+		activeDSNs = append(activeDSNs, client.activeDSN)
 	}
 
 	pool := NewWorkerPool(cfg.Concurrency)
 
-	return &collector{
+	c := &collector{
 		config:  cfg,
 		clients: clients,
-		dsns:    dsns,
+		dsns:    activeDSNs,
 		pool:    pool,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // Collect retrieves and processes query log entries from all nodes.
@@ -76,9 +115,11 @@ func (c *collector) Collect(ctx context.Context) ([]*models.QueryLogEntry, error
 			return nil, fmt.Errorf("failed to fetch query logs: %w", err)
 		}
 		c.meta = &models.CollectionMeta{
-			Nodes:        []string{extractHost(c.dsns[0])},
+			Nodes:        []string{ExtractHost(c.dsns[0])},
 			FailedNodes:  []string{},
 			TotalEntries: len(entries),
+			RowsScanned:  c.clients[0].RowsScanned(),
+			RowLimitHit:  c.clients[0].RowLimitHit(),
 		}
 		return entries, nil
 	}
@@ -100,7 +141,7 @@ func (c *collector) collectMultiNode(ctx context.Context) ([]*models.QueryLogEnt
 		wg.Add(1)
 		go func(idx int, cl *ClickHouseClient, dsn string) {
 			defer wg.Done()
-			host := extractHost(dsn)
+			host := ExtractHost(dsn)
 			entries, err := cl.FetchQueryLogs(ctx, c.config, c.pool)
 			results[idx] = nodeResult{host: host, entries: entries, err: err}
 		}(i, client, c.dsns[i])
@@ -113,10 +154,14 @@ func (c *collector) collectMultiNode(ctx context.Context) ([]*models.QueryLogEnt
 	}
 	var allEntries []*models.QueryLogEntry
 	var successCount int
+	var firstErr error
 
-	for _, r := range results {
+	for i, r := range results {
 		meta.Nodes = append(meta.Nodes, r.host)
 		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
 			slog.Warn("node collection failed, continuing with remaining nodes",
 				slog.String("node", r.host),
 				slog.String("error", r.err.Error()))
@@ -124,11 +169,16 @@ func (c *collector) collectMultiNode(ctx context.Context) ([]*models.QueryLogEnt
 			continue
 		}
 		allEntries = append(allEntries, r.entries...)
+		meta.RowsScanned += c.clients[i].RowsScanned()
+		if c.clients[i].RowLimitHit() {
+			meta.RowLimitHit = true
+		}
 		successCount++
 	}
 
 	if successCount == 0 {
-		return nil, fmt.Errorf("all nodes failed to collect query logs")
+		// Keep the first node's cause so callers can still tell auth from timeouts
+		return nil, fmt.Errorf("all nodes failed to collect query logs: %w", firstErr)
 	}
 
 	// Deduplicate by query_id
@@ -180,7 +230,8 @@ func (c *collector) QueryRaw(ctx context.Context, query string, args ...interfac
 	if len(c.clients) == 0 {
 		return nil, fmt.Errorf("no ClickHouse clients available")
 	}
-	return c.clients[0].conn.QueryContext(ctx, query, args...)
+	client := c.clients[0]
+	return client.conn.QueryContext(client.withSettings(ctx), query, args...)
 }
 
 // Close closes the collector and all its client connections
@@ -194,23 +245,12 @@ func (c *collector) Close() error {
 	return firstErr
 }
 
-// extractHost extracts the host from a DSN string for logging.
-func extractHost(dsn string) string {
-	// Reuse the existing extractHost from analyze.go — this is a collector-level version
-	// that avoids importing cmd package.
-	if len(dsn) < 10 {
+// ExtractHost returns the host name of a ClickHouse DSN for logs and
+// reports, or "unknown". A ${NAME} password reference is never resolved.
+func ExtractHost(dsn string) string {
+	parsed, err := url.Parse(RedactDSNPasswordRef(dsn))
+	if err != nil || parsed.Hostname() == "" {
 		return "unknown"
 	}
-	for i := len("clickhouse://"); i < len(dsn); i++ {
-		if dsn[i] == '@' {
-			rest := dsn[i+1:]
-			for j := range rest {
-				if rest[j] == '/' || rest[j] == '?' {
-					return rest[:j]
-				}
-			}
-			return rest
-		}
-	}
-	return "unknown"
+	return parsed.Hostname()
 }
@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+// maxEntryLineBytes bounds a single JSONL line; queries are truncated to
+// --max-query-length (100k bytes by default) during collection, so this
+// leaves generous headroom unless truncation is disabled.
+const maxEntryLineBytes = 16 * 1024 * 1024
+
+// SaveEntries writes collected query log entries to disk for offline analysis.
+// Files ending in .json are written as a JSON array, anything else as JSONL.
+func SaveEntries(path string, entries []*models.QueryLogEntry) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create entries directory: %w", err)
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create entries file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = writeEntriesJSON(f, entries)
+	} else {
+		err = WriteEntriesJSONL(f, entries)
+	}
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close entries file: %w", closeErr)
+	}
+	return err
+}
+
+// WriteEntriesJSONL writes one JSON-encoded entry per line.
+func WriteEntriesJSONL(w io.Writer, entries []*models.QueryLogEntry) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("encode entry %s: %w", entry.QueryID, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("write entries: %w", err)
+	}
+	return nil
+}
+
+func writeEntriesJSON(w io.Writer, entries []*models.QueryLogEntry) error {
+	if entries == nil {
+		entries = []*models.QueryLogEntry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entries); err != nil {
+		return fmt.Errorf("encode entries: %w", err)
+	}
+	return nil
+}
+
+// LoadEntries reads query log entries previously written by SaveEntries.
+// Both JSON arrays and JSONL are accepted regardless of file extension.
+func LoadEntries(path string) ([]*models.QueryLogEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read entries file: %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return []*models.QueryLogEntry{}, nil
+	}
+
+	if trimmed[0] == '[' {
+		var entries []*models.QueryLogEntry
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("parse entries file %s: %w", path, err)
+		}
+		return entries, nil
+	}
+
+	return readEntriesJSONL(bytes.NewReader(trimmed), path)
+}
+
+func readEntriesJSONL(r io.Reader, path string) ([]*models.QueryLogEntry, error) {
+	entries := make([]*models.QueryLogEntry, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEntryLineBytes)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var entry models.QueryLogEntry
+		if err := json.Unmarshal(text, &entry); err != nil {
+			return nil, fmt.Errorf("parse entries file %s line %d: %w", path, line, err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read entries file %s: %w", path, err)
+	}
+
+	return entries, nil
+}
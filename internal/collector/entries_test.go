@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+func testEntries() []*models.QueryLogEntry {
+	return []*models.QueryLogEntry{
+		{
+			QueryID:   "q1",
+			Type:      "QueryFinish",
+			EventTime: time.Date(2026, 2, 16, 10, 0, 0, 0, time.UTC),
+			QueryKind: "Select",
+			Query:     "SELECT * FROM db.events",
+			User:      "reader",
+			ClientIP:  "10.0.0.1",
+			ReadRows:  42,
+			Duration:  150 * time.Millisecond,
+			Tables:    []string{"db.events"},
+		},
+		{
+			QueryID:     "q2",
+			Type:        "QueryFinish",
+			EventTime:   time.Date(2026, 2, 16, 11, 0, 0, 0, time.UTC),
+			QueryKind:   "Insert",
+			Query:       "INSERT INTO db.events VALUES",
+			User:        "writer",
+			ClientIP:    "10.0.0.2",
+			WrittenRows: 7,
+			Tables:      []string{"db.events"},
+		},
+	}
+}
+
+func TestSaveAndLoadEntriesRoundTrip(t *testing.T) {
+	for _, name := range []string{"entries.jsonl", "entries.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "nested", name)
+			want := testEntries()
+
+			if err := SaveEntries(path, want); err != nil {
+				t.Fatalf("SaveEntries: %v", err)
+			}
+
+			got, err := LoadEntries(path)
+			if err != nil {
+				t.Fatalf("LoadEntries: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSaveEntriesJSONLWritesOneEntryPerLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries.jsonl")
+	if err := SaveEntries(path, testEntries()); err != nil {
+		t.Fatalf("SaveEntries: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read entries: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Fatalf("expected 2 lines, got %d", lines)
+	}
+}
+
+func TestLoadEntriesErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := LoadEntries(filepath.Join(dir, "missing.jsonl")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+
+	bad := filepath.Join(dir, "bad.jsonl")
+	if err := os.WriteFile(bad, []byte("{\"QueryID\":\"q1\"}\nnot-json\n"), 0644); err != nil {
+		t.Fatalf("write bad file: %v", err)
+	}
+	if _, err := LoadEntries(bad); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected line 2 parse error, got %v", err)
+	}
+
+	empty := filepath.Join(dir, "empty.jsonl")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatalf("write empty file: %v", err)
+	}
+	entries, err := LoadEntries(empty)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected no entries and no error, got %d entries, err %v", len(entries), err)
+	}
+}
@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// Sentinel errors for the collector failures callers react to. Errors from
+// the collector match them with errors.Is while keeping their original
+// message, e.g. "failed to ping ClickHouse: code: 516, ...".
+var (
+	ErrAuth    = errors.New("ClickHouse authentication failed")
+	ErrSchema  = errors.New("unsupported system.query_log schema")
+	ErrConnect = errors.New("cannot connect to ClickHouse")
+	ErrTimeout = errors.New("ClickHouse request timed out")
+)
+
+// timeoutExceededCode is ClickHouse's TIMEOUT_EXCEEDED exception code.
+const timeoutExceededCode = 159
+
+// kindError tags err with one or more sentinels without changing its
+// message. errors.As still reaches err's chain, e.g. a *clickhouse.Exception.
+type kindError struct {
+	kinds []error
+	err   error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+
+func (e *kindError) Unwrap() []error {
+	return append([]error{e.err}, e.kinds...)
+}
+
+// withKind tags err with kinds; nil stays nil.
+func withKind(err error, kinds ...error) error {
+	if err == nil || len(kinds) == 0 {
+		return err
+	}
+	return &kindError{kinds: kinds, err: err}
+}
+
+// classify tags err with ErrAuth or ErrTimeout when its cause is a failed
+// login or a deadline. Other failures get fallback, if any; a failed login
+// is not also reported as fallback, so ErrConnect means the server could
+// not be reached.
+func classify(err error, fallback ...error) error {
+	switch {
+	case err == nil:
+		return nil
+	case isAuthError(err):
+		return withKind(err, ErrAuth)
+	case isTimeoutError(err):
+		return withKind(err, append([]error{ErrTimeout}, fallback...)...)
+	default:
+		return withKind(err, fallback...)
+	}
+}
+
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var chErr *clickhouse.Exception
+	if errors.As(err, &chErr) && chErr.Code == timeoutExceededCode {
+		return true
+	}
+	errText := strings.ToLower(err.Error())
+	return strings.Contains(errText, "timeout") || strings.Contains(errText, "timed out")
+}
@@ -0,0 +1,459 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+// errOffline is returned by the file collector for operations that need a
+// live ClickHouse connection.
+var errOffline = errors.New("not available when analyzing a query log file")
+
+// queryLogColumns is the column order FetchQueryLogs selects from
+// system.query_log. TSV dumps without a header row must use it.
+var queryLogColumns = []string{
+	"query_id", "type", "event_time", "query_kind", "query", "user",
+	"client_ip", "read_rows", "written_rows", "query_duration_ms", "exception",
+	"log_comment", "result_rows",
+}
+
+// optionalTrailingColumns are the last queryLogColumns, in order, which
+// headerless dumps exported by older versions may lack.
+var optionalTrailingColumns = []string{"log_comment", "result_rows"}
+
+// clientIPColumns are the query_log columns accepted for the client IP, in
+// order of preference.
+var clientIPColumns = []string{"client_ip", "initial_address", "address"}
+
+// eventTimeLayouts are the event_time formats accepted in dumps. Times
+// without a zone are read as UTC.
+var eventTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// fileCollector collects query log entries from a file instead of
+// ClickHouse: either entries written by 'clickspectre collect', or a raw
+// system.query_log dump in TSV or JSON.
+type fileCollector struct {
+	config *config.Config
+	path   string
+	pool   *WorkerPool
+	meta   *models.CollectionMeta
+}
+
+func newFileCollector(cfg *config.Config) *fileCollector {
+	return &fileCollector{
+		config: cfg,
+		path:   cfg.FromFile,
+		pool:   NewWorkerPool(cfg.Concurrency),
+	}
+}
+
+// Collect reads the file and returns its entries. Raw query_log rows go
+// through the same filtering and table extraction as rows fetched from
+// ClickHouse; the dump's own time range replaces --lookback.
+func (f *fileCollector) Collect(ctx context.Context) ([]*models.QueryLogEntry, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("read query log file: %w", err)
+	}
+	// Trailing tabs are empty TSV columns, so only JSON is trimmed
+	trimmed := bytes.TrimSpace(data)
+
+	var rows []map[string]string
+	switch {
+	case len(trimmed) == 0:
+		rows = []map[string]string{}
+	case trimmed[0] == '[' || trimmed[0] == '{':
+		if isCollectedEntries(trimmed) {
+			entries, err := LoadEntries(f.path)
+			if err != nil {
+				return nil, err
+			}
+			f.meta = &models.CollectionMeta{Nodes: []string{}, FailedNodes: []string{}, TotalEntries: len(entries), RowsScanned: len(entries)}
+			return entries, nil
+		}
+		rows, err = readJSONRows(trimmed)
+	default:
+		rows, err = readTSVRows(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse query log file %s: %w", f.path, err)
+	}
+
+	f.pool.Start(ctx)
+	defer f.pool.Stop()
+
+	entries, scanned, err := f.processRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	f.meta = &models.CollectionMeta{
+		Nodes:        []string{},
+		FailedNodes:  []string{},
+		TotalEntries: len(entries),
+		RowsScanned:  scanned,
+		RowLimitHit:  scanned < len(rows),
+	}
+	if f.meta.RowLimitHit {
+		slog.Warn("max rows limit reached, results are truncated",
+			slog.Int("max_rows", f.config.MaxRows),
+			slog.String("file", f.path),
+		)
+	}
+	return entries, nil
+}
+
+// processRows applies the filters FetchQueryLogs runs in SQL and in
+// processBatch to at most MaxRows rows, then extracts tables. Entries keep
+// the file's order. It also returns the number of rows read.
+func (f *fileCollector) processRows(rows []map[string]string) ([]*models.QueryLogEntry, int, error) {
+	if f.config.MaxRows > 0 && len(rows) > f.config.MaxRows {
+		rows = rows[:f.config.MaxRows]
+	}
+
+	var entries []*models.QueryLogEntry
+	skippedRows := 0
+	for i, row := range rows {
+		entry, err := queryLogEntryFromRow(row)
+		if err != nil {
+			skippedRows++
+			slog.Debug("failed to parse row", slog.Int("row", i+1), slog.String("error", err.Error()))
+			continue
+		}
+		if !f.wantType(entry.Type) || strings.Contains(entry.Query, "system.query_log") {
+			continue
+		}
+		if entry.QueryID == "" || entry.Query == "" {
+			skippedRows++
+			slog.Debug("row has empty essential fields", slog.Int("row", i+1))
+			continue
+		}
+		if f.config.IsEntryExcluded(entry.User, entry.QueryKind, entry.LogComment) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if skippedRows > 0 {
+		slog.Error("skipped problematic rows",
+			slog.Int("skipped_rows", skippedRows),
+			slog.Int("total_rows", len(rows)),
+		)
+	}
+
+	order := make(map[*models.QueryLogEntry]int, len(entries))
+	for i, entry := range entries {
+		order[entry] = i
+	}
+	entries, err := extractEntryTables(f.config, entries, f.pool)
+	if err != nil {
+		return nil, len(rows), err
+	}
+	sort.Slice(entries, func(i, j int) bool { return order[entries[i]] < order[entries[j]] })
+
+	return entries, len(rows), nil
+}
+
+// wantType mirrors the type filter of the query_log query.
+func (f *fileCollector) wantType(queryType string) bool {
+	switch queryType {
+	case "QueryFinish":
+		return true
+	case "ExceptionBeforeStart", "ExceptionWhileProcessing":
+		return f.config.IncludeExceptions
+	}
+	return false
+}
+
+// FetchTableMetadata is not available offline.
+func (f *fileCollector) FetchTableMetadata(ctx context.Context) (map[string]*models.Table, error) {
+	return nil, fmt.Errorf("fetch table metadata: %w", errOffline)
+}
+
+// Close is a no-op; the file is read in full by Collect.
+func (f *fileCollector) Close() error {
+	return nil
+}
+
+// CollectionMeta returns metadata about the last Collect call.
+func (f *fileCollector) CollectionMeta() *models.CollectionMeta {
+	return f.meta
+}
+
+// QueryRaw is not available offline.
+func (f *fileCollector) QueryRaw(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, fmt.Errorf("query: %w", errOffline)
+}
+
+// isCollectedEntries reports whether a JSON file holds entries written by
+// SaveEntries, whose keys are Go field names, rather than query_log rows.
+func isCollectedEntries(data []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if data[0] == '[' {
+		if _, err := dec.Token(); err != nil {
+			return false
+		}
+		if !dec.More() {
+			return true // An empty array is a valid SaveEntries file
+		}
+	}
+	var first map[string]json.RawMessage
+	if err := dec.Decode(&first); err != nil {
+		return false
+	}
+	_, ok := first["QueryID"]
+	return ok
+}
+
+// readJSONRows reads query_log rows from JSONEachRow output, a JSON array of
+// rows, or ClickHouse's JSON format, whose rows are under "data".
+func readJSONRows(data []byte) ([]map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	rows := make([]map[string]string, 0)
+	for {
+		var value any
+		if err := dec.Decode(&value); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				row, err := jsonRow(item)
+				if err != nil {
+					return nil, err
+				}
+				rows = append(rows, row)
+			}
+		case map[string]any:
+			if items, ok := v["data"].([]any); ok {
+				for _, item := range items {
+					row, err := jsonRow(item)
+					if err != nil {
+						return nil, err
+					}
+					rows = append(rows, row)
+				}
+				continue
+			}
+			row, err := jsonRow(v)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		default:
+			return nil, fmt.Errorf("expected a JSON object or array, got %T", value)
+		}
+	}
+	return rows, nil
+}
+
+// jsonRow flattens a JSON object into column values. ClickHouse quotes
+// 64-bit integers by default, so numbers may arrive as strings or numbers.
+func jsonRow(item any) (map[string]string, error) {
+	object, ok := item.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object per row, got %T", item)
+	}
+	row := make(map[string]string, len(object))
+	for column, value := range object {
+		switch v := value.(type) {
+		case nil:
+			row[column] = ""
+		case string:
+			row[column] = v
+		default:
+			row[column] = fmt.Sprint(v)
+		}
+	}
+	return row, nil
+}
+
+// readTSVRows reads query_log rows in ClickHouse's TabSeparated format. A
+// header row (TabSeparatedWithNames) names the columns; without one the
+// columns must be in queryLogColumns order. A types row after the header
+// (TabSeparatedWithNamesAndTypes) is skipped.
+func readTSVRows(data []byte) ([]map[string]string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEntryLineBytes)
+
+	rows := make([]map[string]string, 0)
+	var columns []string
+	skipTypes := false
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		for i, field := range fields {
+			fields[i] = unescapeTSV(field)
+		}
+
+		if columns == nil {
+			for _, field := range fields {
+				if field == "query_id" {
+					columns = fields
+					skipTypes = true
+					break
+				}
+			}
+			if columns != nil {
+				continue
+			}
+			columns = queryLogColumns
+		}
+		if skipTypes {
+			skipTypes = false
+			if isTSVTypesRow(fields) {
+				continue
+			}
+		}
+
+		// Headerless dumps exported before log_comment or result_rows was
+		// collected lack the trailing columns
+		if missing := len(columns) - len(fields); missing > 0 && missing <= len(optionalTrailingColumns) &&
+			slices.Equal(columns[len(columns)-missing:], optionalTrailingColumns[len(optionalTrailingColumns)-missing:]) {
+			fields = append(fields, make([]string, missing)...)
+		}
+		if len(fields) != len(columns) {
+			return nil, fmt.Errorf("line %d: expected %d columns, got %d", line, len(columns), len(fields))
+		}
+		row := make(map[string]string, len(columns))
+		for i, column := range columns {
+			row[column] = fields[i]
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// isTSVTypesRow reports whether fields are ClickHouse type names, as in the
+// second row of TabSeparatedWithNamesAndTypes.
+func isTSVTypesRow(fields []string) bool {
+	for _, field := range fields {
+		switch {
+		case field == "String", field == "DateTime", field == "IPv6", field == "IPv4",
+			strings.HasPrefix(field, "UInt"), strings.HasPrefix(field, "Enum8("),
+			strings.HasPrefix(field, "LowCardinality("), strings.HasPrefix(field, "DateTime64("):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// unescapeTSV reverses ClickHouse's TabSeparated escaping.
+func unescapeTSV(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	b.Grow(len(field))
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c != '\\' || i+1 == len(field) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch field[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case '0':
+			b.WriteByte(0)
+		default:
+			b.WriteByte(field[i])
+		}
+	}
+	return b.String()
+}
+
+// queryLogEntryFromRow builds an entry from one dumped query_log row.
+func queryLogEntryFromRow(row map[string]string) (*models.QueryLogEntry, error) {
+	entry := &models.QueryLogEntry{
+		QueryID:    row["query_id"],
+		Type:       row["type"],
+		QueryKind:  row["query_kind"],
+		Query:      row["query"],
+		User:       row["user"],
+		Exception:  row["exception"],
+		LogComment: row["log_comment"],
+	}
+	for _, column := range clientIPColumns {
+		if ip := row[column]; ip != "" {
+			entry.ClientIP = ip
+			break
+		}
+	}
+
+	eventTime, err := parseEventTime(row["event_time"])
+	if err != nil {
+		return nil, err
+	}
+	entry.EventTime = eventTime
+
+	var durationMs uint64
+	for column, dst := range map[string]*uint64{
+		"read_rows":         &entry.ReadRows,
+		"written_rows":      &entry.WrittenRows,
+		"result_rows":       &entry.ResultRows,
+		"query_duration_ms": &durationMs,
+	} {
+		value := row[column]
+		if value == "" {
+			continue
+		}
+		if *dst, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s %q", column, value)
+		}
+	}
+	entry.Duration = time.Duration(durationMs) * time.Millisecond
+
+	return entry, nil
+}
+
+// parseEventTime parses a dumped event_time in any of eventTimeLayouts.
+func parseEventTime(value string) (time.Time, error) {
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid event_time %q", value)
+}
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+func collectFile(t *testing.T, cfg *config.Config, path string) ([]*models.QueryLogEntry, *models.CollectionMeta) {
+	t.Helper()
+	cfg.FromFile = path
+	col, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, ok := col.(*fileCollector); !ok {
+		t.Fatalf("expected New to select the file collector for --from-file, got %T", col)
+	}
+	entries, err := col.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect(%s) failed: %v", path, err)
+	}
+	return entries, col.CollectionMeta()
+}
+
+func TestFileCollectorReadsTSVAndJSONDumps(t *testing.T) {
+	cfg := config.DefaultConfig()
+	fromTSV, meta := collectFile(t, cfg, filepath.Join("testdata", "query_log.tsv"))
+	fromJSON, _ := collectFile(t, config.DefaultConfig(), filepath.Join("testdata", "query_log.jsonl"))
+
+	want := []*models.QueryLogEntry{
+		{
+			QueryID:   "q1",
+			Type:      "QueryFinish",
+			EventTime: time.Date(2026, 2, 16, 10, 0, 0, 0, time.UTC),
+			QueryKind: "Select",
+			Query:     "SELECT *\nFROM db.events\tWHERE 1",
+			User:      "reader",
+			ClientIP:  "::ffff:10.0.0.1",
+			ReadRows:  120,
+			Duration:  15 * time.Millisecond,
+			Tables:    []string{"db.events"},
+		},
+		{
+			QueryID:     "q2",
+			Type:        "QueryFinish",
+			EventTime:   time.Date(2026, 2, 16, 10, 5, 0, 0, time.UTC),
+			QueryKind:   "Insert",
+			Query:       "INSERT INTO db.events VALUES",
+			User:        "writer",
+			ClientIP:    "10.0.0.2",
+			WrittenRows: 5,
+			Duration:    3 * time.Millisecond,
+			Tables:      []string{"db.events"},
+		},
+	}
+	if !reflect.DeepEqual(fromTSV, want) {
+		t.Fatalf("unexpected TSV entries:\n got %+v\nwant %+v", fromTSV, want)
+	}
+	if !reflect.DeepEqual(fromJSON, fromTSV) {
+		t.Fatalf("expected JSON and TSV dumps to produce identical entries:\nJSON %+v\n TSV %+v", fromJSON, fromTSV)
+	}
+	if meta.RowsScanned != 4 || meta.TotalEntries != 2 || meta.RowLimitHit {
+		t.Fatalf("unexpected collection metadata: %+v", meta)
+	}
+
+	// Failed queries are kept on request, as with the live query
+	cfg = config.DefaultConfig()
+	cfg.IncludeExceptions = true
+	withExceptions, _ := collectFile(t, cfg, filepath.Join("testdata", "query_log.jsonl"))
+	if len(withExceptions) != 3 || withExceptions[2].Exception != "Code: 60. Table does not exist" {
+		t.Fatalf("expected the failed query with --include-exceptions, got %+v", withExceptions)
+	}
+}
+
+func TestFileCollectorAppliesCollectionFilters(t *testing.T) {
+	dir := t.TempDir()
+
+	// TSV without a header must follow the column order of the live query
+	data, err := os.ReadFile(filepath.Join("testdata", "query_log.tsv"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	_, body, _ := strings.Cut(string(data), "\n")
+	headerless := filepath.Join(dir, "query_log.tsv")
+	if err := os.WriteFile(headerless, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write dump: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.ExcludeUsers = []string{"writer"}
+	cfg.MaxQueryLength = 10
+	entries, _ := collectFile(t, cfg, headerless)
+	if len(entries) != 1 || entries[0].QueryID != "q1" {
+		t.Fatalf("expected only q1 after excluding writer, got %+v", entries)
+	}
+	if !reflect.DeepEqual(entries[0].Tables, []string{"db.events"}) || entries[0].Query != "SELECT *\nF"+truncatedQuerySuffix {
+		t.Fatalf("expected tables extracted before truncation, got %+v", entries[0])
+	}
+
+	cfg = config.DefaultConfig()
+	cfg.MaxRows = 1
+	_, meta := collectFile(t, cfg, headerless)
+	if !meta.RowLimitHit || meta.RowsScanned != 1 {
+		t.Fatalf("expected --max-rows to stop reading the dump, got %+v", meta)
+	}
+}
+
+func TestFileCollectorReadsResultRows(t *testing.T) {
+	dir := t.TempDir()
+	dumps := map[string]string{
+		"query_log.tsv": "query_id\ttype\tevent_time\tquery_kind\tquery\tuser\tclient_ip\tread_rows\tresult_rows\n" +
+			"q1\tQueryFinish\t2026-02-16 10:00:00\tSelect\tSELECT * FROM db.events\treader\t10.0.0.1\t5000\t3\n",
+		"query_log.jsonl": `{"query_id":"q1","type":"QueryFinish","event_time":"2026-02-16 10:00:00","query_kind":"Select",` +
+			`"query":"SELECT * FROM db.events","user":"reader","client_ip":"10.0.0.1","read_rows":5000,"result_rows":"3"}` + "\n",
+	}
+	for name, body := range dumps {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("failed to write dump: %v", err)
+		}
+		entries, _ := collectFile(t, config.DefaultConfig(), path)
+		if len(entries) != 1 || entries[0].ReadRows != 5000 || entries[0].ResultRows != 3 {
+			t.Fatalf("%s: expected read_rows=5000 result_rows=3, got %+v", name, entries)
+		}
+	}
+}
+
+func TestFileCollectorLoadsCollectedEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries.json")
+	saved := []*models.QueryLogEntry{{QueryID: "q1", Type: "QueryFinish", Query: "SELECT 1", Tables: []string{"db.kept"}}}
+	if err := SaveEntries(path, saved); err != nil {
+		t.Fatalf("SaveEntries failed: %v", err)
+	}
+
+	entries, _ := collectFile(t, config.DefaultConfig(), path)
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0].Tables, []string{"db.kept"}) {
+		t.Fatalf("expected collected entries to load unchanged, got %+v", entries)
+	}
+}
@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+// InventoryEntry is one system.tables row as fetched, before exclusions,
+// so a cached inventory stays valid when exclusion flags change.
+type InventoryEntry struct {
+	Database         string    `json:"database"`
+	Name             string    `json:"name"`
+	Engine           string    `json:"engine"`
+	TotalBytes       uint64    `json:"total_bytes,omitempty"`
+	TotalRows        uint64    `json:"total_rows,omitempty"`
+	MetadataModified time.Time `json:"create_time"`            // Key predates the rename, so older caches still load
+	Dependencies     []string  `json:"dependencies,omitempty"` // "db.table" of dependent views
+	PartitionKey     string    `json:"partition_key,omitempty"`
+	Sources          []string  `json:"sources,omitempty"` // "db.table" a materialized view reads from
+}
+
+// InventoryCache is a table inventory saved to disk so repeated runs
+// against the same host within the TTL skip the system.tables scan.
+type InventoryCache struct {
+	Host      string           `json:"host"`
+	FetchedAt time.Time        `json:"fetched_at"`
+	Tables    []InventoryEntry `json:"tables"`
+}
+
+// DefaultInventoryCachePath returns the default inventory cache file path.
+func DefaultInventoryCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".clickspectre-inventory.json"
+	}
+	return filepath.Join(home, ".config", "clickspectre", "inventory.json")
+}
+
+// LoadInventoryCache reads an inventory cache from disk. Returns nil if the
+// file doesn't exist.
+func LoadInventoryCache(path string) (*InventoryCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read inventory cache: %w", err)
+	}
+
+	var cache InventoryCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("parse inventory cache: %w", err)
+	}
+	return &cache, nil
+}
+
+// SaveInventoryCache writes the inventory cache to disk, creating parent
+// directories as needed.
+func SaveInventoryCache(path string, cache *InventoryCache) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create inventory cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("marshal inventory cache: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write inventory cache: %w", err)
+	}
+	return nil
+}
+
+// Fresh reports whether the cache was fetched from host less than ttl
+// before now.
+func (ic *InventoryCache) Fresh(host string, ttl time.Duration, now time.Time) bool {
+	if ic == nil || ic.Host != host || ttl <= 0 {
+		return false
+	}
+	age := now.Sub(ic.FetchedAt)
+	return age >= 0 && age < ttl
+}
+
+// table converts the entry into the analyzer's table model.
+func (e InventoryEntry) table() *models.Table {
+	dependencies := e.Dependencies
+	if dependencies == nil {
+		dependencies = []string{}
+	}
+	return &models.Table{
+		Name:             e.Name,
+		Database:         e.Database,
+		FullName:         e.Database + "." + e.Name,
+		Engine:           e.Engine,
+		IsReplicated:     strings.Contains(e.Engine, "Replicated"),
+		TotalBytes:       e.TotalBytes,
+		TotalRows:        e.TotalRows,
+		MetadataModified: e.MetadataModified,
+		IsMV:             strings.HasPrefix(e.Engine, "Materialized"),
+		MVDependency:     dependencies,
+		PartitionKey:     e.PartitionKey,
+		MVSources:        e.Sources,
+		Sparkline:        []models.TimeSeriesPoint{}, // Initialize empty slice
+	}
+}
@@ -3,20 +3,32 @@ package collector
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ppiankov/clickspectre/pkg/config"
 )
 
 const (
 	maxRetryAttempts    = 3
 	initialRetryBackoff = 100 * time.Millisecond
 	maxRetryBackoff     = 2 * time.Second
+
+	// overloadBackoffFactor stretches the backoff after a server overload
+	// error, giving running queries time to finish or free memory.
+	overloadBackoffFactor = 4
 )
 
 var (
+	// authErrorCodes are the ClickHouse exception codes for failed logins
+	authErrorCodes      = []int32{193, 194, 497, 516}
 	authErrorSubstrings = []string{
 		"authentication failed",
 		"authentication error",
@@ -52,11 +64,48 @@ var (
 	}
 )
 
+// exceptionCodePattern finds a ClickHouse exception code in error text from
+// drivers or protocols that do not return a *clickhouse.Exception.
+var exceptionCodePattern = regexp.MustCompile(`(?i)\bcode: (\d+)`)
+
+// errRetryBudgetExhausted is returned once a collection has spent every
+// retry its retryBudget allows.
+var errRetryBudgetExhausted = errors.New("retry budget exhausted")
+
 type retryConfig struct {
 	maxAttempts    int
 	initialBackoff time.Duration
 	maxBackoff     time.Duration
 	sleep          func(context.Context, time.Duration) error
+	budget         *retryBudget // Shared across calls; nil means unlimited
+	overloadCodes  []int32      // ClickHouse exception codes retried with a longer backoff
+}
+
+// retryBudget caps the total retries of every executeWithRetry call that
+// shares it, so a flaky cluster cannot stretch a paginated collection
+// indefinitely. It is safe for concurrent page fetches.
+type retryBudget struct {
+	limit     int64
+	remaining atomic.Int64
+}
+
+// newRetryBudget returns a budget of limit retries, or nil (unlimited) when
+// limit is not positive.
+func newRetryBudget(limit int) *retryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	b := &retryBudget{limit: int64(limit)}
+	b.remaining.Store(int64(limit))
+	return b
+}
+
+// take spends one retry, reporting false once the budget is exhausted.
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	return b.remaining.Add(-1) >= 0
 }
 
 func defaultRetryConfig() retryConfig {
@@ -65,6 +114,7 @@ func defaultRetryConfig() retryConfig {
 		initialBackoff: initialRetryBackoff,
 		maxBackoff:     maxRetryBackoff,
 		sleep:          sleepWithContext,
+		overloadCodes:  config.DefaultRetryErrorCodes(),
 	}
 }
 
@@ -106,11 +156,19 @@ func executeWithRetry(ctx context.Context, cfg retryConfig, fn func() error) err
 			return ctxErr
 		}
 
-		if isAuthError(err) || !isRetryableError(err) || attempt == cfg.maxAttempts {
+		overloaded := isOverloadError(err, cfg.overloadCodes)
+		if isAuthError(err) || !(overloaded || isRetryableError(err)) || attempt == cfg.maxAttempts {
 			return err
 		}
+		if !cfg.budget.take() {
+			return fmt.Errorf("%w (%d retries across all pages): %w", errRetryBudgetExhausted, cfg.budget.limit, err)
+		}
 
-		if err := cfg.sleep(ctx, backoff); err != nil {
+		delay := backoff
+		if overloaded {
+			delay *= overloadBackoffFactor
+		}
+		if err := cfg.sleep(ctx, delay); err != nil {
 			if ctxErr := contextError(ctx); ctxErr != nil {
 				return ctxErr
 			}
@@ -176,11 +234,8 @@ func isAuthError(err error) bool {
 	}
 
 	var chErr *clickhouse.Exception
-	if errors.As(err, &chErr) {
-		switch chErr.Code {
-		case 193, 194, 497, 516:
-			return true
-		}
+	if errors.As(err, &chErr) && slices.Contains(authErrorCodes, chErr.Code) {
+		return true
 	}
 
 	errText := strings.ToLower(err.Error())
@@ -193,6 +248,40 @@ func isAuthError(err error) bool {
 	return false
 }
 
+// ValidateRetryErrorCodes checks codes given to --retry-error-codes. Auth
+// failures always fail fast, so they are rejected rather than ignored.
+func ValidateRetryErrorCodes(codes []int32) error {
+	for _, code := range codes {
+		if code <= 0 {
+			return fmt.Errorf("invalid --retry-error-codes: codes must be positive, got %d", code)
+		}
+		if slices.Contains(authErrorCodes, code) {
+			return fmt.Errorf("invalid --retry-error-codes: %d is an authentication error and is never retried", code)
+		}
+	}
+	return nil
+}
+
+// isOverloadError reports whether err is a ClickHouse exception whose code
+// is in codes, e.g. 202 TOO_MANY_SIMULTANEOUS_QUERIES.
+func isOverloadError(err error, codes []int32) bool {
+	if err == nil || len(codes) == 0 {
+		return false
+	}
+
+	var chErr *clickhouse.Exception
+	if errors.As(err, &chErr) {
+		return slices.Contains(codes, chErr.Code)
+	}
+
+	match := exceptionCodePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return false
+	}
+	code, parseErr := strconv.ParseInt(match[1], 10, 32)
+	return parseErr == nil && slices.Contains(codes, int32(code))
+}
+
 func isRetryableError(err error) bool {
 	if err == nil || errors.Is(err, context.Canceled) {
 		return false
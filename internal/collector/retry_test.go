@@ -3,8 +3,12 @@ package collector
 import (
 	"context"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
 )
 
 func TestExecuteWithRetryTransientBackoff(t *testing.T) {
@@ -42,6 +46,31 @@ func TestExecuteWithRetryTransientBackoff(t *testing.T) {
 	}
 }
 
+func TestExecuteWithRetrySharedBudget(t *testing.T) {
+	cfg := retryConfig{
+		maxAttempts:    3,
+		initialBackoff: time.Millisecond,
+		sleep:          func(context.Context, time.Duration) error { return nil },
+		budget:         newRetryBudget(3),
+	}
+
+	attempts := 0
+	for call := 0; call < 3; call++ {
+		err := executeWithRetry(context.Background(), cfg, func() error {
+			attempts++
+			return errors.New("connection reset by peer")
+		})
+		if exhausted := errors.Is(err, errRetryBudgetExhausted); exhausted != (call > 0) {
+			t.Fatalf("call %d: unexpected error %v", call, err)
+		}
+	}
+	// Call 0 spends 2 retries (3 attempts), call 1 spends the last retry and
+	// is then cut off, call 2 fails once with nothing left
+	if attempts != 6 {
+		t.Fatalf("expected 6 attempts across calls, got %d", attempts)
+	}
+}
+
 func TestExecuteWithRetryAuthFailFast(t *testing.T) {
 	attempts := 0
 	sleepCalls := 0
@@ -71,6 +100,81 @@ func TestExecuteWithRetryAuthFailFast(t *testing.T) {
 	}
 }
 
+func TestExecuteWithRetryOverloadCodes(t *testing.T) {
+	cases := []struct {
+		name         string
+		err          error
+		wantAttempts int
+		wantSleeps   []time.Duration
+	}{
+		{
+			name:         "too_many_simultaneous_queries",
+			err:          &clickhouse.Exception{Code: 202, Message: "Too many simultaneous queries"},
+			wantAttempts: 3,
+			wantSleeps:   []time.Duration{40 * time.Millisecond, 80 * time.Millisecond},
+		},
+		{
+			name:         "code_in_error_text",
+			err:          errors.New("sendQuery: code: 241, message: Memory limit (total) exceeded"),
+			wantAttempts: 3,
+			wantSleeps:   []time.Duration{40 * time.Millisecond, 80 * time.Millisecond},
+		},
+		{
+			name:         "auth_fails_fast",
+			err:          &clickhouse.Exception{Code: 516, Message: "Authentication failed"},
+			wantAttempts: 1,
+		},
+		{
+			name:         "unlisted_code_fails_fast",
+			err:          &clickhouse.Exception{Code: 60, Message: "Table does not exist"},
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var sleeps []time.Duration
+			cfg := retryConfig{
+				maxAttempts:    3,
+				initialBackoff: 10 * time.Millisecond,
+				maxBackoff:     40 * time.Millisecond,
+				sleep: func(_ context.Context, d time.Duration) error {
+					sleeps = append(sleeps, d)
+					return nil
+				},
+				overloadCodes: []int32{202, 241},
+			}
+
+			attempts := 0
+			err := executeWithRetry(context.Background(), cfg, func() error {
+				attempts++
+				return tc.err
+			})
+			if err == nil {
+				t.Fatal("expected the error to surface once attempts run out")
+			}
+			if attempts != tc.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d", tc.wantAttempts, attempts)
+			}
+			if !reflect.DeepEqual(sleeps, tc.wantSleeps) {
+				t.Fatalf("expected backoff %v, got %v", tc.wantSleeps, sleeps)
+			}
+		})
+	}
+}
+
+func TestValidateRetryErrorCodes(t *testing.T) {
+	if err := ValidateRetryErrorCodes([]int32{202, 241}); err != nil {
+		t.Fatalf("expected overload codes to be accepted, got %v", err)
+	}
+	if err := ValidateRetryErrorCodes([]int32{202, 516}); err == nil || !strings.Contains(err.Error(), "516") {
+		t.Fatalf("expected auth code to be rejected, got %v", err)
+	}
+	if err := ValidateRetryErrorCodes([]int32{0}); err == nil {
+		t.Fatal("expected non-positive code to be rejected")
+	}
+}
+
 func TestWithTotalTimeoutContextDeadlineCause(t *testing.T) {
 	ctx, cancel := withTotalTimeoutContext(context.Background(), 20*time.Millisecond)
 	defer cancel()
@@ -0,0 +1,216 @@
+package collector
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sqlToken is one lexical token of a query. Identifiers keep their original
+// case; quoted identifiers are unquoted, so "MyTable" and `MyTable` both
+// yield MyTable.
+type sqlToken struct {
+	text   string
+	ident  bool // Bare or quoted identifier
+	quoted bool // Quoted identifier, never a keyword
+}
+
+// keyword reports whether t is the bare keyword kw, matched case-insensitively.
+func (t sqlToken) keyword(kw string) bool {
+	return t.ident && !t.quoted && strings.EqualFold(t.text, kw)
+}
+
+// tokenizeSQL splits query into identifiers and single-character symbols.
+// String literals, numbers, and comments are dropped, so a keyword inside
+// them is never taken for a table reference.
+func tokenizeSQL(query string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(query); {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += 2 + end + 2
+		case r == '\'':
+			_, next := readQuoted(query, i)
+			i = next
+		case r == '"' || r == '`':
+			text, next := readQuoted(query, i)
+			tokens = append(tokens, sqlToken{text: text, ident: true, quoted: true})
+			i = next
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(query) {
+				r, size := utf8.DecodeRuneInString(query[i:])
+				if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, sqlToken{text: query[start:i], ident: true})
+		case unicode.IsDigit(r):
+			// Numbers, including 1e5, 0x1F, and 1.5, are not identifiers
+			for i < len(query) {
+				r, size := utf8.DecodeRuneInString(query[i:])
+				if r != '_' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+		default:
+			tokens = append(tokens, sqlToken{text: query[i : i+size]})
+			i += size
+		}
+	}
+	return tokens
+}
+
+// readQuoted reads the quoted literal or identifier starting at query[start],
+// honoring backslash escapes and doubled quotes. It returns the unquoted text
+// and the offset after the closing quote, or the end of query when the quote
+// is unterminated.
+func readQuoted(query string, start int) (string, int) {
+	quote := query[start]
+	var b strings.Builder
+	for i := start + 1; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\\' && i+1 < len(query):
+			i++
+			b.WriteByte(query[i])
+		case c == quote && i+1 < len(query) && query[i+1] == quote:
+			i++
+			b.WriteByte(quote)
+		case c == quote:
+			return b.String(), i + 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), len(query)
+}
+
+// extractTables extracts the [db.]table references that follow FROM, JOIN,
+// INSERT INTO, and CREATE TABLE in query. Keywords match case-insensitively,
+// while table names keep their case so they join with system.tables, which
+// is case-sensitive. Table functions such as numbers(10) or s3(...) are not
+// tables and are skipped.
+func extractTables(query string) []string {
+	tokens := tokenizeSQL(query)
+	seen := make(map[string]bool) // Use map to deduplicate
+	var result []string
+
+	// skip advances past the optional keywords kws, in order
+	skip := func(i int, kws ...string) int {
+		for _, kw := range kws {
+			if i < len(tokens) && tokens[i].keyword(kw) {
+				i++
+			}
+		}
+		return i
+	}
+
+	for i, tok := range tokens {
+		ref := -1
+		reads := false // FROM or JOIN, where a name followed by ( is a table function
+		switch {
+		case tok.keyword("from"), tok.keyword("join"):
+			ref = i + 1
+			reads = true
+		case tok.keyword("insert"):
+			if i+1 < len(tokens) && tokens[i+1].keyword("into") {
+				ref = skip(i+2, "table")
+				if ref < len(tokens) && tokens[ref].keyword("function") {
+					ref = -1 // A table function, e.g. INSERT INTO FUNCTION s3(...)
+				}
+			}
+		case tok.keyword("create"):
+			j := i + 1
+			if j+1 < len(tokens) && tokens[j].keyword("or") && tokens[j+1].keyword("replace") {
+				j += 2
+			}
+			if j < len(tokens) && tokens[j].keyword("table") {
+				j++
+				if j+2 < len(tokens) && tokens[j].keyword("if") && tokens[j+1].keyword("not") && tokens[j+2].keyword("exists") {
+					j += 3
+				}
+				ref = j
+			}
+		}
+		if ref < 0 {
+			continue
+		}
+
+		name, next, ok := tableRef(tokens, ref)
+		if !ok || (reads && tableFunctionCall(tokens, next)) {
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// tableRef reads the [db.]table reference starting at tokens[i]. It returns
+// the reference, the index of the token after it, and whether one was found.
+func tableRef(tokens []sqlToken, i int) (string, int, bool) {
+	if i >= len(tokens) || !tokens[i].ident || tokens[i].text == "" {
+		return "", i, false
+	}
+	name := tokens[i].text
+	if i+2 < len(tokens) && tokens[i+1].text == "." && !tokens[i+1].ident && tokens[i+2].ident && tokens[i+2].text != "" {
+		return name + "." + tokens[i+2].text, i + 3, true
+	}
+	return name, i + 1, true
+}
+
+// tableFunctionCall reports whether tokens[next], the token after a name,
+// opens an argument list, making the name a table function.
+func tableFunctionCall(tokens []sqlToken, next int) bool {
+	return next < len(tokens) && tokens[next].text == "(" && !tokens[next].ident
+}
+
+// viewSources returns the [db.]tables a materialized view's CREATE query
+// reads with FROM or JOIN, qualifying bare names with the view's database.
+// Table functions such as numbers(10) are not tables and are skipped.
+func viewSources(database, createQuery string) []string {
+	if createQuery == "" {
+		return nil
+	}
+	tokens := tokenizeSQL(createQuery)
+	seen := make(map[string]bool)
+	var sources []string
+	for i, tok := range tokens {
+		if !tok.keyword("from") && !tok.keyword("join") {
+			continue
+		}
+		name, next, ok := tableRef(tokens, i+1)
+		if !ok {
+			continue
+		}
+		if tableFunctionCall(tokens, next) {
+			continue
+		}
+		if next == i+2 {
+			name = database + "." + name
+		}
+		if !seen[name] {
+			seen[name] = true
+			sources = append(sources, name)
+		}
+	}
+	return sources
+}
@@ -6,12 +6,16 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
 )
 
-// Watermark tracks the last successful collection time per node.
+// Watermark is the incremental state kept between runs. LastRun is the
+// newest query_log event_time collected, so the next run resumes from it.
 type Watermark struct {
-	LastRun time.Time            `json:"last_run"`
-	Nodes   map[string]time.Time `json:"nodes,omitempty"`
+	LastRun time.Time                    `json:"last_run"`
+	Nodes   map[string]time.Time         `json:"nodes,omitempty"`
+	Tables  map[string]models.TableUsage `json:"tables,omitempty"` // Usage accumulated since the first incremental run
 }
 
 // DefaultWatermarkPath returns the default watermark file path.
@@ -56,3 +60,43 @@ func SaveWatermark(path string, w *Watermark) error {
 	}
 	return nil
 }
+
+// NextWatermark returns the state to save after a successful incremental
+// run. LastRun advances to the newest entry collected; when nothing new
+// arrived it keeps the previous mark, or uses now on a first run. Tables
+// holds the merged usage of every table that has seen queries, taken from
+// the run's tables, which already include the previous accumulation.
+func NextWatermark(prev *Watermark, entries []*models.QueryLogEntry, tables []models.Table, now time.Time) *Watermark {
+	next := &Watermark{LastRun: now}
+	if prev != nil && !prev.LastRun.IsZero() {
+		next.LastRun = prev.LastRun
+	}
+	var newest time.Time
+	for _, entry := range entries {
+		if entry.EventTime.After(newest) {
+			newest = entry.EventTime
+		}
+	}
+	if !newest.IsZero() && (prev == nil || newest.After(prev.LastRun)) {
+		next.LastRun = newest
+	}
+	next.LastRun = next.LastRun.UTC()
+
+	for _, table := range tables {
+		if table.ZeroUsage || table.FullName == "" {
+			continue
+		}
+		if next.Tables == nil {
+			next.Tables = make(map[string]models.TableUsage)
+		}
+		next.Tables[table.FullName] = models.TableUsage{
+			Reads:      table.Reads,
+			Writes:     table.Writes,
+			Mutations:  table.Mutations,
+			FirstSeen:  table.FirstSeen,
+			LastAccess: table.LastAccess,
+			LastWrite:  table.LastWrite,
+		}
+	}
+	return next
+}
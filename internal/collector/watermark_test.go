@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+func TestWatermarkRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "watermark.json")
+
+	missing, err := LoadWatermark(path)
+	if err != nil || missing != nil {
+		t.Fatalf("expected nil watermark for missing file, got %+v, %v", missing, err)
+	}
+
+	lastRun := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := &Watermark{
+		LastRun: lastRun,
+		Tables: map[string]models.TableUsage{
+			"db.events": {Reads: 10, Writes: 2, FirstSeen: lastRun.Add(-time.Hour), LastAccess: lastRun},
+		},
+	}
+	if err := SaveWatermark(path, want); err != nil {
+		t.Fatalf("SaveWatermark failed: %v", err)
+	}
+
+	got, err := LoadWatermark(path)
+	if err != nil {
+		t.Fatalf("LoadWatermark failed: %v", err)
+	}
+	if !got.LastRun.Equal(lastRun) {
+		t.Fatalf("expected last run %v, got %v", lastRun, got.LastRun)
+	}
+	usage := got.Tables["db.events"]
+	if usage.Reads != 10 || usage.Writes != 2 || !usage.LastAccess.Equal(lastRun) {
+		t.Fatalf("unexpected table usage after round trip: %+v", usage)
+	}
+}
+
+func TestNextWatermark(t *testing.T) {
+	now := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	prevRun := now.Add(-24 * time.Hour)
+	newest := now.Add(-time.Hour)
+	entries := []*models.QueryLogEntry{
+		{EventTime: newest.Add(-time.Hour)},
+		{EventTime: newest},
+	}
+
+	tests := []struct {
+		name    string
+		prev    *Watermark
+		entries []*models.QueryLogEntry
+		want    time.Time
+	}{
+		{name: "advances to newest entry", prev: &Watermark{LastRun: prevRun}, entries: entries, want: newest},
+		{name: "keeps previous mark without new entries", prev: &Watermark{LastRun: prevRun}, want: prevRun},
+		{name: "first run with entries", entries: entries, want: newest},
+		{name: "first run without entries", want: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextWatermark(tt.prev, tt.entries, nil, now)
+			if !got.LastRun.Equal(tt.want) {
+				t.Fatalf("expected last run %v, got %v", tt.want, got.LastRun)
+			}
+			if got.Tables != nil {
+				t.Fatalf("expected no table usage, got %v", got.Tables)
+			}
+		})
+	}
+}
+
+func TestNextWatermarkRecordsUsedTables(t *testing.T) {
+	now := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	tables := []models.Table{
+		{FullName: "db.events", Reads: 5, Writes: 1, Mutations: 1, LastAccess: now},
+		{FullName: "db.unused", ZeroUsage: true},
+	}
+
+	got := NextWatermark(nil, nil, tables, now)
+	if len(got.Tables) != 1 {
+		t.Fatalf("expected only used tables to be recorded, got %v", got.Tables)
+	}
+	usage := got.Tables["db.events"]
+	if usage.Reads != 5 || usage.Writes != 1 || usage.Mutations != 1 || !usage.LastAccess.Equal(now) {
+		t.Fatalf("unexpected usage for db.events: %+v", usage)
+	}
+}
@@ -9,26 +9,62 @@ import (
 	"github.com/ppiankov/clickspectre/internal/models"
 )
 
+// EntryProcessor enriches a single query log entry inside a pool worker.
+// Implementations must be safe for concurrent use.
+type EntryProcessor interface {
+	Process(entry *models.QueryLogEntry)
+}
+
+// TableExtractor is the EntryProcessor that parses table references out of
+// the query text into entry.Tables.
+type TableExtractor struct{}
+
+// Process sets entry.Tables, recovering from parser panics with an empty list.
+func (TableExtractor) Process(entry *models.QueryLogEntry) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Debug("panic while extracting tables",
+				slog.String("query_id", entry.QueryID),
+				slog.String("panic", fmt.Sprint(r)),
+			)
+			entry.Tables = []string{}
+		}
+	}()
+	entry.Tables = extractTables(entry.Query)
+}
+
+// poolJob is an entry queued for processing. Results go to done when set,
+// otherwise to the pool's shared results channel.
+type poolJob struct {
+	entry *models.QueryLogEntry
+	done  chan<- *models.QueryLogEntry
+}
+
 // WorkerPool manages concurrent processing of query log entries
 type WorkerPool struct {
-	workers int
-	jobs    chan *models.QueryLogEntry
-	results chan *models.QueryLogEntry
-	errors  chan error
-	wg      sync.WaitGroup
-	ctx     context.Context
-	cancel  context.CancelFunc
-	started bool
-	mu      sync.Mutex
+	workers   int
+	processor EntryProcessor
+	jobs      chan poolJob
+	results   chan *models.QueryLogEntry
+	errors    chan error
+	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+	started   bool
+	mu        sync.Mutex
 }
 
-// NewWorkerPool creates a new worker pool
+// NewWorkerPool creates a new worker pool that extracts table references
 func NewWorkerPool(workers int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
 	return &WorkerPool{
-		workers: workers,
-		jobs:    make(chan *models.QueryLogEntry, workers*2),
-		results: make(chan *models.QueryLogEntry, workers*2),
-		errors:  make(chan error, workers),
+		workers:   workers,
+		processor: TableExtractor{},
+		jobs:      make(chan poolJob, workers*2),
+		results:   make(chan *models.QueryLogEntry, workers*2),
+		errors:    make(chan error, workers),
 	}
 }
 
@@ -72,10 +108,17 @@ func (p *WorkerPool) worker(id int) {
 				return
 			}
 
-			// Process the job
-			// In this case, we're just passing through
-			// but this is where you could do additional processing
-			p.results <- job
+			p.processor.Process(job.entry)
+
+			var out chan<- *models.QueryLogEntry = p.results
+			if job.done != nil {
+				out = job.done
+			}
+			select {
+			case <-p.ctx.Done():
+				return
+			case out <- job.entry:
+			}
 		}
 	}
 }
@@ -85,8 +128,54 @@ func (p *WorkerPool) Submit(entry *models.QueryLogEntry) {
 	select {
 	case <-p.ctx.Done():
 		return
-	case p.jobs <- entry:
+	case p.jobs <- poolJob{entry: entry}:
+	}
+}
+
+// ProcessAll runs every entry through the pool's processor and returns them
+// in completion order, which may differ from the input order. When the pool
+// is not running, entries are processed on the calling goroutine instead.
+func (p *WorkerPool) ProcessAll(entries []*models.QueryLogEntry) ([]*models.QueryLogEntry, error) {
+	p.mu.Lock()
+	running, ctx := p.started, p.ctx
+	p.mu.Unlock()
+
+	if !running {
+		for _, entry := range entries {
+			p.processor.Process(entry)
+		}
+		return entries, nil
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	// Buffered so workers never block on a caller that stopped reading
+	done := make(chan *models.QueryLogEntry, len(entries))
+	submitted := make(chan struct{})
+	go func() {
+		defer close(submitted)
+		for _, entry := range entries {
+			select {
+			case <-ctx.Done():
+				return
+			case p.jobs <- poolJob{entry: entry, done: done}:
+			}
+		}
+	}()
+	// Never return while the submitter can still send, so Stop can close jobs safely
+	defer func() { <-submitted }()
+
+	processed := make([]*models.QueryLogEntry, 0, len(entries))
+	for len(processed) < len(entries) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case entry := <-done:
+			processed = append(processed, entry)
+		}
 	}
+	return processed, nil
 }
 
 // Results returns the results channel
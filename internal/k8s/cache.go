@@ -1,21 +1,29 @@
 package k8s
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 )
 
 // ServiceInfo contains resolved Kubernetes service information
 type ServiceInfo struct {
-	Service   string
-	Namespace string
-	Pod       string
+	Service      string `json:"service"`
+	Namespace    string `json:"namespace"`
+	Pod          string `json:"pod"`
+	WorkloadKind string `json:"workload_kind,omitempty"` // e.g. Deployment, StatefulSet, DaemonSet
+	WorkloadName string `json:"workload_name,omitempty"`
+	LookupFailed bool   `json:"lookup_failed,omitempty"` // The K8s API call failed, so Service is the raw IP
 }
 
 // CacheEntry represents a cached service info with expiration
 type CacheEntry struct {
-	Info      *ServiceInfo
-	ExpiresAt time.Time
+	Info      *ServiceInfo `json:"info"`
+	ExpiresAt time.Time    `json:"expires_at"`
 }
 
 // Cache provides thread-safe caching of IP→Service mappings
@@ -113,3 +121,62 @@ func (c *Cache) Size() int {
 	defer c.mu.RUnlock()
 	return len(c.entries)
 }
+
+// Save writes non-expired entries to path as JSON
+func (c *Cache) Save(path string) error {
+	c.mu.RLock()
+	now := time.Now()
+	live := make(map[string]*CacheEntry, len(c.entries))
+	for ip, entry := range c.entries {
+		if entry.Info != nil && now.Before(entry.ExpiresAt) {
+			live[ip] = entry
+		}
+	}
+	c.mu.RUnlock()
+
+	data, err := json.MarshalIndent(live, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal k8s cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create k8s cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write k8s cache file: %w", err)
+	}
+	return nil
+}
+
+// Load reads entries saved by Save, skipping expired ones. A missing file
+// is not an error. It returns the number of entries loaded.
+func (c *Cache) Load(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read k8s cache file: %w", err)
+	}
+
+	var saved map[string]*CacheEntry
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, fmt.Errorf("failed to parse k8s cache file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	loaded := 0
+	for ip, entry := range saved {
+		if entry == nil || entry.Info == nil || !now.Before(entry.ExpiresAt) {
+			continue
+		}
+		if len(c.entries) >= c.maxSize {
+			break
+		}
+		c.entries[ip] = entry
+		loaded++
+	}
+	return loaded, nil
+}
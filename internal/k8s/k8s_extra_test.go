@@ -2,6 +2,9 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -69,6 +72,107 @@ func TestCacheGetExpiredAndEviction(t *testing.T) {
 	}
 }
 
+func TestCacheSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "k8s.json")
+
+	cache := NewCache(time.Hour)
+	cache.Set("10.0.0.1", &ServiceInfo{Service: "api", Namespace: "prod", Pod: "api-0"})
+	cache.Set("10.0.0.2", &ServiceInfo{Service: "worker", Namespace: "prod", Pod: "worker-1"})
+	if err := cache.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	restored := NewCache(time.Hour)
+	loaded, err := restored.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded != 2 {
+		t.Fatalf("expected 2 entries loaded, got %d", loaded)
+	}
+	got := restored.Get("10.0.0.1")
+	if got == nil || got.Service != "api" || got.Namespace != "prod" || got.Pod != "api-0" {
+		t.Fatalf("unexpected restored entry: %+v", got)
+	}
+	if restored.entries["10.0.0.1"].ExpiresAt.IsZero() {
+		t.Fatal("expected restored entry to keep its expiry")
+	}
+}
+
+func TestCacheLoadDropsExpiredEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k8s.json")
+
+	cache := NewCache(time.Hour)
+	cache.entries["10.0.0.1"] = &CacheEntry{
+		Info:      &ServiceInfo{Service: "fresh"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	cache.entries["10.0.0.2"] = &CacheEntry{
+		Info:      &ServiceInfo{Service: "stale"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := cache.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Expire one entry on disk after it was saved
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read cache file: %v", err)
+	}
+	var saved map[string]*CacheEntry
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("failed to parse cache file: %v", err)
+	}
+	saved["10.0.0.2"].ExpiresAt = time.Now().Add(-time.Minute)
+	data, _ = json.Marshal(saved)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to rewrite cache file: %v", err)
+	}
+
+	restored := NewCache(time.Hour)
+	loaded, err := restored.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded != 1 || restored.Size() != 1 {
+		t.Fatalf("expected only the fresh entry to load, got loaded=%d size=%d", loaded, restored.Size())
+	}
+	if restored.Get("10.0.0.2") != nil {
+		t.Fatal("expected expired entry to be dropped on load")
+	}
+}
+
+func TestCacheLoadMissingFile(t *testing.T) {
+	cache := NewCache(time.Hour)
+	loaded, err := cache.Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("expected no error for missing cache file, got %v", err)
+	}
+	if loaded != 0 {
+		t.Fatalf("expected 0 entries, got %d", loaded)
+	}
+}
+
+func TestResolverCloseSavesCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k8s.json")
+	resolver := newTestResolver()
+	resolver.config.K8sCacheFile = path
+	resolver.cache.Set("10.0.0.7", &ServiceInfo{Service: "svc", Namespace: "ns"})
+
+	if err := resolver.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	restored := NewCache(time.Hour)
+	if _, err := restored.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := restored.Get("10.0.0.7"); got == nil || got.Service != "svc" {
+		t.Fatalf("expected saved entry for 10.0.0.7, got %+v", got)
+	}
+}
+
 func TestRateLimiterAllow(t *testing.T) {
 	limiter := NewRateLimiter(1)
 
@@ -2,11 +2,14 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/ppiankov/clickspectre/pkg/config"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -14,6 +17,7 @@ import (
 // K8sResolverInterface defines the interface for Kubernetes IP resolution
 type K8sResolverInterface interface {
 	ResolveIP(ctx context.Context, ip string) (*ServiceInfo, error)
+	ResolveIPs(ctx context.Context, ips []string) (map[string]*ServiceInfo, error)
 	Close() error
 }
 
@@ -36,6 +40,17 @@ func NewResolver(cfg *config.Config) (*Resolver, error) {
 	}
 
 	cache := NewCache(cfg.K8sCacheTTL)
+	if cfg.K8sCacheFile != "" {
+		loaded, err := cache.Load(cfg.K8sCacheFile)
+		if err != nil {
+			slog.Warn("ignoring k8s cache file",
+				slog.String("path", cfg.K8sCacheFile),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			slog.Debug("loaded k8s cache", slog.String("path", cfg.K8sCacheFile), slog.Int("entries", loaded))
+		}
+	}
 	rateLimiter := NewRateLimiter(cfg.K8sRateLimit)
 
 	return &Resolver{
@@ -72,12 +87,16 @@ func (r *Resolver) ResolveIP(ctx context.Context, ip string) (*ServiceInfo, erro
 			slog.String("error", err.Error()),
 		)
 		fallback := &ServiceInfo{
-			Service:   ip,
-			Namespace: "",
-			Pod:       "",
+			Service:      ip,
+			Namespace:    "",
+			Pod:          "",
+			LookupFailed: !errors.Is(err, ErrNotFound),
+		}
+		// Cache a missing workload to avoid repeated lookups, but retry
+		// after an API failure
+		if !fallback.LookupFailed {
+			r.cache.Set(ip, fallback)
 		}
-		// Cache the fallback too to avoid repeated lookups
-		r.cache.Set(ip, fallback)
 		return fallback, nil
 	}
 
@@ -94,6 +113,126 @@ func (r *Resolver) ResolveIP(ctx context.Context, ip string) (*ServiceInfo, erro
 	return info, nil
 }
 
+// ResolveIPs resolves many IP addresses at once. Instead of per-IP lookups it
+// lists all pods and services once and matches every uncached IP in memory.
+// Unresolvable IPs fall back to the raw IP, as with ResolveIP. When the
+// listing fails, nothing is resolved and the error is returned, so callers
+// can fall back to per-IP lookups.
+func (r *Resolver) ResolveIPs(ctx context.Context, ips []string) (map[string]*ServiceInfo, error) {
+	result := make(map[string]*ServiceInfo, len(ips))
+	var pending []string
+	for _, ip := range ips {
+		if _, seen := result[ip]; seen {
+			continue
+		}
+		if cached := r.cache.Get(ip); cached != nil {
+			result[ip] = cached
+			continue
+		}
+		result[ip] = nil
+		pending = append(pending, ip)
+	}
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	pods, services, err := r.listPodsAndServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("batch resolution of %d IPs failed: %w", len(pending), err)
+	}
+
+	owners := make(workloadCache)
+	podsByIP := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.PodIP == "" {
+			continue
+		}
+		// Host-network pods share the node IP; prefer a pod-network match
+		if existing, found := podsByIP[pod.Status.PodIP]; found && !existing.Spec.HostNetwork {
+			continue
+		}
+		podsByIP[pod.Status.PodIP] = pod
+	}
+
+	for _, ip := range pending {
+		cleanIP := stripIPv6MappedPrefix(ip)
+
+		var info *ServiceInfo
+		if pod, found := podsByIP[cleanIP]; found {
+			info = r.podServiceInfo(ctx, pod, services, owners)
+		} else if svc := serviceByIP(services, cleanIP); svc != nil {
+			info = &ServiceInfo{Service: svc.Name, Namespace: svc.Namespace}
+		} else {
+			slog.Debug("no pod or service found, falling back to raw IP", slog.String("ip", ip))
+			info = &ServiceInfo{Service: ip}
+		}
+
+		r.cache.Set(ip, info)
+		result[ip] = info
+	}
+
+	slog.Debug("batch resolved IPs",
+		slog.Int("requested", len(ips)),
+		slog.Int("looked_up", len(pending)),
+	)
+
+	return result, nil
+}
+
+// listPodsAndServices lists all pods and services across namespaces
+func (r *Resolver) listPodsAndServices(ctx context.Context) ([]corev1.Pod, []corev1.Service, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := r.rateLimiter.Wait(queryCtx); err != nil {
+		return nil, nil, fmt.Errorf("rate limiter wait failed: %w", err)
+	}
+	pods, err := r.client.Clientset().CoreV1().Pods("").List(queryCtx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	if err := r.rateLimiter.Wait(queryCtx); err != nil {
+		return nil, nil, fmt.Errorf("rate limiter wait failed: %w", err)
+	}
+	services, err := r.client.Clientset().CoreV1().Services("").List(queryCtx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	return pods.Items, services.Items, nil
+}
+
+// serviceByIP returns the service exposing ip as ClusterIP, LoadBalancer or External IP
+func serviceByIP(services []corev1.Service, ip string) *corev1.Service {
+	for i := range services {
+		svc := &services[i]
+		if svc.Spec.ClusterIP == ip {
+			return svc
+		}
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.IP == ip {
+				return svc
+			}
+		}
+		for _, externalIP := range svc.Spec.ExternalIPs {
+			if externalIP == ip {
+				return svc
+			}
+		}
+	}
+	return nil
+}
+
+// stripIPv6MappedPrefix converts ::ffff:10.0.1.100 to 10.0.1.100
+func stripIPv6MappedPrefix(ip string) string {
+	if len(ip) > 7 && ip[:7] == "::ffff:" {
+		return ip[7:]
+	}
+	return ip
+}
+
 // queryK8sAPI queries the Kubernetes API for pod information by IP
 func (r *Resolver) queryK8sAPI(ctx context.Context, ip string) (*ServiceInfo, error) {
 	// Set timeout for K8s API call
@@ -136,41 +275,148 @@ func (r *Resolver) queryK8sAPI(ctx context.Context, ip string) (*ServiceInfo, er
 		)
 		return serviceInfo, nil
 	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
 
 	// No pod or service found
-	return nil, fmt.Errorf("no pod or service found with IP %s", cleanIP)
+	return nil, fmt.Errorf("%w %s", ErrNotFound, cleanIP)
 }
 
 // resolvePodToService resolves a pod to its owning service
 func (r *Resolver) resolvePodToService(ctx context.Context, pod *corev1.Pod) (*ServiceInfo, error) {
-	// Try to find the owning service
-	serviceName := ""
-	labels := pod.Labels
-
-	if len(labels) > 0 {
+	var services []corev1.Service
+	if len(pod.Labels) > 0 {
 		// Query services in the same namespace
-		services, err := r.client.Clientset().CoreV1().Services(pod.Namespace).List(ctx, metav1.ListOptions{})
+		list, err := r.client.Clientset().CoreV1().Services(pod.Namespace).List(ctx, metav1.ListOptions{})
 		if err == nil {
-			// Find service that matches pod labels
-			for _, svc := range services.Items {
-				if matchesSelector(labels, svc.Spec.Selector) {
-					serviceName = svc.Name
-					break
-				}
-			}
+			services = list.Items
 		}
 	}
 
-	// If no service found, use pod name
-	if serviceName == "" {
-		serviceName = pod.Name
-	}
+	return r.podServiceInfo(ctx, pod, services, nil), nil
+}
 
-	return &ServiceInfo{
-		Service:   serviceName,
+// podServiceInfo builds the ServiceInfo for a pod from the given services,
+// which may span namespaces. Pods no Service selects fall back to their
+// owning workload, then the pod name. owners, when not nil, memoizes owner
+// lookups across the pods of a batch.
+func (r *Resolver) podServiceInfo(ctx context.Context, pod *corev1.Pod, services []corev1.Service, owners workloadCache) *ServiceInfo {
+	info := &ServiceInfo{
 		Namespace: pod.Namespace,
 		Pod:       pod.Name,
-	}, nil
+	}
+
+	// Find service that matches pod labels
+	if len(pod.Labels) > 0 {
+		for _, svc := range services {
+			if svc.Namespace == pod.Namespace && matchesSelector(pod.Labels, svc.Spec.Selector) {
+				info.Service = svc.Name
+				return info
+			}
+		}
+	}
+
+	info.WorkloadKind, info.WorkloadName = r.resolvePodOwner(ctx, pod, owners)
+	if info.WorkloadName != "" {
+		info.Service = info.WorkloadName
+	} else {
+		info.Service = pod.Name
+	}
+	return info
+}
+
+// workloadRef is the kind and name of a pod's top-level workload.
+type workloadRef struct {
+	kind string
+	name string
+}
+
+// workloadCache maps a namespace/kind/name owner reference to its top-level
+// workload, so the pods of one ReplicaSet or Job cost a single GET.
+type workloadCache map[string]workloadRef
+
+// resolvePodOwner walks a pod's controller references up to its top-level
+// workload (ReplicaSet→Deployment, StatefulSet, DaemonSet, Job→CronJob).
+// It returns empty strings for pods without an owner. Lookups are memoized
+// in owners when it is not nil.
+func (r *Resolver) resolvePodOwner(ctx context.Context, pod *corev1.Pod, owners workloadCache) (kind string, name string) {
+	owner := controllerRef(pod.OwnerReferences)
+	if owner == nil {
+		return "", ""
+	}
+	if owner.Kind != "ReplicaSet" && owner.Kind != "Job" {
+		return owner.Kind, owner.Name
+	}
+
+	key := pod.Namespace + "/" + owner.Kind + "/" + owner.Name
+	if ref, ok := owners[key]; ok {
+		return ref.kind, ref.name
+	}
+	ref := r.lookupWorkload(ctx, pod.Namespace, owner)
+	if owners != nil {
+		owners[key] = ref
+	}
+	return ref.kind, ref.name
+}
+
+// lookupWorkload fetches a ReplicaSet or Job owner and returns its
+// controller, or the owner itself when it has none or the lookup fails.
+func (r *Resolver) lookupWorkload(ctx context.Context, namespace string, owner *metav1.OwnerReference) workloadRef {
+	ref := workloadRef{kind: owner.Kind, name: owner.Name}
+	if err := r.rateLimiter.Wait(ctx); err != nil {
+		slog.Debug("rate limiter wait failed",
+			slog.String("namespace", namespace),
+			slog.String("kind", owner.Kind),
+			slog.String("name", owner.Name),
+			slog.String("error", err.Error()),
+		)
+		return ref
+	}
+
+	var (
+		parents []metav1.OwnerReference
+		err     error
+	)
+	switch owner.Kind {
+	case "ReplicaSet":
+		var rs *appsv1.ReplicaSet
+		if rs, err = r.client.Clientset().AppsV1().ReplicaSets(namespace).Get(ctx, owner.Name, metav1.GetOptions{}); err == nil {
+			parents = rs.OwnerReferences
+		}
+	case "Job":
+		var job *batchv1.Job
+		if job, err = r.client.Clientset().BatchV1().Jobs(namespace).Get(ctx, owner.Name, metav1.GetOptions{}); err == nil {
+			parents = job.OwnerReferences
+		}
+	}
+	if err != nil {
+		slog.Debug("failed to get pod owner",
+			slog.String("namespace", namespace),
+			slog.String("kind", owner.Kind),
+			slog.String("name", owner.Name),
+			slog.String("error", err.Error()),
+		)
+		return ref
+	}
+
+	if parent := controllerRef(parents); parent != nil {
+		return workloadRef{kind: parent.Kind, name: parent.Name}
+	}
+	return ref
+}
+
+// controllerRef returns the managing controller reference, or the first owner if none is marked
+func controllerRef(refs []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return &refs[i]
+		}
+	}
+	if len(refs) > 0 {
+		return &refs[0]
+	}
+	return nil
 }
 
 // findServiceByIP searches for a service with the given IP
@@ -230,7 +476,7 @@ func (r *Resolver) findServiceByIP(ctx context.Context, ip string) (*ServiceInfo
 		}
 	}
 
-	return nil, fmt.Errorf("no service found with IP %s", ip)
+	return nil, fmt.Errorf("no service found with IP %s: %w", ip, ErrNotFound)
 }
 
 // matchesSelector checks if pod labels match service selector
@@ -250,6 +496,13 @@ func matchesSelector(podLabels, svcSelector map[string]string) bool {
 
 // Close closes the resolver (currently a no-op)
 func (r *Resolver) Close() error {
-	// Nothing to close currently
+	// Persist the cache for the next run (if enabled)
+	if r.config == nil || r.config.K8sCacheFile == "" {
+		return nil
+	}
+	if err := r.cache.Save(r.config.K8sCacheFile); err != nil {
+		return err
+	}
+	slog.Debug("saved k8s cache", slog.String("path", r.config.K8sCacheFile), slog.Int("entries", r.cache.Size()))
 	return nil
 }
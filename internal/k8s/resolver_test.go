@@ -3,10 +3,14 @@ package k8s
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ppiankov/clickspectre/pkg/config"
+	"golang.org/x/time/rate"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -102,7 +106,71 @@ func TestResolveIP(t *testing.T) {
 					return true, nil, errors.New("boom")
 				})
 			},
-			want: ServiceInfo{Service: "10.0.0.4", Namespace: "", Pod: ""},
+			want: ServiceInfo{Service: "10.0.0.4", Namespace: "", Pod: "", LookupFailed: true},
+		},
+		{
+			name: "fallback_on_not_found",
+			ip:   "10.0.0.8",
+			want: ServiceInfo{Service: "10.0.0.8", Namespace: "", Pod: ""},
+		},
+		{
+			name: "pod_without_service_resolves_to_deployment",
+			ip:   "10.0.0.5",
+			objects: []runtime.Object{
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "api-7d9f8-abcde",
+						Namespace: "ns1",
+						Labels:    map[string]string{"app": "api"},
+						OwnerReferences: []metav1.OwnerReference{
+							{Kind: "ReplicaSet", Name: "api-7d9f8", Controller: boolPtr(true)},
+						},
+					},
+					Status: corev1.PodStatus{PodIP: "10.0.0.5"},
+				},
+				&appsv1.ReplicaSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "api-7d9f8",
+						Namespace: "ns1",
+						OwnerReferences: []metav1.OwnerReference{
+							{Kind: "Deployment", Name: "api", Controller: boolPtr(true)},
+						},
+					},
+				},
+			},
+			want: ServiceInfo{Service: "api", Namespace: "ns1", Pod: "api-7d9f8-abcde", WorkloadKind: "Deployment", WorkloadName: "api"},
+		},
+		{
+			name: "pod_without_service_resolves_to_statefulset",
+			ip:   "10.0.0.6",
+			objects: []runtime.Object{
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "db-0",
+						Namespace: "ns1",
+						OwnerReferences: []metav1.OwnerReference{
+							{Kind: "StatefulSet", Name: "db", Controller: boolPtr(true)},
+						},
+					},
+					Status: corev1.PodStatus{PodIP: "10.0.0.6"},
+				},
+			},
+			want: ServiceInfo{Service: "db", Namespace: "ns1", Pod: "db-0", WorkloadKind: "StatefulSet", WorkloadName: "db"},
+		},
+		{
+			name: "pod_without_owner_falls_back_to_pod_name",
+			ip:   "10.0.0.7",
+			objects: []runtime.Object{
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "debug-shell",
+						Namespace: "ns1",
+						Labels:    map[string]string{"app": "debug"},
+					},
+					Status: corev1.PodStatus{PodIP: "10.0.0.7"},
+				},
+			},
+			want: ServiceInfo{Service: "debug-shell", Namespace: "ns1", Pod: "debug-shell"},
 		},
 	}
 
@@ -127,9 +195,177 @@ func TestResolveIP(t *testing.T) {
 			if err != nil {
 				t.Fatalf("ResolveIP failed: %v", err)
 			}
-			if got.Service != tc.want.Service || got.Namespace != tc.want.Namespace || got.Pod != tc.want.Pod {
+			if *got != tc.want {
 				t.Fatalf("expected %+v, got %+v", tc.want, *got)
 			}
 		})
 	}
 }
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func TestResolveIPsBatchesListCalls(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "api-0",
+				Namespace: "ns1",
+				Labels:    map[string]string{"app": "api"},
+			},
+			Status: corev1.PodStatus{PodIP: "10.0.0.1"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "ns1"},
+			Spec: corev1.ServiceSpec{
+				Selector:  map[string]string{"app": "api"},
+				ClusterIP: "10.96.0.10",
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "ns2"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.20"},
+		},
+	)
+
+	resolver := &Resolver{
+		client:      &Client{clientset: client},
+		cache:       NewCache(time.Minute),
+		rateLimiter: NewRateLimiter(1000),
+		config:      config.DefaultConfig(),
+	}
+
+	got, err := resolver.ResolveIPs(context.Background(), []string{"::ffff:10.0.0.1", "10.96.0.20", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("ResolveIPs failed: %v", err)
+	}
+
+	want := map[string]ServiceInfo{
+		"::ffff:10.0.0.1": {Service: "api", Namespace: "ns1", Pod: "api-0"},
+		"10.96.0.20":      {Service: "db", Namespace: "ns2"},
+		"192.168.1.1":     {Service: "192.168.1.1"},
+	}
+	for ip, expected := range want {
+		info := got[ip]
+		if info == nil || *info != expected {
+			t.Fatalf("expected %s to resolve to %+v, got %+v", ip, expected, info)
+		}
+	}
+
+	lists := map[string]int{}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "list" {
+			lists[action.GetResource().Resource]++
+		}
+	}
+	if lists["pods"] != 1 || lists["services"] != 1 || len(lists) != 2 {
+		t.Fatalf("expected one list each of pods and services, got %v", lists)
+	}
+
+	// A second batch is served entirely from cache
+	client.ClearActions()
+	if _, err := resolver.ResolveIPs(context.Background(), []string{"::ffff:10.0.0.1", "192.168.1.1"}); err != nil {
+		t.Fatalf("ResolveIPs (cached) failed: %v", err)
+	}
+	if n := len(client.Actions()); n != 0 {
+		t.Fatalf("expected no API calls for cached IPs, got %d", n)
+	}
+}
+
+func TestResolveIPsLooksUpEachPodOwnerOnce(t *testing.T) {
+	objects := []runtime.Object{
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "worker-5c6d7",
+				Namespace: "ns1",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Deployment", Name: "worker", Controller: boolPtr(true)},
+				},
+			},
+		},
+	}
+	var ips []string
+	for i := 1; i <= 3; i++ {
+		ip := fmt.Sprintf("10.0.1.%d", i)
+		ips = append(ips, ip)
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("worker-5c6d7-%d", i),
+				Namespace: "ns1",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "ReplicaSet", Name: "worker-5c6d7", Controller: boolPtr(true)},
+				},
+			},
+			Status: corev1.PodStatus{PodIP: ip},
+		})
+	}
+	client := fake.NewSimpleClientset(objects...)
+
+	// A limiter that never refills during the test counts the calls it admits
+	limiter := &RateLimiter{limiter: rate.NewLimiter(rate.Limit(0.001), 10)}
+	resolver := &Resolver{
+		client:      &Client{clientset: client},
+		cache:       NewCache(time.Minute),
+		rateLimiter: limiter,
+		config:      config.DefaultConfig(),
+	}
+
+	got, err := resolver.ResolveIPs(context.Background(), ips)
+	if err != nil {
+		t.Fatalf("ResolveIPs failed: %v", err)
+	}
+	for _, ip := range ips {
+		if info := got[ip]; info == nil || info.WorkloadKind != "Deployment" || info.WorkloadName != "worker" {
+			t.Fatalf("expected %s to resolve to Deployment worker, got %+v", ip, info)
+		}
+	}
+
+	gets := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "get" {
+			gets++
+		}
+	}
+	if gets != 1 {
+		t.Fatalf("expected one ReplicaSet GET for three pods, got %d", gets)
+	}
+	// Two listings and the GET each wait on the limiter
+	if tokens := limiter.limiter.Tokens(); tokens < 6.5 || tokens > 7.5 {
+		t.Fatalf("expected 3 rate limiter waits, %v of 10 tokens left", tokens)
+	}
+}
+
+func TestResolveIPsReturnsListError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// Only the unfiltered batch listing fails; per-IP lookups filter by IP
+	client.PrependReactor("list", "pods", func(action testingcore.Action) (bool, runtime.Object, error) {
+		if action.(testingcore.ListAction).GetListRestrictions().Fields.Empty() {
+			return true, nil, errors.New("boom")
+		}
+		return false, nil, nil
+	})
+
+	resolver := &Resolver{
+		client:      &Client{clientset: client},
+		cache:       NewCache(time.Minute),
+		rateLimiter: NewRateLimiter(1000),
+		config:      config.DefaultConfig(),
+	}
+
+	// The error is what lets the analyzer fall back to per-IP lookups
+	if _, err := resolver.ResolveIPs(context.Background(), []string{"10.0.0.9"}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the listing error, got %v", err)
+	}
+	if cached := resolver.cache.Get("10.0.0.9"); cached != nil {
+		t.Fatalf("expected failed lookup not to be cached, got %+v", cached)
+	}
+
+	info, err := resolver.ResolveIP(context.Background(), "10.0.0.9")
+	if err != nil {
+		t.Fatalf("ResolveIP failed: %v", err)
+	}
+	if info.Service != "10.0.0.9" || info.LookupFailed {
+		t.Fatalf("expected the per-IP fallback to find no workload, got %+v", info)
+	}
+}
@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // QueryLogEntry represents a single entry from system.query_log
 type QueryLogEntry struct {
@@ -13,8 +20,10 @@ type QueryLogEntry struct {
 	ClientIP    string
 	ReadRows    uint64
 	WrittenRows uint64
+	ResultRows  uint64 // Rows the query returned
 	Duration    time.Duration
 	Exception   string
+	LogComment  string   // log_comment setting the client tagged the query with
 	Tables      []string // Extracted from query
 }
 
@@ -29,26 +38,63 @@ type UserActivity struct {
 
 // Table represents a ClickHouse table with usage stats
 type Table struct {
-	Name         string            `json:"name"`
-	Database     string            `json:"database"`
-	FullName     string            `json:"full_name"` // "db.table"
-	Reads        uint64            `json:"reads"`
-	Writes       uint64            `json:"writes"`
-	LastAccess   time.Time         `json:"last_access"`
-	FirstSeen    time.Time         `json:"first_seen"`
-	Sparkline    []TimeSeriesPoint `json:"sparkline"`
-	Score        float64           `json:"score"`
-	Category     string            `json:"category"` // "active", "unused", "suspect"
-	IsMV         bool              `json:"is_materialized_view"`
-	MVDependency []string          `json:"mv_dependencies,omitempty"`
+	Name             string            `json:"name"`
+	Database         string            `json:"database"`
+	FullName         string            `json:"full_name"` // "db.table"
+	Reads            uint64            `json:"reads"`
+	Writes           uint64            `json:"writes"`
+	Mutations        uint64            `json:"mutations"`                // ALTER/UPDATE/DELETE queries, counted even when they write no rows
+	FailedQueries    uint64            `json:"failed_queries,omitempty"` // Queries that ended in an exception
+	ErrorRate        float64           `json:"error_rate,omitempty"`     // FailedQueries / all queries touching the table
+	DistinctServices int               `json:"distinct_services"`        // Distinct services (K8s service name, else client IP) with an edge to the table
+	TopConsumers     []EdgeSummary     `json:"top_consumers,omitempty"`  // Services with the most reads plus writes, busiest first, capped by --top-consumers
+	LastAccess       time.Time         `json:"last_access"`
+	LastWrite        time.Time         `json:"last_write"` // Last successful write or mutation; zero without one
+	FirstSeen        time.Time         `json:"first_seen"`
+	Sparkline        []TimeSeriesPoint `json:"sparkline"`
+	Heatmap          *AccessHeatmap    `json:"heatmap,omitempty"` // Queries by weekday and hour; nil without queries
+	Score            float64           `json:"score"`
+	Category         string            `json:"category"` // "active", "unused", "suspect"
+	IsMV             bool              `json:"is_materialized_view"`
+	MVDependency     []string          `json:"mv_dependencies,omitempty"` // Views that read this table, from system.tables dependencies
+	MVSources        []string          `json:"mv_sources,omitempty"`      // Tables a materialized view reads from, parsed from its CREATE query
+	SampleQueries    []string          `json:"sample_queries,omitempty"`  // Distinct example queries, kept with --keep-sample-queries
 
 	// New fields for unused table detection
-	Engine       string    `json:"engine,omitempty"`      // "MergeTree", "ReplicatedMergeTree", etc.
-	IsReplicated bool      `json:"is_replicated"`         // Derived from engine name
-	TotalBytes   uint64    `json:"total_bytes,omitempty"` // Table size in bytes
-	TotalRows    uint64    `json:"total_rows,omitempty"`  // Row count
-	CreateTime   time.Time `json:"create_time,omitempty"` // Table creation time
-	ZeroUsage    bool      `json:"zero_usage"`            // Flag: no queries in lookback period
+	Engine           string    `json:"engine,omitempty"`            // "MergeTree", "ReplicatedMergeTree", etc.
+	IsReplicated     bool      `json:"is_replicated"`               // Derived from engine name
+	TotalBytes       uint64    `json:"total_bytes,omitempty"`       // Table size in bytes
+	TotalRows        uint64    `json:"total_rows,omitempty"`        // Row count
+	MetadataModified time.Time `json:"metadata_modified,omitempty"` // system.tables metadata_modification_time: creation or last ALTER
+	ZeroUsage        bool      `json:"zero_usage"`                  // Flag: no queries in lookback period
+
+	PartitionKey  string         `json:"partition_key,omitempty"`  // system.tables partition_key expression
+	Partitions    []string       `json:"-"`                        // Active partition IDs, fetched with --partition-heat
+	PartitionHeat *PartitionHeat `json:"partition_heat,omitempty"` // Estimated with --partition-heat; nil when not estimated
+}
+
+// PartitionHeat is a heuristic split of a date-partitioned table's active
+// partitions into those its reads filtered on and those no read reached.
+// It is estimated from literal date filters on the partition key column in
+// the query text, not from the parts ClickHouse actually read, so reads
+// whose filter could not be parsed are only counted in UnfilteredReads and
+// may in fact touch cold partitions.
+type PartitionHeat struct {
+	Estimated       bool     `json:"estimated"` // Always true, so consumers do not mistake it for measured access
+	HotPartitions   []string `json:"hot_partitions"`
+	ColdPartitions  []string `json:"cold_partitions"`
+	FilteredReads   uint64   `json:"filtered_reads"`   // Reads with a recognized date filter on the partition key
+	UnfilteredReads uint64   `json:"unfiltered_reads"` // Reads without one; they may touch any partition
+}
+
+// TableUsage is a table's query activity accumulated across incremental runs.
+type TableUsage struct {
+	Reads      uint64    `json:"reads"`
+	Writes     uint64    `json:"writes"`
+	Mutations  uint64    `json:"mutations,omitempty"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastAccess time.Time `json:"last_access"`
+	LastWrite  time.Time `json:"last_write"`
 }
 
 // Service represents a Kubernetes service or raw IP
@@ -64,22 +110,74 @@ type Service struct {
 
 // Edge represents a Service→Table relationship
 type Edge struct {
-	ServiceIP    string    `json:"service"`
-	ServiceName  string    `json:"service_name,omitempty"`
-	TableName    string    `json:"table"`
-	Reads        uint64    `json:"reads"`
-	Writes       uint64    `json:"writes"`
-	LastActivity time.Time `json:"last_activity"`
+	ServiceIP     string    `json:"service"`
+	ServiceName   string    `json:"service_name,omitempty"`
+	TableName     string    `json:"table"`
+	Reads         uint64    `json:"reads"`
+	Writes        uint64    `json:"writes"`
+	FirstActivity time.Time `json:"first_activity"`
+	LastActivity  time.Time `json:"last_activity"`
+	// KindCounts counts queries on the edge by query kind, e.g. SELECT: 200
+	KindCounts map[string]uint64 `json:"kind_counts,omitempty"`
+}
+
+// EdgeSummary is one service's traffic to a table, summed over its edges
+type EdgeSummary struct {
+	Service string `json:"service"` // K8s service name, else client IP
+	Reads   uint64 `json:"reads"`
+	Writes  uint64 `json:"writes"`
 }
 
 // Anomaly represents unusual access patterns
 type Anomaly struct {
+	ID              string    `json:"id"` // AnomalyID of Type (plus the rule for policy_violation), AffectedTable, and AffectedService
 	Type            string    `json:"type"`
 	Description     string    `json:"description"`
 	Severity        string    `json:"severity"` // "low", "medium", "high"
 	AffectedTable   string    `json:"affected_table,omitempty"`
 	AffectedService string    `json:"affected_service,omitempty"`
 	DetectedAt      time.Time `json:"detected_at"`
+	InBaseline      *bool     `json:"in_baseline,omitempty"` // Whether --baseline covers the anomaly; set only with --compare-baseline
+	// Metrics holds the measurements behind the anomaly, e.g. ratio: 6.2.
+	// They change from run to run, so they are kept out of Description,
+	// which baselines fingerprint.
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// MetricsText formats the anomaly's metrics as "name=value" pairs sorted by
+// name, or returns "" when it has none.
+func (a Anomaly) MetricsText() string {
+	names := make([]string, 0, len(a.Metrics))
+	for name := range a.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + strconv.FormatFloat(a.Metrics[name], 'f', -1, 64)
+	}
+	return strings.Join(pairs, " ")
+}
+
+// AnomalyID returns a deterministic identifier for an anomaly, so the same
+// condition on the same table or service keeps its ID across runs. It does
+// not depend on the description or detection time.
+func AnomalyID(anomalyType, table, service string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{anomalyType, table, service}, "\x1f")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// AccessHeatmap counts a table's queries by day of week and hour of day in
+// the report's timezone (Metadata.Timezone). Rows are indexed by
+// time.Weekday (Sunday = 0), columns by hour (0-23). Unlike the sparkline it
+// folds the whole lookback window, so business-hours or weekly batch access
+// stands out.
+type AccessHeatmap [7][24]uint64
+
+// Add counts one query at t, converted to loc.
+func (h *AccessHeatmap) Add(t time.Time, loc *time.Location) {
+	t = t.In(loc)
+	h[t.Weekday()][t.Hour()]++
 }
 
 // TimeSeriesPoint for sparkline visualization
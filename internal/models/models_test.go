@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -105,3 +106,77 @@ func TestReportJSONTags(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeReportSchemaVersion(t *testing.T) {
+	current, err := json.Marshal(Report{SchemaVersion: ReportSchemaVersion, Tool: "clickspectre"})
+	if err != nil {
+		t.Fatalf("failed to marshal report: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "current", input: string(current)},
+		{name: "legacy_unversioned", input: `{"tool":"clickspectre","tables":[]}`},
+		{name: "bumped", input: `{"schema_version":2,"tool":"clickspectre"}`, wantErr: true},
+		{name: "unknown", input: `{"schema_version":-1,"tool":"clickspectre"}`, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := DecodeReport(strings.NewReader(tc.input))
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if report.Tool != "clickspectre" {
+					t.Fatalf("expected decoded tool, got %+v", report)
+				}
+				return
+			}
+			var versionErr *SchemaVersionError
+			if !errors.As(err, &versionErr) {
+				t.Fatalf("expected *SchemaVersionError, got %v", err)
+			}
+			if versionErr.Want != ReportSchemaVersion {
+				t.Fatalf("expected wanted version %d, got %d", ReportSchemaVersion, versionErr.Want)
+			}
+		})
+	}
+
+	if _, err := DecodeReport(strings.NewReader("{not json")); err == nil {
+		t.Fatal("expected error for malformed JSON")
+	}
+}
+
+func TestAnomalyMetricsText(t *testing.T) {
+	if got := (Anomaly{}).MetricsText(); got != "" {
+		t.Fatalf("expected no metrics text, got %q", got)
+	}
+	anomaly := Anomaly{Metrics: map[string]float64{"ratio": 6.2, "queries": 1200000}}
+	if got, want := anomaly.MetricsText(), "queries=1200000 ratio=6.2"; got != want {
+		t.Fatalf("MetricsText() = %q, want %q", got, want)
+	}
+}
+
+func TestAnomalyID(t *testing.T) {
+	id := AnomalyID("stale_table", "db.events", "")
+	if id != AnomalyID("stale_table", "db.events", "") {
+		t.Fatal("expected AnomalyID to be deterministic")
+	}
+	if len(id) != 16 {
+		t.Fatalf("expected 16 hex characters, got %q", id)
+	}
+	distinct := []string{
+		AnomalyID("low_activity", "db.events", ""),
+		AnomalyID("stale_table", "db.other", ""),
+		AnomalyID("stale_table", "db", "events"),
+	}
+	for _, other := range distinct {
+		if other == id {
+			t.Fatalf("expected distinct conditions to get distinct IDs, both got %q", id)
+		}
+	}
+}
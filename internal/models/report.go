@@ -1,9 +1,22 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReportSchemaVersion is the report.json layout written by this build. Bump
+// it when a change would make older readers misinterpret a report.
+const ReportSchemaVersion = 1
 
 // Report is the complete output structure
 type Report struct {
+	SchemaVersion          int                    `json:"schema_version"` // 0 for reports written before versioning
 	Tool                   string                 `json:"tool"`
 	Version                string                 `json:"version"`
 	Timestamp              string                 `json:"timestamp"`
@@ -15,6 +28,16 @@ type Report struct {
 	Anomalies              []Anomaly              `json:"anomalies"`
 	Users                  []UserActivity         `json:"users,omitempty"`
 	CleanupRecommendations CleanupRecommendations `json:"cleanup_recommendations"`
+	Graph                  *ServiceGraph          `json:"graph,omitempty"` // Services and edges rolled up by --graph-granularity; nil at pod level
+}
+
+// ServiceGraph is the service→table graph with services collapsed to a
+// coarser granularity. Services and Edges on the Report keep one node per
+// client IP.
+type ServiceGraph struct {
+	Granularity string    `json:"granularity"` // "service" or "namespace"
+	Services    []Service `json:"services"`
+	Edges       []Edge    `json:"edges"`
 }
 
 // CollectionMeta holds metadata about the query_log collection across nodes.
@@ -23,6 +46,8 @@ type CollectionMeta struct {
 	FailedNodes  []string `json:"failed_nodes"`
 	TotalEntries int      `json:"total_entries"`
 	Deduplicated int      `json:"deduplicated,omitempty"`
+	RowsScanned  int      `json:"rows_scanned"`            // query_log rows read across nodes, before filtering
+	RowLimitHit  bool     `json:"row_limit_hit,omitempty"` // At least one node stopped at --max-rows
 }
 
 // Metadata contains report generation info
@@ -34,6 +59,15 @@ type Metadata struct {
 	AnalysisDuration     string    `json:"analysis_duration"`
 	Version              string    `json:"version"`
 	K8sResolutionEnabled bool      `json:"k8s_resolution_enabled"`
+	Truncated            bool      `json:"truncated"`              // Collection stopped at RowLimit, so stats may be incomplete
+	RowsScanned          int       `json:"rows_scanned,omitempty"` // query_log rows read before filtering
+	RowLimit             int       `json:"row_limit,omitempty"`    // --max-rows in effect when Truncated
+	Timezone             string    `json:"timezone,omitempty"`     // IANA zone of sparkline and heatmap buckets; empty means UTC
+
+	K8sResolved           int  `json:"k8s_resolved,omitempty"`            // Client IPs resolved to a K8s workload
+	K8sUnresolved         int  `json:"k8s_unresolved,omitempty"`          // Client IPs no pod or service has, labeled with the raw IP
+	K8sResolutionErrors   int  `json:"k8s_resolution_errors,omitempty"`   // Client IPs whose lookup failed, labeled with the raw IP
+	K8sResolutionDegraded bool `json:"k8s_resolution_degraded,omitempty"` // Too many lookups failed to trust the service labels
 }
 
 // CleanupRecommendations groups tables by safety category
@@ -43,6 +77,9 @@ type CleanupRecommendations struct {
 	SafeToDrop             []string              `json:"safe_to_drop"`
 	LikelySafe             []string              `json:"likely_safe"`
 	Keep                   []string              `json:"keep"`
+	KeepReasons            map[string]string     `json:"keep_reasons,omitempty"` // Why each table is in Keep: a safety rule, or "active" for a high score
+	Ranked                 []TableRecommendation `json:"ranked"`                 // Every zero-usage, safe-to-drop, and likely-safe table, highest confidence first
+	ReclaimableBytes       uint64                `json:"reclaimable_bytes"`      // Estimated storage freed by dropping zero-usage and safe-to-drop tables
 }
 
 // TableRecommendation contains detailed information about a table for cleanup recommendations
@@ -53,4 +90,64 @@ type TableRecommendation struct {
 	IsReplicated bool    `json:"is_replicated"`
 	SizeMB       float64 `json:"size_mb"`
 	Rows         uint64  `json:"rows"`
+	ShadowOf     string  `json:"shadow_of,omitempty"` // Active sibling this table looks like an abandoned copy of
+
+	Category      string  `json:"category,omitempty"`       // Recommendation list holding the table, e.g. "safe_to_drop"
+	Confidence    float64 `json:"confidence"`               // Strength of the evidence for the recommendation, 0-1
+	LowConfidence bool    `json:"low_confidence,omitempty"` // Confidence too low to act on without review, e.g. inventory not fetched
+	InBaseline    *bool   `json:"in_baseline,omitempty"`    // Whether --baseline covers the recommendation; set only with --compare-baseline
+}
+
+// SchemaVersionError reports a report.json whose schema version this build
+// cannot read.
+type SchemaVersionError struct {
+	Got  int
+	Want int
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf("unsupported report schema version %d (this build reads version %d); regenerate the report or upgrade clickspectre", e.Got, e.Want)
+}
+
+// DecodeReport decodes a report.json and checks its schema version. Reports
+// that predate versioning (no schema_version) are accepted as compatible;
+// any other version than ReportSchemaVersion returns a *SchemaVersionError.
+func DecodeReport(r io.Reader) (*Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+	if report.SchemaVersion != 0 && report.SchemaVersion != ReportSchemaVersion {
+		return nil, &SchemaVersionError{Got: report.SchemaVersion, Want: ReportSchemaVersion}
+	}
+	return &report, nil
+}
+
+// LoadReport reads a report.json written by analyze. A directory path is
+// taken to hold report.json.
+func LoadReport(path string) (*Report, error) {
+	// If path is a directory, look for report.json inside it
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		path = filepath.Join(path, "report.json")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	report, err := DecodeReport(f)
+	if err != nil {
+		var versionErr *SchemaVersionError
+		if errors.As(err, &versionErr) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+	}
+	return report, nil
 }
@@ -0,0 +1,187 @@
+package reporter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveExtensions lists the archive formats Archive can write, by file
+// extension.
+var ArchiveExtensions = []string{".zip", ".tar.gz", ".tgz"}
+
+// IsArchivePath reports whether dest has an extension Archive supports.
+func IsArchivePath(dest string) bool {
+	return archiveKind(dest) != ""
+}
+
+// archiveKind returns "zip" or "tar.gz" for dest's extension, or "".
+func archiveKind(dest string) string {
+	lower := strings.ToLower(dest)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// Archive packages every file under dir into a single zip or tar.gz archive
+// at dest, picked by its extension. Entries keep their slash-separated path
+// relative to dir (e.g. libs/d3.v7.min.js), so the extracted directory
+// renders like the original. dest is skipped when it lies inside dir.
+func Archive(dir, dest string) (err error) {
+	kind := archiveKind(dest)
+	if kind == "" {
+		return fmt.Errorf("unsupported archive %q (supported: %s)", dest, strings.Join(ArchiveExtensions, ", "))
+	}
+	if info, statErr := os.Stat(dir); statErr != nil {
+		return fmt.Errorf("failed to read report directory: %w", statErr)
+	} else if !info.IsDir() {
+		return fmt.Errorf("report path %s is not a directory", dir)
+	}
+
+	destAbs, err := filepath.Abs(dest)
+	if err != nil {
+		return fmt.Errorf("failed to resolve archive path: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(destAbs), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	file, err := os.Create(destAbs)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close archive: %w", closeErr)
+		}
+	}()
+
+	if kind == "zip" {
+		err = writeZip(file, dir, destAbs)
+	} else {
+		err = writeTarGz(file, dir, destAbs)
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("report archived", slog.String("output_dir", dir), slog.String("archive", dest))
+	return nil
+}
+
+// archiveEntry is called for each file or directory under the report
+// directory with its path relative to it.
+type archiveEntry func(path, rel string, info fs.FileInfo) error
+
+// walkArchive visits everything under dir except the root and skip.
+func walkArchive(dir, skip string, visit archiveEntry) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if abs == skip {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil // Sockets, devices, and symlinks do not belong in a report
+		}
+		return visit(path, filepath.ToSlash(rel), info)
+	})
+}
+
+func writeZip(w io.Writer, dir, skip string) error {
+	zw := zip.NewWriter(w)
+	err := walkArchive(dir, skip, func(path, rel string, info fs.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if info.IsDir() {
+			header.Name += "/"
+			_, err = zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		return copyFile(entry, path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write zip archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write zip archive: %w", err)
+	}
+	return nil
+}
+
+func writeTarGz(w io.Writer, dir, skip string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := walkArchive(dir, skip, func(path, rel string, info fs.FileInfo) error {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return copyFile(tw, path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write tar.gz archive: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write tar.gz archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write tar.gz archive: %w", err)
+	}
+	return nil
+}
+
+// copyFile streams the file at path into w.
+func copyFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	_, err = io.Copy(w, file)
+	return err
+}
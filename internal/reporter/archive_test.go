@@ -0,0 +1,156 @@
+package reporter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func writeReportDirFixture(t *testing.T) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "report")
+	files := map[string]string{
+		"report.json":       `{"tool":"clickspectre"}`,
+		"index.html":        "<html>ok</html>",
+		"libs/d3.v7.min.js": "// d3",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create fixture directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write fixture file %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func readZipEntries(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("failed to open zip: %v", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	entries := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		entries[f.Name] = string(data)
+	}
+	return entries
+}
+
+func readTarGzEntries(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("failed to open gzip stream: %v", err)
+	}
+
+	entries := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar entry: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", header.Name, err)
+		}
+		entries[header.Name] = string(data)
+	}
+	return entries
+}
+
+func TestArchive(t *testing.T) {
+	wantNames := []string{"index.html", "libs/", "libs/d3.v7.min.js", "report.json"}
+
+	cases := []struct {
+		name string
+		dest string
+		read func(*testing.T, string) map[string]string
+	}{
+		{name: "zip", dest: "report.zip", read: readZipEntries},
+		{name: "tar_gz", dest: "report.tar.gz", read: readTarGzEntries},
+		{name: "tgz", dest: "report.TGZ", read: readTarGzEntries},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeReportDirFixture(t)
+			dest := filepath.Join(filepath.Dir(dir), tc.dest)
+			if err := Archive(dir, dest); err != nil {
+				t.Fatalf("Archive failed: %v", err)
+			}
+
+			entries := tc.read(t, dest)
+			var names []string
+			for name := range entries {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, wantNames) {
+				t.Fatalf("expected entries %v, got %v", wantNames, names)
+			}
+			if entries["libs/d3.v7.min.js"] != "// d3" || entries["report.json"] != `{"tool":"clickspectre"}` {
+				t.Fatalf("unexpected entry contents: %v", entries)
+			}
+		})
+	}
+}
+
+func TestArchiveSkipsItselfInsideReportDir(t *testing.T) {
+	dir := writeReportDirFixture(t)
+	dest := filepath.Join(dir, "bundle.zip")
+	if err := Archive(dir, dest); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	entries := readZipEntries(t, dest)
+	if _, ok := entries["bundle.zip"]; ok {
+		t.Fatal("expected the archive not to contain itself")
+	}
+	if _, ok := entries["report.json"]; !ok {
+		t.Fatalf("expected report.json in the archive, got %v", entries)
+	}
+}
+
+func TestArchiveRejectsUnsupportedExtension(t *testing.T) {
+	dir := writeReportDirFixture(t)
+	dest := filepath.Join(t.TempDir(), "report.rar")
+	if err := Archive(dir, dest); err == nil {
+		t.Fatal("expected an error for an unsupported extension")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("expected no archive to be created, got %v", err)
+	}
+}
@@ -2,50 +2,75 @@ package reporter
 
 import (
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+
+	"github.com/ppiankov/clickspectre/web"
 )
 
-// WriteAssets writes all static assets from web/ directory to the output directory
+// assetFiles lists the static UI files written next to report.json
+var assetFiles = []string{
+	"index.html",
+	"app.js",
+	"styles.css",
+	"libs/d3.v7.min.js",
+}
+
+// WriteAssets writes all static assets to the output directory. Assets come
+// from an on-disk web/ directory when one exists (useful during development)
+// and from the copy embedded in the binary otherwise.
 func WriteAssets(outputDir string) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Find the web directory (relative to project root)
-	webDir := findWebDir()
-	if webDir == "" {
-		return fmt.Errorf("web directory not found")
+	assets, err := ReadAssets()
+	if err != nil {
+		return err
 	}
 
-	// Copy files from web/ to output directory
-	files := []string{"index.html", "app.js", "styles.css"}
-	for _, file := range files {
-		src := filepath.Join(webDir, file)
-		dst := filepath.Join(outputDir, file)
-		if err := copyFile(src, dst); err != nil {
-			return fmt.Errorf("failed to copy %s: %w", file, err)
+	for _, file := range assetFiles {
+		dst := filepath.Join(outputDir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+		if err := os.WriteFile(dst, assets[file], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
 		}
 	}
 
-	// Copy libs directory
-	libsSrc := filepath.Join(webDir, "libs")
-	libsDst := filepath.Join(outputDir, "libs")
-	if err := os.MkdirAll(libsDst, 0755); err != nil {
-		return fmt.Errorf("failed to create libs directory: %w", err)
-	}
+	slog.Debug("static assets written", slog.String("output_dir", outputDir))
+
+	return nil
+}
+
+// ReadAssets returns the contents of every static asset keyed by its
+// slash-separated path relative to the report directory.
+func ReadAssets() (map[string][]byte, error) {
+	source := assetFS()
 
-	d3Src := filepath.Join(libsSrc, "d3.v7.min.js")
-	d3Dst := filepath.Join(libsDst, "d3.v7.min.js")
-	if err := copyFile(d3Src, d3Dst); err != nil {
-		return fmt.Errorf("failed to copy d3.v7.min.js: %w", err)
+	assets := make(map[string][]byte, len(assetFiles))
+	for _, file := range assetFiles {
+		data, err := fs.ReadFile(source, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		assets[file] = data
 	}
 
-	slog.Debug("static assets written", slog.String("output_dir", outputDir))
+	return assets, nil
+}
 
-	return nil
+// assetFS returns the on-disk web/ directory if present, else the embedded assets
+func assetFS() fs.FS {
+	if webDir := findWebDir(); webDir != "" {
+		slog.Debug("using on-disk web assets", slog.String("dir", webDir))
+		return os.DirFS(webDir)
+	}
+	return web.FS
 }
 
 // findWebDir finds the web directory relative to the current working directory
@@ -66,13 +91,3 @@ func findWebDir() string {
 
 	return ""
 }
-
-// copyFile copies a file from src to dst
-func copyFile(src, dst string) error {
-	data, err := os.ReadFile(src)
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(dst, data, 0644)
-}
@@ -1,6 +1,7 @@
 package reporter
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/ppiankov/clickspectre/internal/models"
 	"github.com/ppiankov/clickspectre/pkg/config"
+	"github.com/ppiankov/clickspectre/web"
 )
 
 func setWorkingDir(t *testing.T, dir string) {
@@ -75,15 +77,35 @@ func TestWriteAssetsSuccess(t *testing.T) {
 			t.Fatalf("expected %s to exist: %v", path, err)
 		}
 	}
+
+	// The on-disk web/ directory takes precedence over embedded assets
+	index, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if err != nil || string(index) != "<html>ok</html>" {
+		t.Fatalf("expected index.html from web/ fixture, got %q (err=%v)", index, err)
+	}
 }
 
-func TestWriteAssetsMissingWebDir(t *testing.T) {
+func TestWriteAssetsEmbeddedWithoutWebDir(t *testing.T) {
 	root := t.TempDir()
 	setWorkingDir(t, root)
 
-	err := WriteAssets(filepath.Join(root, "out"))
-	if err == nil || !strings.Contains(err.Error(), "web directory not found") {
-		t.Fatalf("expected web directory error, got %v", err)
+	outDir := filepath.Join(root, "out")
+	if err := WriteAssets(outDir); err != nil {
+		t.Fatalf("WriteAssets failed without web directory: %v", err)
+	}
+
+	for _, file := range assetFiles {
+		want, err := fs.ReadFile(web.FS, file)
+		if err != nil {
+			t.Fatalf("failed to read embedded %s: %v", file, err)
+		}
+		got, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", file, err)
+		}
+		if len(want) == 0 || string(got) != string(want) {
+			t.Fatalf("expected %s to match embedded asset", file)
+		}
 	}
 }
 
@@ -103,13 +125,6 @@ func TestWriteAssetsOutputDirError(t *testing.T) {
 	}
 }
 
-func TestCopyFileError(t *testing.T) {
-	err := copyFile(filepath.Join(t.TempDir(), "missing"), filepath.Join(t.TempDir(), "dst"))
-	if err == nil {
-		t.Fatal("expected copyFile error for missing source")
-	}
-}
-
 func TestReporterGenerateAndWriteAssets(t *testing.T) {
 	root := t.TempDir()
 	createWebFixture(t, root)
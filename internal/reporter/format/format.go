@@ -0,0 +1,39 @@
+// Package format renders sizes and counts for human-readable report output.
+// Machine-readable formats keep the raw numbers.
+package format
+
+import "fmt"
+
+var byteUnits = []string{"B", "KB", "MB", "GB", "TB", "PB"}
+
+var countUnits = []string{"", "K", "M", "B", "T"}
+
+// HumanBytes formats a byte count with decimal units, e.g. "1.2 GB". Units
+// are powers of 1000 so values agree with the reports' size_mb fields.
+func HumanBytes(bytes uint64) string {
+	if bytes < 1000 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value, unit := scale(float64(bytes), len(byteUnits))
+	return fmt.Sprintf("%.1f %s", value, byteUnits[unit])
+}
+
+// HumanCount formats a count with a K/M/B/T suffix, e.g. "3.4M".
+func HumanCount(count uint64) string {
+	if count < 1000 {
+		return fmt.Sprintf("%d", count)
+	}
+	value, unit := scale(float64(count), len(countUnits))
+	return fmt.Sprintf("%.1f%s", value, countUnits[unit])
+}
+
+// scale divides value by 1000 until it prints below 1000 at one decimal
+// place, returning the scaled value and the index of its unit.
+func scale(value float64, units int) (float64, int) {
+	unit := 0
+	for value >= 999.95 && unit < units-1 {
+		value /= 1000
+		unit++
+	}
+	return value, unit
+}
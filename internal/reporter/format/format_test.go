@@ -0,0 +1,50 @@
+package format
+
+import "testing"
+
+func TestHumanBytes(t *testing.T) {
+	cases := []struct {
+		bytes uint64
+		want  string
+	}{
+		{0, "0 B"},
+		{1, "1 B"},
+		{999, "999 B"},
+		{1000, "1.0 KB"},
+		{1024, "1.0 KB"},
+		{1500, "1.5 KB"},
+		{999_949, "999.9 KB"},
+		{999_950, "1.0 MB"},
+		{1_048_576, "1.0 MB"},
+		{512_000_000, "512.0 MB"},
+		{1_073_741_824, "1.1 GB"},
+		{1_200_000_000, "1.2 GB"},
+		{5_000_000_000_000, "5.0 TB"},
+		{2_000_000_000_000_000_000, "2000.0 PB"},
+	}
+	for _, tc := range cases {
+		if got := HumanBytes(tc.bytes); got != tc.want {
+			t.Errorf("HumanBytes(%d) = %q, want %q", tc.bytes, got, tc.want)
+		}
+	}
+}
+
+func TestHumanCount(t *testing.T) {
+	cases := []struct {
+		count uint64
+		want  string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1.0K"},
+		{3_400_000, "3.4M"},
+		{999_999, "1.0M"},
+		{2_500_000_000, "2.5B"},
+		{7_000_000_000_000_000, "7000.0T"},
+	}
+	for _, tc := range cases {
+		if got := HumanCount(tc.count); got != tc.want {
+			t.Errorf("HumanCount(%d) = %q, want %q", tc.count, got, tc.want)
+		}
+	}
+}
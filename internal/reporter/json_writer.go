@@ -19,7 +19,7 @@ func WriteJSON(report *models.Report, cfg *config.Config) error {
 	}
 
 	// Marshal report to JSON with pretty printing
-	data, err := json.MarshalIndent(report, "", "  ")
+	data, err := json.MarshalIndent(selectedSections(cfg).jsonReport(report), "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal report to JSON: %w", err)
 	}
@@ -0,0 +1,264 @@
+package reporter
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/internal/reporter/format"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+// markdownSeverityOrder lists anomaly severities from most to least urgent.
+var markdownSeverityOrder = []string{"high", "medium", "low"}
+
+// WriteMarkdown writes a GitHub-flavored Markdown report to report.md,
+// suitable for posting as a pull request comment.
+func WriteMarkdown(report *models.Report, cfg *config.Config) error {
+	if report == nil {
+		return fmt.Errorf("report is nil")
+	}
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	outputPath := filepath.Join(cfg.OutputDir, "report.md")
+	if err := os.WriteFile(outputPath, []byte(buildMarkdown(report, selectedSections(cfg))), 0644); err != nil {
+		return fmt.Errorf("failed to write report.md: %w", err)
+	}
+
+	slog.Debug("report written", slog.String("path", outputPath))
+	return nil
+}
+
+// buildMarkdown renders report as Markdown, leaving out sections not in
+// sections (nil renders every section).
+func buildMarkdown(report *models.Report, sections sectionSet) string {
+	var b strings.Builder
+	report = sections.filter(report)
+
+	generatedAt := strings.TrimSpace(report.Timestamp)
+	if generatedAt == "" {
+		if !report.Metadata.GeneratedAt.IsZero() {
+			generatedAt = report.Metadata.GeneratedAt.In(reportLocation(report)).Format(time.RFC3339)
+		} else {
+			generatedAt = "unknown"
+		}
+	}
+	host := strings.TrimSpace(report.Metadata.ClickHouseHost)
+	if host == "" {
+		host = "unknown"
+	}
+
+	recs := report.CleanupRecommendations
+	b.WriteString("## ClickSpectre Audit Report\n\n")
+	fmt.Fprintf(&b, "Generated %s for `%s` over the last %d days (%d queries analyzed).\n\n",
+		generatedAt, markdownCode(host), report.Metadata.LookbackDays, report.Metadata.TotalQueriesAnalyzed)
+	if warning := truncationWarning(report); warning != "" {
+		fmt.Fprintf(&b, "> **Warning:** %s.\n\n", warning)
+	}
+
+	b.WriteString("### Summary\n\n")
+	b.WriteString("| Metric | Value |\n")
+	b.WriteString("| --- | ---: |\n")
+	if sections.has(sectionTables) {
+		fmt.Fprintf(&b, "| Total tables | %d |\n", len(report.Tables))
+		fmt.Fprintf(&b, "| Unused tables | %d |\n", countUnusedTables(report.Tables))
+	}
+	if sections.has(sectionRecommendations) {
+		fmt.Fprintf(&b, "| Safe to drop | %d |\n", len(recs.SafeToDrop))
+		fmt.Fprintf(&b, "| Likely safe | %d |\n", len(recs.LikelySafe))
+	}
+	if sections.has(sectionAnomalies) {
+		fmt.Fprintf(&b, "| Anomalies | %d |\n", len(report.Anomalies))
+	}
+	if sections.has(sectionRecommendations) {
+		fmt.Fprintf(&b, "| Estimated reclaimable | %s |\n", format.HumanBytes(recs.ReclaimableBytes))
+	}
+	b.WriteString("\n")
+
+	if sections.has(sectionTables) {
+		writeMarkdownFindings(&b, report)
+	}
+	if sections.has(sectionRecommendations) {
+		writeMarkdownRecommendations(&b, recs)
+	}
+	if sections.has(sectionAnomalies) {
+		writeMarkdownAnomalies(&b, report.Anomalies)
+	}
+
+	return b.String()
+}
+
+func writeMarkdownFindings(b *strings.Builder, report *models.Report) {
+	b.WriteString("### Findings\n\n")
+
+	findings, _ := buildTableFindings(report, "")
+	if len(findings) == 0 {
+		b.WriteString("No table findings detected.\n\n")
+		return
+	}
+
+	usage := make(map[string]models.Table, len(report.Tables))
+	for _, table := range report.Tables {
+		usage[normalizeTableName(table.FullName, table.Database, table.Name)] = table
+	}
+
+	b.WriteString("| Table | Score | Category | Reads | Writes | Mutations |\n")
+	b.WriteString("| --- | ---: | --- | ---: | ---: | ---: |\n")
+	var consumers []string
+	for _, finding := range findings {
+		score := "n/a"
+		if finding.HasScore {
+			score = fmt.Sprintf("%.2f", finding.Score)
+		}
+		table := usage[finding.Name]
+		fmt.Fprintf(b, "| `%s` | %s | %s | %d | %d | %d |\n",
+			markdownCode(finding.Name),
+			score,
+			markdownCell(textCategory(finding.Category)),
+			table.Reads,
+			table.Writes,
+			table.Mutations,
+		)
+		if len(table.TopConsumers) > 0 {
+			consumers = append(consumers, fmt.Sprintf("- `%s`: %s", markdownCode(finding.Name), markdownConsumers(table.TopConsumers)))
+		}
+	}
+	b.WriteString("\n")
+
+	if len(consumers) > 0 {
+		b.WriteString("#### Top consumers\n\n")
+		b.WriteString(strings.Join(consumers, "\n"))
+		b.WriteString("\n\n")
+	}
+}
+
+// markdownConsumers lists a table's top consumers busiest first, e.g.
+// "`api` (120 reads, 7 writes), `worker` (40 reads, 0 writes)".
+func markdownConsumers(consumers []models.EdgeSummary) string {
+	parts := make([]string, 0, len(consumers))
+	for _, consumer := range consumers {
+		parts = append(parts, fmt.Sprintf("`%s` (%d reads, %d writes)", markdownCode(consumer.Service), consumer.Reads, consumer.Writes))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func writeMarkdownRecommendations(b *strings.Builder, recs models.CleanupRecommendations) {
+	total := len(recs.ZeroUsageNonReplicated) + len(recs.ZeroUsageReplicated) + len(recs.SafeToDrop) + len(recs.LikelySafe)
+
+	b.WriteString("### Recommendations\n\n")
+	if total == 0 {
+		b.WriteString("No cleanup recommendations.\n\n")
+		return
+	}
+
+	// GitHub only renders Markdown inside <details> when surrounded by blank lines
+	fmt.Fprintf(b, "<details>\n<summary>%d cleanup recommendations</summary>\n\n", total)
+	writeMarkdownTableRecommendations(b, "Zero usage (non-replicated)", recs.ZeroUsageNonReplicated)
+	writeMarkdownTableRecommendations(b, "Zero usage (replicated)", recs.ZeroUsageReplicated)
+	writeMarkdownNameList(b, "Safe to drop", recs.SafeToDrop)
+	writeMarkdownNameList(b, "Likely safe", recs.LikelySafe)
+	b.WriteString("</details>\n\n")
+}
+
+func writeMarkdownTableRecommendations(b *strings.Builder, title string, recs []models.TableRecommendation) {
+	if len(recs) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "#### %s\n\n", title)
+	for _, rec := range recs {
+		fmt.Fprintf(b, "- `%s` — %s, %s rows", markdownCode(normalizeNamedTable(rec.Name)), format.HumanBytes(mbToBytes(rec.SizeMB)), format.HumanCount(rec.Rows))
+		if rec.ShadowOf != "" {
+			fmt.Fprintf(b, " (shadow of `%s`)", markdownCode(rec.ShadowOf))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+func writeMarkdownNameList(b *strings.Builder, title string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "#### %s\n\n", title)
+	for _, name := range names {
+		fmt.Fprintf(b, "- `%s`\n", markdownCode(normalizeNamedTable(name)))
+	}
+	b.WriteString("\n")
+}
+
+func writeMarkdownAnomalies(b *strings.Builder, anomalies []models.Anomaly) {
+	b.WriteString("### Anomalies\n\n")
+	if len(anomalies) == 0 {
+		b.WriteString("No anomalies detected.\n")
+		return
+	}
+
+	bySeverity := make(map[string][]models.Anomaly)
+	for _, anomaly := range anomalies {
+		severity := normalizeSeverity(anomaly.Severity)
+		bySeverity[severity] = append(bySeverity[severity], anomaly)
+	}
+
+	severities := append([]string(nil), markdownSeverityOrder...)
+	var others []string
+	for severity := range bySeverity {
+		if !slices.Contains(markdownSeverityOrder, severity) {
+			others = append(others, severity)
+		}
+	}
+	sort.Strings(others)
+	severities = append(severities, others...)
+
+	for _, severity := range severities {
+		group := bySeverity[severity]
+		if len(group) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "#### %s (%d)\n\n", strings.ToUpper(severity[:1])+severity[1:], len(group))
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].AffectedTable < group[j].AffectedTable
+		})
+		for _, anomaly := range group {
+			description := strings.TrimSpace(anomaly.Description)
+			if description == "" {
+				description = strings.TrimSpace(anomaly.Type)
+			}
+			if metrics := anomaly.MetricsText(); metrics != "" {
+				description += " [" + metrics + "]"
+			}
+			target := strings.TrimSpace(anomaly.AffectedTable)
+			if target == "" {
+				target = strings.TrimSpace(anomaly.AffectedService)
+			}
+			if target != "" {
+				fmt.Fprintf(b, "- `%s`: %s\n", markdownCode(target), description)
+			} else {
+				fmt.Fprintf(b, "- %s\n", description)
+			}
+		}
+		b.WriteString("\n")
+	}
+}
+
+// markdownCell escapes characters that would break a table cell.
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+// markdownCode makes a value safe inside a single-backtick code span within a table cell.
+func markdownCode(value string) string {
+	return markdownCell(strings.ReplaceAll(value, "`", "'"))
+}
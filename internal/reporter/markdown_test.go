@@ -0,0 +1,129 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+func TestBuildMarkdownSectionsAndTables(t *testing.T) {
+	report := &models.Report{
+		Timestamp: "2026-02-15T00:00:00Z",
+		Metadata:  models.Metadata{ClickHouseHost: "ch-1", LookbackDays: 30, TotalQueriesAnalyzed: 42},
+		Tables: []models.Table{
+			{FullName: "db.orders", Score: 0.9, Category: "active", Reads: 120, Writes: 7, Mutations: 2, TopConsumers: []models.EdgeSummary{
+				{Service: "api", Reads: 100, Writes: 7},
+				{Service: "etl", Reads: 20},
+			}},
+			{FullName: "db.old_events", Score: 0.1, Category: "unused", ZeroUsage: true},
+		},
+		CleanupRecommendations: models.CleanupRecommendations{
+			ZeroUsageNonReplicated: []models.TableRecommendation{
+				{Name: "db.old_events", SizeMB: 512, Rows: 1000},
+			},
+			SafeToDrop: []string{"db.old_events"},
+		},
+		Anomalies: []models.Anomaly{
+			{Type: "usage_drop", Severity: "low", Description: "reads dropped", AffectedTable: "db.orders"},
+			{Type: "usage_spike", Severity: "high", Description: "reads spiked", AffectedTable: "db.orders"},
+			{Type: "broad_access", Severity: "medium", Description: "touches 40 tables", AffectedService: "etl"},
+		},
+	}
+
+	output := buildMarkdown(report, nil)
+
+	for _, header := range []string{
+		"## ClickSpectre Audit Report\n",
+		"### Summary\n",
+		"### Findings\n",
+		"### Recommendations\n",
+		"### Anomalies\n",
+		"#### High (1)\n",
+		"#### Medium (1)\n",
+		"#### Low (1)\n",
+	} {
+		if !strings.Contains(output, header) {
+			t.Fatalf("expected header %q, got:\n%s", header, output)
+		}
+	}
+
+	if !strings.Contains(output, "| Table | Score | Category | Reads | Writes | Mutations |\n| --- | ---: | --- | ---: | ---: | ---: |\n") {
+		t.Fatalf("expected findings table header, got:\n%s", output)
+	}
+	if !strings.Contains(output, "| `db.orders` | 0.90 | active | 120 | 7 | 2 |\n") {
+		t.Fatalf("expected db.orders findings row, got:\n%s", output)
+	}
+	if !strings.Contains(output, "#### Top consumers\n\n- `db.orders`: `api` (100 reads, 7 writes), `etl` (20 reads, 0 writes)\n") {
+		t.Fatalf("expected db.orders top consumers, got:\n%s", output)
+	}
+	if !strings.Contains(output, "<details>\n<summary>2 cleanup recommendations</summary>\n\n") || !strings.Contains(output, "</details>") {
+		t.Fatalf("expected collapsible recommendations, got:\n%s", output)
+	}
+	if !strings.Contains(output, "- `db.old_events` — 512.0 MB, 1.0K rows\n") {
+		t.Fatalf("expected zero usage recommendation, got:\n%s", output)
+	}
+
+	// Severity groups are ordered high, medium, low
+	high := strings.Index(output, "#### High")
+	medium := strings.Index(output, "#### Medium")
+	low := strings.Index(output, "#### Low")
+	if !(high < medium && medium < low) {
+		t.Fatalf("expected severity groups in order high, medium, low, got:\n%s", output)
+	}
+	if !strings.Contains(output, "- `etl`: touches 40 tables\n") {
+		t.Fatalf("expected service anomaly, got:\n%s", output)
+	}
+}
+
+func TestBuildMarkdownEscapesTableCells(t *testing.T) {
+	report := &models.Report{
+		Tables: []models.Table{{FullName: "db.a|b", Category: "un|used"}},
+		CleanupRecommendations: models.CleanupRecommendations{
+			SafeToDrop: []string{"db.a|b"},
+		},
+	}
+
+	output := buildMarkdown(report, nil)
+	if !strings.Contains(output, "| `db.a\\|b` | 0.00 | un\\|used | 0 | 0 | 0 |\n") {
+		t.Fatalf("expected escaped pipes in table row, got:\n%s", output)
+	}
+}
+
+func TestWriteMarkdownCreatesReportFile(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.OutputDir = t.TempDir()
+
+	if err := WriteMarkdown(&models.Report{}, cfg); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.OutputDir, "report.md"))
+	if err != nil {
+		t.Fatalf("failed to read report.md: %v", err)
+	}
+	if !strings.Contains(string(data), "No anomalies detected.") {
+		t.Fatalf("unexpected report.md contents:\n%s", data)
+	}
+
+	if err := WriteMarkdown(nil, cfg); err == nil {
+		t.Fatal("expected error for nil report")
+	}
+}
+
+func TestBuildMarkdownMarksShadowTables(t *testing.T) {
+	report := &models.Report{
+		CleanupRecommendations: models.CleanupRecommendations{
+			ZeroUsageNonReplicated: []models.TableRecommendation{
+				{Name: "db.events_old", SizeMB: 2000, Rows: 10, ShadowOf: "db.events"},
+			},
+		},
+	}
+
+	output := buildMarkdown(report, nil)
+	if !strings.Contains(output, "- `db.events_old` — 2.0 GB, 10 rows (shadow of `db.events`)\n") {
+		t.Fatalf("expected shadow sibling in recommendation, got:\n%s", output)
+	}
+}
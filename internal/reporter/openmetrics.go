@@ -0,0 +1,152 @@
+package reporter
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+// openMetricsExemplarMaxRunes is the OpenMetrics limit for the combined
+// length of exemplar label names and values.
+const openMetricsExemplarMaxRunes = 128
+
+// buildOpenMetrics renders the report in OpenMetrics text exposition format.
+// The safe-to-drop counter carries an exemplar pointing at the largest
+// cleanup candidate so scrapers can link the sample back to a table.
+func buildOpenMetrics(report *models.Report) string {
+	var b strings.Builder
+
+	b.WriteString("# HELP clickspectre_tables Number of tables observed in the analysis.\n")
+	b.WriteString("# TYPE clickspectre_tables gauge\n")
+	fmt.Fprintf(&b, "clickspectre_tables %d\n", len(report.Tables))
+
+	b.WriteString("# HELP clickspectre_queries_analyzed Number of query log entries analyzed.\n")
+	b.WriteString("# TYPE clickspectre_queries_analyzed gauge\n")
+	fmt.Fprintf(&b, "clickspectre_queries_analyzed %d\n", report.Metadata.TotalQueriesAnalyzed)
+
+	truncated := 0
+	if report.Metadata.Truncated {
+		truncated = 1
+	}
+	b.WriteString("# HELP clickspectre_results_truncated Whether collection stopped at --max-rows, so stats may be incomplete.\n")
+	b.WriteString("# TYPE clickspectre_results_truncated gauge\n")
+	fmt.Fprintf(&b, "clickspectre_results_truncated %d\n", truncated)
+
+	recs := report.CleanupRecommendations
+	b.WriteString("# HELP clickspectre_recommendations Number of cleanup recommendations by category.\n")
+	b.WriteString("# TYPE clickspectre_recommendations gauge\n")
+	fmt.Fprintf(&b, "clickspectre_recommendations{category=\"zero_usage_non_replicated\"} %d\n", len(recs.ZeroUsageNonReplicated))
+	fmt.Fprintf(&b, "clickspectre_recommendations{category=\"zero_usage_replicated\"} %d\n", len(recs.ZeroUsageReplicated))
+	fmt.Fprintf(&b, "clickspectre_recommendations{category=\"safe_to_drop\"} %d\n", len(recs.SafeToDrop))
+	fmt.Fprintf(&b, "clickspectre_recommendations{category=\"likely_safe\"} %d\n", len(recs.LikelySafe))
+	fmt.Fprintf(&b, "clickspectre_recommendations{category=\"keep\"} %d\n", len(recs.Keep))
+
+	b.WriteString("# HELP clickspectre_anomalies Number of anomalies by severity.\n")
+	b.WriteString("# TYPE clickspectre_anomalies gauge\n")
+	anomalies := make(map[string]int)
+	for _, anomaly := range report.Anomalies {
+		anomalies[normalizeSeverity(anomaly.Severity)]++
+	}
+	severities := make([]string, 0, len(anomalies))
+	for severity := range anomalies {
+		severities = append(severities, severity)
+	}
+	sort.Strings(severities)
+	for _, severity := range severities {
+		fmt.Fprintf(&b, "clickspectre_anomalies{severity=\"%s\"} %d\n", escapeOpenMetricsLabel(severity), anomalies[severity])
+	}
+
+	candidates, largest := safeToDropCandidates(report)
+	b.WriteString("# HELP clickspectre_safe_to_drop Tables recommended for removal.\n")
+	b.WriteString("# TYPE clickspectre_safe_to_drop counter\n")
+	fmt.Fprintf(&b, "clickspectre_safe_to_drop_total %d", candidates)
+	if exemplar := openMetricsTableExemplar(largest); exemplar != "" {
+		fmt.Fprintf(&b, " # %s 1", exemplar)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("# EOF\n")
+	return b.String()
+}
+
+// WriteOpenMetrics writes the report in OpenMetrics format to report.prom.
+func WriteOpenMetrics(report *models.Report, cfg *config.Config) error {
+	if report == nil {
+		return fmt.Errorf("report is nil")
+	}
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	outputPath := filepath.Join(cfg.OutputDir, "report.prom")
+	if err := os.WriteFile(outputPath, []byte(buildOpenMetrics(report)), 0644); err != nil {
+		return fmt.Errorf("failed to write report.prom: %w", err)
+	}
+
+	slog.Debug("report written", slog.String("path", outputPath))
+	return nil
+}
+
+// safeToDropCandidates counts drop candidates across the zero-usage and
+// safe_to_drop categories and returns the name of the largest one by size.
+func safeToDropCandidates(report *models.Report) (int, string) {
+	sizes := make(map[string]uint64, len(report.Tables))
+	for _, table := range report.Tables {
+		sizes[normalizeTableName(table.FullName, table.Database, table.Name)] = table.TotalBytes
+	}
+
+	var (
+		count       int
+		largest     string
+		largestSize float64 = -1
+	)
+	consider := func(name string, sizeMB float64) {
+		count++
+		if sizeMB > largestSize || (sizeMB == largestSize && name < largest) {
+			largest = name
+			largestSize = sizeMB
+		}
+	}
+
+	recs := report.CleanupRecommendations
+	for _, item := range recs.ZeroUsageNonReplicated {
+		consider(normalizeNamedTable(item.Name), item.SizeMB)
+	}
+	for _, item := range recs.ZeroUsageReplicated {
+		consider(normalizeNamedTable(item.Name), item.SizeMB)
+	}
+	for _, name := range recs.SafeToDrop {
+		name = normalizeNamedTable(name)
+		consider(name, float64(sizes[name])/1e6)
+	}
+
+	return count, largest
+}
+
+func openMetricsTableExemplar(table string) string {
+	if table == "" {
+		return ""
+	}
+
+	// The limit counts label characters, not escape sequences, so truncate
+	// before escaping; that also never splits an escape in half
+	if budget := openMetricsExemplarMaxRunes - len("table"); len([]rune(table)) > budget {
+		table = string([]rune(table)[:budget])
+	}
+	return fmt.Sprintf("{table=\"%s\"}", escapeOpenMetricsLabel(table))
+}
+
+func escapeOpenMetricsLabel(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return replacer.Replace(value)
+}
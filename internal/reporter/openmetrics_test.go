@@ -0,0 +1,93 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+func TestBuildOpenMetricsSafeToDropExemplar(t *testing.T) {
+	report := &models.Report{
+		Tables: []models.Table{
+			{FullName: "db.stale_small", TotalBytes: 10 * 1e6},
+			{FullName: "db.stale_huge", TotalBytes: 900 * 1e6},
+		},
+		CleanupRecommendations: models.CleanupRecommendations{
+			ZeroUsageNonReplicated: []models.TableRecommendation{
+				{Name: "db.old_events", Database: "db", SizeMB: 512},
+			},
+			SafeToDrop: []string{"db.stale_small", "db.stale_huge"},
+		},
+		Anomalies: []models.Anomaly{
+			{Type: "stale_table", Severity: "medium"},
+		},
+	}
+
+	output := buildOpenMetrics(report)
+
+	if !strings.HasSuffix(output, "# EOF\n") {
+		t.Fatalf("expected output to end with # EOF, got:\n%s", output)
+	}
+	if !strings.Contains(output, "# TYPE clickspectre_safe_to_drop counter\n") {
+		t.Fatalf("expected counter type declaration, got:\n%s", output)
+	}
+
+	want := `clickspectre_safe_to_drop_total 3 # {table="db.stale_huge"} 1` + "\n"
+	if !strings.Contains(output, want) {
+		t.Fatalf("expected exemplar line %q, got:\n%s", want, output)
+	}
+	if !strings.Contains(output, `clickspectre_anomalies{severity="medium"} 1`) {
+		t.Fatalf("expected anomaly gauge, got:\n%s", output)
+	}
+}
+
+func TestBuildOpenMetricsWithoutCandidates(t *testing.T) {
+	output := buildOpenMetrics(&models.Report{})
+
+	if !strings.Contains(output, "clickspectre_safe_to_drop_total 0\n") {
+		t.Fatalf("expected zero counter without exemplar, got:\n%s", output)
+	}
+	if strings.Contains(output, "{table=") {
+		t.Fatalf("expected no exemplar without candidates, got:\n%s", output)
+	}
+}
+
+func TestOpenMetricsTableExemplarEscapesAndTruncates(t *testing.T) {
+	if got := openMetricsTableExemplar(`db."odd"`); got != `{table="db.\"odd\""}` {
+		t.Fatalf("unexpected escaped exemplar: %s", got)
+	}
+
+	long := openMetricsTableExemplar("db." + strings.Repeat("x", 200))
+	if runes := len([]rune(long)) - len(`{table=""}`) + len("table"); runes > openMetricsExemplarMaxRunes {
+		t.Fatalf("expected exemplar within %d runes, got %d", openMetricsExemplarMaxRunes, runes)
+	}
+
+	// The quote is the last character that fits: it is kept and escaped
+	// whole, and its escape does not count against the limit
+	prefix := "db." + strings.Repeat("x", 119)
+	quoted := openMetricsTableExemplar(prefix + `"` + strings.Repeat("y", 20))
+	if want := `{table="` + prefix + `\""}`; quoted != want {
+		t.Fatalf("expected quote at the cut point escaped intact\n got: %s\nwant: %s", quoted, want)
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{OutputDir: dir}
+
+	if err := WriteOpenMetrics(&models.Report{}, cfg); err != nil {
+		t.Fatalf("WriteOpenMetrics: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "report.prom"))
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.HasSuffix(string(data), "# EOF\n") {
+		t.Fatalf("expected written report to end with # EOF")
+	}
+}
@@ -1,6 +1,7 @@
 package reporter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -38,6 +39,13 @@ func (r *reporter) Generate(report *models.Report) error {
 	if IsStdout(r.config) {
 		return r.generateToStdout(report)
 	}
+	if r.config.OutputURL != "" {
+		sink, err := NewSink(r.config.OutputURL)
+		if err != nil {
+			return err
+		}
+		return GenerateToSink(context.Background(), sink, report, r.config)
+	}
 
 	switch strings.ToLower(r.config.Format) {
 	case "json":
@@ -59,6 +67,14 @@ func (r *reporter) Generate(report *models.Report) error {
 		if err := WriteSpectreHub(report, r.config); err != nil {
 			return err
 		}
+	case "openmetrics":
+		if err := WriteOpenMetrics(report, r.config); err != nil {
+			return err
+		}
+	case "markdown":
+		if err := WriteMarkdown(report, r.config); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("unsupported format %q", r.config.Format)
 	}
@@ -72,7 +88,7 @@ func (r *reporter) generateToStdout(report *models.Report) error {
 
 	switch strings.ToLower(r.config.Format) {
 	case "json":
-		return enc.Encode(report)
+		return enc.Encode(selectedSections(r.config).jsonReport(report))
 	case "text":
 		return writeText(report, r.config, os.Stdout)
 	case "sarif":
@@ -81,11 +97,26 @@ func (r *reporter) generateToStdout(report *models.Report) error {
 	case "spectrehub":
 		envelope := buildSpectreHub(report, r.config)
 		return enc.Encode(envelope)
+	case "openmetrics":
+		_, err := os.Stdout.WriteString(buildOpenMetrics(report))
+		return err
+	case "markdown":
+		_, err := os.Stdout.WriteString(buildMarkdown(report, selectedSections(r.config)))
+		return err
 	default:
 		return fmt.Errorf("unsupported format %q", r.config.Format)
 	}
 }
 
+// truncationWarning returns the warning every format shows when collection
+// stopped at --max-rows, or "" when the report covers all query_log rows.
+func truncationWarning(report *models.Report) string {
+	if !report.Metadata.Truncated {
+		return ""
+	}
+	return fmt.Sprintf("results truncated at %d rows; stats may be incomplete", report.Metadata.RowLimit)
+}
+
 // WriteAssets writes static HTML/JS/CSS files to output directory
 func (r *reporter) WriteAssets() error {
 	return WriteAssets(r.config.OutputDir)
@@ -6,11 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/internal/reporter/format"
 	"github.com/ppiankov/clickspectre/pkg/config"
 )
 
@@ -39,6 +41,18 @@ type sarifRun struct {
 	Tool              sarifTool               `json:"tool"`
 	Results           []sarifResult           `json:"results"`
 	AutomationDetails *sarifAutomationDetails `json:"automationDetails,omitempty"`
+	Invocations       []sarifInvocation       `json:"invocations,omitempty"`
+	Properties        map[string]any          `json:"properties,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
 }
 
 type sarifTool struct {
@@ -121,6 +135,21 @@ func buildSARIF(report *models.Report, cfg *config.Config) *sarifLog {
 		reportVersion = report.Metadata.Version
 	}
 
+	properties := map[string]any{
+		"reclaimable_bytes": report.CleanupRecommendations.ReclaimableBytes,
+	}
+	var invocations []sarifInvocation
+	if warning := truncationWarning(report); warning != "" {
+		properties["truncated"] = true
+		invocations = []sarifInvocation{{
+			ExecutionSuccessful: true,
+			ToolExecutionNotifications: []sarifNotification{{
+				Level:   "warning",
+				Message: sarifMessage{Text: warning},
+			}},
+		}}
+	}
+
 	return &sarifLog{
 		Version: "2.1.0",
 		Schema:  sarifSchemaURI,
@@ -170,10 +199,12 @@ func buildSARIF(report *models.Report, cfg *config.Config) *sarifLog {
 						},
 					},
 				},
-				Results: buildSARIFResults(report),
+				Results: buildSARIFResults(report, cfg.SARIFLocationRoot),
 				AutomationDetails: &sarifAutomationDetails{
 					ID: "clickspectre/analyze",
 				},
+				Invocations: invocations,
+				Properties:  properties,
 			},
 		},
 	}
@@ -206,7 +237,10 @@ func WriteSARIF(report *models.Report, cfg *config.Config) error {
 	return nil
 }
 
-func buildSARIFResults(report *models.Report) []sarifResult {
+// buildSARIFResults converts findings to SARIF results. When locationRoot is
+// set, table findings point at <locationRoot>/<db>/<table>.sql instead of the
+// README fallback.
+func buildSARIFResults(report *models.Report, locationRoot string) []sarifResult {
 	results := make([]sarifResult, 0)
 	if report == nil {
 		return results
@@ -220,8 +254,8 @@ func buildSARIFResults(report *models.Report) []sarifResult {
 			RuleID:    ruleZeroUsage,
 			RuleIndex: ruleIndexPtr(ruleIndexZeroUsage),
 			Level:     "warning",
-			Message:   sarifMessage{Text: fmt.Sprintf("Table %q has zero usage and is non-replicated (size: %.2f MB, rows: %d).", tableName, item.SizeMB, item.Rows)},
-			Locations: tableLocation(tableName),
+			Message:   sarifMessage{Text: fmt.Sprintf("Table %q has zero usage and is non-replicated (size: %s, rows: %s).", tableName, format.HumanBytes(mbToBytes(item.SizeMB)), format.HumanCount(item.Rows))},
+			Locations: tableLocation(tableName, locationRoot),
 			PartialFingerprints: map[string]string{
 				"clickspectre/findingHash": fingerprint,
 			},
@@ -245,8 +279,8 @@ func buildSARIFResults(report *models.Report) []sarifResult {
 			RuleID:    ruleZeroUsage,
 			RuleIndex: ruleIndexPtr(ruleIndexZeroUsage),
 			Level:     "warning",
-			Message:   sarifMessage{Text: fmt.Sprintf("Table %q has zero usage and is replicated (size: %.2f MB, rows: %d).", tableName, item.SizeMB, item.Rows)},
-			Locations: tableLocation(tableName),
+			Message:   sarifMessage{Text: fmt.Sprintf("Table %q has zero usage and is replicated (size: %s, rows: %s).", tableName, format.HumanBytes(mbToBytes(item.SizeMB)), format.HumanCount(item.Rows))},
+			Locations: tableLocation(tableName, locationRoot),
 			PartialFingerprints: map[string]string{
 				"clickspectre/findingHash": fingerprint,
 			},
@@ -262,41 +296,43 @@ func buildSARIFResults(report *models.Report) []sarifResult {
 		})
 	}
 
+	// safe_to_drop and likely_safe only carry names; size comes from the table list
+	tablesByName := make(map[string]models.Table, len(report.Tables))
+	for _, table := range report.Tables {
+		tablesByName[normalizeTableName(table.FullName, table.Database, table.Name)] = table
+	}
+
 	for _, table := range report.CleanupRecommendations.SafeToDrop {
 		category := "safe_to_drop"
 		fingerprint := hashFinding("recommendation", category, table)
+		message, properties := namedRecommendation(category, table, tablesByName)
 		results = append(results, sarifResult{
 			RuleID:    ruleZeroUsage,
 			RuleIndex: ruleIndexPtr(ruleIndexZeroUsage),
 			Level:     "warning",
-			Message:   sarifMessage{Text: fmt.Sprintf("Table %q is marked safe_to_drop.", table)},
-			Locations: tableLocation(table),
+			Message:   sarifMessage{Text: message},
+			Locations: tableLocation(table, locationRoot),
 			PartialFingerprints: map[string]string{
 				"clickspectre/findingHash": fingerprint,
 			},
-			Properties: map[string]any{
-				"category": category,
-				"table":    table,
-			},
+			Properties: properties,
 		})
 	}
 
 	for _, table := range report.CleanupRecommendations.LikelySafe {
 		category := "likely_safe"
 		fingerprint := hashFinding("recommendation", category, table)
+		message, properties := namedRecommendation(category, table, tablesByName)
 		results = append(results, sarifResult{
 			RuleID:    ruleLowUsage,
 			RuleIndex: ruleIndexPtr(ruleIndexLowUsage),
 			Level:     "note",
-			Message:   sarifMessage{Text: fmt.Sprintf("Table %q is marked likely_safe.", table)},
-			Locations: tableLocation(table),
+			Message:   sarifMessage{Text: message},
+			Locations: tableLocation(table, locationRoot),
 			PartialFingerprints: map[string]string{
 				"clickspectre/findingHash": fingerprint,
 			},
-			Properties: map[string]any{
-				"category": category,
-				"table":    table,
-			},
+			Properties: properties,
 		})
 	}
 
@@ -321,7 +357,7 @@ func buildSARIFResults(report *models.Report) []sarifResult {
 			RuleIndex: ruleIndexPtr(ruleIndexAnomaly),
 			Level:     level,
 			Message:   sarifMessage{Text: message},
-			Locations: anomalyLocation(anomaly),
+			Locations: anomalyLocation(anomaly, locationRoot),
 			PartialFingerprints: map[string]string{
 				"clickspectre/findingHash": fingerprint,
 			},
@@ -353,22 +389,22 @@ func buildTableName(database string, name string) string {
 	}
 }
 
-func tableLocation(tableName string) []sarifLocation {
+func tableLocation(tableName, locationRoot string) []sarifLocation {
 	normalized := strings.TrimSpace(tableName)
 	if normalized == "" {
 		normalized = "unknown_table"
 	}
 
-	name := normalized
+	database, name := "", normalized
 	if strings.Contains(normalized, ".") {
 		parts := strings.SplitN(normalized, ".", 2)
-		name = parts[1]
+		database, name = parts[0], parts[1]
 	}
 
 	return []sarifLocation{
 		{
 			PhysicalLocation: sarifPhysicalLocation{
-				ArtifactLocation: sarifArtifactLocation{URI: sarifFallbackLocationURI},
+				ArtifactLocation: sarifArtifactLocation{URI: tableArtifactURI(locationRoot, database, name)},
 				Region: &sarifRegion{
 					StartLine: 1,
 				},
@@ -384,9 +420,24 @@ func tableLocation(tableName string) []sarifLocation {
 	}
 }
 
-func anomalyLocation(anomaly models.Anomaly) []sarifLocation {
+// tableArtifactURI maps a table to its SQL definition under locationRoot,
+// following the <root>/<db>/<table>.sql convention.
+func tableArtifactURI(locationRoot, database, table string) string {
+	root := strings.TrimSpace(locationRoot)
+	if root == "" {
+		return sarifFallbackLocationURI
+	}
+	// SARIF URIs always use forward slashes
+	root = filepath.ToSlash(root)
+	if database == "" {
+		return path.Join(root, table+".sql")
+	}
+	return path.Join(root, database, table+".sql")
+}
+
+func anomalyLocation(anomaly models.Anomaly, locationRoot string) []sarifLocation {
 	if table := strings.TrimSpace(anomaly.AffectedTable); table != "" {
-		return tableLocation(table)
+		return tableLocation(table, locationRoot)
 	}
 
 	logical := sarifLogicalLocation{
@@ -415,6 +466,28 @@ func anomalyLocation(anomaly models.Anomaly) []sarifLocation {
 	}
 }
 
+// namedRecommendation builds the message and properties for a name-only
+// recommendation, attaching size and engine details when the table is known.
+func namedRecommendation(category, table string, tables map[string]models.Table) (string, map[string]any) {
+	properties := map[string]any{
+		"category": category,
+		"table":    table,
+	}
+
+	info, ok := tables[normalizeNamedTable(table)]
+	if !ok {
+		return fmt.Sprintf("Table %q is marked %s.", table, category), properties
+	}
+
+	sizeMB := float64(info.TotalBytes) / 1e6
+	properties["database"] = info.Database
+	properties["engine"] = info.Engine
+	properties["rows"] = info.TotalRows
+	properties["size_mb"] = sizeMB
+	properties["is_replicated"] = info.IsReplicated
+	return fmt.Sprintf("Table %q is marked %s (size: %s, rows: %s).", table, category, format.HumanBytes(info.TotalBytes), format.HumanCount(info.TotalRows)), properties
+}
+
 func normalizeSeverity(severity string) string {
 	normalized := strings.ToLower(strings.TrimSpace(severity))
 	if normalized == "" {
@@ -113,6 +113,113 @@ func TestWriteSARIFProducesExpectedShape(t *testing.T) {
 	}
 }
 
+func TestBuildSARIFAttachesSizeToNamedRecommendations(t *testing.T) {
+	report := &models.Report{
+		Tables: []models.Table{
+			{FullName: "db.stale", Database: "db", Engine: "MergeTree", TotalBytes: 250 * 1e6, TotalRows: 1234},
+		},
+		CleanupRecommendations: models.CleanupRecommendations{
+			SafeToDrop:       []string{"db.stale"},
+			LikelySafe:       []string{"db.unknown"},
+			ReclaimableBytes: 250 * 1e6,
+		},
+	}
+
+	// Round-trip through JSON so property types match what dashboards see
+	data, err := json.Marshal(buildSARIF(report, config.DefaultConfig()))
+	if err != nil {
+		t.Fatalf("failed to marshal SARIF: %v", err)
+	}
+	var decoded struct {
+		Runs []struct {
+			Properties map[string]any `json:"properties"`
+			Results    []struct {
+				Message    struct{ Text string } `json:"message"`
+				Properties map[string]any        `json:"properties"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode SARIF: %v", err)
+	}
+
+	run := decoded.Runs[0]
+	if got, ok := run.Properties["reclaimable_bytes"].(float64); !ok || got != 250e6 {
+		t.Fatalf("expected run reclaimable_bytes 250e6, got %v", run.Properties["reclaimable_bytes"])
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+
+	safe := run.Results[0]
+	if safe.Properties["category"] != "safe_to_drop" {
+		t.Fatalf("expected safe_to_drop result first, got %v", safe.Properties)
+	}
+	if got, ok := safe.Properties["size_mb"].(float64); !ok || got != 250 {
+		t.Fatalf("expected numeric size_mb 250, got %#v", safe.Properties["size_mb"])
+	}
+	if got, ok := safe.Properties["rows"].(float64); !ok || got != 1234 {
+		t.Fatalf("expected rows 1234, got %#v", safe.Properties["rows"])
+	}
+	if !strings.Contains(safe.Message.Text, "size: 250.0 MB, rows: 1.2K") {
+		t.Fatalf("expected size in message, got %q", safe.Message.Text)
+	}
+
+	// Tables missing from the table list keep the name-only shape
+	if _, ok := run.Results[1].Properties["size_mb"]; ok {
+		t.Fatalf("expected no size_mb for unknown table, got %v", run.Results[1].Properties)
+	}
+}
+
+func TestSARIFResultLocations(t *testing.T) {
+	report := &models.Report{
+		CleanupRecommendations: models.CleanupRecommendations{
+			SafeToDrop: []string{"analytics.events", "orphan"},
+		},
+		Anomalies: []models.Anomaly{
+			{Type: "usage_spike", Severity: "high", AffectedTable: "analytics.events"},
+			{Type: "broad_access", Severity: "medium", AffectedService: "etl"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		root     string
+		wantURIs []string
+	}{
+		{
+			name:     "fallback_to_readme",
+			wantURIs: []string{"README.md", "README.md", "README.md", "README.md"},
+		},
+		{
+			name:     "configured_root",
+			root:     "schema/",
+			wantURIs: []string{"schema/analytics/events.sql", "schema/orphan.sql", "schema/analytics/events.sql", "README.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := buildSARIFResults(report, tt.root)
+			if len(results) != len(tt.wantURIs) {
+				t.Fatalf("expected %d results, got %d", len(tt.wantURIs), len(results))
+			}
+			for i, result := range results {
+				loc := result.Locations[0]
+				if got := loc.PhysicalLocation.ArtifactLocation.URI; got != tt.wantURIs[i] {
+					t.Fatalf("result %d uri = %q, want %q", i, got, tt.wantURIs[i])
+				}
+				if len(loc.LogicalLocations) != 1 || loc.LogicalLocations[0].FullyQualifiedName == "" {
+					t.Fatalf("result %d missing logical location: %+v", i, loc.LogicalLocations)
+				}
+			}
+			if got := results[0].Locations[0].LogicalLocations[0]; got.FullyQualifiedName != "analytics.events" || got.Name != "events" || got.Kind != "table" {
+				t.Fatalf("unexpected table logical location: %+v", got)
+			}
+		})
+	}
+}
+
 func TestReporterGenerateSARIFFormat(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.OutputDir = t.TempDir()
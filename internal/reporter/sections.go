@@ -0,0 +1,104 @@
+package reporter
+
+import (
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+const (
+	sectionTables          = "tables"
+	sectionAnomalies       = "anomalies"
+	sectionRecommendations = "recommendations"
+	sectionServices        = "services"
+)
+
+// ReportSections lists the sections accepted by --report-sections.
+var ReportSections = []string{sectionTables, sectionAnomalies, sectionRecommendations, sectionServices}
+
+// sectionSet records which report sections the json, text, and markdown
+// formats render. A nil set selects every section.
+type sectionSet map[string]bool
+
+// selectedSections returns the sections chosen by cfg.ReportSections, or nil
+// when none are set or every section is listed.
+func selectedSections(cfg *config.Config) sectionSet {
+	if cfg == nil || len(cfg.ReportSections) == 0 {
+		return nil
+	}
+	sections := make(sectionSet, len(cfg.ReportSections))
+	for _, name := range cfg.ReportSections {
+		sections[name] = true
+	}
+	for _, name := range ReportSections {
+		if !sections[name] {
+			return sections
+		}
+	}
+	return nil
+}
+
+// has reports whether section is selected.
+func (s sectionSet) has(section string) bool {
+	return s == nil || s[section]
+}
+
+// filter returns a shallow copy of report with unselected sections emptied.
+// Edges and the rolled-up graph belong to the services section, since they
+// map services to tables.
+func (s sectionSet) filter(report *models.Report) *models.Report {
+	if s == nil || report == nil {
+		return report
+	}
+	filtered := *report
+	if !s.has(sectionTables) {
+		filtered.Tables = nil
+	}
+	if !s.has(sectionServices) {
+		filtered.Services = nil
+		filtered.Edges = nil
+		filtered.Graph = nil
+	}
+	if !s.has(sectionAnomalies) {
+		filtered.Anomalies = nil
+	}
+	if !s.has(sectionRecommendations) {
+		filtered.CleanupRecommendations = models.CleanupRecommendations{}
+	}
+	return &filtered
+}
+
+// sectionedReport shadows the selectable sections of models.Report with
+// omitempty pointers, so unselected sections drop out of the JSON entirely.
+type sectionedReport struct {
+	*models.Report
+	Tables                 *[]models.Table                `json:"tables,omitempty"`
+	Services               *[]models.Service              `json:"services,omitempty"`
+	Edges                  *[]models.Edge                 `json:"edges,omitempty"`
+	Anomalies              *[]models.Anomaly              `json:"anomalies,omitempty"`
+	CleanupRecommendations *models.CleanupRecommendations `json:"cleanup_recommendations,omitempty"`
+	Graph                  *models.ServiceGraph           `json:"graph,omitempty"`
+}
+
+// jsonReport returns the value to encode for report: the report itself when
+// every section is selected, otherwise a sectionedReport without the rest.
+func (s sectionSet) jsonReport(report *models.Report) any {
+	if s == nil || report == nil {
+		return report
+	}
+	out := sectionedReport{Report: report}
+	if s.has(sectionTables) {
+		out.Tables = &report.Tables
+	}
+	if s.has(sectionServices) {
+		out.Services = &report.Services
+		out.Edges = &report.Edges
+		out.Graph = report.Graph
+	}
+	if s.has(sectionAnomalies) {
+		out.Anomalies = &report.Anomalies
+	}
+	if s.has(sectionRecommendations) {
+		out.CleanupRecommendations = &report.CleanupRecommendations
+	}
+	return out
+}
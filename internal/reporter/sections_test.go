@@ -0,0 +1,153 @@
+package reporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+func sectionsTestReport() *models.Report {
+	return &models.Report{
+		SchemaVersion: models.ReportSchemaVersion,
+		Tool:          "clickspectre",
+		Timestamp:     "2026-02-15T00:00:00Z",
+		Metadata:      models.Metadata{ClickHouseHost: "ch-1", LookbackDays: 30},
+		Tables: []models.Table{
+			{FullName: "db.orders", Score: 0.9, Category: "active", Reads: 120},
+			{FullName: "db.old_events", Score: 0.1, Category: "unused", ZeroUsage: true},
+		},
+		Services: []models.Service{{IP: "10.0.0.1", K8sService: "checkout", QueryCount: 12, TablesUsed: []string{"db.orders"}}},
+		Edges:    []models.Edge{{ServiceIP: "10.0.0.1", TableName: "db.orders", Reads: 120}},
+		Anomalies: []models.Anomaly{
+			{Type: "usage_spike", Severity: "high", Description: "reads spiked", AffectedTable: "db.orders"},
+			{Type: "broad_access", Severity: "medium", Description: "touches 40 tables", AffectedService: "etl"},
+		},
+		CleanupRecommendations: models.CleanupRecommendations{
+			SafeToDrop:       []string{"db.old_events"},
+			ReclaimableBytes: 5e8,
+		},
+	}
+}
+
+func TestSelectedSections(t *testing.T) {
+	cases := []struct {
+		name     string
+		sections []string
+		wantAll  bool
+	}{
+		{name: "unset", wantAll: true},
+		{name: "every_section", sections: []string{"services", "tables", "recommendations", "anomalies"}, wantAll: true},
+		{name: "subset", sections: []string{"anomalies"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.ReportSections = tc.sections
+			if got := selectedSections(cfg) == nil; got != tc.wantAll {
+				t.Fatalf("expected all sections=%v, got %v", tc.wantAll, got)
+			}
+		})
+	}
+}
+
+func TestWriteJSONAnomaliesOnly(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.ReportSections = []string{"anomalies"}
+
+	if err := WriteJSON(sectionsTestReport(), cfg); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.OutputDir, "report.json"))
+	if err != nil {
+		t.Fatalf("failed to read report.json: %v", err)
+	}
+
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode report.json: %v", err)
+	}
+	for _, key := range []string{"tables", "services", "edges", "cleanup_recommendations"} {
+		if _, ok := decoded[key]; ok {
+			t.Fatalf("expected %q to be omitted, got:\n%s", key, data)
+		}
+	}
+	for _, key := range []string{"schema_version", "metadata", "anomalies"} {
+		if _, ok := decoded[key]; !ok {
+			t.Fatalf("expected %q to be kept, got:\n%s", key, data)
+		}
+	}
+
+	var anomalies []models.Anomaly
+	if err := json.Unmarshal(decoded["anomalies"], &anomalies); err != nil || len(anomalies) != 2 {
+		t.Fatalf("expected 2 anomalies, got %v (%v)", anomalies, err)
+	}
+}
+
+func TestRenderTextAnomaliesOnly(t *testing.T) {
+	output := renderTextReport(sectionsTestReport(), false, 0, "score", sectionSet{sectionAnomalies: true})
+
+	assertContains(t, output, "Anomalies\n---------\n")
+	assertContains(t, output, "- db.orders: anomaly[high]: reads spiked\n")
+	assertContains(t, output, "- anomaly[medium]: touches 40 tables (service=etl)\n")
+	for _, unwanted := range []string{"Summary", "Findings By Table", "Details", "Services", "Recommendations", "checkout"} {
+		if strings.Contains(output, unwanted) {
+			t.Fatalf("expected %q to be omitted, got:\n%s", unwanted, output)
+		}
+	}
+}
+
+func TestRenderTextStandaloneSections(t *testing.T) {
+	sections := sectionSet{sectionRecommendations: true, sectionServices: true}
+	output := renderTextReport(sectionsTestReport(), false, 0, "score", sections)
+
+	assertContains(t, output, "Estimated reclaimable: 500.0 MB\n")
+	assertContains(t, output, "- safe_to_drop: db.old_events\n")
+	assertContains(t, output, "- checkout (queries=12 tables=1)\n")
+	for _, unwanted := range []string{"Total tables", "Findings By Table", "Anomalies", "spiked"} {
+		if strings.Contains(output, unwanted) {
+			t.Fatalf("expected %q to be omitted, got:\n%s", unwanted, output)
+		}
+	}
+}
+
+func TestBuildMarkdownAnomaliesOnly(t *testing.T) {
+	output := buildMarkdown(sectionsTestReport(), sectionSet{sectionAnomalies: true})
+
+	assertContains(t, output, "| Anomalies | 2 |\n")
+	assertContains(t, output, "### Anomalies\n")
+	for _, unwanted := range []string{"### Findings", "### Recommendations", "Total tables", "Safe to drop", "db.old_events"} {
+		if strings.Contains(output, unwanted) {
+			t.Fatalf("expected %q to be omitted, got:\n%s", unwanted, output)
+		}
+	}
+}
+
+func TestRenderTextUsesRolledUpGraph(t *testing.T) {
+	report := sectionsTestReport()
+	report.Graph = &models.ServiceGraph{
+		Granularity: "namespace",
+		Services:    []models.Service{{IP: "shop", K8sNamespace: "shop", QueryCount: 12, TablesUsed: []string{"db.orders"}}},
+		Edges:       []models.Edge{{ServiceIP: "shop", ServiceName: "shop", TableName: "db.orders", Reads: 120}},
+	}
+	output := renderTextReport(report, false, 0, "score", sectionSet{sectionServices: true})
+
+	assertContains(t, output, "- shop (queries=12 tables=1)\n")
+	if strings.Contains(output, "checkout") {
+		t.Fatalf("expected per-pod services to be replaced by the graph, got:\n%s", output)
+	}
+
+	data, err := json.Marshal(sectionSet{sectionServices: true}.jsonReport(report))
+	if err != nil {
+		t.Fatalf("failed to marshal report: %v", err)
+	}
+	if !strings.Contains(string(data), `"k8s_service":"checkout"`) || !strings.Contains(string(data), `"granularity":"namespace"`) {
+		t.Fatalf("expected JSON to keep raw services alongside the graph, got:\n%s", data)
+	}
+}
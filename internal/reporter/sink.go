@@ -0,0 +1,397 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+// Sink receives a rendered report document, e.g. report.json, in place of
+// the local output directory.
+type Sink interface {
+	Put(ctx context.Context, name, contentType string, data []byte) error
+}
+
+// sinkTimeout bounds a single upload.
+const sinkTimeout = 2 * time.Minute
+
+// NewSink returns the sink for an --output-url:
+//
+//   - s3://bucket/prefix/ uploads to Amazon S3 (or AWS_ENDPOINT_URL) with
+//     the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN
+//     credentials in AWS_REGION
+//   - gs://bucket/prefix/ uploads to Google Cloud Storage with
+//     GOOGLE_OAUTH_ACCESS_TOKEN, else a token from the GCE metadata server
+//   - http(s)://host/path POSTs the document, with basic auth from the URL
+//
+// Object URLs ending in "/" (or naming only the bucket) get the document
+// name appended; otherwise the URL is the object itself. NewSink makes no
+// network calls.
+func NewSink(rawURL string) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output URL %s", RedactURL(rawURL))
+	}
+	client := &http.Client{Timeout: sinkTimeout}
+
+	switch strings.ToLower(u.Scheme) {
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid output URL %s: missing bucket", RedactURL(rawURL))
+		}
+		sink, err := newS3Sink(client, u.Host, strings.TrimPrefix(u.Path, "/"))
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	case "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid output URL %s: missing bucket", RedactURL(rawURL))
+		}
+		return &gcsSink{client: client, bucket: u.Host, key: strings.TrimPrefix(u.Path, "/")}, nil
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid output URL %s: missing host", RedactURL(rawURL))
+		}
+		return &httpSink{client: client, url: u}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output URL scheme %q (supported: s3, gs, http, https)", u.Scheme)
+	}
+}
+
+// RedactURL returns rawURL without its password and query string, which may
+// hold credentials, for logs and errors.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<unparseable URL>"
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = "REDACTED"
+	}
+	return u.Redacted()
+}
+
+// GenerateToSink renders the report in cfg.Format and uploads it to sink.
+func GenerateToSink(ctx context.Context, sink Sink, report *models.Report, cfg *config.Config) error {
+	name, contentType, data, err := renderDocument(report, cfg)
+	if err != nil {
+		return err
+	}
+	if err := sink.Put(ctx, name, contentType, data); err != nil {
+		return err
+	}
+	slog.Debug("report uploaded", slog.String("document", name), slog.Int("bytes", len(data)))
+	return nil
+}
+
+// renderDocument renders the single report document written for cfg.Format,
+// returning its file name and content type.
+func renderDocument(report *models.Report, cfg *config.Config) (string, string, []byte, error) {
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		data, err := json.MarshalIndent(selectedSections(cfg).jsonReport(report), "", "  ")
+		return "report.json", "application/json", data, err
+	case "text":
+		rendered := renderTextReport(report, false, cfg.TextTop, cfg.TextSortBy, selectedSections(cfg))
+		return "report.txt", "text/plain; charset=utf-8", []byte(rendered), nil
+	case "sarif":
+		data, err := json.MarshalIndent(buildSARIF(report, cfg), "", "  ")
+		return "report.sarif", "application/sarif+json", data, err
+	case "spectrehub":
+		data, err := json.MarshalIndent(buildSpectreHub(report, cfg), "", "  ")
+		return "report.spectrehub.json", "application/json", data, err
+	case "openmetrics":
+		return "report.prom", "application/openmetrics-text; version=1.0.0; charset=utf-8", []byte(buildOpenMetrics(report)), nil
+	case "markdown":
+		return "report.md", "text/markdown; charset=utf-8", []byte(buildMarkdown(report, selectedSections(cfg))), nil
+	default:
+		return "", "", nil, fmt.Errorf("unsupported format %q", cfg.Format)
+	}
+}
+
+// objectKey appends name to an object prefix, or returns key as-is when it
+// names an object.
+func objectKey(key, name string) string {
+	if key == "" || strings.HasSuffix(key, "/") {
+		return key + name
+	}
+	return key
+}
+
+// doUpload sends req and fails on non-2xx responses. target is the redacted
+// destination used in errors, since url.Error would print the full URL.
+func doUpload(client *http.Client, req *http.Request, target string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to upload report to %s: %w", target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to upload report to %s: %s: %s", target, resp.Status, strings.TrimSpace(string(body)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// httpSink POSTs the document to an ingest endpoint.
+type httpSink struct {
+	client *http.Client
+	url    *url.URL
+}
+
+func (s *httpSink) Put(ctx context.Context, name, contentType string, data []byte) error {
+	// Credentials go in the Authorization header, never into the request URL
+	target := *s.url
+	user := target.User
+	target.User = nil
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build upload request for %s", RedactURL(s.url.String()))
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Clickspectre-Document", name)
+	if user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+	return doUpload(s.client, req, RedactURL(s.url.String()))
+}
+
+// s3Sink uploads with a SigV4-signed PutObject request.
+type s3Sink struct {
+	client       *http.Client
+	endpoint     *url.URL // Custom S3-compatible endpoint; nil for AWS
+	bucket       string
+	key          string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	now          func() time.Time
+}
+
+func newS3Sink(client *http.Client, bucket, key string) (*s3Sink, error) {
+	s := &s3Sink{
+		client:       client,
+		bucket:       bucket,
+		key:          key,
+		region:       firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		now:          time.Now,
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("s3 output needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid AWS_ENDPOINT_URL %s", RedactURL(endpoint))
+		}
+		s.endpoint = u
+	}
+	return s, nil
+}
+
+func (s *s3Sink) Put(ctx context.Context, name, contentType string, data []byte) error {
+	key := objectKey(s.key, name)
+
+	// AWS uses virtual-hosted buckets; custom endpoints (MinIO and the like)
+	// are addressed path-style
+	target := &url.URL{Scheme: "https", Host: s.bucket + ".s3." + s.region + ".amazonaws.com", Path: "/" + key}
+	if s.endpoint != nil {
+		target = &url.URL{Scheme: s.endpoint.Scheme, Host: s.endpoint.Host, Path: strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.bucket + "/" + key}
+	}
+	target.RawPath = uriEncodePath(target.Path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build upload request for s3://%s/%s", s.bucket, key)
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data)
+	return doUpload(s.client, req, "s3://"+s.bucket+"/"+key)
+}
+
+// sign adds AWS Signature Version 4 headers for the S3 service.
+func (s *s3Sink) sign(req *http.Request, payload []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// gcsSink uploads through the Cloud Storage XML API with an OAuth token.
+type gcsSink struct {
+	client *http.Client
+	bucket string
+	key    string
+}
+
+func (s *gcsSink) Put(ctx context.Context, name, contentType string, data []byte) error {
+	key := objectKey(s.key, name)
+	token, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	// STORAGE_EMULATOR_HOST points the upload at a local GCS emulator
+	target := &url.URL{Scheme: "https", Host: "storage.googleapis.com"}
+	if emulator := os.Getenv("STORAGE_EMULATOR_HOST"); emulator != "" {
+		target.Scheme, target.Host = "http", strings.TrimPrefix(emulator, "http://")
+	}
+	target.Path = "/" + s.bucket + "/" + key
+	target.RawPath = uriEncodePath(target.Path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build upload request for gs://%s/%s", s.bucket, key)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return doUpload(s.client, req, "gs://"+s.bucket+"/"+key)
+}
+
+// token returns GOOGLE_OAUTH_ACCESS_TOKEN, or the default service account
+// token from the GCE metadata server (GCE_METADATA_HOST overrides its host).
+func (s *gcsSink) token(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build metadata token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gs output needs GOOGLE_OAUTH_ACCESS_TOKEN or the GCE metadata server: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gs output needs GOOGLE_OAUTH_ACCESS_TOKEN or the GCE metadata server: %s", resp.Status)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.AccessToken == "" {
+		return "", fmt.Errorf("failed to read access token from the GCE metadata server")
+	}
+	return body.AccessToken, nil
+}
+
+// uriEncodePath percent-encodes each segment of path with the RFC 3986
+// unreserved set, as SigV4 and Cloud Storage object names require.
+func uriEncodePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		var b strings.Builder
+		for _, c := range []byte(segment) {
+			if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+				c == '-' || c == '_' || c == '.' || c == '~' {
+				b.WriteByte(c)
+				continue
+			}
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+		segments[i] = b.String()
+	}
+	return strings.Join(segments, "/")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// firstEnv returns the first non-empty environment variable of names.
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
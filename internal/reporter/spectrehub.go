@@ -23,6 +23,7 @@ type spectreEnvelope struct {
 	Target    spectreTarget    `json:"target"`
 	Findings  []spectreFinding `json:"findings"`
 	Summary   spectreSummary   `json:"summary"`
+	Warnings  []string         `json:"warnings,omitempty"`
 }
 
 type spectreTarget struct {
@@ -115,6 +116,10 @@ func buildSpectreHub(report *models.Report, cfg *config.Config) *spectreEnvelope
 		countSev(&envelope.Summary, severity)
 	}
 
+	if warning := truncationWarning(report); warning != "" {
+		envelope.Warnings = append(envelope.Warnings, warning)
+	}
+
 	envelope.Summary.Total = len(envelope.Findings)
 	if envelope.Findings == nil {
 		envelope.Findings = []spectreFinding{}
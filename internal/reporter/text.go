@@ -10,26 +10,46 @@ import (
 	"time"
 
 	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/internal/reporter/format"
 	"github.com/ppiankov/clickspectre/pkg/config"
 )
 
 const (
 	textANSIReset = "\x1b[0m"
 	textANSIBold  = "\x1b[1m"
+
+	// textSampleQueryWidth caps each sample query line in the details section
+	textSampleQueryWidth = 200
 )
 
+// TextSortKeys lists the orders accepted by --sort-by for the text report.
+var TextSortKeys = []string{"score", "reads", "writes", "size", "last_access", "severity"}
+
+// ColorModes lists the values accepted by --color.
+var ColorModes = []string{"auto", "always", "never"}
+
 type textServiceUsage struct {
 	Reads  uint64
 	Writes uint64
+	Kinds  map[string]uint64
 }
 
 type textTableFinding struct {
-	Name     string
-	Score    float64
-	HasScore bool
-	Category string
-	Services map[string]textServiceUsage
-	Findings []string
+	Name       string
+	Score      float64
+	HasScore   bool
+	Category   string
+	Reads      uint64
+	Writes     uint64
+	SizeBytes  uint64
+	Severity   string // Highest severity among the table's anomalies, "" without any
+	LastAccess time.Time
+	Services   map[string]textServiceUsage
+	Consumers  []models.EdgeSummary
+	Findings   []string
+	Samples    []string
+	Heatmap    *models.AccessHeatmap
+	KeepReason string
 }
 
 // WriteText writes a human-readable text report to report.txt and stdout.
@@ -52,13 +72,17 @@ func writeText(report *models.Report, cfg *config.Config, out io.Writer) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	rendered := renderTextReport(report, supportsANSI(out))
+	// report.txt stays plain; only the copy on out may be styled
+	rendered := renderTextReport(report, false, cfg.TextTop, cfg.TextSortBy, selectedSections(cfg))
 	outputPath := filepath.Join(cfg.OutputDir, "report.txt")
 
 	if err := os.WriteFile(outputPath, []byte(rendered), 0644); err != nil {
 		return fmt.Errorf("failed to write report.txt: %w", err)
 	}
 
+	if colorEnabled(cfg.Color, out) {
+		rendered = renderTextReport(report, true, cfg.TextTop, cfg.TextSortBy, selectedSections(cfg))
+	}
 	if _, err := io.WriteString(out, rendered); err != nil {
 		return fmt.Errorf("failed to write text report to output: %w", err)
 	}
@@ -66,13 +90,19 @@ func writeText(report *models.Report, cfg *config.Config, out io.Writer) error {
 	return nil
 }
 
-func renderTextReport(report *models.Report, useANSI bool) string {
+// renderTextReport renders report as plain text. Table findings are ordered
+// by sortBy and, when top is positive, limited to the first top tables.
+// Sections not in sections are left out; without the tables section,
+// anomalies, recommendations, and services are listed on their own instead
+// of per table.
+func renderTextReport(report *models.Report, useANSI bool, top int, sortBy string, sections sectionSet) string {
 	var b strings.Builder
+	report = graphView(sections.filter(report))
 
 	generatedAt := strings.TrimSpace(report.Timestamp)
 	if generatedAt == "" {
 		if !report.Metadata.GeneratedAt.IsZero() {
-			generatedAt = report.Metadata.GeneratedAt.UTC().Format(time.RFC3339)
+			generatedAt = report.Metadata.GeneratedAt.In(reportLocation(report)).Format(time.RFC3339)
 		} else {
 			generatedAt = "unknown"
 		}
@@ -88,19 +118,45 @@ func renderTextReport(report *models.Report, useANSI bool) string {
 	fmt.Fprintf(&b, "ClickHouse host: %s\n", host)
 	fmt.Fprintf(&b, "Lookback days: %d\n", report.Metadata.LookbackDays)
 	fmt.Fprintf(&b, "Total queries analyzed: %d\n", report.Metadata.TotalQueriesAnalyzed)
+	if warning := truncationWarning(report); warning != "" {
+		label := "WARNING:"
+		if useANSI {
+			label = textANSIBold + label + textANSIReset
+		}
+		fmt.Fprintf(&b, "%s %s\n", label, warning)
+	}
 	b.WriteString("\n")
 
-	lowScore, mediumScore, highScore := scoreDistribution(report.Tables)
-	writeTextSectionHeader(&b, "Summary", useANSI)
-	fmt.Fprintf(&b, "Total tables: %d\n", len(report.Tables))
-	fmt.Fprintf(&b, "Unused tables: %d\n", countUnusedTables(report.Tables))
-	b.WriteString("Score distribution:\n")
-	fmt.Fprintf(&b, "  0.00-0.29: %d\n", lowScore)
-	fmt.Fprintf(&b, "  0.30-0.69: %d\n", mediumScore)
-	fmt.Fprintf(&b, "  0.70-1.00: %d\n", highScore)
-	b.WriteString("\n")
+	if sections.has(sectionTables) || sections.has(sectionRecommendations) {
+		writeTextSectionHeader(&b, "Summary", useANSI)
+		if sections.has(sectionTables) {
+			fmt.Fprintf(&b, "Total tables: %d\n", len(report.Tables))
+			fmt.Fprintf(&b, "Unused tables: %d\n", countUnusedTables(report.Tables))
+		}
+		if sections.has(sectionRecommendations) {
+			fmt.Fprintf(&b, "Estimated reclaimable: %s\n", format.HumanBytes(report.CleanupRecommendations.ReclaimableBytes))
+		}
+		if sections.has(sectionTables) {
+			lowScore, mediumScore, highScore := scoreDistribution(report.Tables)
+			b.WriteString("Score distribution:\n")
+			fmt.Fprintf(&b, "  0.00-0.29: %d\n", lowScore)
+			fmt.Fprintf(&b, "  0.30-0.69: %d\n", mediumScore)
+			fmt.Fprintf(&b, "  0.70-1.00: %d\n", highScore)
+		}
+		b.WriteString("\n")
+	}
+
+	if !sections.has(sectionTables) {
+		writeTextStandaloneSections(&b, report, sections, useANSI)
+		return b.String()
+	}
 
-	findingsByTable, globalAnomalies := buildTableFindings(report)
+	findingsByTable, globalAnomalies := buildTableFindings(report, sortBy)
+	omitted := 0
+	if top > 0 && len(findingsByTable) > top {
+		omitted = len(findingsByTable) - top
+		findingsByTable = findingsByTable[:top]
+	}
 	writeTextSectionHeader(&b, "Findings By Table", useANSI)
 	if len(findingsByTable) == 0 {
 		b.WriteString("No table findings detected.\n")
@@ -122,6 +178,9 @@ func renderTextReport(report *models.Report, useANSI bool) string {
 				len(finding.Findings),
 			)
 		}
+		if omitted > 0 {
+			fmt.Fprintf(&b, "... %d more tables omitted (showing top %d by %s)\n", omitted, top, textSortKey(sortBy))
+		}
 	}
 
 	if len(findingsByTable) > 0 {
@@ -133,7 +192,19 @@ func renderTextReport(report *models.Report, useANSI bool) string {
 				score = fmt.Sprintf("%.2f", finding.Score)
 			}
 
-			fmt.Fprintf(&b, "%s | safety score=%s | category=%s\n", finding.Name, score, textCategory(finding.Category))
+			// High-severity anomalies are marked so they stand out while triaging
+			name := finding.Name
+			if finding.Severity == "high" {
+				marker := "[!]"
+				if useANSI {
+					marker = textANSIBold + marker + textANSIReset
+				}
+				name = marker + " " + name
+			}
+			fmt.Fprintf(&b, "%s | safety score=%s | category=%s\n", name, score, textCategory(finding.Category))
+			if finding.KeepReason != "" {
+				fmt.Fprintf(&b, "  kept: %s\n", finding.KeepReason)
+			}
 
 			serviceMappings := sortedServiceMappings(finding.Services)
 			if len(serviceMappings) == 0 {
@@ -144,11 +215,27 @@ func renderTextReport(report *models.Report, useANSI bool) string {
 					fmt.Fprintf(&b, "    - %s\n", mapping)
 				}
 			}
+			if len(finding.Consumers) > 0 {
+				b.WriteString("  top consumers:\n")
+				for i, consumer := range finding.Consumers {
+					fmt.Fprintf(&b, "    %d. %s (reads=%d writes=%d)\n", i+1, consumer.Service, consumer.Reads, consumer.Writes)
+				}
+			}
 
 			b.WriteString("  findings:\n")
 			for _, item := range finding.Findings {
 				fmt.Fprintf(&b, "    - %s\n", item)
 			}
+
+			if len(finding.Samples) > 0 {
+				b.WriteString("  sample queries:\n")
+				for _, query := range finding.Samples {
+					fmt.Fprintf(&b, "    - %s\n", truncateTextValue(strings.Join(strings.Fields(query), " "), textSampleQueryWidth))
+				}
+			}
+			if finding.Heatmap != nil {
+				writeTextHeatmap(&b, finding.Heatmap, reportLocation(report))
+			}
 			b.WriteString("\n")
 		}
 	}
@@ -163,6 +250,108 @@ func renderTextReport(report *models.Report, useANSI bool) string {
 	return b.String()
 }
 
+// textHeatmapRamp shades heatmap cells from no queries to the table's
+// busiest hour.
+const textHeatmapRamp = ".:-=+*#%@"
+
+// writeTextHeatmap draws heatmap as one row of hour cells per weekday; loc
+// names the zone its hours were bucketed in.
+func writeTextHeatmap(b *strings.Builder, heatmap *models.AccessHeatmap, loc *time.Location) {
+	var peak uint64
+	for _, day := range heatmap {
+		for _, count := range day {
+			peak = max(peak, count)
+		}
+	}
+	if peak == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "  access heatmap (%s hour, busiest=@):\n", loc)
+	b.WriteString("         0     6     12    18\n")
+	for weekday, day := range heatmap {
+		fmt.Fprintf(b, "    %s  ", time.Weekday(weekday).String()[:3])
+		for _, count := range day {
+			level := 0
+			if count > 0 {
+				level = int((count*uint64(len(textHeatmapRamp)-1) + peak - 1) / peak)
+			}
+			b.WriteByte(textHeatmapRamp[level])
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeTextStandaloneSections lists recommendations, anomalies, and services
+// outside the per-table findings, for reports rendered without tables.
+func writeTextStandaloneSections(b *strings.Builder, report *models.Report, sections sectionSet, useANSI bool) {
+	if sections.has(sectionRecommendations) {
+		lines := textRecommendationLines(report.CleanupRecommendations)
+		writeTextList(b, "Recommendations", "No cleanup recommendations.", lines, useANSI)
+	}
+
+	if sections.has(sectionAnomalies) {
+		lines := make([]string, 0, len(report.Anomalies))
+		for _, anomaly := range report.Anomalies {
+			formatted := formatAnomalyFinding(anomaly)
+			if tableName := strings.TrimSpace(anomaly.AffectedTable); tableName != "" {
+				formatted = tableName + ": " + formatted
+			}
+			lines = append(lines, formatted)
+		}
+		sort.Strings(lines)
+		writeTextList(b, "Anomalies", "No anomalies detected.", lines, useANSI)
+	}
+
+	if sections.has(sectionServices) {
+		serviceByIP := make(map[string]models.Service, len(report.Services))
+		for _, service := range report.Services {
+			serviceByIP[strings.TrimSpace(service.IP)] = service
+		}
+		lines := make([]string, 0, len(report.Services))
+		for _, service := range report.Services {
+			label := resolveServiceLabel(models.Edge{ServiceIP: service.IP}, serviceByIP)
+			lines = append(lines, fmt.Sprintf("%s (queries=%d tables=%d)", label, service.QueryCount, len(service.TablesUsed)))
+		}
+		sort.Strings(lines)
+		writeTextList(b, "Services", "No services detected.", lines, useANSI)
+	}
+}
+
+// reportLocation returns the zone report's time buckets were built in, UTC
+// for reports that predate --timezone.
+func reportLocation(report *models.Report) *time.Location {
+	loc, err := config.LoadTimezone(report.Metadata.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// graphView returns a shallow copy of report whose services and edges are
+// the rolled-up graph, when --graph-granularity produced one.
+func graphView(report *models.Report) *models.Report {
+	if report == nil || report.Graph == nil {
+		return report
+	}
+	view := *report
+	view.Services = report.Graph.Services
+	view.Edges = report.Graph.Edges
+	return &view
+}
+
+// writeTextList writes a titled bullet list, or empty when there are no lines.
+func writeTextList(b *strings.Builder, title, empty string, lines []string, useANSI bool) {
+	writeTextSectionHeader(b, title, useANSI)
+	if len(lines) == 0 {
+		fmt.Fprintf(b, "%s\n", empty)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(b, "- %s\n", line)
+	}
+	b.WriteString("\n")
+}
+
 func writeTextSectionHeader(b *strings.Builder, title string, useANSI bool) {
 	header := title
 	if useANSI {
@@ -172,7 +361,30 @@ func writeTextSectionHeader(b *strings.Builder, title string, useANSI bool) {
 	fmt.Fprintf(b, "%s\n", strings.Repeat("-", len(title)))
 }
 
-func supportsANSI(out io.Writer) bool {
+// colorEnabled decides whether the text report written to out is styled
+// with ANSI escapes. "always" and "never" are final. Otherwise ("auto" or
+// empty) a non-empty NO_COLOR disables color, a FORCE_COLOR other than "0"
+// or "false" enables it, e.g. for CI logs that render ANSI, and failing
+// both, color is used only when out is a terminal.
+func colorEnabled(mode string, out io.Writer) bool {
+	switch strings.ToLower(mode) {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := strings.ToLower(os.Getenv("FORCE_COLOR")); force != "" {
+		return force != "0" && force != "false"
+	}
+	return isTerminal(out)
+}
+
+// isTerminal reports whether out is a character device. It is a variable so
+// tests can stand in for a terminal.
+var isTerminal = func(out io.Writer) bool {
 	file, ok := out.(*os.File)
 	if !ok {
 		return false
@@ -186,7 +398,9 @@ func supportsANSI(out io.Writer) bool {
 	return info.Mode()&os.ModeCharDevice != 0
 }
 
-func buildTableFindings(report *models.Report) ([]textTableFinding, []string) {
+// buildTableFindings groups findings per table, ordered by sortBy (see
+// TextSortKeys). An empty sortBy orders by ascending score.
+func buildTableFindings(report *models.Report, sortBy string) ([]textTableFinding, []string) {
 	serviceByIP := make(map[string]models.Service, len(report.Services))
 	for _, service := range report.Services {
 		serviceByIP[strings.TrimSpace(service.IP)] = service
@@ -199,6 +413,18 @@ func buildTableFindings(report *models.Report) ([]textTableFinding, []string) {
 		entry.HasScore = true
 		entry.Score = table.Score
 		entry.Category = normalizeCategory(table.Category, table.ZeroUsage, table.Score)
+		entry.Reads = table.Reads
+		entry.Writes = table.Writes
+		entry.SizeBytes = table.TotalBytes
+		entry.LastAccess = table.LastAccess
+		entry.Samples = table.SampleQueries
+		entry.Consumers = table.TopConsumers
+		entry.Heatmap = table.Heatmap
+	}
+	for tableName, reason := range report.CleanupRecommendations.KeepReasons {
+		if entry, ok := findings[normalizeNamedTable(tableName)]; ok {
+			entry.KeepReason = reason
+		}
 	}
 
 	for _, edge := range report.Edges {
@@ -211,20 +437,34 @@ func buildTableFindings(report *models.Report) ([]textTableFinding, []string) {
 		usage := entry.Services[serviceName]
 		usage.Reads += edge.Reads
 		usage.Writes += edge.Writes
+		for kind, count := range edge.KindCounts {
+			if usage.Kinds == nil {
+				usage.Kinds = make(map[string]uint64)
+			}
+			usage.Kinds[kind] += count
+		}
 		entry.Services[serviceName] = usage
 	}
 
+	// Findings of ranked recommendations carry their confidence
+	ranked := make(map[string]models.TableRecommendation, len(report.CleanupRecommendations.Ranked))
+	for _, item := range report.CleanupRecommendations.Ranked {
+		ranked[item.Category+"/"+item.Name] = item
+	}
 	for _, item := range report.CleanupRecommendations.ZeroUsageNonReplicated {
-		addTableFinding(findings, normalizeNamedTable(item.Name), fmt.Sprintf("zero_usage_non_replicated (size=%.2fMB rows=%d)", item.SizeMB, item.Rows))
+		addTableFinding(findings, normalizeNamedTable(item.Name), withConfidence(fmt.Sprintf("zero_usage_non_replicated (size=%s rows=%s)", format.HumanBytes(mbToBytes(item.SizeMB)), format.HumanCount(item.Rows)), ranked, "zero_usage_non_replicated", item.Name))
 	}
 	for _, item := range report.CleanupRecommendations.ZeroUsageReplicated {
-		addTableFinding(findings, normalizeNamedTable(item.Name), fmt.Sprintf("zero_usage_replicated (size=%.2fMB rows=%d)", item.SizeMB, item.Rows))
+		addTableFinding(findings, normalizeNamedTable(item.Name), withConfidence(fmt.Sprintf("zero_usage_replicated (size=%s rows=%s)", format.HumanBytes(mbToBytes(item.SizeMB)), format.HumanCount(item.Rows)), ranked, "zero_usage_replicated", item.Name))
+	}
+	for _, item := range shadowRecommendations(report.CleanupRecommendations) {
+		addTableFinding(findings, normalizeNamedTable(item.Name), shadowFinding(item))
 	}
 	for _, tableName := range report.CleanupRecommendations.SafeToDrop {
-		addTableFinding(findings, normalizeNamedTable(tableName), "safe_to_drop")
+		addTableFinding(findings, normalizeNamedTable(tableName), withConfidence("safe_to_drop", ranked, "safe_to_drop", tableName))
 	}
 	for _, tableName := range report.CleanupRecommendations.LikelySafe {
-		addTableFinding(findings, normalizeNamedTable(tableName), "likely_safe")
+		addTableFinding(findings, normalizeNamedTable(tableName), withConfidence("likely_safe", ranked, "likely_safe", tableName))
 	}
 
 	globalAnomalies := make([]string, 0)
@@ -236,6 +476,9 @@ func buildTableFindings(report *models.Report) ([]textTableFinding, []string) {
 			continue
 		}
 		addTableFinding(findings, tableName, formatted)
+		if entry := ensureTextTableFinding(findings, tableName); textSeverityRank(anomaly.Severity) > textSeverityRank(entry.Severity) {
+			entry.Severity = anomaly.Severity
+		}
 	}
 	sort.Strings(globalAnomalies)
 
@@ -248,17 +491,153 @@ func buildTableFindings(report *models.Report) ([]textTableFinding, []string) {
 		grouped = append(grouped, *finding)
 	}
 
-	sort.Slice(grouped, func(i, j int) bool {
-		if grouped[i].HasScore != grouped[j].HasScore {
-			return grouped[i].HasScore
+	sortTableFindings(grouped, sortBy)
+
+	return grouped, globalAnomalies
+}
+
+// sortTableFindings orders findings so the most actionable tables come
+// first: lowest score, most reads/writes, largest size, or oldest access.
+// Ties and tables without usage data fall back to the table name. The
+// severity order is for triage instead: tables with the most severe
+// anomalies first, then the largest, whether or not they have usage data.
+func sortTableFindings(findings []textTableFinding, sortBy string) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if textSortKey(sortBy) == "severity" {
+			if rankA, rankB := textSeverityRank(a.Severity), textSeverityRank(b.Severity); rankA != rankB {
+				return rankA > rankB
+			}
+			if a.SizeBytes != b.SizeBytes {
+				return a.SizeBytes > b.SizeBytes
+			}
+			return a.Name < b.Name
 		}
-		if grouped[i].HasScore && grouped[i].Score != grouped[j].Score {
-			return grouped[i].Score < grouped[j].Score
+		if a.HasScore != b.HasScore {
+			return a.HasScore
+		}
+		if a.HasScore {
+			switch textSortKey(sortBy) {
+			case "reads":
+				if a.Reads != b.Reads {
+					return a.Reads > b.Reads
+				}
+			case "writes":
+				if a.Writes != b.Writes {
+					return a.Writes > b.Writes
+				}
+			case "size":
+				if a.SizeBytes != b.SizeBytes {
+					return a.SizeBytes > b.SizeBytes
+				}
+			case "last_access":
+				if !a.LastAccess.Equal(b.LastAccess) {
+					return a.LastAccess.Before(b.LastAccess)
+				}
+			default:
+				if a.Score != b.Score {
+					return a.Score < b.Score
+				}
+			}
 		}
-		return grouped[i].Name < grouped[j].Name
+		return a.Name < b.Name
 	})
+}
 
-	return grouped, globalAnomalies
+// textSeverityRank orders anomaly severities, most severe highest.
+func textSeverityRank(severity string) int {
+	switch severity {
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func textSortKey(sortBy string) string {
+	if sortBy == "" {
+		return "score"
+	}
+	return sortBy
+}
+
+// textRecommendationLines lists the cleanup recommendations, most confident
+// first. Reports written before recommendations were ranked fall back to
+// listing them by category.
+func textRecommendationLines(recs models.CleanupRecommendations) []string {
+	lines := make([]string, 0)
+	if len(recs.Ranked) > 0 {
+		for _, item := range recs.Ranked {
+			lines = append(lines, fmt.Sprintf("%s: %s (%s)", item.Category, normalizeNamedTable(item.Name), recommendationDetail(item)))
+		}
+		for _, item := range shadowRecommendations(recs) {
+			lines = append(lines, normalizeNamedTable(item.Name)+": "+shadowFinding(item))
+		}
+		return lines
+	}
+
+	for _, item := range recs.ZeroUsageNonReplicated {
+		lines = append(lines, fmt.Sprintf("zero_usage_non_replicated: %s (size=%s rows=%s)", normalizeNamedTable(item.Name), format.HumanBytes(mbToBytes(item.SizeMB)), format.HumanCount(item.Rows)))
+	}
+	for _, item := range recs.ZeroUsageReplicated {
+		lines = append(lines, fmt.Sprintf("zero_usage_replicated: %s (size=%s rows=%s)", normalizeNamedTable(item.Name), format.HumanBytes(mbToBytes(item.SizeMB)), format.HumanCount(item.Rows)))
+	}
+	for _, item := range shadowRecommendations(recs) {
+		lines = append(lines, normalizeNamedTable(item.Name)+": "+shadowFinding(item))
+	}
+	for _, tableName := range recs.SafeToDrop {
+		lines = append(lines, "safe_to_drop: "+normalizeNamedTable(tableName))
+	}
+	for _, tableName := range recs.LikelySafe {
+		lines = append(lines, "likely_safe: "+normalizeNamedTable(tableName))
+	}
+	return lines
+}
+
+// recommendationDetail formats the size and confidence of a ranked
+// recommendation, flagging low confidence.
+func recommendationDetail(item models.TableRecommendation) string {
+	detail := fmt.Sprintf("size=%s rows=%s confidence=%.2f", format.HumanBytes(mbToBytes(item.SizeMB)), format.HumanCount(item.Rows), item.Confidence)
+	if item.LowConfidence {
+		detail += " LOW CONFIDENCE"
+	}
+	return detail
+}
+
+// withConfidence appends the confidence of the ranked recommendation of
+// tableName in category to finding, if there is one.
+func withConfidence(finding string, ranked map[string]models.TableRecommendation, category, tableName string) string {
+	item, ok := ranked[category+"/"+tableName]
+	if !ok {
+		return finding
+	}
+	finding += fmt.Sprintf(" confidence=%.2f", item.Confidence)
+	if item.LowConfidence {
+		finding += " LOW CONFIDENCE"
+	}
+	return finding
+}
+
+// shadowRecommendations returns the zero-usage recommendations flagged as
+// shadow tables of an active sibling.
+func shadowRecommendations(recs models.CleanupRecommendations) []models.TableRecommendation {
+	shadows := make([]models.TableRecommendation, 0)
+	for _, list := range [][]models.TableRecommendation{recs.ZeroUsageNonReplicated, recs.ZeroUsageReplicated} {
+		for _, item := range list {
+			if item.ShadowOf != "" {
+				shadows = append(shadows, item)
+			}
+		}
+	}
+	return shadows
+}
+
+func shadowFinding(item models.TableRecommendation) string {
+	return fmt.Sprintf("shadow_table (active sibling=%s)", item.ShadowOf)
 }
 
 func ensureTextTableFinding(findings map[string]*textTableFinding, tableName string) *textTableFinding {
@@ -297,11 +676,36 @@ func sortedServiceMappings(mappings map[string]textServiceUsage) []string {
 	result := make([]string, 0, len(names))
 	for _, name := range names {
 		usage := mappings[name]
-		result = append(result, fmt.Sprintf("%s (reads=%d writes=%d)", name, usage.Reads, usage.Writes))
+		mapping := fmt.Sprintf("%s (reads=%d writes=%d)", name, usage.Reads, usage.Writes)
+		if kinds := formatKindCounts(usage.Kinds); kinds != "" {
+			mapping += ": " + kinds
+		}
+		result = append(result, mapping)
 	}
 	return result
 }
 
+// formatKindCounts renders query kind counts busiest first, e.g.
+// "200 SELECT, 5 ALTER".
+func formatKindCounts(kinds map[string]uint64) string {
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if kinds[names[i]] != kinds[names[j]] {
+			return kinds[names[i]] > kinds[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, kind := range names {
+		parts = append(parts, fmt.Sprintf("%d %s", kinds[kind], kind))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func resolveServiceLabel(edge models.Edge, services map[string]models.Service) string {
 	serviceName := strings.TrimSpace(edge.ServiceName)
 	if serviceName != "" {
@@ -340,6 +744,9 @@ func formatAnomalyFinding(anomaly models.Anomaly) string {
 	if description == "" {
 		description = "unspecified anomaly"
 	}
+	if metrics := anomaly.MetricsText(); metrics != "" {
+		description += " [" + metrics + "]"
+	}
 
 	service := strings.TrimSpace(anomaly.AffectedService)
 	if service == "" {
@@ -431,6 +838,14 @@ func scoreDistribution(tables []models.Table) (int, int, int) {
 	return low, medium, high
 }
 
+// mbToBytes converts a recommendation's SizeMB back to bytes for formatting.
+func mbToBytes(sizeMB float64) uint64 {
+	if sizeMB <= 0 {
+		return 0
+	}
+	return uint64(sizeMB * 1e6)
+}
+
 func countUnusedTables(tables []models.Table) int {
 	unused := 0
 	for _, table := range tables {
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ppiankov/clickspectre/internal/models"
 	"github.com/ppiankov/clickspectre/pkg/config"
@@ -38,6 +39,9 @@ func TestWriteTextProducesReadableOutput(t *testing.T) {
 				Score:     0.12,
 				Category:  "unused",
 				ZeroUsage: true,
+				TopConsumers: []models.EdgeSummary{
+					{Service: "prod/api", Reads: 5, Writes: 1},
+				},
 			},
 		},
 		Services: []models.Service{
@@ -53,10 +57,18 @@ func TestWriteTextProducesReadableOutput(t *testing.T) {
 				TableName: "analytics.old_sessions",
 				Reads:     5,
 				Writes:    1,
+				KindCounts: map[string]uint64{
+					"SELECT": 2,
+					"ALTER":  1,
+				},
 			},
 		},
 		CleanupRecommendations: models.CleanupRecommendations{
-			SafeToDrop: []string{"analytics.old_sessions"},
+			ZeroUsageNonReplicated: []models.TableRecommendation{
+				{Name: "analytics.old_sessions", SizeMB: 2500, Rows: 3_400_000},
+			},
+			SafeToDrop:       []string{"analytics.old_sessions"},
+			ReclaimableBytes: 2_500_000_000,
 		},
 		Anomalies: []models.Anomaly{
 			{
@@ -77,9 +89,13 @@ func TestWriteTextProducesReadableOutput(t *testing.T) {
 	assertContains(t, textOutput, "Total tables: 2")
 	assertContains(t, textOutput, "Unused tables: 1")
 	assertContains(t, textOutput, "0.00-0.29: 1")
+	assertContains(t, textOutput, "Estimated reclaimable: 2.5 GB")
+	assertContains(t, textOutput, "zero_usage_non_replicated (size=2.5 GB rows=3.4M)")
 	assertContains(t, textOutput, "analytics.old_sessions")
 	assertContains(t, textOutput, "safety score=0.12")
 	assertContains(t, textOutput, "prod/api (reads=5 writes=1)")
+	assertContains(t, textOutput, "prod/api (reads=5 writes=1): 2 SELECT, 1 ALTER")
+	assertContains(t, textOutput, "  top consumers:\n    1. prod/api (reads=5 writes=1)\n")
 	assertContains(t, textOutput, "safe_to_drop")
 	assertContains(t, textOutput, "anomaly[high]: Query spike detected")
 
@@ -119,6 +135,280 @@ func TestWriteTextInputValidation(t *testing.T) {
 	}
 }
 
+func TestWriteTextColorPolicy(t *testing.T) {
+	report := &models.Report{
+		Tables:    []models.Table{{Database: "db", Name: "events", FullName: "db.events"}},
+		Anomalies: []models.Anomaly{{Type: "query_spike", Severity: "high", Description: "spike", AffectedTable: "db.events"}},
+	}
+
+	cases := []struct {
+		name       string
+		mode       string
+		noColor    string
+		forceColor string
+		tty        bool
+		wantANSI   bool
+	}{
+		{name: "auto_tty", mode: "auto", tty: true, wantANSI: true},
+		{name: "auto_pipe", mode: "auto", tty: false, wantANSI: false},
+		{name: "always_pipe", mode: "always", tty: false, wantANSI: true},
+		{name: "never_tty", mode: "never", tty: true, wantANSI: false},
+		{name: "no_color_tty", mode: "auto", noColor: "1", tty: true, wantANSI: false},
+		{name: "force_color_pipe", mode: "auto", forceColor: "1", tty: false, wantANSI: true},
+		{name: "force_color_zero_tty", mode: "auto", forceColor: "0", tty: true, wantANSI: false},
+		{name: "no_color_beats_force_color", mode: "auto", noColor: "1", forceColor: "1", tty: true, wantANSI: false},
+		{name: "always_beats_no_color", mode: "always", noColor: "1", tty: false, wantANSI: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tc.noColor)
+			t.Setenv("FORCE_COLOR", tc.forceColor)
+			original := isTerminal
+			isTerminal = func(io.Writer) bool { return tc.tty }
+			t.Cleanup(func() { isTerminal = original })
+
+			cfg := config.DefaultConfig()
+			cfg.OutputDir = t.TempDir()
+			cfg.Color = tc.mode
+
+			var out bytes.Buffer
+			if err := writeText(report, cfg, &out); err != nil {
+				t.Fatalf("writeText failed: %v", err)
+			}
+			if got := strings.Contains(out.String(), "\x1b["); got != tc.wantANSI {
+				t.Fatalf("expected ANSI=%v on stdout, got %q", tc.wantANSI, out.String())
+			}
+
+			fileOutput, err := os.ReadFile(filepath.Join(cfg.OutputDir, "report.txt"))
+			if err != nil {
+				t.Fatalf("failed to read report.txt: %v", err)
+			}
+			if strings.Contains(string(fileOutput), "\x1b[") {
+				t.Fatal("expected report.txt to stay free of ANSI escapes")
+			}
+		})
+	}
+}
+
+func sortableTextReport() *models.Report {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	return &models.Report{
+		Tables: []models.Table{
+			{FullName: "db.a", Score: 0.50, Reads: 10, Writes: 300, TotalBytes: 2e6, LastAccess: now.Add(-2 * time.Hour)},
+			{FullName: "db.b", Score: 0.10, Reads: 500, Writes: 1, TotalBytes: 9e6, LastAccess: now.Add(-1 * time.Hour)},
+			{FullName: "db.c", Score: 0.90, Reads: 50, Writes: 20, TotalBytes: 5e6, LastAccess: now.Add(-72 * time.Hour)},
+		},
+		Anomalies: []models.Anomaly{
+			{Type: "stale_table", Severity: "low", Description: "stale", AffectedTable: "db.a"},
+			{Type: "stale_table", Severity: "low", Description: "stale", AffectedTable: "db.b"},
+			{Type: "stale_table", Severity: "low", Description: "stale", AffectedTable: "db.c"},
+		},
+	}
+}
+
+func TestBuildTableFindingsSortKeys(t *testing.T) {
+	cases := []struct {
+		sortBy string
+		want   []string
+	}{
+		{sortBy: "", want: []string{"db.b", "db.a", "db.c"}},
+		{sortBy: "score", want: []string{"db.b", "db.a", "db.c"}},
+		{sortBy: "reads", want: []string{"db.b", "db.c", "db.a"}},
+		{sortBy: "writes", want: []string{"db.a", "db.c", "db.b"}},
+		{sortBy: "size", want: []string{"db.b", "db.c", "db.a"}},
+		{sortBy: "last_access", want: []string{"db.c", "db.a", "db.b"}},
+		{sortBy: "severity", want: []string{"db.b", "db.c", "db.a"}}, // All low, so largest first
+	}
+
+	for _, tc := range cases {
+		t.Run("sort_by_"+tc.sortBy, func(t *testing.T) {
+			findings, _ := buildTableFindings(sortableTextReport(), tc.sortBy)
+			got := make([]string, 0, len(findings))
+			for _, finding := range findings {
+				got = append(got, finding.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("expected order %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderTextReportSeverityOrder(t *testing.T) {
+	report := &models.Report{
+		Tables: []models.Table{
+			{FullName: "db.dead", Score: 0.05, TotalBytes: 50e6},
+			{FullName: "db.spiky", Score: 0.90, TotalBytes: 1e6},
+			{FullName: "db.stale", Score: 0.40, TotalBytes: 9e6},
+		},
+		Anomalies: []models.Anomaly{
+			{Type: "query_spike", Severity: "high", Description: "spike", AffectedTable: "db.spiky"},
+			{Type: "stale_table", Severity: "low", Description: "stale", AffectedTable: "db.spiky"},
+			{Type: "stale_table", Severity: "medium", Description: "stale", AffectedTable: "db.stale"},
+		},
+		CleanupRecommendations: models.CleanupRecommendations{SafeToDrop: []string{"db.dead"}},
+	}
+
+	findings, _ := buildTableFindings(report, "severity")
+	got := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		got = append(got, finding.Name)
+	}
+	// The high-severity table outranks the lowest-scoring table without anomalies
+	if want := []string{"db.spiky", "db.stale", "db.dead"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected order %v, got %v", want, got)
+	}
+
+	output := renderTextReport(report, false, 0, "severity", nil)
+	details := output[strings.Index(output, "Details"):]
+	spiky := strings.Index(details, "[!] db.spiky | safety score=0.90")
+	dead := strings.Index(details, "db.dead | safety score=0.05")
+	if spiky < 0 || dead < 0 || spiky > dead {
+		t.Fatalf("expected marked db.spiky above db.dead in Details, got:\n%s", details)
+	}
+	if strings.Contains(details, "[!] db.stale") || strings.Contains(details, "[!] db.dead") {
+		t.Fatalf("expected only high-severity tables to be marked, got:\n%s", details)
+	}
+
+	// Under the default order the lowest score still comes first
+	findings, _ = buildTableFindings(report, "score")
+	if findings[0].Name != "db.dead" {
+		t.Fatalf("expected db.dead first by score, got %s", findings[0].Name)
+	}
+}
+
+func TestRenderTextReportTopLimitsTables(t *testing.T) {
+	output := renderTextReport(sortableTextReport(), false, 2, "reads", nil)
+	assertContains(t, output, "... 1 more tables omitted (showing top 2 by reads)")
+	if strings.Contains(output, "db.a |") {
+		t.Fatalf("expected db.a details to be omitted, got:\n%s", output)
+	}
+	assertContains(t, output, "db.b | safety score=0.10")
+	assertContains(t, output, "db.c | safety score=0.90")
+
+	full := renderTextReport(sortableTextReport(), false, 0, "score", nil)
+	if strings.Contains(full, "omitted") {
+		t.Fatalf("expected no omission footer without --top, got:\n%s", full)
+	}
+	for _, name := range []string{"db.a |", "db.b |", "db.c |"} {
+		assertContains(t, full, name)
+	}
+
+	exact := renderTextReport(sortableTextReport(), false, 3, "score", nil)
+	if strings.Contains(exact, "omitted") {
+		t.Fatalf("expected no omission footer when --top covers every table, got:\n%s", exact)
+	}
+}
+
+func TestRenderTextReportSampleQueries(t *testing.T) {
+	report := sortableTextReport()
+	report.Tables[1].SampleQueries = []string{"SELECT *\n  FROM db.b WHERE id = ?"}
+
+	output := renderTextReport(report, false, 0, "score", nil)
+	assertContains(t, output, "  sample queries:\n    - SELECT * FROM db.b WHERE id = ?\n")
+	if strings.Count(output, "sample queries:") != 1 {
+		t.Fatalf("expected sample queries only for db.b, got:\n%s", output)
+	}
+}
+
+func TestRenderTextReportKeepReasons(t *testing.T) {
+	report := sortableTextReport()
+	report.CleanupRecommendations = models.CleanupRecommendations{
+		Keep:        []string{"db.a", "db.c"},
+		KeepReasons: map[string]string{"db.a": "recent_writes", "db.c": "active"},
+	}
+
+	output := renderTextReport(report, false, 0, "score", nil)
+	assertContains(t, output, "db.a | safety score=0.50 | category=suspect\n  kept: recent_writes\n")
+	assertContains(t, output, "db.c | safety score=0.90 | category=active\n  kept: active\n")
+	if strings.Count(output, "kept:") != 2 {
+		t.Fatalf("expected a keep reason only for kept tables, got:\n%s", output)
+	}
+}
+
+func TestRenderTextReportRecommendationConfidence(t *testing.T) {
+	report := sortableTextReport()
+	report.CleanupRecommendations = models.CleanupRecommendations{
+		SafeToDrop: []string{"db.b"},
+		LikelySafe: []string{"db.a"},
+		Ranked: []models.TableRecommendation{
+			{Name: "db.b", Category: "safe_to_drop", SizeMB: 2, Rows: 10, Confidence: 0.82},
+			{Name: "db.a", Category: "likely_safe", Confidence: 0.31, LowConfidence: true},
+		},
+	}
+
+	output := renderTextReport(report, false, 0, "score", nil)
+	assertContains(t, output, "safe_to_drop confidence=0.82\n")
+	assertContains(t, output, "likely_safe confidence=0.31 LOW CONFIDENCE\n")
+
+	// Without the tables section the recommendations follow the ranking
+	output = renderTextReport(report, false, 0, "score", sectionSet{sectionRecommendations: true})
+	high := strings.Index(output, "safe_to_drop: db.b (size=2.0 MB rows=10 confidence=0.82)")
+	low := strings.Index(output, "likely_safe: db.a (size=0 B rows=0 confidence=0.31 LOW CONFIDENCE)")
+	if high < 0 || low < 0 || high > low {
+		t.Fatalf("expected ranked recommendations, most confident first, got:\n%s", output)
+	}
+}
+
+func TestRenderTextReportHeatmap(t *testing.T) {
+	report := sortableTextReport()
+	heatmap := &models.AccessHeatmap{}
+	heatmap[time.Monday][2] = 8
+	heatmap[time.Monday][3] = 1
+	heatmap[time.Sunday][23] = 4
+	report.Tables[1].Heatmap = heatmap
+
+	output := renderTextReport(report, false, 0, "score", nil)
+	assertContains(t, output, "  access heatmap (UTC hour, busiest=@):\n"+
+		"         0     6     12    18\n"+
+		"    Sun  .......................+\n"+
+		"    Mon  ..@:....................\n"+
+		"    Tue  ........................\n")
+	if strings.Count(output, "access heatmap") != 1 {
+		t.Fatalf("expected a heatmap only for db.b, got:\n%s", output)
+	}
+
+	// Reports built with --timezone label hours and times in that zone
+	report.Timestamp = ""
+	report.Metadata.GeneratedAt = time.Date(2026, 2, 16, 3, 30, 0, 0, time.UTC)
+	report.Metadata.Timezone = "Asia/Kolkata"
+	output = renderTextReport(report, false, 0, "score", nil)
+	assertContains(t, output, "  access heatmap (Asia/Kolkata hour, busiest=@):\n")
+	assertContains(t, output, "Generated: 2026-02-16T09:00:00+05:30\n")
+	assertContains(t, buildMarkdown(report, nil), "Generated 2026-02-16T09:00:00+05:30 for")
+}
+
+func TestTruncatedReportWarnsInEveryFormat(t *testing.T) {
+	report := sortableTextReport()
+	report.Metadata.Truncated = true
+	report.Metadata.RowLimit = 1000
+	want := "results truncated at 1000 rows; stats may be incomplete"
+	cfg := config.DefaultConfig()
+
+	assertContains(t, renderTextReport(report, false, 0, "score", nil), "WARNING: "+want)
+	assertContains(t, buildMarkdown(report, nil), "> **Warning:** "+want)
+	assertContains(t, buildOpenMetrics(report), "clickspectre_results_truncated 1\n")
+
+	sarif := buildSARIF(report, cfg)
+	notes := sarif.Runs[0].Invocations
+	if len(notes) != 1 || notes[0].ToolExecutionNotifications[0].Message.Text != want {
+		t.Fatalf("expected SARIF tool notification %q, got %+v", want, notes)
+	}
+	if hub := buildSpectreHub(report, cfg); len(hub.Warnings) != 1 || hub.Warnings[0] != want {
+		t.Fatalf("expected spectrehub warning %q, got %v", want, hub.Warnings)
+	}
+
+	complete := sortableTextReport()
+	if strings.Contains(renderTextReport(complete, false, 0, "score", nil), "WARNING:") {
+		t.Fatal("expected no truncation warning for a complete report")
+	}
+	assertContains(t, buildOpenMetrics(complete), "clickspectre_results_truncated 0\n")
+	if len(buildSARIF(complete, cfg).Runs[0].Invocations) != 0 {
+		t.Fatal("expected no SARIF invocations for a complete report")
+	}
+}
+
 func TestReporterGenerateTextFormat(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.OutputDir = t.TempDir()
@@ -167,3 +457,16 @@ func assertContains(t *testing.T, output string, want string) {
 		t.Fatalf("expected output to contain %q, got:\n%s", want, output)
 	}
 }
+
+func TestFormatAnomalyFindingAppendsMetrics(t *testing.T) {
+	anomaly := models.Anomaly{
+		Severity:      "low",
+		Description:   "Hourly usage spiked above the trailing mean",
+		AffectedTable: "db.events",
+		Metrics:       map[string]float64{"ratio": 7.7, "queries": 80},
+	}
+	want := "anomaly[low]: Hourly usage spiked above the trailing mean [queries=80 ratio=7.7]"
+	if got := formatAnomalyFinding(anomaly); got != want {
+		t.Fatalf("formatAnomalyFinding() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package scorer
+
+import (
+	"math"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+// LowConfidence is the confidence below which a recommendation is flagged
+// for manual review.
+const LowConfidence = 0.50
+
+// Confidence rates the evidence behind recommending table for cleanup as of
+// now, from 0 (guesswork) to 1 (certain). It weighs how much of the lookback
+// the table has been idle (35%), how few services read it (25%), whether its
+// size is known (20%), and whether its metadata modification time is known
+// (20%). Tables
+// without inventory metadata, e.g. when it was not fetched, score low.
+//
+// Zero-usage tables have been idle for the whole lookback, or since they
+// were created or last altered when that is more recent.
+func Confidence(table *models.Table, now time.Time, lookback time.Duration) float64 {
+	var idle time.Duration
+	switch {
+	case table.ZeroUsage && !table.MetadataModified.IsZero():
+		idle = min(now.Sub(table.MetadataModified), lookback)
+	case table.ZeroUsage:
+		idle = lookback
+	case !table.LastAccess.IsZero():
+		idle = now.Sub(table.LastAccess)
+	}
+	var idleEvidence float64
+	if lookback > 0 {
+		idleEvidence = math.Max(0, math.Min(1, float64(idle)/float64(lookback)))
+	}
+
+	// Every service still reading the table is a reason to doubt it is dead
+	consumerEvidence := 1 / float64(1+max(table.DistinctServices, 0))
+
+	var sizeEvidence, createEvidence float64
+	if table.TotalBytes > 0 || table.TotalRows > 0 {
+		sizeEvidence = 1
+	}
+	if !table.MetadataModified.IsZero() {
+		createEvidence = 1
+	}
+
+	confidence := 0.35*idleEvidence + 0.25*consumerEvidence + 0.20*sizeEvidence + 0.20*createEvidence
+	return math.Round(confidence*100) / 100
+}
+
+// recommendation describes the table name as a cleanup recommendation in
+// category.
+func recommendation(name string, table *models.Table, category string, now time.Time, lookback time.Duration) models.TableRecommendation {
+	confidence := Confidence(table, now, lookback)
+	return models.TableRecommendation{
+		Name:          name,
+		Database:      table.Database,
+		Engine:        table.Engine,
+		IsReplicated:  table.IsReplicated,
+		SizeMB:        float64(table.TotalBytes) / 1e6,
+		Rows:          table.TotalRows,
+		Category:      category,
+		Confidence:    confidence,
+		LowConfidence: confidence < LowConfidence,
+	}
+}
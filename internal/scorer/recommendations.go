@@ -2,6 +2,7 @@ package scorer
 
 import (
 	"log/slog"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -16,7 +17,7 @@ func GenerateRecommendations(
 	services map[string]*models.Service,
 	config *config.Config,
 ) models.CleanupRecommendations {
-	scorer := NewScorer(config.ScoringAlgorithm)
+	scorer := NewScorer(config)
 
 	// Initialize as empty slices instead of nil to avoid JSON null values
 	zeroUsageNonReplicated := []models.TableRecommendation{}
@@ -24,10 +25,44 @@ func GenerateRecommendations(
 	safeToDrop := []string{}
 	likelySafe := []string{}
 	keep := []string{}
+	keepReasons := map[string]string{}
+	ranked := []models.TableRecommendation{}
+	var reclaimableBytes uint64
+
+	// Tables an MV reads from or writes to must survive as long as the MV does.
+	// Dependencies are only known when the table inventory was fetched.
+	mvLinked := mvLinkedTables(tables)
+
+	// Zero-usage tables next to an active sibling are recommended regardless
+	// of score; the live sibling is strong evidence they were abandoned
+	var shadows map[string]string
+	if config.DetectShadowTables {
+		if suffix, err := regexp.Compile(config.ShadowSuffixPattern); err != nil {
+			slog.Warn("invalid shadow table suffix pattern, skipping shadow detection",
+				slog.String("pattern", config.ShadowSuffixPattern),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			shadows = shadowTables(tables, suffix)
+		}
+	}
 
 	now := time.Now()
 
 	for tableName, table := range tables {
+		// Protected tables are still scored and reported, but never recommended
+		if pattern, protected := config.MatchProtectedTable(tableName); protected {
+			table.Score = scorer.Score(table, services)
+			table.Category = scorer.Categorize(table.Score)
+			keep = append(keep, tableName)
+			keepReasons[tableName] = "protected"
+			slog.Debug("table protected from cleanup",
+				slog.String("table", tableName),
+				slog.String("pattern", pattern),
+			)
+			continue
+		}
+
 		// Phase 1: Zero-usage tables (highest priority)
 		if table.ZeroUsage {
 			// Apply size filter
@@ -40,34 +75,45 @@ func GenerateRecommendations(
 			score := scorer.Score(table, services)
 			table.Score = score
 
-			// Only recommend if score is low enough and not an MV or MV dependency
-			if score < 0.30 && !table.IsMV && len(table.MVDependency) == 0 {
-				rec := models.TableRecommendation{
-					Name:         table.FullName,
-					Database:     table.Database,
-					Engine:       table.Engine,
-					IsReplicated: table.IsReplicated,
-					SizeMB:       sizeMB,
-					Rows:         table.TotalRows,
+			// A new table has zero usage simply for lack of history
+			if isTooNew(table, now, config.MinTableAge) {
+				keep = append(keep, tableName)
+				keepReasons[tableName] = "too_new"
+				continue
+			}
+
+			// Only recommend if score is low enough (or the table shadows an
+			// active sibling) and not an MV or MV dependency
+			shadowOf := shadows[tableName]
+			if (score < 0.30 || shadowOf != "") && !table.IsMV && !mvLinked[tableName] && !isProxyEngine(table.Engine) {
+				category := "zero_usage_non_replicated"
+				if table.IsReplicated {
+					category = "zero_usage_replicated"
 				}
+				rec := recommendation(table.FullName, table, category, now, config.LookbackPeriod)
+				rec.ShadowOf = shadowOf
 
+				reclaimableBytes += reclaimableTableBytes(table, config.ReplicaFactor)
 				if table.IsReplicated {
 					zeroUsageReplicated = append(zeroUsageReplicated, rec)
 				} else {
 					zeroUsageNonReplicated = append(zeroUsageNonReplicated, rec)
 				}
+				ranked = append(ranked, rec)
 				continue // Don't add to other categories
 			}
 		}
 
 		// Phase 2: Tables with usage (existing logic)
 		// Apply safety rules first
-		if !isSafeToRecommend(tableName, table, now) {
+		if reason := keepReason(tableName, table, now, config, mvLinked); reason != "" {
 			keep = append(keep, tableName)
+			keepReasons[tableName] = reason
 			continue
 		}
 		if config.MinQueryCount > 0 && tableQueryCount(table) < config.MinQueryCount {
 			likelySafe = append(likelySafe, tableName)
+			ranked = append(ranked, recommendation(tableName, table, "likely_safe", now, config.LookbackPeriod))
 			continue
 		}
 
@@ -83,27 +129,50 @@ func GenerateRecommendations(
 		switch category {
 		case "active":
 			keep = append(keep, tableName)
+			keepReasons[tableName] = "active"
 		case "suspect":
 			likelySafe = append(likelySafe, tableName)
+			ranked = append(ranked, recommendation(tableName, table, "likely_safe", now, config.LookbackPeriod))
 		case "unused":
 			safeToDrop = append(safeToDrop, tableName)
+			ranked = append(ranked, recommendation(tableName, table, "safe_to_drop", now, config.LookbackPeriod))
+			reclaimableBytes += reclaimableTableBytes(table, config.ReplicaFactor)
 		}
 	}
 
-	// Sort zero-usage by size (largest first = highest value cleanup)
+	// Sort zero-usage shadow tables first, as the most certain findings, then
+	// by size (largest first = highest value cleanup)
 	sort.Slice(zeroUsageNonReplicated, func(i, j int) bool {
-		return zeroUsageNonReplicated[i].SizeMB > zeroUsageNonReplicated[j].SizeMB
+		return zeroUsageBefore(zeroUsageNonReplicated[i], zeroUsageNonReplicated[j])
 	})
 	sort.Slice(zeroUsageReplicated, func(i, j int) bool {
-		return zeroUsageReplicated[i].SizeMB > zeroUsageReplicated[j].SizeMB
+		return zeroUsageBefore(zeroUsageReplicated[i], zeroUsageReplicated[j])
+	})
+	// Order safe-to-drop by bytes reclaimed so cleanup starts with real savings
+	sort.Slice(safeToDrop, func(i, j int) bool {
+		bi := reclaimableTableBytes(tables[safeToDrop[i]], config.ReplicaFactor)
+		bj := reclaimableTableBytes(tables[safeToDrop[j]], config.ReplicaFactor)
+		if bi != bj {
+			return bi > bj
+		}
+		return safeToDrop[i] < safeToDrop[j]
+	})
+	// The ranked list puts the best-evidenced recommendations first
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Confidence != ranked[j].Confidence {
+			return ranked[i].Confidence > ranked[j].Confidence
+		}
+		return ranked[i].Name < ranked[j].Name
 	})
 
 	slog.Debug("recommendations summary",
+		slog.Int("shadow_tables", len(shadows)),
 		slog.Int("zero_usage_non_replicated", len(zeroUsageNonReplicated)),
 		slog.Int("zero_usage_replicated", len(zeroUsageReplicated)),
 		slog.Int("safe_to_drop", len(safeToDrop)),
 		slog.Int("likely_safe", len(likelySafe)),
 		slog.Int("keep", len(keep)),
+		slog.Uint64("reclaimable_bytes", reclaimableBytes),
 	)
 
 	return models.CleanupRecommendations{
@@ -112,32 +181,118 @@ func GenerateRecommendations(
 		SafeToDrop:             safeToDrop,
 		LikelySafe:             likelySafe,
 		Keep:                   keep,
+		KeepReasons:            keepReasons,
+		Ranked:                 ranked,
+		ReclaimableBytes:       reclaimableBytes,
+	}
+}
+
+// zeroUsageBefore orders shadow tables ahead of other zero-usage tables,
+// then larger tables first.
+func zeroUsageBefore(a, b models.TableRecommendation) bool {
+	if (a.ShadowOf != "") != (b.ShadowOf != "") {
+		return a.ShadowOf != ""
 	}
+	return a.SizeMB > b.SizeMB
 }
 
-// isSafeToRecommend applies safety rules to determine if a table can be recommended for cleanup
-func isSafeToRecommend(tableName string, table *models.Table, now time.Time) bool {
+// reclaimableTableBytes returns the storage freed by dropping a table. Replicated
+// tables free their size on every replica, so they are scaled by replicaFactor.
+func reclaimableTableBytes(table *models.Table, replicaFactor int) uint64 {
+	if table.IsReplicated && replicaFactor > 1 {
+		return table.TotalBytes * uint64(replicaFactor)
+	}
+	return table.TotalBytes
+}
+
+// keepReason applies safety rules to determine if a table can be recommended for cleanup.
+// It returns the rule that forbids recommending the table, or "" when none applies.
+func keepReason(tableName string, table *models.Table, now time.Time, cfg *config.Config, mvLinked map[string]bool) string {
 	// Rule 1: Never recommend system tables
 	if isSystemTable(tableName) {
-		return false
+		return "system_table"
 	}
 
-	// Rule 2: Never recommend tables with writes in the last 7 days
-	daysSinceWrite := now.Sub(table.LastAccess).Hours() / 24
-	if table.Writes > 0 && daysSinceWrite < 7 {
-		return false
+	// Rule 2: Never recommend tables with writes or mutations within the
+	// protection window
+	if isRecentlyWritten(table, now, cfg.ProtectRecentWrite) {
+		return "recent_writes"
 	}
 
 	// Rule 3: Never recommend materialized views (requires special handling)
 	if table.IsMV {
+		return "materialized_view"
+	}
+
+	// Rule 4: Never recommend tables that feed or are fed by an MV
+	if mvLinked[tableName] {
+		return "mv_dependency"
+	}
+
+	// Rule 5: Never recommend views or Distributed tables, which hold no data
+	if isProxyEngine(table.Engine) {
+		return "proxy_engine"
+	}
+
+	// Rule 6: Never recommend tables created or altered within minAge, which
+	// may have too little history in the lookback window to judge
+	if isTooNew(table, now, cfg.MinTableAge) {
+		return "too_new"
+	}
+
+	return ""
+}
+
+// isRecentlyWritten reports whether table was written or mutated less than
+// window before now. Tables from reports or watermarks that predate write
+// tracking have writes but no LastWrite; their last access, which is never
+// earlier than the last write, stands in for it.
+func isRecentlyWritten(table *models.Table, now time.Time, window time.Duration) bool {
+	if window <= 0 {
 		return false
 	}
+	lastWrite := table.LastWrite
+	if lastWrite.IsZero() {
+		if table.Writes == 0 && table.Mutations == 0 {
+			return false
+		}
+		lastWrite = table.LastAccess
+	}
+	return now.Sub(lastWrite) < window
+}
 
-	// Rule 4: Never recommend tables that are MV dependencies
-	// (This would require cross-checking with other tables' MVDependency field)
-	// For now, we'll be conservative and skip this check
+// isTooNew reports whether table was created or altered less than minAge
+// before now. ClickHouse records no creation time, only the metadata
+// modification time from the table inventory, which every ALTER also
+// bumps; tables without one are never too new.
+func isTooNew(table *models.Table, now time.Time, minAge time.Duration) bool {
+	return minAge > 0 && !table.MetadataModified.IsZero() && now.Sub(table.MetadataModified) < minAge
+}
 
-	return true
+// mvLinkedTables returns every table on either side of an MV dependency:
+// tables that list dependents in MVDependency and the dependents themselves.
+func mvLinkedTables(tables map[string]*models.Table) map[string]bool {
+	linked := make(map[string]bool)
+	for tableName, table := range tables {
+		if len(table.MVDependency) == 0 {
+			continue
+		}
+		linked[tableName] = true
+		for _, dep := range table.MVDependency {
+			linked[dep] = true
+		}
+	}
+	return linked
+}
+
+// isProxyEngine reports whether engine only forwards to other tables.
+// Dropping one frees no storage but breaks every query that goes through it.
+func isProxyEngine(engine string) bool {
+	switch strings.ToLower(strings.TrimSpace(engine)) {
+	case "view", "distributed":
+		return true
+	}
+	return false
 }
 
 // isSystemTable checks if a table is a system table
@@ -161,5 +316,5 @@ func tableQueryCount(table *models.Table) uint64 {
 		return total
 	}
 
-	return table.Reads + table.Writes
+	return table.Reads + table.Writes + table.Mutations
 }
@@ -2,6 +2,7 @@ package scorer
 
 import (
 	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
 )
 
 // Scorer interface for table scoring algorithms
@@ -10,12 +11,23 @@ type Scorer interface {
 	Categorize(score float64) string
 }
 
-// NewScorer creates a scorer based on the algorithm name
-func NewScorer(algorithm string) Scorer {
-	switch algorithm {
-	case "simple":
-		return &SimpleScorer{}
-	default:
+// ScoreFactor is one factor's contribution to a table's score
+type ScoreFactor struct {
+	Name         string  `json:"name"`
+	Contribution float64 `json:"contribution"`
+	Detail       string  `json:"detail"`
+}
+
+// NewScorer creates a scorer based on the configured algorithm. "simple" is
+// the only algorithm, so it is used for any value; a nil cfg gets the
+// default weights.
+func NewScorer(cfg *config.Config) Scorer {
+	if cfg == nil {
 		return &SimpleScorer{}
 	}
+	return &SimpleScorer{
+		DiversityBuckets:   cfg.DiversityBuckets,
+		RecencyHalfLife:    cfg.RecencyHalfLife,
+		SizePressureWeight: cfg.SizePressureWeight,
+	}
 }
@@ -2,6 +2,7 @@ package scorer
 
 import (
 	"math"
+	"reflect"
 	"testing"
 	"time"
 
@@ -26,7 +27,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-48 * time.Hour), // 2 days ago
 			},
 			services: servicesUsingTable("db.table1", 6),
-			want:     1.0, // 0.4 (recent) + 0.3 (high queries) + 0.2 (many services) + 0.1 (writes)
+			want:     recencyAt(2) + 0.3 + 0.2 + 0.1, // recency + high queries + many services + writes
 		},
 		{
 			name: "moderate_usage_low_diversity_no_writes",
@@ -37,7 +38,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-20 * 24 * time.Hour), // 20 days ago
 			},
 			services: servicesUsingTable("db.table2", 2),
-			want:     0.45, // 0.3 (30 days ago) + 0.1 (10-100 queries) + 0.05 (2 services)
+			want:     recencyAt(20) + 0.1 + 0.05, // recency + 10-100 queries + 2 services
 		},
 		{
 			name: "old_low_usage_no_services",
@@ -48,7 +49,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-120 * 24 * time.Hour), // 120 days ago
 			},
 			services: map[string]*models.Service{},
-			want:     0.0,
+			want:     recencyAt(120), // only the decayed recency remains
 		},
 		// --- Zero Usage Tables ---
 		{
@@ -133,7 +134,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-1 * 24 * time.Hour), // 1 day ago
 			},
 			services: servicesUsingTable("db.table11", 1),
-			want:     0.45, // 0.4 (recent) + 0.05 (1 service)
+			want:     recencyAt(1) + 0.05, // recency + 1 service
 		},
 		{
 			name: "medium_old_medium_volume_no_services_with_writes",
@@ -144,7 +145,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-60 * 24 * time.Hour), // 60 days ago
 			},
 			services: map[string]*models.Service{},
-			want:     0.3, // 0.1 (60 days ago) + 0.1 (10-100 queries) + 0.1 (writes)
+			want:     recencyAt(60) + 0.1 + 0.1, // recency + 10-100 queries + writes
 		},
 		{
 			name: "very_old_high_volume_many_services_with_writes",
@@ -155,7 +156,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-100 * 24 * time.Hour), // 100 days ago
 			},
 			services: servicesUsingTable("db.table13", 10),
-			want:     0.6, // 0.3 (high queries) + 0.2 (many services) + 0.1 (writes)
+			want:     recencyAt(100) + 0.3 + 0.2 + 0.1, // recency + high queries + many services + writes
 		},
 		{
 			name: "no_activity_no_services",
@@ -166,7 +167,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-365 * 24 * time.Hour), // 1 year ago
 			},
 			services: map[string]*models.Service{},
-			want:     0.0,
+			want:     recencyAt(365),
 		},
 		{
 			name: "just_above_10_queries_threshold",
@@ -177,7 +178,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-5 * 24 * time.Hour),
 			},
 			services: map[string]*models.Service{},
-			want:     0.5, // 0.4 (recent) + 0.1 (10-100 queries)
+			want:     recencyAt(5) + 0.1, // recency + 10-100 queries
 		},
 		{
 			name: "just_above_100_queries_threshold",
@@ -188,7 +189,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-5 * 24 * time.Hour),
 			},
 			services: map[string]*models.Service{},
-			want:     0.6, // 0.4 (recent) + 0.2 (100-1000 queries) = 0.6
+			want:     recencyAt(5) + 0.2, // recency + 100-1000 queries
 		},
 		{
 			name: "just_above_1000_queries_threshold",
@@ -199,7 +200,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-5 * 24 * time.Hour),
 			},
 			services: map[string]*models.Service{},
-			want:     0.7, // 0.4 (recent) + 0.3 ( > 1000 queries) = 0.7
+			want:     recencyAt(5) + 0.3, // recency + > 1000 queries
 		},
 		{
 			name: "just_above_0_unique_services",
@@ -210,7 +211,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-5 * 24 * time.Hour),
 			},
 			services: servicesUsingTable("db.table18", 1),
-			want:     0.45, // 0.4 (recent) + 0.05 (1 service)
+			want:     recencyAt(5) + 0.05, // recency + 1 service
 		},
 		{
 			name: "just_above_2_unique_services",
@@ -221,7 +222,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-5 * 24 * time.Hour),
 			},
 			services: servicesUsingTable("db.table19", 3),
-			want:     0.55, // 0.4 (recent) + 0.15 (3 services)
+			want:     recencyAt(5) + 0.15, // recency + 3 services
 		},
 		{
 			name: "just_above_5_unique_services",
@@ -232,7 +233,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-5 * 24 * time.Hour),
 			},
 			services: servicesUsingTable("db.table20", 6),
-			want:     0.6, // 0.4 (recent) + 0.2 (6 services)
+			want:     recencyAt(5) + 0.2, // recency + 6 services
 		},
 		{
 			name: "no_writes_no_reads_recent_access",
@@ -243,7 +244,7 @@ func TestSimpleScorerScore(t *testing.T) {
 				LastAccess: now.Add(-1 * 24 * time.Hour), // 1 day ago
 			},
 			services: servicesUsingTable("db.table21", 1),
-			want:     0.45, // 0.4 (recent) + 0.05 (1 service)
+			want:     recencyAt(1) + 0.05, // recency + 1 service
 		},
 		{
 			name: "mv_dependency_zero_usage_large_not_replicated",
@@ -282,6 +283,429 @@ func TestSimpleScorerScore(t *testing.T) {
 	}
 }
 
+// recencyAt returns the recency contribution for a table last accessed
+// days ago under the default half-life.
+func recencyAt(days float64) float64 {
+	return 0.40 * math.Exp2(-days*24*float64(time.Hour)/float64(config.DefaultRecencyHalfLife))
+}
+
+func TestSimpleScorerRecencyDecay(t *testing.T) {
+	now := time.Now()
+	scorer := &SimpleScorer{}
+
+	previous := math.Inf(1)
+	for _, days := range []int{0, 1, 7, 8, 29, 30, 60, 90, 365} {
+		table := &models.Table{FullName: "db.t", LastAccess: now.Add(-time.Duration(days) * 24 * time.Hour)}
+		got := scorer.Explain(table, nil, now)[0]
+		if got.Name != "recency" {
+			t.Fatalf("expected recency factor first, got %s", got.Name)
+		}
+		if got.Contribution >= previous {
+			t.Fatalf("expected recency to decrease at %d days, got %.4f after %.4f", days, got.Contribution, previous)
+		}
+		previous = got.Contribution
+	}
+
+	halfLife := &models.Table{FullName: "db.t", LastAccess: now.Add(-config.DefaultRecencyHalfLife)}
+	if got := scorer.Explain(halfLife, nil, now)[0].Contribution; math.Abs(got-0.20) > 0.0001 {
+		t.Fatalf("expected half weight at one half-life, got %.4f", got)
+	}
+
+	future := &models.Table{FullName: "db.t", LastAccess: now.Add(time.Hour)}
+	if got := scorer.Explain(future, nil, now)[0].Contribution; got != 0.40 {
+		t.Fatalf("expected full weight for future access, got %.4f", got)
+	}
+}
+
+func TestSimpleScorerRecencyHalfLifeShiftsCurve(t *testing.T) {
+	now := time.Now()
+	table := &models.Table{FullName: "db.t", LastAccess: now.Add(-14 * 24 * time.Hour)}
+
+	short := (&SimpleScorer{RecencyHalfLife: 7 * 24 * time.Hour}).Explain(table, nil, now)[0].Contribution
+	long := (&SimpleScorer{RecencyHalfLife: 56 * 24 * time.Hour}).Explain(table, nil, now)[0].Contribution
+
+	if math.Abs(short-0.10) > 0.0001 {
+		t.Fatalf("expected 0.10 after two 7-day half-lives, got %.4f", short)
+	}
+	if long <= short {
+		t.Fatalf("expected a longer half-life to keep more recency, got %.4f <= %.4f", long, short)
+	}
+	if got := NewScorer(&config.Config{RecencyHalfLife: 7 * 24 * time.Hour}).(*SimpleScorer).RecencyHalfLife; got != 7*24*time.Hour {
+		t.Fatalf("expected NewScorer to pass the configured half-life, got %s", got)
+	}
+	if got := NewScorer(nil).(*SimpleScorer).RecencyHalfLife; got != 0 {
+		t.Fatalf("expected NewScorer(nil) to use the default half-life, got %s", got)
+	}
+}
+
+func TestSimpleScorerSizePressure(t *testing.T) {
+	now := time.Now()
+	small := &models.Table{FullName: "db.small", ZeroUsage: true, TotalBytes: 2e6}
+	large := &models.Table{FullName: "db.large", ZeroUsage: true, TotalBytes: 500e6}
+
+	plain := &SimpleScorer{}
+	if plain.Score(small, nil) != plain.Score(large, nil) {
+		t.Fatalf("expected equal scores without size pressure, got %.4f and %.4f", plain.Score(small, nil), plain.Score(large, nil))
+	}
+
+	pressured := &SimpleScorer{SizePressureWeight: 0.2}
+	if got, want := pressured.Score(large, nil), pressured.Score(small, nil); got >= want {
+		t.Fatalf("expected the large cold table to rank below the small one, got %.4f >= %.4f", got, want)
+	}
+
+	// A tiny table read many times over is pushed towards keep, a huge
+	// table read a sliver of is pushed towards cleanup
+	hotTiny := &models.Table{FullName: "db.hot", LastAccess: now, Reads: 50000, TotalRows: 1000, TotalBytes: 1e6}
+	coldHuge := &models.Table{FullName: "db.cold", LastAccess: now, Reads: 50000, TotalRows: 1e9, TotalBytes: 1e12}
+	hot := pressured.Explain(hotTiny, nil, now)
+	cold := pressured.Explain(coldHuge, nil, now)
+	if last := hot[len(hot)-1]; last.Name != "size_pressure" || math.Abs(last.Contribution-0.2) > 0.0001 {
+		t.Fatalf("expected +0.20 size_pressure for a hot tiny table, got %+v", last)
+	}
+	if last := cold[len(cold)-1]; last.Name != "size_pressure" || last.Contribution > -0.19 {
+		t.Fatalf("expected close to -0.20 size_pressure for a cold huge table, got %+v", last)
+	}
+
+	if got := NewScorer(&config.Config{SizePressureWeight: 0.2}).(*SimpleScorer).SizePressureWeight; got != 0.2 {
+		t.Fatalf("expected NewScorer to pass the size pressure weight, got %v", got)
+	}
+}
+
+func TestGenerateRecommendationsOrdersSafeToDropByBytes(t *testing.T) {
+	stale := time.Now().Add(-200 * 24 * time.Hour)
+	tables := map[string]*models.Table{
+		"db.mid":   {FullName: "db.mid", LastAccess: stale, Reads: 1, TotalBytes: 5e8},
+		"db.tiny":  {FullName: "db.tiny", LastAccess: stale, Reads: 1, TotalBytes: 1e3},
+		"db.huge":  {FullName: "db.huge", LastAccess: stale, Reads: 1, TotalBytes: 9e9},
+		"db.equal": {FullName: "db.equal", LastAccess: stale, Reads: 1, TotalBytes: 5e8},
+	}
+
+	recs := GenerateRecommendations(tables, map[string]*models.Service{}, config.DefaultConfig())
+
+	want := []string{"db.huge", "db.equal", "db.mid", "db.tiny"}
+	if !reflect.DeepEqual(recs.SafeToDrop, want) {
+		t.Fatalf("expected safe_to_drop ordered by bytes reclaimed %v, got %v", want, recs.SafeToDrop)
+	}
+}
+
+func TestGenerateRecommendationsNeverDropsProxyEngines(t *testing.T) {
+	stale := time.Now().Add(-200 * 24 * time.Hour)
+	tables := map[string]*models.Table{
+		"db.events":      {FullName: "db.events", Engine: "MergeTree", LastAccess: stale, Reads: 1, TotalBytes: 5e8},
+		"db.events_view": {FullName: "db.events_view", Engine: "View", LastAccess: stale, Reads: 1},
+		"db.events_dist": {FullName: "db.events_dist", Engine: "Distributed", LastAccess: stale, Reads: 1},
+		"db.unused_view": {FullName: "db.unused_view", Engine: "View", ZeroUsage: true, TotalBytes: 5e8},
+	}
+
+	recs := GenerateRecommendations(tables, map[string]*models.Service{}, config.DefaultConfig())
+
+	if !reflect.DeepEqual(recs.SafeToDrop, []string{"db.events"}) {
+		t.Fatalf("expected only the MergeTree table in safe_to_drop, got %v", recs.SafeToDrop)
+	}
+	if len(recs.ZeroUsageNonReplicated) != 0 {
+		t.Fatalf("expected zero-usage view not to be recommended, got %+v", recs.ZeroUsageNonReplicated)
+	}
+	for _, name := range []string{"db.events_view", "db.events_dist", "db.unused_view"} {
+		if recs.KeepReasons[name] != "proxy_engine" {
+			t.Fatalf("expected %s kept with reason proxy_engine, got %q", name, recs.KeepReasons[name])
+		}
+	}
+}
+
+func TestGenerateRecommendationsRecordsKeepReasons(t *testing.T) {
+	now := time.Now()
+	tables := map[string]*models.Table{
+		"system.query_log": {FullName: "system.query_log", Name: "query_log", Database: "system", Reads: 10, LastAccess: now.Add(-30 * 24 * time.Hour)},
+		"db.recent_write":  {FullName: "db.recent_write", Name: "recent_write", Database: "db", Writes: 3, LastAccess: now.Add(-2 * 24 * time.Hour)},
+		"db.active":        {FullName: "db.active", Name: "active", Database: "db", Reads: 2000, LastAccess: now.Add(-48 * time.Hour)},
+		"db.unused":        {FullName: "db.unused", Name: "unused", Database: "db", Reads: 1, LastAccess: now.Add(-200 * 24 * time.Hour)},
+	}
+
+	recs := GenerateRecommendations(tables, servicesUsingTable("db.active", 6), config.DefaultConfig())
+
+	want := map[string]string{
+		"system.query_log": "system_table",
+		"db.recent_write":  "recent_writes",
+		"db.active":        "active",
+	}
+	if !reflect.DeepEqual(recs.KeepReasons, want) {
+		t.Fatalf("unexpected keep reasons: got %v, want %v", recs.KeepReasons, want)
+	}
+	// Every kept table has a reason, and the flat list stays for compatibility
+	if len(recs.Keep) != len(want) {
+		t.Fatalf("expected %d kept tables, got %v", len(want), recs.Keep)
+	}
+}
+
+func TestGenerateRecommendationsProtectsRecentWrites(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	tables := func() map[string]*models.Table {
+		return map[string]*models.Table{
+			// Loaded weekly but last read before the write, so last access is as
+			// old as the last write
+			"db.weekly_load": {FullName: "db.weekly_load", Writes: 1000, Reads: 1, LastWrite: now.Add(-20 * day), LastAccess: now.Add(-20 * day)},
+			// Written long ago, read yesterday
+			"db.old_write": {FullName: "db.old_write", Writes: 1000, Reads: 1, LastWrite: now.Add(-60 * day), LastAccess: now.Add(-day)},
+			// From a report that predates write tracking
+			"db.legacy": {FullName: "db.legacy", Writes: 1000, Reads: 1, LastAccess: now.Add(-2 * day)},
+			// Written two days ago by an insert that wrote no rows, so only
+			// LastWrite records it
+			"db.empty_insert": {FullName: "db.empty_insert", Reads: 1, LastWrite: now.Add(-2 * day), LastAccess: now.Add(-2 * day)},
+		}
+	}
+
+	cases := []struct {
+		name   string
+		window time.Duration
+		want   map[string]bool // Tables kept as recent_writes
+	}{
+		{name: "default", window: config.DefaultProtectRecentWrite, want: map[string]bool{"db.legacy": true, "db.empty_insert": true}},
+		{name: "month", window: 30 * day, want: map[string]bool{"db.weekly_load": true, "db.legacy": true, "db.empty_insert": true}},
+		{name: "off", window: 0, want: map[string]bool{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.ProtectRecentWrite = tc.window
+			recs := GenerateRecommendations(tables(), map[string]*models.Service{}, cfg)
+
+			for name := range tables() {
+				if got := recs.KeepReasons[name] == "recent_writes"; got != tc.want[name] {
+					t.Fatalf("%s kept as recent_writes = %v, want %v (reasons %v)", name, got, tc.want[name], recs.KeepReasons)
+				}
+			}
+		})
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	lookback := 30 * 24 * time.Hour
+	cases := []struct {
+		name     string
+		table    *models.Table
+		wantLow  bool
+		minScore float64
+		maxScore float64
+	}{
+		{
+			name:     "full_metadata_long_idle",
+			table:    &models.Table{ZeroUsage: true, TotalBytes: 5e8, TotalRows: 1e6, MetadataModified: now.Add(-200 * 24 * time.Hour)},
+			minScore: 0.95,
+			maxScore: 1,
+		},
+		{
+			name:     "zero_usage_created_mid_lookback",
+			table:    &models.Table{ZeroUsage: true, TotalBytes: 5e8, MetadataModified: now.Add(-15 * 24 * time.Hour)},
+			minScore: 0.80,
+			maxScore: 0.85,
+		},
+		{
+			name:     "missing_metadata",
+			table:    &models.Table{Reads: 3, DistinctServices: 1, LastAccess: now.Add(-25 * 24 * time.Hour)},
+			wantLow:  true,
+			minScore: 0.35,
+			maxScore: 0.45,
+		},
+		{
+			name:     "many_recent_consumers",
+			table:    &models.Table{Reads: 50, DistinctServices: 4, TotalBytes: 1e6, MetadataModified: now.Add(-400 * 24 * time.Hour), LastAccess: now.Add(-24 * time.Hour)},
+			minScore: 0.45,
+			maxScore: 0.50,
+			wantLow:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Confidence(tc.table, now, lookback)
+			if got < tc.minScore || got > tc.maxScore {
+				t.Fatalf("expected confidence in [%.2f, %.2f], got %.2f", tc.minScore, tc.maxScore, got)
+			}
+			if low := got < LowConfidence; low != tc.wantLow {
+				t.Fatalf("expected low=%v for confidence %.2f", tc.wantLow, got)
+			}
+		})
+	}
+}
+
+func TestGenerateRecommendationsRanksByConfidence(t *testing.T) {
+	now := time.Now()
+	tables := map[string]*models.Table{
+		"db.documented": {FullName: "db.documented", Name: "documented", Database: "db", Engine: "MergeTree", ZeroUsage: true, TotalBytes: 5e8, MetadataModified: now.Add(-200 * 24 * time.Hour)},
+		"db.stale":      {FullName: "db.stale", Name: "stale", Database: "db", Reads: 1, TotalBytes: 5e8, MetadataModified: now.Add(-200 * 24 * time.Hour), LastAccess: now.Add(-20 * 24 * time.Hour)},
+		"db.unknown":    {FullName: "db.unknown", Name: "unknown", Database: "db", Reads: 1, DistinctServices: 1, LastAccess: now.Add(-20 * 24 * time.Hour)},
+	}
+
+	recs := GenerateRecommendations(tables, map[string]*models.Service{}, config.DefaultConfig())
+
+	var order []string
+	for _, rec := range recs.Ranked {
+		order = append(order, rec.Category+":"+rec.Name)
+	}
+	want := []string{"zero_usage_non_replicated:db.documented", "safe_to_drop:db.stale", "safe_to_drop:db.unknown"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("expected ranked order %v, got %v", want, order)
+	}
+	if top := recs.Ranked[0]; top.Confidence < 0.95 || top.LowConfidence {
+		t.Fatalf("expected high confidence for a long-idle table with full metadata, got %+v", top)
+	}
+	if last := recs.Ranked[2]; !last.LowConfidence {
+		t.Fatalf("expected a table without inventory metadata flagged low confidence, got %+v", last)
+	}
+	if zero := recs.ZeroUsageNonReplicated[0]; zero.Confidence != recs.Ranked[0].Confidence {
+		t.Fatalf("expected zero-usage list to carry the confidence, got %+v", zero)
+	}
+}
+
+func TestGenerateRecommendationsKeepsTooNewTables(t *testing.T) {
+	now := time.Now()
+	stale := now.Add(-200 * 24 * time.Hour)
+	tables := map[string]*models.Table{
+		"db.fresh_unused": {FullName: "db.fresh_unused", Name: "fresh_unused", Database: "db", ZeroUsage: true, TotalBytes: 5e8, MetadataModified: now.Add(-24 * time.Hour)},
+		"db.old_unused":   {FullName: "db.old_unused", Name: "old_unused", Database: "db", ZeroUsage: true, TotalBytes: 5e8, MetadataModified: stale},
+		"db.fresh_stale":  {FullName: "db.fresh_stale", LastAccess: stale, Reads: 1, TotalBytes: 5e8, MetadataModified: now.Add(-3 * 24 * time.Hour)},
+		"db.no_inventory": {FullName: "db.no_inventory", LastAccess: stale, Reads: 1, TotalBytes: 5e8},
+	}
+
+	recs := GenerateRecommendations(tables, map[string]*models.Service{}, config.DefaultConfig())
+
+	for _, name := range []string{"db.fresh_unused", "db.fresh_stale"} {
+		if recs.KeepReasons[name] != "too_new" {
+			t.Fatalf("expected %s kept with reason too_new, got %q", name, recs.KeepReasons[name])
+		}
+	}
+	if len(recs.ZeroUsageNonReplicated) != 1 || recs.ZeroUsageNonReplicated[0].Name != "db.old_unused" {
+		t.Fatalf("expected only the old zero-usage table recommended, got %+v", recs.ZeroUsageNonReplicated)
+	}
+	if !reflect.DeepEqual(recs.SafeToDrop, []string{"db.no_inventory"}) {
+		t.Fatalf("expected a table without a creation time to stay recommendable, got %v", recs.SafeToDrop)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.MinTableAge = 0
+	recs = GenerateRecommendations(tables, map[string]*models.Service{}, cfg)
+	if _, kept := recs.KeepReasons["db.fresh_unused"]; kept || len(recs.ZeroUsageNonReplicated) != 2 {
+		t.Fatalf("expected --min-table-age 0 to disable the guard, got %+v", recs)
+	}
+}
+
+func TestGenerateRecommendationsFlagsShadowTables(t *testing.T) {
+	now := time.Now()
+	newTables := func() map[string]*models.Table {
+		return map[string]*models.Table{
+			"db.events":          {FullName: "db.events", Database: "db", Name: "events", Engine: "MergeTree", LastAccess: now, Reads: 500},
+			"db.events_old":      {FullName: "db.events_old", Database: "db", Name: "events_old", Engine: "MergeTree", ZeroUsage: true, TotalBytes: 2e9},
+			"db.events_20240101": {FullName: "db.events_20240101", Database: "db", Name: "events_20240101", Engine: "MergeTree", ZeroUsage: true, TotalBytes: 1e9},
+			"db.orders_v2":       {FullName: "db.orders_v2", Database: "db", Name: "orders_v2", Engine: "MergeTree", ZeroUsage: true, TotalBytes: 3e9},
+			"db.events_archive":  {FullName: "db.events_archive", Database: "db", Name: "events_archive", Engine: "MergeTree", ZeroUsage: true, TotalBytes: 4e9},
+		}
+	}
+	shadowsOf := func(recs models.CleanupRecommendations) map[string]string {
+		shadows := map[string]string{}
+		for _, rec := range recs.ZeroUsageNonReplicated {
+			if rec.ShadowOf != "" {
+				shadows[rec.Name] = rec.ShadowOf
+			}
+		}
+		return shadows
+	}
+
+	cases := []struct {
+		name    string
+		enabled bool
+		pattern string
+		want    map[string]string
+	}{
+		{name: "disabled", want: map[string]string{}},
+		{
+			name:    "default_pattern",
+			enabled: true,
+			want:    map[string]string{"db.events_old": "db.events", "db.events_20240101": "db.events"},
+		},
+		{
+			name:    "custom_pattern",
+			enabled: true,
+			pattern: `_archive$`,
+			want:    map[string]string{"db.events_archive": "db.events"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.DetectShadowTables = tc.enabled
+			if tc.pattern != "" {
+				cfg.ShadowSuffixPattern = tc.pattern
+			}
+
+			recs := GenerateRecommendations(newTables(), map[string]*models.Service{}, cfg)
+
+			if got := shadowsOf(recs); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected shadow tables %v, got %v", tc.want, got)
+			}
+			for _, rec := range recs.ZeroUsageNonReplicated {
+				if rec.Name == "db.events" {
+					t.Fatal("expected the live sibling never to be recommended")
+				}
+			}
+			if len(tc.want) > 0 && recs.ZeroUsageNonReplicated[0].ShadowOf == "" {
+				t.Fatalf("expected shadow tables listed first, got %+v", recs.ZeroUsageNonReplicated)
+			}
+		})
+	}
+}
+
+func TestSimpleScorerDiversityBuckets(t *testing.T) {
+	now := time.Now()
+	table := &models.Table{
+		FullName:   "db.shared",
+		Reads:      50,
+		LastAccess: now.Add(-20 * 24 * time.Hour), // recencyAt(20) + 0.1 (10-100 queries)
+	}
+	services := servicesUsingTable("db.shared", 8)
+
+	cases := []struct {
+		name    string
+		buckets []config.DiversityBucket
+		want    float64
+	}{
+		{
+			name:    "defaults",
+			buckets: nil,
+			want:    recencyAt(20) + 0.1 + 0.2, // 6+ services
+		},
+		{
+			name: "sprawl_tolerant",
+			buckets: []config.DiversityBucket{
+				{MinServices: 20, Weight: 0.20},
+				{MinServices: 10, Weight: 0.10},
+				{MinServices: 1, Weight: 0.02},
+			},
+			want: recencyAt(20) + 0.1 + 0.02, // 8 services falls into the 1+ bucket
+		},
+		{
+			name: "diversity_dominant",
+			buckets: []config.DiversityBucket{
+				{MinServices: 2, Weight: 0.40},
+			},
+			want: recencyAt(20) + 0.1 + 0.4, // 2+ services
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			scorer := &SimpleScorer{DiversityBuckets: tc.buckets}
+			got := scorer.Score(table, services)
+			if math.Abs(got-tc.want) > 0.0001 {
+				t.Fatalf("expected score %.2f, got %.2f", tc.want, got)
+			}
+		})
+	}
+}
+
 func TestSimpleScorerCategorize(t *testing.T) {
 	cases := []struct {
 		name  string
@@ -489,6 +913,157 @@ func TestGenerateRecommendations(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "mv_linked_tables_are_kept",
+			tables: map[string]*models.Table{
+				"db.raw_events": {
+					Name:         "raw_events",
+					Database:     "db",
+					FullName:     "db.raw_events",
+					LastAccess:   now.Add(-120 * 24 * time.Hour),
+					MVDependency: []string{"db.events_mv"},
+				},
+				"db.events_mv": {
+					Name:         "events_mv",
+					Database:     "db",
+					FullName:     "db.events_mv",
+					IsMV:         true,
+					LastAccess:   now.Add(-120 * 24 * time.Hour),
+					MVDependency: []string{"db.events_rollup"},
+				},
+				"db.events_rollup": {
+					Name:       "events_rollup",
+					Database:   "db",
+					FullName:   "db.events_rollup",
+					ZeroUsage:  true,
+					TotalBytes: 2 * 1e9,
+				},
+				"db.unused": {
+					Name:       "unused",
+					Database:   "db",
+					FullName:   "db.unused",
+					LastAccess: now.Add(-120 * 24 * time.Hour),
+				},
+			},
+			services: map[string]*models.Service{},
+			cfg:      config.DefaultConfig(),
+			verify: func(t *testing.T, recs models.CleanupRecommendations) {
+				for _, table := range []string{"db.raw_events", "db.events_rollup"} {
+					if !containsString(recs.Keep, table) {
+						t.Fatalf("expected %s in keep, got keep=%v safe_to_drop=%v", table, recs.Keep, recs.SafeToDrop)
+					}
+					if got := recs.KeepReasons[table]; got != "mv_dependency" {
+						t.Fatalf("expected %s keep reason mv_dependency, got %q", table, got)
+					}
+				}
+				if got := recs.KeepReasons["db.events_mv"]; got != "materialized_view" {
+					t.Fatalf("expected db.events_mv keep reason materialized_view, got %q", got)
+				}
+				if len(recs.ZeroUsageNonReplicated) != 0 {
+					t.Fatalf("expected MV target not to be a zero-usage recommendation, got %+v", recs.ZeroUsageNonReplicated)
+				}
+				if !containsString(recs.SafeToDrop, "db.unused") || len(recs.SafeToDrop) != 1 {
+					t.Fatalf("expected only db.unused in safe_to_drop, got %v", recs.SafeToDrop)
+				}
+			},
+		},
+		{
+			name: "protected_tables_forced_into_keep",
+			tables: map[string]*models.Table{
+				"billing.invoices_archive": {
+					Name:       "invoices_archive",
+					Database:   "billing",
+					FullName:   "billing.invoices_archive",
+					ZeroUsage:  true,
+					TotalBytes: 2 * 1e9,
+				},
+				"billing.ledger": {
+					Name:       "ledger",
+					Database:   "billing",
+					FullName:   "billing.ledger",
+					LastAccess: now.Add(-120 * 24 * time.Hour),
+				},
+				"db.unused": {
+					Name:       "unused",
+					Database:   "db",
+					FullName:   "db.unused",
+					LastAccess: now.Add(-120 * 24 * time.Hour),
+				},
+			},
+			services: map[string]*models.Service{},
+			cfg: func() *config.Config {
+				cfg := config.DefaultConfig()
+				cfg.ProtectedTables = []string{"billing.*"}
+				return cfg
+			}(),
+			verify: func(t *testing.T, recs models.CleanupRecommendations) {
+				for _, table := range []string{"billing.invoices_archive", "billing.ledger"} {
+					if !containsString(recs.Keep, table) {
+						t.Fatalf("expected %s in keep, got keep=%v", table, recs.Keep)
+					}
+					if got := recs.KeepReasons[table]; got != "protected" {
+						t.Fatalf("expected %s keep reason protected, got %q", table, got)
+					}
+				}
+				if len(recs.ZeroUsageNonReplicated) != 0 || len(recs.ZeroUsageReplicated) != 0 {
+					t.Fatalf("expected no zero-usage recommendations, got %+v %+v", recs.ZeroUsageNonReplicated, recs.ZeroUsageReplicated)
+				}
+				if !containsString(recs.SafeToDrop, "db.unused") || len(recs.SafeToDrop) != 1 {
+					t.Fatalf("expected only db.unused in safe_to_drop, got %v", recs.SafeToDrop)
+				}
+				if recs.ReclaimableBytes != 0 {
+					t.Fatalf("expected protected tables not to count as reclaimable, got %d", recs.ReclaimableBytes)
+				}
+			},
+		},
+		{
+			name: "reclaimable_bytes_scales_replicated_tables",
+			tables: map[string]*models.Table{
+				"db.zero_nonrep": {
+					Name:       "zero_nonrep",
+					Database:   "db",
+					FullName:   "db.zero_nonrep",
+					ZeroUsage:  true,
+					TotalBytes: 2 * 1e9,
+				},
+				"db.zero_rep": {
+					Name:         "zero_rep",
+					Database:     "db",
+					FullName:     "db.zero_rep",
+					ZeroUsage:    true,
+					IsReplicated: true,
+					TotalBytes:   2 * 1e9,
+				},
+				"db.unused": {
+					Name:       "unused",
+					Database:   "db",
+					FullName:   "db.unused",
+					LastAccess: now.Add(-120 * 24 * time.Hour),
+					TotalBytes: 5e8,
+				},
+				"db.active": {
+					Name:       "active",
+					Database:   "db",
+					FullName:   "db.active",
+					Reads:      2000,
+					Writes:     1,
+					LastAccess: now.Add(-48 * time.Hour),
+					TotalBytes: 7e9,
+				},
+			},
+			services: servicesUsingTable("db.active", 6),
+			cfg: func() *config.Config {
+				cfg := config.DefaultConfig()
+				cfg.ReplicaFactor = 3
+				return cfg
+			}(),
+			verify: func(t *testing.T, recs models.CleanupRecommendations) {
+				// 2 GB non-replicated + 2 GB replicated x3 + 0.5 GB safe to drop
+				if want := uint64(8.5e9); recs.ReclaimableBytes != want {
+					t.Fatalf("expected %d reclaimable bytes, got %d", want, recs.ReclaimableBytes)
+				}
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,75 @@
+package scorer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ppiankov/clickspectre/internal/models"
+)
+
+// shadowTables returns, for every zero-usage table sharing a name stem with
+// an active table in the same database, the active sibling it appears to be
+// an abandoned copy of (events_old and events_v2 next to a live events).
+// Stems are table names with suffix stripped; when several siblings are
+// active, the most used one is reported.
+func shadowTables(tables map[string]*models.Table, suffix *regexp.Regexp) map[string]string {
+	type group struct {
+		active  string
+		usage   uint64
+		unused  []string
+		hasLive bool
+	}
+
+	groups := make(map[string]*group)
+	for tableName, table := range tables {
+		stem := tableStem(table, tableName, suffix)
+		if stem == "" {
+			continue
+		}
+		g := groups[stem]
+		if g == nil {
+			g = &group{}
+			groups[stem] = g
+		}
+
+		if table.ZeroUsage {
+			g.unused = append(g.unused, tableName)
+			continue
+		}
+		usage := table.Reads + table.Writes + table.Mutations
+		if usage == 0 {
+			continue
+		}
+		if !g.hasLive || usage > g.usage || (usage == g.usage && tableName < g.active) {
+			g.active, g.usage, g.hasLive = tableName, usage, true
+		}
+	}
+
+	shadows := make(map[string]string)
+	for _, g := range groups {
+		if !g.hasLive {
+			continue
+		}
+		for _, tableName := range g.unused {
+			shadows[tableName] = g.active
+		}
+	}
+	return shadows
+}
+
+// tableStem returns the "db.stem" grouping key for table, or "" when
+// stripping the suffix leaves nothing to group on.
+func tableStem(table *models.Table, tableName string, suffix *regexp.Regexp) string {
+	database, name := table.Database, table.Name
+	if name == "" {
+		var ok bool
+		if database, name, ok = strings.Cut(tableName, "."); !ok {
+			database, name = "", tableName
+		}
+	}
+	stem := suffix.ReplaceAllString(strings.ToLower(name), "")
+	if stem == "" {
+		return ""
+	}
+	return strings.ToLower(database) + "." + stem
+}
@@ -1,80 +1,134 @@
 package scorer
 
 import (
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
 )
 
 // SimpleScorer implements a simple scoring algorithm
-type SimpleScorer struct{}
+type SimpleScorer struct {
+	// DiversityBuckets weights access diversity by service count, highest
+	// MinServices first. Nil uses config.DefaultDiversityBuckets.
+	DiversityBuckets []config.DiversityBucket
+	// RecencyHalfLife is the access age at which the recency factor drops
+	// to half its weight. Zero uses config.DefaultRecencyHalfLife.
+	RecencyHalfLife time.Duration
+	// SizePressureWeight scales the size_pressure factor, which raises the
+	// score of small, heavily read tables and lowers it for large, cold
+	// ones. Zero leaves the factor out.
+	SizePressureWeight float64
+}
 
 // Score calculates a score for a table (0.0 - 1.0)
 func (s *SimpleScorer) Score(table *models.Table, services map[string]*models.Service) float64 {
+	score := 0.0
+	for _, factor := range s.Explain(table, services, time.Now()) {
+		score += factor.Contribution
+	}
+	return score
+}
+
+// Explain returns the per-factor contributions that make up a table's score
+// as of now. The contributions sum to Score.
+func (s *SimpleScorer) Explain(table *models.Table, services map[string]*models.Service, now time.Time) []ScoreFactor {
 	// Special handling for zero-usage tables
 	if table.ZeroUsage {
-		score := 0.0
-
 		// Risk factors that INCREASE score (less safe to delete):
+		mv := ScoreFactor{Name: "mv_dependency", Detail: "no materialized view involvement"}
 		if table.IsMV || len(table.MVDependency) > 0 {
-			score += 0.50 // Has MV dependencies
+			mv.Contribution = 0.50
+			mv.Detail = "materialized view or MV dependency"
 		}
+		replicated := ScoreFactor{Name: "replicated", Detail: "not replicated"}
 		if table.IsReplicated {
-			score += 0.30 // Replicated table (might be intentionally idle)
-		}
-		if table.TotalBytes < 1e6 { // < 1MB
-			score += 0.20 // Too small to matter
+			replicated.Contribution = 0.30 // Might be intentionally idle
+			replicated.Detail = "replicated table"
 		}
 
 		// Negative factors (decrease score = safer to delete):
-		if table.TotalBytes > 1e9 { // > 1GB
-			score -= 0.10 // Large size = valuable cleanup
+		size := ScoreFactor{Name: "size", Detail: "between 1MB and 1GB"}
+		if table.TotalBytes < 1e6 {
+			size.Contribution = 0.20 // Too small to matter
+			size.Detail = "under 1MB"
+		}
+		if table.TotalBytes > 1e9 {
+			size.Contribution = -0.10 // Large size = valuable cleanup
+			size.Detail = "over 1GB"
 		}
 
-		return score
+		return s.withSizePressure([]ScoreFactor{mv, replicated, size}, table)
 	}
 
-	score := 0.0
-	now := time.Now()
-
-	// Factor 1: Recent activity (40% weight)
+	// Factor 1: Recent activity (40% weight), decaying smoothly with age
+	recency := ScoreFactor{Name: "recency", Contribution: 0.40 * s.recencyDecay(now.Sub(table.LastAccess))}
 	daysSinceAccess := now.Sub(table.LastAccess).Hours() / 24
-	if daysSinceAccess < 7 {
-		score += 0.40
-	} else if daysSinceAccess < 30 {
-		score += 0.30
-	} else if daysSinceAccess < 90 {
-		score += 0.10
-	}
-	// else: no points for very old tables
+	recency.Detail = fmt.Sprintf("last access %.0f days ago", daysSinceAccess)
 
 	// Factor 2: Query volume (30% weight)
-	totalQueries := table.Reads + table.Writes
+	volume := ScoreFactor{Name: "query_volume"}
+	totalQueries := table.Reads + table.Writes + table.Mutations
 	if totalQueries > 1000 {
-		score += 0.30
+		volume.Contribution = 0.30
 	} else if totalQueries > 100 {
-		score += 0.20
+		volume.Contribution = 0.20
 	} else if totalQueries > 10 {
-		score += 0.10
+		volume.Contribution = 0.10
 	}
 	// else: very low activity
+	volume.Detail = fmt.Sprintf("%d queries", totalQueries)
 
 	// Factor 3: Access diversity - count unique services using this table (20% weight)
 	uniqueServices := countServicesUsingTable(table.FullName, services)
-	if uniqueServices > 5 {
-		score += 0.20
-	} else if uniqueServices > 2 {
-		score += 0.15
-	} else if uniqueServices > 0 {
-		score += 0.05
+	diversity := ScoreFactor{
+		Name:         "access_diversity",
+		Contribution: s.diversityWeight(uniqueServices),
+		Detail:       fmt.Sprintf("%d services", uniqueServices),
 	}
 
 	// Factor 4: Write activity (10% weight)
+	writes := ScoreFactor{Name: "write_activity", Detail: "no writes"}
 	if table.Writes > 0 {
-		score += 0.10 // Active writes indicate the table is being maintained
+		writes.Contribution = 0.10 // Active writes indicate the table is being maintained
+		writes.Detail = fmt.Sprintf("%d writes", table.Writes)
+	} else if table.Mutations > 0 {
+		writes.Contribution = 0.10
+		writes.Detail = fmt.Sprintf("%d mutations", table.Mutations)
 	}
 
-	return score
+	return s.withSizePressure([]ScoreFactor{recency, volume, diversity, writes}, table)
+}
+
+// withSizePressure appends the size_pressure factor when it is enabled and
+// the table size is known.
+func (s *SimpleScorer) withSizePressure(factors []ScoreFactor, table *models.Table) []ScoreFactor {
+	if s.SizePressureWeight <= 0 || table.TotalBytes == 0 {
+		return factors
+	}
+
+	// heat is the share of the table read during the lookback, capped at
+	// one full scan; bigness grows logarithmically from 1MB (0) to 1GB (1)
+	readBytes := estimatedReadBytes(table)
+	heat := math.Min(1, readBytes/float64(table.TotalBytes))
+	bigness := math.Max(0, math.Min(1, math.Log10(float64(table.TotalBytes)/1e6)/3))
+
+	return append(factors, ScoreFactor{
+		Name:         "size_pressure",
+		Contribution: s.SizePressureWeight * (heat - bigness),
+		Detail:       fmt.Sprintf("%.1f MB, ~%.1f MB read", float64(table.TotalBytes)/1e6, readBytes/1e6),
+	})
+}
+
+// estimatedReadBytes approximates bytes read from the rows read and the
+// table's average row size, since query_log read_bytes is not collected.
+func estimatedReadBytes(table *models.Table) float64 {
+	if table.TotalRows == 0 {
+		return 0
+	}
+	return float64(table.Reads) * float64(table.TotalBytes) / float64(table.TotalRows)
 }
 
 // Categorize returns a category based on the score
@@ -88,6 +142,33 @@ func (s *SimpleScorer) Categorize(score float64) string {
 	}
 }
 
+// recencyDecay returns 2^(-age/halfLife): 1 for a table accessed now, 0.5 at
+// one half-life. Future access times (clock skew) count as now.
+func (s *SimpleScorer) recencyDecay(age time.Duration) float64 {
+	halfLife := s.RecencyHalfLife
+	if halfLife <= 0 {
+		halfLife = config.DefaultRecencyHalfLife
+	}
+	if age < 0 {
+		age = 0
+	}
+	return math.Exp2(-float64(age) / float64(halfLife))
+}
+
+// diversityWeight returns the weight of the first bucket the service count reaches
+func (s *SimpleScorer) diversityWeight(uniqueServices int) float64 {
+	buckets := s.DiversityBuckets
+	if buckets == nil {
+		buckets = config.DefaultDiversityBuckets()
+	}
+	for _, bucket := range buckets {
+		if uniqueServices >= bucket.MinServices {
+			return bucket.Weight
+		}
+	}
+	return 0
+}
+
 // countServicesUsingTable counts how many services use a given table
 func countServicesUsingTable(tableName string, services map[string]*models.Service) int {
 	count := 0
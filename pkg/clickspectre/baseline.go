@@ -0,0 +1,113 @@
+package clickspectre
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/baseline"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+// BaselineOutcome describes how report findings were matched against the
+// baseline file.
+type BaselineOutcome struct {
+	File         string   // Baseline file findings were compared with
+	Suppressed   int      // Findings removed from the report as known
+	ExpiredRules []string // Patterns of rules past their until date, which were ignored
+
+	// Set by cfg.CompareBaseline, which annotates findings and removes none
+	Compared bool
+	Known    int       // Findings the baseline covers
+	New      []Finding // Findings the baseline does not cover
+}
+
+// ApplyBaseline suppresses the report's known findings (cfg.BaselinePath),
+// marks them with InBaseline (cfg.CompareBaseline), or merges them into the
+// baseline file (cfg.UpdateBaseline). The outcome is nil unless the report
+// was compared with the baseline.
+func ApplyBaseline(cfg *config.Config, report *Report) (*BaselineOutcome, error) {
+	// Determine baseline file path
+	baselinePath := cfg.BaselinePath
+	if baselinePath == "" {
+		baselinePath = baseline.DefaultPath
+	}
+
+	// Load existing baseline findings
+	existingBaselineFindings, err := baseline.LoadFormat(baselinePath, cfg.BaselineFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load baseline from %s: %w", baselinePath, err)
+	}
+
+	// Generate current findings from the report
+	currentFindings, err := baseline.GenerateFindings(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate findings for baseline: %w", err)
+	}
+
+	// If --baseline flag is used (but not --update-baseline), apply suppression,
+	// or only annotate findings with --compare-baseline
+	var outcome *BaselineOutcome
+	if cfg.BaselinePath != "" && !cfg.UpdateBaseline {
+		outcome = &BaselineOutcome{File: baselinePath}
+		for _, rule := range baseline.ExpiredRules(existingBaselineFindings, time.Now()) {
+			outcome.ExpiredRules = append(outcome.ExpiredRules, rule.Pattern)
+			slog.Warn("ignoring expired baseline rule",
+				slog.String("pattern", rule.Pattern),
+				slog.String("until", rule.Until),
+				slog.String("reason", rule.Reason),
+				slog.String("baseline_file", baselinePath),
+			)
+		}
+		if cfg.CompareBaseline {
+			known, newFindings, err := baseline.Annotate(report, existingBaselineFindings)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare findings with baseline: %w", err)
+			}
+			outcome.Compared = true
+			outcome.Known = known
+			outcome.New = newFindings
+			return outcome, nil
+		}
+		suppressedCount, err := baseline.ApplySuppression(report, existingBaselineFindings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply baseline suppression: %w", err)
+		}
+		if suppressedCount > 0 {
+			slog.Debug("suppressed known findings",
+				slog.Int("suppressed", suppressedCount),
+				slog.String("baseline_file", baselinePath),
+			)
+		}
+		outcome.Suppressed = suppressedCount
+	}
+
+	// If --update-baseline flag is used, merge current findings into the baseline and save
+	if cfg.UpdateBaseline {
+		addedBy := baselineAuthor()
+		for i := range currentFindings {
+			currentFindings[i].Reason = cfg.BaselineReason
+			currentFindings[i].AddedBy = addedBy
+		}
+		mergedFindings := baseline.MergeFindings(existingBaselineFindings, currentFindings)
+		if err := baseline.Save(baselinePath, mergedFindings); err != nil {
+			return nil, fmt.Errorf("failed to save updated baseline to %s: %w", baselinePath, err)
+		}
+		slog.Debug("baseline updated",
+			slog.String("baseline_file", baselinePath),
+			slog.Int("total_findings", len(mergedFindings)),
+		)
+	}
+
+	return outcome, nil
+}
+
+// baselineAuthor returns the local user name recorded on new baseline entries.
+func baselineAuthor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,387 @@
+// Package clickspectre runs the ClickSpectre analysis workflow as a library.
+//
+// Analyze collects query logs, builds the usage model, scores tables, and
+// applies the baseline and policy, returning the report without writing any
+// output. Formatting and persisting the report are left to the caller; the
+// clickspectre CLI is one such caller.
+package clickspectre
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/analyzer"
+	"github.com/ppiankov/clickspectre/internal/baseline"
+	"github.com/ppiankov/clickspectre/internal/collector"
+	"github.com/ppiankov/clickspectre/internal/k8s"
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/internal/policy"
+	"github.com/ppiankov/clickspectre/internal/scorer"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+// DefaultVersion is the tool version recorded in reports unless WithVersion
+// sets another.
+const DefaultVersion = "dev"
+
+// Types of the public API, defined in internal packages.
+type (
+	// Report is the analysis report Analyze returns.
+	Report = models.Report
+	// QueryLogEntry is one collected query_log row.
+	QueryLogEntry = models.QueryLogEntry
+	// TableUsage is a table's usage accumulated by incremental runs.
+	TableUsage = models.TableUsage
+	// Table is a table's metadata as returned by a Collector.
+	Table = models.Table
+	// CollectionMeta describes the nodes and rows of the last collection.
+	CollectionMeta = models.CollectionMeta
+	// Collector reads query logs and table metadata, see WithCollector.
+	Collector = collector.Collector
+	// CollectorOption configures the collector opened from the config.
+	CollectorOption = collector.Option
+	// Progress reports collection progress after each query_log page.
+	Progress = collector.Progress
+	// Resolver resolves client IPs to Kubernetes workloads, see WithResolver.
+	Resolver = k8s.K8sResolverInterface
+	// ServiceInfo is the workload a Resolver maps a client IP to.
+	ServiceInfo = k8s.ServiceInfo
+	// Finding is a baseline finding, see BaselineOutcome.
+	Finding = baseline.Finding
+)
+
+// Option configures a run of Analyze or Run.
+type Option func(*options)
+
+type options struct {
+	collector     Collector
+	collectorOpts []CollectorOption
+	resolver      Resolver
+	clock         func() time.Time
+	version       string
+	priorUsage    map[string]TableUsage
+}
+
+// WithCollector reads query logs from col instead of opening a collector
+// from the config. The caller keeps ownership of col and closes it.
+func WithCollector(col Collector) Option {
+	return func(o *options) { o.collector = col }
+}
+
+// WithCollectorOptions passes opts to the collector opened from the config,
+// e.g. WithProgress.
+func WithCollectorOptions(opts ...CollectorOption) Option {
+	return func(o *options) { o.collectorOpts = append(o.collectorOpts, opts...) }
+}
+
+// WithProgress reports per-page collection progress to fn. With multiple
+// nodes fn may be called concurrently.
+func WithProgress(fn func(Progress)) CollectorOption {
+	return collector.WithProgress(fn)
+}
+
+// WithResolver resolves client IPs through r instead of a Kubernetes
+// resolver built from the config. It is used only with cfg.ResolveK8s, and
+// the caller keeps ownership of r.
+func WithResolver(r Resolver) Option {
+	return func(o *options) { o.resolver = r }
+}
+
+// WithClock sets the clock behind report timestamps and the analysis
+// duration (default time.Now).
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) { o.clock = clock }
+}
+
+// WithVersion sets the tool version recorded in the report.
+func WithVersion(version string) Option {
+	return func(o *options) { o.version = version }
+}
+
+// WithPriorUsage merges usage accumulated by earlier incremental runs into
+// the analysis, see analyzer.Analyzer.SetPriorUsage.
+func WithPriorUsage(usage map[string]TableUsage) Option {
+	return func(o *options) { o.priorUsage = usage }
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	Report   *Report
+	Entries  []*QueryLogEntry // Collected query log entries; nil for cfg.PlanReport
+	Baseline *BaselineOutcome // Set when cfg.BaselinePath suppressed findings
+}
+
+// Analyze runs the analysis described by cfg and returns the report.
+func Analyze(ctx context.Context, cfg *config.Config, opts ...Option) (*Report, error) {
+	result, err := Run(ctx, cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Report, nil
+}
+
+// Run is Analyze, also returning the collected entries and the baseline
+// comparison for callers that persist incremental state or report on the
+// baseline.
+//
+// Query logs are collected from ClickHouse, or read from cfg.FromFile; with
+// cfg.PlanReport a prior report is re-scored instead and nothing is
+// collected.
+func Run(ctx context.Context, cfg *config.Config, opts ...Option) (*Result, error) {
+	o := &options{clock: time.Now, version: DefaultVersion}
+	for _, opt := range opts {
+		opt(o)
+	}
+	startTime := o.clock()
+
+	// 1. Open a collector, unless re-scoring a report or one was supplied
+	col := o.collector
+	if col == nil && cfg.PlanReport == "" {
+		if cfg.FromFile == "" {
+			slog.Debug("connecting to ClickHouse", slog.String("host", collector.ExtractHost(cfg.ClickHouseDSN)))
+		}
+		var err error
+		col, err = collector.New(cfg, o.collectorOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create collector: %w", err)
+		}
+		defer func() { _ = col.Close() }()
+	}
+
+	// 2. Initialize K8s resolver (if enabled)
+	resolver := o.resolver
+	if cfg.ResolveK8s && resolver == nil {
+		slog.Debug("connecting to Kubernetes", slog.String("kubeconfig", cfg.KubeConfig))
+		r, err := k8s.NewResolver(cfg)
+		if err != nil {
+			slog.Error("failed to initialize Kubernetes resolver",
+				slog.String("error", err.Error()),
+				slog.String("fallback", "continuing without Kubernetes resolution"),
+			)
+			// Disable resolution for this run only, leaving the caller's cfg as is
+			runCfg := *cfg
+			runCfg.ResolveK8s = false
+			cfg = &runCfg
+		} else {
+			resolver = r
+			defer func() {
+				if err := r.Close(); err != nil {
+					slog.Warn("failed to close Kubernetes resolver", slog.String("error", err.Error()))
+				}
+			}()
+		}
+	}
+
+	// 3. Collect query logs, or load a prior report for --plan
+	var entries []*models.QueryLogEntry
+	var prior *models.Report
+	var collectionMeta *models.CollectionMeta
+	var err error
+	switch {
+	case cfg.PlanReport != "":
+		slog.Debug("loading prior report", slog.String("path", cfg.PlanReport))
+		prior, err = models.LoadReport(cfg.PlanReport)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load report from %s: %w", cfg.PlanReport, err)
+		}
+	case cfg.FromFile != "":
+		slog.Debug("loading query logs from file", slog.String("path", cfg.FromFile))
+		entries, err = col.Collect(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load query logs from %s: %w", cfg.FromFile, err)
+		}
+		collectionMeta = col.CollectionMeta()
+	default:
+		slog.Debug("collecting query logs",
+			slog.Duration("lookback", cfg.LookbackPeriod),
+			slog.Int("batch_size", cfg.BatchSize),
+		)
+		entries, err = col.Collect(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect query logs: %w", err)
+		}
+		collectionMeta = col.CollectionMeta()
+	}
+	slog.Debug("collected query log entries", slog.Int("count", len(entries)))
+
+	// 4. Analyze data
+	an := analyzer.New(cfg, resolver, col)
+	an.SetPriorUsage(o.priorUsage)
+	if prior != nil {
+		slog.Debug("analyzing prior report", slog.Int("tables", len(prior.Tables)))
+		if err := an.AnalyzeReport(ctx, prior); err != nil {
+			return nil, fmt.Errorf("failed to analyze report: %w", err)
+		}
+	} else {
+		slog.Debug("analyzing data", slog.Int("entries", len(entries)))
+		if err := an.Analyze(ctx, entries); err != nil {
+			return nil, fmt.Errorf("failed to analyze data: %w", err)
+		}
+	}
+	slog.Debug("analysis complete",
+		slog.Int("tables", len(an.Tables())),
+		slog.Int("services", len(an.Services())),
+		slog.Int("edges", len(an.Edges())),
+	)
+
+	// 5. Score tables and generate recommendations
+	slog.Debug("scoring tables", slog.Int("tables", len(an.Tables())))
+	recommendations := scorer.GenerateRecommendations(an.Tables(), an.Services(), cfg)
+	slog.Debug("recommendations generated",
+		slog.Int("safe_to_drop", len(recommendations.SafeToDrop)),
+		slog.Int("likely_safe", len(recommendations.LikelySafe)),
+		slog.Int("keep", len(recommendations.Keep)),
+	)
+
+	// 6. Build report
+	report := buildReport(cfg, o, entries, an, recommendations, startTime, collectionMeta)
+	if prior != nil {
+		carryPriorMetadata(report, prior)
+	}
+
+	// 7. Apply baseline and policy
+	outcome, err := ApplyBaseline(cfg, report)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPolicy(cfg, report); err != nil {
+		return nil, err
+	}
+
+	return &Result{Report: report, Entries: entries, Baseline: outcome}, nil
+}
+
+// applyPolicy records every violation of cfg.PolicyFile as a
+// policy_violation anomaly with a stable ID, skipping any already in the
+// report. One table can break several rules, so the ID is keyed by rule too.
+func applyPolicy(cfg *config.Config, report *models.Report) error {
+	if cfg.PolicyFile == "" {
+		return nil
+	}
+	pol, err := policy.Load(cfg.PolicyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load policy: %w", err)
+	}
+	violations := pol.Evaluate(report)
+	if len(violations) > 0 {
+		slog.Info("policy violations detected", slog.Int("count", len(violations)))
+		seen := make(map[string]struct{}, len(report.Anomalies))
+		for _, a := range report.Anomalies {
+			seen[a.ID] = struct{}{}
+		}
+		for _, v := range violations {
+			id := models.AnomalyID("policy_violation/"+v.Rule, v.Table, "")
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+			report.Anomalies = append(report.Anomalies, models.Anomaly{
+				ID:            id,
+				Type:          "policy_violation",
+				Severity:      v.Severity,
+				Description:   v.Message,
+				AffectedTable: v.Table,
+			})
+		}
+	}
+	return nil
+}
+
+// carryPriorMetadata keeps the collection details of a prior report that a
+// --plan run cannot recompute without the original query log entries.
+func carryPriorMetadata(report, prior *models.Report) {
+	report.Collection = prior.Collection
+	report.Metadata.ClickHouseHost = prior.Metadata.ClickHouseHost
+	report.Metadata.LookbackDays = prior.Metadata.LookbackDays
+	report.Metadata.TotalQueriesAnalyzed = prior.Metadata.TotalQueriesAnalyzed
+	report.Metadata.K8sResolutionEnabled = prior.Metadata.K8sResolutionEnabled
+	report.Metadata.K8sResolved = prior.Metadata.K8sResolved
+	report.Metadata.K8sUnresolved = prior.Metadata.K8sUnresolved
+	report.Metadata.K8sResolutionErrors = prior.Metadata.K8sResolutionErrors
+	report.Metadata.K8sResolutionDegraded = prior.Metadata.K8sResolutionDegraded
+	report.Users = prior.Users
+}
+
+// buildReport constructs the final report. Its timestamps and duration come
+// from the run's clock, measured from startTime.
+func buildReport(
+	cfg *config.Config,
+	o *options,
+	entries []*models.QueryLogEntry,
+	an *analyzer.Analyzer,
+	recommendations models.CleanupRecommendations,
+	startTime time.Time,
+	collectionMeta *models.CollectionMeta,
+) *models.Report {
+	generatedAt := o.clock().UTC()
+
+	// Convert maps to slices
+	var tables []models.Table
+	for _, table := range an.Tables() {
+		tables = append(tables, *table)
+	}
+
+	var services []models.Service
+	for _, service := range an.Services() {
+		services = append(services, *service)
+	}
+
+	var edges []models.Edge
+	for _, edge := range an.Edges() {
+		edges = append(edges, *edge)
+	}
+
+	consumers := analyzer.TopConsumers(edges, cfg.TopConsumers)
+	for i := range tables {
+		tables[i].TopConsumers = consumers[tables[i].FullName]
+	}
+
+	var anomalies []models.Anomaly
+	for _, anomaly := range an.Anomalies() {
+		anomalies = append(anomalies, *anomaly)
+	}
+
+	report := &models.Report{
+		SchemaVersion: models.ReportSchemaVersion,
+		Tool:          "clickspectre",
+		Version:       o.version,
+		Timestamp:     generatedAt.In(cfg.TimeLocation()).Format(time.RFC3339),
+		Collection:    collectionMeta,
+		Metadata: models.Metadata{
+			GeneratedAt:          generatedAt,
+			LookbackDays:         int(cfg.LookbackPeriod.Hours() / 24),
+			ClickHouseHost:       collector.ExtractHost(cfg.ClickHouseDSN),
+			TotalQueriesAnalyzed: uint64(len(entries)),
+			AnalysisDuration:     generatedAt.Sub(startTime).Round(time.Second).String(),
+			Version:              o.version,
+			K8sResolutionEnabled: cfg.ResolveK8s,
+			Timezone:             cfg.TimeLocation().String(),
+		},
+		Tables:                 tables,
+		Services:               services,
+		Edges:                  edges,
+		Anomalies:              anomalies,
+		CleanupRecommendations: recommendations,
+		Graph:                  analyzer.RollupGraph(services, edges, cfg.GraphGranularity),
+	}
+
+	k8sStats := an.K8sResolution()
+	report.Metadata.K8sResolved = k8sStats.Resolved
+	report.Metadata.K8sUnresolved = k8sStats.Unresolved
+	report.Metadata.K8sResolutionErrors = k8sStats.Errors
+	report.Metadata.K8sResolutionDegraded = k8sStats.Degraded()
+
+	if collectionMeta != nil && collectionMeta.RowLimitHit {
+		report.Metadata.Truncated = true
+		report.Metadata.RowsScanned = collectionMeta.RowsScanned
+		report.Metadata.RowLimit = cfg.MaxRows
+	}
+
+	if cfg.ByUser {
+		report.Users = analyzer.BuildUserActivity(entries)
+	}
+
+	return report
+}
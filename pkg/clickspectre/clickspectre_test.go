@@ -0,0 +1,239 @@
+package clickspectre
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/clickspectre/internal/analyzer"
+	"github.com/ppiankov/clickspectre/internal/models"
+	"github.com/ppiankov/clickspectre/pkg/config"
+)
+
+// fakeCollector serves fixed query log entries and table metadata.
+type fakeCollector struct {
+	entries []*models.QueryLogEntry
+	tables  map[string]*models.Table
+	closed  bool
+}
+
+func (f *fakeCollector) Collect(ctx context.Context) ([]*models.QueryLogEntry, error) {
+	return f.entries, nil
+}
+
+func (f *fakeCollector) FetchTableMetadata(ctx context.Context) (map[string]*models.Table, error) {
+	return f.tables, nil
+}
+
+func (f *fakeCollector) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeCollector) CollectionMeta() *models.CollectionMeta {
+	return &models.CollectionMeta{Nodes: []string{"fake"}, TotalEntries: len(f.entries)}
+}
+
+func (f *fakeCollector) QueryRaw(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, nil
+}
+
+func TestAnalyzeWithFakeCollector(t *testing.T) {
+	eventTime := time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC)
+	col := &fakeCollector{
+		entries: []*models.QueryLogEntry{
+			{QueryID: "q1", EventTime: eventTime, QueryKind: "Select", ClientIP: "10.0.0.1", Tables: []string{"db.events"}, ReadRows: 100},
+			{QueryID: "q2", EventTime: eventTime, QueryKind: "Insert", ClientIP: "10.0.0.2", Tables: []string{"db.events"}, WrittenRows: 5},
+		},
+		tables: map[string]*models.Table{
+			"db.events": {Name: "events", Database: "db", FullName: "db.events", Engine: "MergeTree", TotalBytes: 10 << 20},
+			"db.legacy": {Name: "legacy", Database: "db", FullName: "db.legacy", Engine: "MergeTree", TotalBytes: 50 << 20},
+		},
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.ClickHouseDSN = "clickhouse://ch.internal:9000/default"
+	cfg.DetectUnusedTables = true
+	cfg.MinTableAge = 0
+	fixed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	report, err := Analyze(context.Background(), cfg,
+		WithCollector(col),
+		WithClock(func() time.Time { return fixed }),
+		WithVersion("v1.2.3"),
+	)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if col.closed {
+		t.Fatal("expected a supplied collector to be left open for its owner")
+	}
+
+	if report.Version != "v1.2.3" || report.Metadata.ClickHouseHost != "ch.internal" || report.Timestamp != "2026-03-01T12:00:00Z" {
+		t.Fatalf("unexpected report header: version=%q host=%q timestamp=%q",
+			report.Version, report.Metadata.ClickHouseHost, report.Timestamp)
+	}
+	if report.Metadata.TotalQueriesAnalyzed != 2 || report.Collection == nil || report.Collection.TotalEntries != 2 {
+		t.Fatalf("expected collection details from the collector, got %+v / %+v", report.Metadata, report.Collection)
+	}
+	if len(report.Tables) != 2 || len(report.Services) != 2 || len(report.Edges) != 2 {
+		t.Fatalf("expected 2 tables, services, and edges, got %d, %d, %d",
+			len(report.Tables), len(report.Services), len(report.Edges))
+	}
+	if recs := report.CleanupRecommendations; len(recs.ZeroUsageNonReplicated) != 1 || recs.ZeroUsageNonReplicated[0].Name != "db.legacy" {
+		t.Fatalf("expected db.legacy to be recommended for cleanup, got %+v", recs)
+	}
+}
+
+func TestBuildReportIncludesAnalyzedData(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ClickHouseDSN = "clickhouse://localhost:9000/default"
+	cfg.AnomalyDetection = false
+
+	entries := []*models.QueryLogEntry{
+		{
+			QueryID:   "q1",
+			EventTime: time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC),
+			Query:     "SELECT * FROM db.events",
+			ClientIP:  "10.0.0.1",
+			Tables:    []string{"db.events"},
+			ReadRows:  100,
+		},
+	}
+
+	an := analyzer.New(cfg, nil, nil)
+	if err := an.Analyze(context.Background(), entries); err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+
+	o := &options{clock: time.Now, version: DefaultVersion}
+	report := buildReport(cfg, o, entries, an, models.CleanupRecommendations{
+		SafeToDrop: []string{"db.old_events"},
+	}, time.Now().Add(-2*time.Second), nil)
+
+	if report.Tool != "clickspectre" {
+		t.Fatalf("expected tool to be %q, got %q", "clickspectre", report.Tool)
+	}
+	if report.Version != DefaultVersion {
+		t.Fatalf("expected report version to be %q, got %q", DefaultVersion, report.Version)
+	}
+	if report.SchemaVersion != models.ReportSchemaVersion {
+		t.Fatalf("expected schema version %d, got %d", models.ReportSchemaVersion, report.SchemaVersion)
+	}
+	parsedTimestamp, err := time.Parse(time.RFC3339, report.Timestamp)
+	if err != nil {
+		t.Fatalf("expected RFC3339 timestamp, got %q: %v", report.Timestamp, err)
+	}
+	if report.Timestamp != report.Metadata.GeneratedAt.UTC().Format(time.RFC3339) {
+		t.Fatalf("expected timestamp to match metadata.generated_at at RFC3339 precision, got %q and %q", report.Timestamp, report.Metadata.GeneratedAt.UTC().Format(time.RFC3339))
+	}
+	if parsedTimestamp.Location() != time.UTC {
+		t.Fatalf("expected UTC timestamp, got location %q", parsedTimestamp.Location())
+	}
+
+	if report.Metadata.TotalQueriesAnalyzed != 1 {
+		t.Fatalf("expected total queries 1, got %d", report.Metadata.TotalQueriesAnalyzed)
+	}
+	if report.Metadata.ClickHouseHost == "unknown" {
+		t.Fatalf("expected extracted host, got %q", report.Metadata.ClickHouseHost)
+	}
+	if len(report.Tables) == 0 {
+		t.Fatal("expected at least one table in report")
+	}
+	if len(report.Services) == 0 {
+		t.Fatal("expected at least one service in report")
+	}
+	if len(report.Edges) == 0 {
+		t.Fatal("expected at least one edge in report")
+	}
+	if len(report.CleanupRecommendations.SafeToDrop) != 1 {
+		t.Fatal("expected recommendations to be included")
+	}
+}
+
+func TestBuildReportUsesInjectedClock(t *testing.T) {
+	fixed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	o := &options{clock: func() time.Time { return fixed }, version: DefaultVersion}
+
+	cfg := config.DefaultConfig()
+	cfg.ClickHouseDSN = "clickhouse://localhost:9000/default"
+	an := analyzer.New(cfg, nil, nil)
+	if err := an.Analyze(context.Background(), nil); err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+
+	startTime := fixed.Add(-90 * time.Second)
+	first := buildReport(cfg, o, nil, an, models.CleanupRecommendations{}, startTime, nil)
+	second := buildReport(cfg, o, nil, an, models.CleanupRecommendations{}, startTime, nil)
+
+	if first.Timestamp != "2026-03-01T12:00:00Z" || !first.Metadata.GeneratedAt.Equal(fixed) {
+		t.Fatalf("expected pinned timestamps, got %q / %s", first.Timestamp, first.Metadata.GeneratedAt)
+	}
+	if first.Metadata.AnalysisDuration != "1m30s" {
+		t.Fatalf("expected duration derived from the clock, got %q", first.Metadata.AnalysisDuration)
+	}
+	if first.Timestamp != second.Timestamp || !first.Metadata.GeneratedAt.Equal(second.Metadata.GeneratedAt) ||
+		first.Metadata.AnalysisDuration != second.Metadata.AnalysisDuration {
+		t.Fatalf("expected stable report metadata, got %+v and %+v", first.Metadata, second.Metadata)
+	}
+}
+
+func TestRunDisablesK8sWithoutChangingCallerConfig(t *testing.T) {
+	col := &fakeCollector{
+		entries: []*models.QueryLogEntry{
+			{QueryID: "q1", EventTime: time.Now(), QueryKind: "Select", ClientIP: "10.0.0.1", Tables: []string{"db.events"}},
+		},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ResolveK8s = true
+	cfg.KubeConfig = filepath.Join(t.TempDir(), "missing-kubeconfig")
+
+	result, err := Run(context.Background(), cfg, WithCollector(col))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Report.Metadata.K8sResolutionEnabled {
+		t.Fatal("expected K8s resolution to be disabled when the resolver cannot start")
+	}
+	if !cfg.ResolveK8s {
+		t.Fatal("expected the caller's config to keep ResolveK8s")
+	}
+}
+
+func TestApplyPolicyAssignsStableIDs(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("max_zero_usage_days: 90\nmax_table_size_gb: 1\n"), 0o644); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.PolicyFile = policyPath
+
+	// db.big breaks both rules, so it gets two violations
+	report := &models.Report{
+		Tables: []models.Table{{FullName: "db.big", ZeroUsage: true, TotalBytes: 2 << 30}},
+	}
+	for range 2 {
+		if err := applyPolicy(cfg, report); err != nil {
+			t.Fatalf("applyPolicy failed: %v", err)
+		}
+	}
+
+	if len(report.Anomalies) != 2 {
+		t.Fatalf("expected two violations, added once, got %+v", report.Anomalies)
+	}
+	ids := make(map[string]bool)
+	for _, a := range report.Anomalies {
+		if a.ID == "" {
+			t.Fatalf("expected policy_violation to have an ID, got %+v", a)
+		}
+		ids[a.ID] = true
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected distinct IDs per rule, got %+v", report.Anomalies)
+	}
+	if want := models.AnomalyID("policy_violation/max_zero_usage_days", "db.big", ""); !ids[want] {
+		t.Fatalf("expected ID %s for the zero-usage rule, got %+v", want, report.Anomalies)
+	}
+}
@@ -5,79 +5,252 @@ import "time"
 // Config holds all runtime configuration
 type Config struct {
 	// ClickHouse settings
-	ClickHouseDSN    string
-	ClickHouseDSNs   []string // Parsed from comma-separated ClickHouseDSN
-	QueryTimeout     time.Duration
-	BatchSize        int
-	MaxRows          int
-	LookbackPeriod   time.Duration
-	MinQueryCount    uint64
-	ExcludeTables    []string
-	ExcludeDatabases []string
+	ClickHouseDSN      string
+	ClickHouseDSNs     []string // Parsed from comma-separated ClickHouseDSN
+	QueryTimeout       time.Duration
+	BatchSize          int
+	MaxRows            int
+	InventoryPageSize  int           // system.tables rows fetched per inventory query
+	InventoryCacheTTL  time.Duration // Reuse an on-disk table inventory younger than this (0 = off)
+	InventoryCacheFile string        // Path to the inventory cache (default: ~/.config/clickspectre/inventory.json)
+	MaxQueryLength     int           // Stored query text is cut to this many bytes after table extraction (0 = unlimited)
+	RetryBudget        int           // Total query retries per node across all query_log pages (0 = unlimited)
+	RetryErrorCodes    []int32       // ClickHouse exception codes retried as transient server overload
+	LookbackPeriod     time.Duration
+	MinQueryCount      uint64
+	ExcludeTables      []string
+	ExcludeDatabases   []string
+	IncludeTables      []string // When set with IncludeDatabases, only matching tables are analyzed (glob patterns)
+	IncludeDatabases   []string // When set with IncludeTables, only tables in matching databases are analyzed (glob patterns)
+	ExcludeUsers       []string // Drop query_log entries from these users (glob patterns)
+	ExcludeQueryKinds  []string // Drop query_log entries of these query kinds (glob patterns)
+	ExcludeLogComments []string // Drop query_log entries whose log_comment matches, e.g. monitoring tags (glob patterns)
+	IncludeExceptions  bool     // Also collect failed queries (Exception* query_log rows)
+	EngineAllow        []string // When set, only tables whose engine matches are analyzed (glob patterns)
+	EngineDeny         []string // Tables whose engine matches are dropped from analysis (glob patterns)
+	ProtectedTables    []string // Tables never recommended for cleanup (glob patterns)
+	SensitiveTables    []string // Tables whose new consumers are flagged new_sensitive_access (glob patterns)
+
+	ClickHousePasswordFile string // File holding the ClickHouse password, overriding the DSN's
+	SessionSettings        bool   // Send max_execution_time/max_memory_usage when the user is not readonly
+	MaxMemoryUsage         int64  // max_memory_usage in bytes sent with SessionSettings (0 = server default)
+
+	// ClickHouse TLS, applied over whatever the DSN implies when any is set
+	ClickHouseTLS                bool   // Connect over TLS
+	ClickHouseCACert             string // PEM CA bundle used to verify the server (implies TLS)
+	ClickHouseInsecureSkipVerify bool   // Skip server certificate verification (implies TLS)
+
+	// Exclusion debugging
+	ExplainExclusions bool            // Report which exclusion pattern removed each table
+	ExplainTables     []string        // Candidate tables to explain instead of all excluded ones
+	ExclusionTrace    *ExclusionTrace // Set when ExplainExclusions is enabled
 
 	// Kubernetes settings
 	ResolveK8s   bool
 	KubeConfig   string
 	K8sCacheTTL  time.Duration
 	K8sRateLimit int
+	K8sCacheFile string // Persist resolved IPs between runs (empty = in-memory only)
 
 	// Concurrency settings
-	Concurrency int
+	Concurrency   int
+	PrefetchPages bool // Request up to Concurrency query_log pages ahead of the one being processed
 
 	// Output settings
-	OutputDir string
-	Format    string
+	OutputDir         string
+	Format            string
+	SARIFLocationRoot string   // SARIF table locations as <root>/<db>/<table>.sql (empty = README.md)
+	TextTop           int      // Limit the text report to the first N tables (0 = all)
+	TextSortBy        string   // Text report table order: score, reads, writes, size, last_access, severity
+	Color             string   // ANSI styling of the text report on stdout: auto, always, or never
+	SummaryJSON       string   // Also write a machine summary here regardless of Format ("-" = stderr)
+	OutputArchive     string   // Also bundle OutputDir into this .zip or .tar.gz file
+	OutputURL         string   // Upload the report here (s3://, gs://, http(s)://) instead of writing OutputDir
+	ReportSections    []string // Sections rendered in json, text, and markdown output (empty = all)
+	GraphGranularity  string   // Service nodes in the graph and text views: pod, service, or namespace
+	Timezone          string   // IANA zone for sparkline and heatmap buckets and report times
 
 	// Baseline settings
 	BaselinePath   string
 	UpdateBaseline bool
+	BaselineReason string // Justification stamped on entries added by UpdateBaseline
+	BaselineDiff   string // Optional path for the baseline diff JSON
+	BaselineFormat string // "json" (default) or "list"
+	// CompareBaseline annotates findings with whether BaselinePath covers
+	// them instead of suppressing them
+	CompareBaseline bool
 
 	// Analysis settings
-	ScoringAlgorithm   string
-	AnomalyDetection   bool
-	IncludeMVDeps      bool
-	DetectUnusedTables bool       // Enable detection of tables with zero usage
-	MinTableSizeMB     float64    // Minimum table size in MB for unused table recommendations
-	ByUser             bool       // Include per-user activity analysis
-	Incremental        bool       // Only fetch entries newer than last run
-	IncrementalSince   *time.Time // Set internally from watermark — fetch entries after this time
-	WatermarkFile      string     // Path to watermark file for incremental mode
-	ResetWatermark     bool       // Delete watermark and force full rescan
-	PolicyFile         string     // Path to policy file for enforcement
+	ScoringAlgorithm    string
+	DiversityBuckets    []DiversityBucket // Service-count buckets for the scorer's access diversity factor
+	RecencyHalfLife     time.Duration     // Access age at which the scorer's recency factor halves
+	SizePressureWeight  float64           // Weight of the scorer's size_pressure factor (0 = off)
+	AnomalyDetection    bool
+	IncludeMVDeps       bool
+	DetectUnusedTables  bool              // Enable detection of tables with zero usage
+	DetectShadowTables  bool              // Recommend zero-usage tables whose name stem matches an active table
+	PartitionHeat       bool              // Estimate hot and cold partitions of date-partitioned tables from query filters
+	ShadowSuffixPattern string            // Regexp stripped from table names to find their stem for shadow detection
+	MinTableSizeMB      float64           // Minimum table size in MB for unused table recommendations
+	MinTableAge         time.Duration     // Tables created more recently are kept as too_new (0 = off)
+	ProtectRecentWrite  time.Duration     // Tables written more recently are kept as recent_writes (0 = off)
+	ReplicaFactor       int               // Replicas freed when dropping a replicated table (scales reclaimable storage)
+	ByUser              bool              // Include per-user activity analysis
+	IPAliases           map[string]string // Client IP -> service label, applied instead of K8s resolution (keys from CanonicalIPAliases)
+	NormalizeIPv6       bool              // Canonicalize client IPs so mapped and bare forms key the same service
+	KeepSampleQueries   int               // Distinct example queries retained per table (0 = none)
+	TopConsumers        int               // Busiest services listed per table in the report (0 = none)
+	RedactLiterals      bool              // Replace string and numeric literals in retained example queries
+	Incremental         bool              // Only fetch entries newer than last run, merging usage saved in the watermark
+	IncrementalSince    *time.Time        // Set internally from watermark — fetch entries after this time
+	WatermarkFile       string            // Path to watermark file for incremental mode
+	ResetWatermark      bool              // Delete watermark and force full rescan
+	PolicyFile          string            // Path to policy file for enforcement
+	FromFile            string            // Analyze entries from a collect dump instead of ClickHouse
+	PlanReport          string            // Re-score a prior report.json offline instead of collecting
+	Anomalies           AnomalyThresholds
 
 	// Server settings
 	ServerPort int
 
 	// Operational flags
-	Verbose bool
-	DryRun  bool
+	Verbose  bool
+	DryRun   bool
+	Progress bool // Render a live collection counter on a terminal stderr
+}
+
+// AnomalyThresholds tunes when detectAnomalies flags a table or service
+type AnomalyThresholds struct {
+	StaleDays             int     // Days without access before a table is stale
+	ReadOnlyMinReads      uint64  // Reads above which a never-written table is flagged read_only
+	LowActivityMaxAccess  uint64  // Accesses below which a table has low activity
+	LowActivityMinDays    int     // Days without access before low activity is flagged
+	BroadAccessTableCount int     // Tables above which a service has broad access
+	UsageSpikeMultiplier  float64 // Hourly bucket above this multiple of the trailing mean is a spike
+	UsageSpikeMinQueries  uint64  // Queries an hourly bucket needs before it can count as a spike
+	UsageDropBuckets      int     // Number of trailing hourly buckets that must be near-zero for a drop
+	UsageDropMinMean      float64 // Minimum prior hourly mean for a table to count as previously busy
+	ErrorProneRate        float64 // Failed-query share above which a table is flagged error_prone
+	ErrorProneMinFailures uint64  // Failed queries a table needs before error_prone is considered
+	FullScanRatio         float64 // Rows read per result row above which a table is flagged full_scan_suspect (0 = off)
+	FullScanMinQueries    uint64  // Successful reads a table needs before full_scan_suspect is considered
+	NewAccessAfter        float64 // Share of the window after which a service's first access to a sensitive table is new
+}
+
+// DefaultAnomalyThresholds returns the built-in anomaly thresholds
+func DefaultAnomalyThresholds() AnomalyThresholds {
+	return AnomalyThresholds{
+		StaleDays:             30,
+		ReadOnlyMinReads:      100,
+		LowActivityMaxAccess:  10,
+		LowActivityMinDays:    7,
+		BroadAccessTableCount: 20,
+		UsageSpikeMultiplier:  5.0,
+		UsageSpikeMinQueries:  10,
+		UsageDropBuckets:      6,
+		UsageDropMinMean:      10.0,
+		ErrorProneRate:        0.2,
+		ErrorProneMinFailures: 5,
+		FullScanRatio:         0, // Off: read_rows is per query, so joins over-count
+		FullScanMinQueries:    10,
+		NewAccessAfter:        0.5,
+	}
+}
+
+// DefaultRecencyHalfLife is the default half-life of the scorer's recency
+// factor. At 30 days it roughly tracks the old 7/30/90-day steps: about
+// 0.34 of 0.40 at a week, 0.20 at a month and 0.05 at three months.
+const DefaultRecencyHalfLife = 30 * 24 * time.Hour
+
+// DefaultMinTableAge is how old a table must be before it can be
+// recommended for cleanup. Younger tables have too little history to judge.
+const DefaultMinTableAge = 7 * 24 * time.Hour
+
+// DefaultProtectRecentWrite is how long after its last write a table stays
+// protected from cleanup recommendations.
+const DefaultProtectRecentWrite = 7 * 24 * time.Hour
+
+// DefaultShadowSuffixPattern strips the version, copy, and date suffixes
+// that distinguish abandoned copies of a table (events_v2, events_old,
+// events_20240101) from the table itself.
+const DefaultShadowSuffixPattern = `(?:_v\d+|_new|_old|_bak|_backup|_tmp|_copy|_\d{4}_?\d{2}_?\d{2})+$`
+
+// DefaultMaxQueryLength is the default length, in bytes, at which stored
+// query text is truncated.
+const DefaultMaxQueryLength = 100000
+
+// DefaultRetryBudget is the default number of retries a collection may
+// spend across all pages of one node before giving up.
+const DefaultRetryBudget = 20
+
+// DefaultInventoryPageSize is the default number of system.tables rows read
+// per table inventory query.
+const DefaultInventoryPageSize = 10000
+
+// DefaultTopConsumers is the default number of busiest services listed per
+// table.
+const DefaultTopConsumers = 5
+
+// DefaultRetryErrorCodes returns the ClickHouse exception codes retried as
+// transient server overload: 202 TOO_MANY_SIMULTANEOUS_QUERIES, 203
+// NO_FREE_CONNECTION, 241 MEMORY_LIMIT_EXCEEDED, and 439
+// CANNOT_SCHEDULE_TASK.
+func DefaultRetryErrorCodes() []int32 {
+	return []int32{202, 203, 241, 439}
 }
 
 // DefaultConfig returns sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
-		QueryTimeout:       5 * time.Minute,
-		BatchSize:          100000,
-		MaxRows:            1000000,
-		LookbackPeriod:     30 * 24 * time.Hour, // 30 days
-		MinQueryCount:      0,
-		ExcludeTables:      []string{},
-		ExcludeDatabases:   []string{},
-		ResolveK8s:         false,
-		K8sCacheTTL:        5 * time.Minute,
-		K8sRateLimit:       10,
-		Concurrency:        5,
-		OutputDir:          "./report",
-		Format:             "json",
-		BaselinePath:       "",
-		UpdateBaseline:     false,
-		ScoringAlgorithm:   "simple",
-		AnomalyDetection:   true,
-		IncludeMVDeps:      true,
-		DetectUnusedTables: false, // Opt-in via flag
-		MinTableSizeMB:     1.0,   // 1MB default threshold
-		ServerPort:         8080,
-		Verbose:            false,
-		DryRun:             false,
+		QueryTimeout:        5 * time.Minute,
+		BatchSize:           100000,
+		MaxRows:             1000000,
+		InventoryPageSize:   DefaultInventoryPageSize,
+		MaxQueryLength:      DefaultMaxQueryLength,
+		RetryBudget:         DefaultRetryBudget,
+		RetryErrorCodes:     DefaultRetryErrorCodes(),
+		LookbackPeriod:      30 * 24 * time.Hour, // 30 days
+		MinQueryCount:       0,
+		ExcludeTables:       []string{},
+		ExcludeDatabases:    []string{},
+		IncludeTables:       []string{},
+		IncludeDatabases:    []string{},
+		EngineAllow:         []string{},
+		EngineDeny:          []string{},
+		ExcludeUsers:        []string{},
+		ExcludeQueryKinds:   []string{},
+		ExcludeLogComments:  []string{},
+		ProtectedTables:     []string{},
+		SensitiveTables:     []string{},
+		ResolveK8s:          false,
+		K8sCacheTTL:         5 * time.Minute,
+		K8sRateLimit:        10,
+		Concurrency:         5,
+		OutputDir:           "./report",
+		Format:              "json",
+		TextSortBy:          "score",
+		Color:               "auto",
+		GraphGranularity:    "pod",
+		Timezone:            DefaultTimezone,
+		BaselinePath:        "",
+		UpdateBaseline:      false,
+		BaselineFormat:      "json",
+		ScoringAlgorithm:    "simple",
+		DiversityBuckets:    DefaultDiversityBuckets(),
+		RecencyHalfLife:     DefaultRecencyHalfLife,
+		AnomalyDetection:    true,
+		IncludeMVDeps:       true,
+		DetectUnusedTables:  false, // Opt-in via flag
+		ShadowSuffixPattern: DefaultShadowSuffixPattern,
+		MinTableAge:         DefaultMinTableAge,
+		ProtectRecentWrite:  DefaultProtectRecentWrite,
+		MinTableSizeMB:      1.0, // 1MB default threshold
+		ReplicaFactor:       1,   // Count replicated tables once unless told otherwise
+		NormalizeIPv6:       true,
+		TopConsumers:        DefaultTopConsumers,
+		Anomalies:           DefaultAnomalyThresholds(),
+		ServerPort:          8080,
+		Verbose:             false,
+		DryRun:              false,
 	}
 }
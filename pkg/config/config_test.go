@@ -28,10 +28,24 @@ func TestDefaultConfig(t *testing.T) {
 		{name: "BaselinePath", got: cfg.BaselinePath, want: ""},
 		{name: "UpdateBaseline", got: cfg.UpdateBaseline, want: false},
 		{name: "ScoringAlgorithm", got: cfg.ScoringAlgorithm, want: "simple"},
+		{name: "DiversityBuckets", got: FormatDiversityBuckets(cfg.DiversityBuckets), want: "6:0.2,3:0.15,1:0.05"},
 		{name: "AnomalyDetection", got: cfg.AnomalyDetection, want: true},
 		{name: "IncludeMVDeps", got: cfg.IncludeMVDeps, want: true},
 		{name: "DetectUnusedTables", got: cfg.DetectUnusedTables, want: false},
 		{name: "MinTableSizeMB", got: cfg.MinTableSizeMB, want: 1.0},
+		{name: "NormalizeIPv6", got: cfg.NormalizeIPv6, want: true},
+		{name: "Anomalies.StaleDays", got: cfg.Anomalies.StaleDays, want: 30},
+		{name: "Anomalies.ReadOnlyMinReads", got: cfg.Anomalies.ReadOnlyMinReads, want: uint64(100)},
+		{name: "Anomalies.LowActivityMaxAccess", got: cfg.Anomalies.LowActivityMaxAccess, want: uint64(10)},
+		{name: "Anomalies.LowActivityMinDays", got: cfg.Anomalies.LowActivityMinDays, want: 7},
+		{name: "Anomalies.BroadAccessTableCount", got: cfg.Anomalies.BroadAccessTableCount, want: 20},
+		{name: "Anomalies.UsageSpikeMultiplier", got: cfg.Anomalies.UsageSpikeMultiplier, want: 5.0},
+		{name: "Anomalies.UsageSpikeMinQueries", got: cfg.Anomalies.UsageSpikeMinQueries, want: uint64(10)},
+		{name: "Anomalies.UsageDropBuckets", got: cfg.Anomalies.UsageDropBuckets, want: 6},
+		{name: "Anomalies.UsageDropMinMean", got: cfg.Anomalies.UsageDropMinMean, want: 10.0},
+		{name: "Anomalies.ErrorProneRate", got: cfg.Anomalies.ErrorProneRate, want: 0.2},
+		{name: "Anomalies.ErrorProneMinFailures", got: cfg.Anomalies.ErrorProneMinFailures, want: uint64(5)},
+		{name: "IncludeExceptions", got: cfg.IncludeExceptions, want: false},
 		{name: "ServerPort", got: cfg.ServerPort, want: 8080},
 		{name: "Verbose", got: cfg.Verbose, want: false},
 		{name: "DryRun", got: cfg.DryRun, want: false},
@@ -79,3 +93,107 @@ func TestParseDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDiversityBuckets(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "defaults", input: "6:0.20,3:0.15,1:0.05", want: "6:0.2,3:0.15,1:0.05"},
+		{name: "sorted_highest_first", input: "1:0.05, 10:0.1", want: "10:0.1,1:0.05"},
+		{name: "missing_weight", input: "6", wantErr: true},
+		{name: "zero_services", input: "0:0.1", wantErr: true},
+		{name: "weight_out_of_range", input: "3:1.5", wantErr: true},
+		{name: "duplicate", input: "3:0.1,3:0.2", wantErr: true},
+		{name: "empty", input: " ", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseDiversityBuckets(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q", tc.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %v", tc.input, err)
+			}
+			if formatted := FormatDiversityBuckets(got); formatted != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, formatted)
+			}
+		})
+	}
+}
+
+func TestParseIPAliases(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   []string
+		lookup  string
+		want    string
+		wantErr bool
+	}{
+		{name: "ipv4", input: []string{"10.0.0.1=batch"}, lookup: "10.0.0.1", want: "batch"},
+		{name: "ipv4_mapped_lookup", input: []string{"10.0.0.1 = batch"}, lookup: "::ffff:10.0.0.1", want: "batch"},
+		{name: "ipv6", input: []string{"2001:DB8::1=edge"}, lookup: "2001:db8::1", want: "edge"},
+		{name: "missing_label", input: []string{"10.0.0.1="}, wantErr: true},
+		{name: "missing_separator", input: []string{"10.0.0.1"}, wantErr: true},
+		{name: "not_an_ip", input: []string{"lb.internal=batch"}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			aliases, err := ParseIPAliases(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q", tc.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %v", tc.input, err)
+			}
+			cfg := &Config{IPAliases: aliases}
+			if got, ok := cfg.IPAlias(tc.lookup); !ok || got != tc.want {
+				t.Fatalf("IPAlias(%q) = %q, %v; want %q", tc.lookup, got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalIP(t *testing.T) {
+	cases := map[string]string{
+		"10.0.0.1":                "10.0.0.1",
+		"::ffff:10.0.0.1":         "10.0.0.1",
+		" 2001:0DB8:0:0:0:0:0:1 ": "2001:db8::1",
+		"2001:db8:0:0:1:0:0:1":    "2001:db8::1:0:0:1",
+		"fe80::1%eth0":            "fe80::1%eth0",
+		"":                        "",
+	}
+	for input, want := range cases {
+		if got := CanonicalIP(input); got != want {
+			t.Errorf("CanonicalIP(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv(EnvClickHouseDSN, " clickhouse://env:9000/default ")
+	t.Setenv(EnvFormat, "")
+	t.Setenv(EnvExcludeTables, "a.*, ,b.tmp_*")
+
+	env := LoadEnv()
+	if env.ClickHouseDSN != "clickhouse://env:9000/default" {
+		t.Fatalf("expected trimmed DSN, got %q", env.ClickHouseDSN)
+	}
+	if env.Format != "" || env.Lookback != "" || env.QueryTimeout != "" {
+		t.Fatalf("expected unset variables to stay empty, got %+v", env)
+	}
+	if len(env.ExcludeTables) != 2 || env.ExcludeTables[0] != "a.*" || env.ExcludeTables[1] != "b.tmp_*" {
+		t.Fatalf("unexpected exclude tables: %v", env.ExcludeTables)
+	}
+}
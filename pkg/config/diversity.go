@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiversityBucket awards Weight to tables used by at least MinServices services.
+type DiversityBucket struct {
+	MinServices int     `yaml:"min_services" json:"min_services"`
+	Weight      float64 `yaml:"weight" json:"weight"`
+}
+
+// DefaultDiversityBuckets returns the built-in access diversity buckets:
+// 6+ services score 0.20, 3-5 score 0.15, 1-2 score 0.05.
+func DefaultDiversityBuckets() []DiversityBucket {
+	return []DiversityBucket{
+		{MinServices: 6, Weight: 0.20},
+		{MinServices: 3, Weight: 0.15},
+		{MinServices: 1, Weight: 0.05},
+	}
+}
+
+// ParseDiversityBuckets parses "min:weight" pairs such as "6:0.20,3:0.15,1:0.05".
+// The result is sorted by MinServices, highest first.
+func ParseDiversityBuckets(s string) ([]DiversityBucket, error) {
+	var buckets []DiversityBucket
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		minStr, weightStr, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid diversity bucket %q: expected min_services:weight", part)
+		}
+		minServices, err := strconv.Atoi(strings.TrimSpace(minStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid diversity bucket %q: %w", part, err)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(weightStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid diversity bucket %q: %w", part, err)
+		}
+		buckets = append(buckets, DiversityBucket{MinServices: minServices, Weight: weight})
+	}
+
+	if err := ValidateDiversityBuckets(buckets); err != nil {
+		return nil, err
+	}
+	SortDiversityBuckets(buckets)
+	return buckets, nil
+}
+
+// ValidateDiversityBuckets rejects empty, duplicate or out-of-range buckets.
+func ValidateDiversityBuckets(buckets []DiversityBucket) error {
+	if len(buckets) == 0 {
+		return fmt.Errorf("invalid diversity buckets: at least one bucket is required")
+	}
+
+	seen := make(map[int]bool, len(buckets))
+	for _, bucket := range buckets {
+		if bucket.MinServices < 1 {
+			return fmt.Errorf("invalid diversity bucket min_services %d: must be at least 1", bucket.MinServices)
+		}
+		if bucket.Weight < 0 || bucket.Weight > 1 {
+			return fmt.Errorf("invalid diversity bucket weight %v: must be between 0 and 1", bucket.Weight)
+		}
+		if seen[bucket.MinServices] {
+			return fmt.Errorf("invalid diversity buckets: duplicate min_services %d", bucket.MinServices)
+		}
+		seen[bucket.MinServices] = true
+	}
+	return nil
+}
+
+// SortDiversityBuckets orders buckets by MinServices, highest first.
+func SortDiversityBuckets(buckets []DiversityBucket) {
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].MinServices > buckets[j].MinServices
+	})
+}
+
+// FormatDiversityBuckets renders buckets in the form accepted by ParseDiversityBuckets.
+func FormatDiversityBuckets(buckets []DiversityBucket) string {
+	parts := make([]string, 0, len(buckets))
+	for _, bucket := range buckets {
+		parts = append(parts, fmt.Sprintf("%d:%s", bucket.MinServices, strconv.FormatFloat(bucket.Weight, 'f', -1, 64)))
+	}
+	return strings.Join(parts, ",")
+}
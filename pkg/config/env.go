@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Environment variables read by LoadEnv.
+const (
+	EnvClickHouseDSN = "CLICKSPECTRE_CLICKHOUSE_DSN"
+	EnvFormat        = "CLICKSPECTRE_FORMAT"
+	EnvLookback      = "CLICKSPECTRE_LOOKBACK"
+	EnvExcludeTables = "CLICKSPECTRE_EXCLUDE_TABLES"
+	EnvQueryTimeout  = "CLICKSPECTRE_QUERY_TIMEOUT"
+)
+
+// EnvConfig holds values read from CLICKSPECTRE_* environment variables.
+// Empty fields mean the variable was unset or blank.
+type EnvConfig struct {
+	ClickHouseDSN string
+	Format        string
+	Lookback      string
+	ExcludeTables []string
+	QueryTimeout  string
+}
+
+// LoadEnv reads the supported CLICKSPECTRE_* environment variables.
+// CLICKSPECTRE_EXCLUDE_TABLES is a comma-separated list of patterns.
+func LoadEnv() EnvConfig {
+	env := EnvConfig{
+		ClickHouseDSN: strings.TrimSpace(os.Getenv(EnvClickHouseDSN)),
+		Format:        strings.TrimSpace(os.Getenv(EnvFormat)),
+		Lookback:      strings.TrimSpace(os.Getenv(EnvLookback)),
+		QueryTimeout:  strings.TrimSpace(os.Getenv(EnvQueryTimeout)),
+	}
+	if raw := os.Getenv(EnvExcludeTables); strings.TrimSpace(raw) != "" {
+		env.ExcludeTables = normalizeList(strings.Split(raw, ","))
+	}
+	return env
+}
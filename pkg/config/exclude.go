@@ -12,57 +12,194 @@ func (c *Config) Normalize() {
 	}
 	c.ExcludeTables = normalizePatterns(c.ExcludeTables)
 	c.ExcludeDatabases = normalizePatterns(c.ExcludeDatabases)
+	c.IncludeTables = normalizePatterns(c.IncludeTables)
+	c.IncludeDatabases = normalizePatterns(c.IncludeDatabases)
+	c.ExcludeUsers = normalizePatterns(c.ExcludeUsers)
+	c.ExcludeQueryKinds = normalizePatterns(c.ExcludeQueryKinds)
+	c.ExcludeLogComments = normalizePatterns(c.ExcludeLogComments)
+	c.ProtectedTables = normalizePatterns(c.ProtectedTables)
+	c.SensitiveTables = normalizePatterns(c.SensitiveTables)
+	c.EngineAllow = normalizePatterns(c.EngineAllow)
+	c.EngineDeny = normalizePatterns(c.EngineDeny)
+}
+
+// ExclusionMatch describes the config rule and pattern that excluded a table.
+type ExclusionMatch struct {
+	Rule    string // "exclude_databases", "exclude_tables", or "include" (no allowlist match)
+	Pattern string // Empty for the "include" rule
 }
 
 // IsDatabaseExcluded reports whether database matches exclude patterns.
 func (c *Config) IsDatabaseExcluded(database string) bool {
+	_, excluded := c.MatchDatabaseExclusion(database)
+	return excluded
+}
+
+// MatchDatabaseExclusion returns the first exclude_databases pattern matching database.
+func (c *Config) MatchDatabaseExclusion(database string) (string, bool) {
 	if c == nil || len(c.ExcludeDatabases) == 0 {
-		return false
+		return "", false
 	}
 
 	value := normalizePattern(database)
 	if value == "" {
-		return false
+		return "", false
 	}
 
 	for _, pattern := range c.ExcludeDatabases {
 		if patternMatches(pattern, value) {
-			return true
+			return pattern, true
 		}
 	}
 
-	return false
+	return "", false
+}
+
+// IsTableIncluded reports whether table should be analyzed: it matches an
+// include_tables or include_databases pattern (when any are set) and no
+// exclude pattern.
+func (c *Config) IsTableIncluded(fullName string) bool {
+	return !c.IsTableExcluded(fullName)
 }
 
-// IsTableExcluded reports whether table matches exclude tables/databases patterns.
+// IsTableExcluded reports whether table matches exclude tables/databases
+// patterns or misses the include allowlists. Matches are recorded in
+// ExclusionTrace when one is set.
 func (c *Config) IsTableExcluded(fullName string) bool {
+	match, excluded := c.MatchTableExclusion(fullName)
+	if excluded && c.ExclusionTrace != nil {
+		c.ExclusionTrace.Record(fullName, match)
+	}
+	return excluded
+}
+
+// MatchTableExclusion returns the rule and pattern that exclude table, if any.
+// Database patterns are checked before table patterns, and exclude patterns
+// before the include allowlists, so an explicit exclusion is always reported.
+func (c *Config) MatchTableExclusion(fullName string) (ExclusionMatch, bool) {
 	if c == nil {
-		return false
+		return ExclusionMatch{}, false
 	}
 
 	normalized := normalizePattern(fullName)
 	if normalized == "" {
-		return false
+		return ExclusionMatch{}, false
 	}
 
 	database, table := splitTableName(normalized)
-	if database != "" && c.IsDatabaseExcluded(database) {
-		return true
-	}
-
-	if len(c.ExcludeTables) == 0 {
-		return false
+	if database != "" {
+		if pattern, excluded := c.MatchDatabaseExclusion(database); excluded {
+			return ExclusionMatch{Rule: "exclude_databases", Pattern: pattern}, true
+		}
 	}
 
 	for _, pattern := range c.ExcludeTables {
 		if patternMatches(pattern, normalized) {
-			return true
+			return ExclusionMatch{Rule: "exclude_tables", Pattern: pattern}, true
 		}
 		if table != "" && patternMatches(pattern, table) {
-			return true
+			return ExclusionMatch{Rule: "exclude_tables", Pattern: pattern}, true
+		}
+	}
+
+	if !c.matchesIncludes(database, normalized, table) {
+		return ExclusionMatch{Rule: "include"}, true
+	}
+
+	return ExclusionMatch{}, false
+}
+
+// matchesIncludes reports whether a table matches any include_databases or
+// include_tables pattern. With no includes configured every table matches.
+func (c *Config) matchesIncludes(database, fullName, table string) bool {
+	if len(c.IncludeDatabases) == 0 && len(c.IncludeTables) == 0 {
+		return true
+	}
+	if database != "" && matchesAny(c.IncludeDatabases, database) {
+		return true
+	}
+	if matchesAny(c.IncludeTables, fullName) {
+		return true
+	}
+	return table != "" && matchesAny(c.IncludeTables, table)
+}
+
+// MatchProtectedTable returns the protected_tables pattern matching table, if any.
+// Patterns match either the full "db.table" name or the bare table name.
+func (c *Config) MatchProtectedTable(fullName string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	return matchTablePattern(c.ProtectedTables, fullName)
+}
+
+// MatchSensitiveTable returns the sensitive_tables pattern matching table, if
+// any. Patterns match like protected_tables.
+func (c *Config) MatchSensitiveTable(fullName string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	return matchTablePattern(c.SensitiveTables, fullName)
+}
+
+// matchTablePattern returns the first pattern matching either the full
+// "db.table" name or the bare table name.
+func matchTablePattern(patterns []string, fullName string) (string, bool) {
+	if len(patterns) == 0 {
+		return "", false
+	}
+
+	normalized := normalizePattern(fullName)
+	if normalized == "" {
+		return "", false
+	}
+
+	_, table := splitTableName(normalized)
+	for _, pattern := range patterns {
+		if patternMatches(pattern, normalized) || (table != "" && patternMatches(pattern, table)) {
+			return pattern, true
 		}
 	}
 
+	return "", false
+}
+
+// IsEngineExcluded reports whether a table engine matches the engine deny
+// patterns or misses the allowlist. An unknown (empty) engine is never
+// excluded, since engines are only known once system.tables is fetched.
+func (c *Config) IsEngineExcluded(engine string) bool {
+	if c == nil || strings.TrimSpace(engine) == "" {
+		return false
+	}
+	if matchesAny(c.EngineDeny, engine) {
+		return true
+	}
+	return len(c.EngineAllow) > 0 && !matchesAny(c.EngineAllow, engine)
+}
+
+// IsEntryExcluded reports whether a query_log entry from user with the given
+// query kind and log_comment matches the exclude_users, exclude_query_kinds,
+// or exclude_log_comments patterns. Excluded entries are dropped before any
+// table or service is modelled.
+func (c *Config) IsEntryExcluded(user, queryKind, logComment string) bool {
+	if c == nil {
+		return false
+	}
+	return matchesAny(c.ExcludeUsers, user) || matchesAny(c.ExcludeQueryKinds, queryKind) ||
+		matchesAny(c.ExcludeLogComments, logComment)
+}
+
+// HasEntryExclusions reports whether any pattern can exclude query_log entries.
+func (c *Config) HasEntryExclusions() bool {
+	return c != nil && (len(c.ExcludeUsers) > 0 || len(c.ExcludeQueryKinds) > 0 || len(c.ExcludeLogComments) > 0)
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if patternMatches(pattern, value) {
+			return true
+		}
+	}
 	return false
 }
 
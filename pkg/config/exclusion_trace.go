@@ -0,0 +1,54 @@
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+// ExcludedTable is a table removed by an exclusion rule.
+type ExcludedTable struct {
+	Table string
+	ExclusionMatch
+}
+
+// ExclusionTrace records which exclusion rule removed each table.
+// It is safe for concurrent use.
+type ExclusionTrace struct {
+	mu     sync.Mutex
+	tables map[string]ExclusionMatch
+}
+
+// NewExclusionTrace creates an empty exclusion trace.
+func NewExclusionTrace() *ExclusionTrace {
+	return &ExclusionTrace{tables: make(map[string]ExclusionMatch)}
+}
+
+// Record stores the match for table, keeping the first match seen.
+func (t *ExclusionTrace) Record(table string, match ExclusionMatch) {
+	if t == nil || table == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, exists := t.tables[table]; !exists {
+		t.tables[table] = match
+	}
+}
+
+// Tables returns the recorded tables sorted by name.
+func (t *ExclusionTrace) Tables() []ExcludedTable {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]ExcludedTable, 0, len(t.tables))
+	for table, match := range t.tables {
+		result = append(result, ExcludedTable{Table: table, ExclusionMatch: match})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Table < result[j].Table
+	})
+	return result
+}
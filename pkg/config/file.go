@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -15,19 +16,116 @@ const (
 	DefaultConfigFileYAML = ".clickspectre.yaml"
 	// DefaultConfigFileYML is a compatible alternate config filename.
 	DefaultConfigFileYML = ".clickspectre.yml"
+	// DefaultConfigFileJSON is the JSON config filename, checked after YAML.
+	DefaultConfigFileJSON = ".clickspectre.json"
 )
 
-// FileConfig represents values loaded from a .clickspectre.yaml file.
+// FileConfig represents values loaded from a .clickspectre.yaml or
+// .clickspectre.json file. Both formats share the same keys.
 type FileConfig struct {
-	ClickHouseURL    string   `yaml:"clickhouse_url"`
-	ClickHouseDSN    string   `yaml:"clickhouse_dsn"`
-	ExcludeTables    []string `yaml:"exclude_tables"`
-	ExcludeDatabases []string `yaml:"exclude_databases"`
-	MinQueryCount    *uint64  `yaml:"min_query_count"`
-	Format           string   `yaml:"format"`
-	Timeout          string   `yaml:"timeout"`
-	QueryTimeout     string   `yaml:"query_timeout"`
-	MinTableSizeMB   *float64 `yaml:"min_table_size"`
+	ClickHouseURL      string   `yaml:"clickhouse_url" json:"clickhouse_url"`
+	ClickHouseDSN      string   `yaml:"clickhouse_dsn" json:"clickhouse_dsn"`
+	ExcludeTables      []string `yaml:"exclude_tables" json:"exclude_tables"`
+	ExcludeDatabases   []string `yaml:"exclude_databases" json:"exclude_databases"`
+	ProtectedTables    []string `yaml:"protected_tables" json:"protected_tables"`
+	SensitiveTables    []string `yaml:"sensitive_tables" json:"sensitive_tables"`
+	IncludeTables      []string `yaml:"include_tables" json:"include_tables"`
+	IncludeDatabases   []string `yaml:"include_databases" json:"include_databases"`
+	ExcludeUsers       []string `yaml:"exclude_users" json:"exclude_users"`
+	ExcludeQueryKinds  []string `yaml:"exclude_query_kinds" json:"exclude_query_kinds"`
+	ExcludeLogComments []string `yaml:"exclude_log_comments" json:"exclude_log_comments"`
+	Engines            []string `yaml:"engines" json:"engines"`
+	ExcludeEngines     []string `yaml:"exclude_engines" json:"exclude_engines"`
+	MinQueryCount      *uint64  `yaml:"min_query_count" json:"min_query_count"`
+	Format             string   `yaml:"format" json:"format"`
+	Timeout            string   `yaml:"timeout" json:"timeout"`
+	QueryTimeout       string   `yaml:"query_timeout" json:"query_timeout"`
+	Timezone           string   `yaml:"timezone" json:"timezone"`
+	MinTableSizeMB     *float64 `yaml:"min_table_size" json:"min_table_size"`
+	MinTableAge        string   `yaml:"min_table_age" json:"min_table_age"`
+	ProtectRecentWrite string   `yaml:"protect_recent_write" json:"protect_recent_write"`
+	ReplicaFactor      *int     `yaml:"replica_factor" json:"replica_factor"`
+
+	IPAliases map[string]string `yaml:"ip_aliases" json:"ip_aliases"`
+
+	Anomalies *FileAnomalyThresholds `yaml:"anomalies" json:"anomalies"`
+	Scoring   *FileScoring           `yaml:"scoring" json:"scoring"`
+}
+
+// FileScoring holds the optional scoring: block.
+type FileScoring struct {
+	Diversity           []DiversityBucket `yaml:"diversity" json:"diversity"`
+	RecencyHalfLife     string            `yaml:"recency_half_life" json:"recency_half_life"`
+	SizePressureWeight  *float64          `yaml:"size_pressure_weight" json:"size_pressure_weight"`
+	ShadowSuffixPattern string            `yaml:"shadow_suffix_pattern" json:"shadow_suffix_pattern"`
+}
+
+// FileAnomalyThresholds holds the optional anomalies: block. Unset fields
+// keep the built-in defaults.
+type FileAnomalyThresholds struct {
+	StaleDays             *int     `yaml:"stale_days" json:"stale_days"`
+	ReadOnlyMinReads      *uint64  `yaml:"read_only_min_reads" json:"read_only_min_reads"`
+	LowActivityMaxAccess  *uint64  `yaml:"low_activity_max_access" json:"low_activity_max_access"`
+	LowActivityMinDays    *int     `yaml:"low_activity_min_days" json:"low_activity_min_days"`
+	BroadAccessTableCount *int     `yaml:"broad_access_table_count" json:"broad_access_table_count"`
+	UsageSpikeMultiplier  *float64 `yaml:"usage_spike_multiplier" json:"usage_spike_multiplier"`
+	UsageSpikeMinQueries  *uint64  `yaml:"usage_spike_min_queries" json:"usage_spike_min_queries"`
+	UsageDropBuckets      *int     `yaml:"usage_drop_hours" json:"usage_drop_hours"`
+	UsageDropMinMean      *float64 `yaml:"usage_drop_min_mean" json:"usage_drop_min_mean"`
+	ErrorProneRate        *float64 `yaml:"error_prone_rate" json:"error_prone_rate"`
+	ErrorProneMinFailures *uint64  `yaml:"error_prone_min_failures" json:"error_prone_min_failures"`
+	FullScanRatio         *float64 `yaml:"full_scan_ratio" json:"full_scan_ratio"`
+	FullScanMinQueries    *uint64  `yaml:"full_scan_min_queries" json:"full_scan_min_queries"`
+	NewAccessAfter        *float64 `yaml:"new_access_after" json:"new_access_after"`
+}
+
+// ApplyTo copies every configured threshold onto t.
+func (ft *FileAnomalyThresholds) ApplyTo(t *AnomalyThresholds) {
+	if ft == nil || t == nil {
+		return
+	}
+	if ft.StaleDays != nil {
+		t.StaleDays = *ft.StaleDays
+	}
+	if ft.ReadOnlyMinReads != nil {
+		t.ReadOnlyMinReads = *ft.ReadOnlyMinReads
+	}
+	if ft.LowActivityMaxAccess != nil {
+		t.LowActivityMaxAccess = *ft.LowActivityMaxAccess
+	}
+	if ft.LowActivityMinDays != nil {
+		t.LowActivityMinDays = *ft.LowActivityMinDays
+	}
+	if ft.BroadAccessTableCount != nil {
+		t.BroadAccessTableCount = *ft.BroadAccessTableCount
+	}
+	if ft.UsageSpikeMultiplier != nil {
+		t.UsageSpikeMultiplier = *ft.UsageSpikeMultiplier
+	}
+	if ft.UsageSpikeMinQueries != nil {
+		t.UsageSpikeMinQueries = *ft.UsageSpikeMinQueries
+	}
+	if ft.UsageDropBuckets != nil {
+		t.UsageDropBuckets = *ft.UsageDropBuckets
+	}
+	if ft.UsageDropMinMean != nil {
+		t.UsageDropMinMean = *ft.UsageDropMinMean
+	}
+	if ft.ErrorProneRate != nil {
+		t.ErrorProneRate = *ft.ErrorProneRate
+	}
+	if ft.ErrorProneMinFailures != nil {
+		t.ErrorProneMinFailures = *ft.ErrorProneMinFailures
+	}
+	if ft.FullScanRatio != nil {
+		t.FullScanRatio = *ft.FullScanRatio
+	}
+	if ft.FullScanMinQueries != nil {
+		t.FullScanMinQueries = *ft.FullScanMinQueries
+	}
+	if ft.NewAccessAfter != nil {
+		t.NewAccessAfter = *ft.NewAccessAfter
+	}
 }
 
 // ClickHouseEndpoint returns the first configured ClickHouse endpoint.
@@ -59,6 +157,15 @@ func (fc *FileConfig) Normalize() {
 	}
 	fc.ExcludeTables = normalizeList(fc.ExcludeTables)
 	fc.ExcludeDatabases = normalizeList(fc.ExcludeDatabases)
+	fc.ProtectedTables = normalizeList(fc.ProtectedTables)
+	fc.SensitiveTables = normalizeList(fc.SensitiveTables)
+	fc.IncludeTables = normalizeList(fc.IncludeTables)
+	fc.IncludeDatabases = normalizeList(fc.IncludeDatabases)
+	fc.ExcludeUsers = normalizeList(fc.ExcludeUsers)
+	fc.ExcludeQueryKinds = normalizeList(fc.ExcludeQueryKinds)
+	fc.ExcludeLogComments = normalizeList(fc.ExcludeLogComments)
+	fc.Engines = normalizeList(fc.Engines)
+	fc.ExcludeEngines = normalizeList(fc.ExcludeEngines)
 	fc.ClickHouseURL = strings.TrimSpace(fc.ClickHouseURL)
 	fc.ClickHouseDSN = strings.TrimSpace(fc.ClickHouseDSN)
 	fc.Format = strings.TrimSpace(fc.Format)
@@ -71,12 +178,14 @@ func AutoLoadFile() (*FileConfig, string, error) {
 	candidates := []string{
 		DefaultConfigFileYAML,
 		DefaultConfigFileYML,
+		DefaultConfigFileJSON,
 	}
 
 	if homeDir, err := os.UserHomeDir(); err == nil && strings.TrimSpace(homeDir) != "" {
 		candidates = append(candidates,
 			filepath.Join(homeDir, DefaultConfigFileYAML),
 			filepath.Join(homeDir, DefaultConfigFileYML),
+			filepath.Join(homeDir, DefaultConfigFileJSON),
 		)
 	}
 
@@ -112,7 +221,8 @@ func LoadFirstExistingFile(paths []string) (*FileConfig, string, error) {
 	return nil, "", nil
 }
 
-// LoadFile loads config values from a specific YAML file path.
+// LoadFile loads config values from a specific file path. Files ending in
+// .json are parsed as JSON; everything else is parsed as YAML.
 func LoadFile(path string) (*FileConfig, error) {
 	filename := strings.TrimSpace(path)
 	if filename == "" {
@@ -125,7 +235,12 @@ func LoadFile(path string) (*FileConfig, error) {
 	}
 
 	cfg := &FileConfig{}
-	if err := yaml.Unmarshal(data, cfg); err != nil {
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		err = json.Unmarshal(data, cfg)
+	} else {
+		err = yaml.Unmarshal(data, cfg)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file %q: %w", filename, err)
 	}
 
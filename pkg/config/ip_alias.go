@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ParseIPAliases parses ip=label pairs as given to --ip-alias.
+func ParseIPAliases(values []string) (map[string]string, error) {
+	aliases := make(map[string]string, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		ip, label, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid IP alias %q: expected ip=label", value)
+		}
+		aliases[strings.TrimSpace(ip)] = strings.TrimSpace(label)
+	}
+	return CanonicalIPAliases(aliases)
+}
+
+// CanonicalIPAliases validates aliases and keys them by canonical IP form,
+// so the IPv4-mapped addresses query_log reports (::ffff:10.0.0.1) match
+// aliases written as plain IPv4.
+func CanonicalIPAliases(aliases map[string]string) (map[string]string, error) {
+	canonical := make(map[string]string, len(aliases))
+	for ip, label := range aliases {
+		parsed := net.ParseIP(strings.TrimSpace(ip))
+		if parsed == nil {
+			return nil, fmt.Errorf("invalid IP alias %q: not an IP address", ip)
+		}
+		label = strings.TrimSpace(label)
+		if label == "" {
+			return nil, fmt.Errorf("invalid IP alias %q: label is empty", ip)
+		}
+		canonical[parsed.String()] = label
+	}
+	return canonical, nil
+}
+
+// IPAlias returns the configured label for a client IP. IPAliases must be
+// keyed by CanonicalIPAliases.
+func (c *Config) IPAlias(ip string) (string, bool) {
+	if len(c.IPAliases) == 0 {
+		return "", false
+	}
+	label, ok := c.IPAliases[CanonicalIP(ip)]
+	return label, ok
+}
+
+// CanonicalIP returns ip in canonical text form: IPv4-mapped IPv6 addresses
+// (::ffff:10.0.0.1) become plain IPv4 and IPv6 is lowercased with zero runs
+// compressed. Values that do not parse as an IP are returned trimmed.
+func CanonicalIP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimezone is the zone time buckets and report times use unless
+// --timezone says otherwise.
+const DefaultTimezone = "UTC"
+
+// LoadTimezone resolves an IANA zone name such as "America/New_York". An
+// empty name is UTC.
+func LoadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// TimeLocation returns the location for c.Timezone, falling back to UTC for
+// names that do not resolve. Flags and config files are validated with
+// LoadTimezone first.
+func (c *Config) TimeLocation() *time.Location {
+	loc, err := LoadTimezone(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
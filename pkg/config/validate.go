@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReportFormats lists the values accepted by the format key and --format.
+var ReportFormats = []string{"json", "text", "sarif", "spectrehub", "openmetrics", "markdown"}
+
+// FileValidation describes how the keys and values of a config file were
+// interpreted. Unknown keys are warnings; Errors are values that would be
+// rejected when the file is applied.
+type FileValidation struct {
+	Path       string
+	Recognized []string
+	Unknown    []string
+	Errors     []string
+}
+
+// ValidateFile loads path, reports which keys map onto FileConfig and which
+// are ignored, and checks duration and format values. The returned error is
+// reserved for files that cannot be read or parsed.
+func ValidateFile(path string) (*FileValidation, error) {
+	fc, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := strings.TrimSpace(path)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %q: %w", filename, err)
+	}
+
+	raw := map[string]any{}
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %q: %w", filename, err)
+	}
+
+	recognized := map[string]bool{}
+	unknown := map[string]bool{}
+	collectFileKeys(reflect.TypeOf(FileConfig{}), raw, "", recognized, unknown)
+
+	return &FileValidation{
+		Path:       filename,
+		Recognized: sortedKeys(recognized),
+		Unknown:    sortedKeys(unknown),
+		Errors:     fc.Validate(),
+	}, nil
+}
+
+// Validate checks the values that are parsed later, when the file is applied
+// to a Config, so mistakes surface before a run.
+func (fc *FileConfig) Validate() []string {
+	if fc == nil {
+		return nil
+	}
+
+	var errs []string
+	if fc.Format != "" && !isReportFormat(fc.Format) {
+		errs = append(errs, fmt.Sprintf("format: unsupported value %q (supported: %s)",
+			fc.Format, strings.Join(ReportFormats, ", ")))
+	}
+	for _, field := range []struct {
+		key   string
+		value string
+	}{
+		{"timeout", fc.Timeout},
+		{"query_timeout", fc.QueryTimeout},
+	} {
+		if msg := validatePositiveDuration(field.value); msg != "" {
+			errs = append(errs, field.key+": "+msg)
+		}
+	}
+	if _, err := LoadTimezone(fc.Timezone); err != nil {
+		errs = append(errs, "timezone: "+err.Error())
+	}
+	if fc.ReplicaFactor != nil && *fc.ReplicaFactor < 1 {
+		errs = append(errs, fmt.Sprintf("replica_factor: must be at least 1, got %d", *fc.ReplicaFactor))
+	}
+	if fc.MinTableSizeMB != nil && *fc.MinTableSizeMB < 0 {
+		errs = append(errs, fmt.Sprintf("min_table_size: must not be negative, got %g", *fc.MinTableSizeMB))
+	}
+	if age := strings.TrimSpace(fc.MinTableAge); age != "" {
+		if d, err := ParseDuration(age); err != nil {
+			errs = append(errs, fmt.Sprintf("min_table_age: invalid duration %q", age))
+		} else if d < 0 {
+			errs = append(errs, fmt.Sprintf("min_table_age: must not be negative, got %q", age))
+		}
+	}
+	if window := strings.TrimSpace(fc.ProtectRecentWrite); window != "" {
+		if d, err := ParseDuration(window); err != nil {
+			errs = append(errs, fmt.Sprintf("protect_recent_write: invalid duration %q", window))
+		} else if d < 0 {
+			errs = append(errs, fmt.Sprintf("protect_recent_write: must not be negative, got %q", window))
+		}
+	}
+	if len(fc.IPAliases) > 0 {
+		if _, err := CanonicalIPAliases(fc.IPAliases); err != nil {
+			errs = append(errs, "ip_aliases: "+err.Error())
+		}
+	}
+	if fc.Scoring != nil {
+		if msg := validatePositiveDuration(strings.TrimSpace(fc.Scoring.RecencyHalfLife)); msg != "" {
+			errs = append(errs, "scoring.recency_half_life: "+msg)
+		}
+		if w := fc.Scoring.SizePressureWeight; w != nil && (*w < 0 || *w > 1) {
+			errs = append(errs, fmt.Sprintf("scoring.size_pressure_weight: must be between 0 and 1, got %g", *w))
+		}
+		if p := fc.Scoring.ShadowSuffixPattern; p != "" {
+			if _, err := regexp.Compile(p); err != nil {
+				errs = append(errs, "scoring.shadow_suffix_pattern: "+err.Error())
+			}
+		}
+		if len(fc.Scoring.Diversity) > 0 {
+			if err := ValidateDiversityBuckets(fc.Scoring.Diversity); err != nil {
+				errs = append(errs, "scoring.diversity: "+err.Error())
+			}
+		}
+	}
+	return errs
+}
+
+func isReportFormat(format string) bool {
+	format = strings.ToLower(format)
+	for _, f := range ReportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePositiveDuration(value string) string {
+	if value == "" {
+		return ""
+	}
+	d, err := ParseDuration(value)
+	if err != nil {
+		return fmt.Sprintf("invalid duration %q", value)
+	}
+	if d <= 0 {
+		return fmt.Sprintf("duration must be positive, got %q", value)
+	}
+	return ""
+}
+
+// collectFileKeys walks raw against the yaml tags of t, descending into
+// nested blocks and lists of blocks, and records dotted key paths.
+func collectFileKeys(t reflect.Type, raw map[string]any, prefix string, recognized, unknown map[string]bool) {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field.Type
+	}
+
+	for key, value := range raw {
+		path := prefix + key
+		fieldType, ok := fields[key]
+		if !ok {
+			unknown[path] = true
+			continue
+		}
+		recognized[path] = true
+
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		switch fieldType.Kind() {
+		case reflect.Struct:
+			if nested, ok := value.(map[string]any); ok {
+				collectFileKeys(fieldType, nested, path+".", recognized, unknown)
+			}
+		case reflect.Slice:
+			elem := fieldType.Elem()
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			items, _ := value.([]any)
+			for _, item := range items {
+				if nested, ok := item.(map[string]any); ok {
+					collectFileKeys(elem, nested, path+".", recognized, unknown)
+				}
+			}
+		}
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -6,8 +6,14 @@ let tablesSortDesc = false;
 
 // Load report on page load
 window.addEventListener('DOMContentLoaded', async () => {
+    await loadReport();
+    watchReport();
+});
+
+// Fetch report.json and render every section
+async function loadReport() {
     try {
-        const response = await fetch('report.json');
+        const response = await fetch('report.json', { cache: 'no-store' });
         if (!response.ok) {
             throw new Error(`HTTP ${response.status}: ${response.statusText}`);
         }
@@ -27,7 +33,17 @@ window.addEventListener('DOMContentLoaded', async () => {
             <p>Opening the HTML file directly (file://) will not work due to browser security restrictions.</p>
         </div>`;
     }
-});
+}
+
+// Reload the report when 'clickspectre serve --watch' reports a change.
+// Without --watch the events endpoint returns 404 and the browser gives up.
+function watchReport() {
+    if (!window.EventSource) return;
+    const events = new EventSource('events');
+    events.addEventListener('reload', () => {
+        loadReport();
+    });
+}
 
 // Render all sections
 function renderAll() {
@@ -49,6 +65,7 @@ function renderMetadata() {
         <span>Host: ${meta.clickhouse_host}</span>
         <span>Duration: ${meta.analysis_duration}</span>
         ${meta.k8s_resolution_enabled ? '<span class="badge">K8s Resolution Enabled</span>' : ''}
+        ${meta.truncated ? `<span class="badge badge-suspect">Results truncated at ${meta.row_limit.toLocaleString()} rows; stats may be incomplete</span>` : ''}
     `;
 }
 
@@ -85,6 +102,7 @@ function renderTables() {
                 <td><strong>${table.full_name}</strong></td>
                 <td>${table.reads.toLocaleString()}</td>
                 <td>${table.writes.toLocaleString()}</td>
+                <td>${(table.mutations || 0).toLocaleString()}</td>
                 <td>${new Date(table.last_access).toLocaleDateString()}</td>
                 <td>${sparkline}</td>
                 <td>${table.score.toFixed(2)}</td>
@@ -242,6 +260,7 @@ function renderAnomalies() {
                     <strong>${anomaly.type.replace(/_/g, ' ').toUpperCase()}</strong>
                 </div>
                 <p>${anomaly.description}</p>
+                ${anomaly.metrics ? `<small>${Object.keys(anomaly.metrics).sort().map(name => `${name}=${anomaly.metrics[name]}`).join(' ')}</small>` : ''}
                 ${anomaly.affected_table ? `<small>Table: <code>${anomaly.affected_table}</code></small>` : ''}
                 ${anomaly.affected_service ? `<small>Service: <code>${anomaly.affected_service}</code></small>` : ''}
             </div>
@@ -265,9 +284,11 @@ function renderGraph() {
         .attr('height', height);
 
     // Prepare data
-    const services = report.services.slice(0, 20); // Limit to top 20
+    // Prefer the --graph-granularity rollup over per-pod services
+    const graph = report.graph || report;
+    const services = graph.services.slice(0, 20); // Limit to top 20
     const tables = report.tables.slice(0, 20); // Limit to top 20
-    const edges = report.edges.filter(e =>
+    const edges = graph.edges.filter(e =>
         services.some(s => s.ip === e.service) &&
         tables.some(t => t.full_name === e.table)
     );
@@ -295,7 +316,9 @@ function renderGraph() {
         .attr('stroke', '#888')
         .attr('stroke-width', d => Math.min((d.reads + d.writes) / 100, 5))
         .attr('fill', 'none')
-        .attr('opacity', 0.4);
+        .attr('opacity', 0.4)
+        .append('title')
+        .text(d => edgeTooltip(d));
 
     // Draw service nodes
     svg.selectAll('.service-node')
@@ -342,6 +365,16 @@ function renderGraph() {
         .text(d => d.full_name);
 }
 
+// Edge hover text, e.g. "svc-x → db.t: 200 SELECT, 5 ALTER"
+function edgeTooltip(edge) {
+    const kinds = Object.entries(edge.kind_counts || {})
+        .sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]))
+        .map(([kind, count]) => `${count} ${kind}`)
+        .join(', ');
+    const label = `${edge.service_name || edge.service} → ${edge.table}`;
+    return kinds ? `${label}: ${kinds}` : `${label}: reads=${edge.reads} writes=${edge.writes}`;
+}
+
 // Tab navigation
 function showTab(tabName) {
     // Update buttons
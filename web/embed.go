@@ -0,0 +1,10 @@
+// Package web embeds the static report UI so the binary can write it
+// without access to the source tree.
+package web
+
+import "embed"
+
+// FS holds the report UI assets.
+//
+//go:embed index.html app.js styles.css libs/d3.v7.min.js
+var FS embed.FS